@@ -1,10 +1,11 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -122,13 +123,24 @@ through a pipeline of JSON-based operations that can be automated or AI-assisted
 	// Add subcommands
 	cmd.AddCommand(
 		newDetectCmd(),
+		newPreviewCmd(),
+		newForecastCmd(),
 		newPayloadCmd(),
 		newAIApplyCmd(),
 		newBatchCmd(),
 		newFormatCmd(),
 		newValidateCmd(),
 		newCommitCmd(),
+		newContinueCmd(),
 		newResolveCmd(),
+		newRebaseCmd(),
+		newMergeDriverCmd(),
+		newInstallDriverCmd(),
+		newMemoryCmd(),
+		newRunsCmd(),
+		newUndoCmd(),
+		newReviewCmd(),
+		newApplyCmd(),
 	)
 
 	return cmd
@@ -163,6 +175,111 @@ func newDetectCmd() *cobra.Command {
 	return cmd
 }
 
+func newPreviewCmd() *cobra.Command {
+	var outputFile string
+	var outputFormat string
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "preview <ours> <theirs>",
+		Short: "Preview the conflicts of merging two refs without touching the working tree",
+		Long: `Merges <theirs> into <ours> in memory with git merge-tree --write-tree and
+outputs the same report as detect. The working tree and index are not modified,
+so conflicts can be inspected, and AI resolutions prepared, before anyone
+starts the merge. Requires git 2.38 or later.
+
+Examples:
+  # Conflicts a pull request branch would hit when merged into main
+  syncwright preview main feature/login
+
+  # Human-readable report
+  syncwright preview origin/main HEAD --format text`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := commands.DetectOptions{
+				OutputFile:    outputFile,
+				OutputFormat:  outputFormat,
+				Verbose:       verbose,
+				PreviewOurs:   args[0],
+				PreviewTheirs: args[1],
+			}
+
+			detectCmd := commands.NewDetectCommand(options)
+			_, err := detectCmd.Execute()
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file for conflicts JSON (default: stdout)")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, text")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	return cmd
+}
+
+func newForecastCmd() *cobra.Command {
+	var (
+		target       string
+		branches     string
+		pairwise     bool
+		outputFile   string
+		outputFormat string
+		batchSize    int
+		concurrency  int
+		timeoutSec   int
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "forecast",
+		Short: "Predict which branches will conflict with a target branch",
+		Long: `Previews the merge of every branch matching --branches into the target branch
+with git merge-tree, without touching the working tree, and outputs a matrix of
+conflicting files, hunk counts and a complexity estimate for each pair.
+
+The glob is matched against local and remote-tracking branch names. Previews
+run in batches using the same batch size, concurrency and timeout settings as
+the batch command.
+
+Examples:
+  # Which feature branches collide with main
+  syncwright forecast --branches 'feature/*' --format text
+
+  # Also compare the feature branches with each other
+  syncwright forecast --target origin/main --branches 'origin/feature/*' --pairwise`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := commands.ForecastOptions{
+				Target:       target,
+				Branches:     branches,
+				Pairwise:     pairwise,
+				OutputFile:   outputFile,
+				OutputFormat: outputFormat,
+				Verbose:      verbose,
+				Batch: commands.BatchOptions{
+					BatchSize:   batchSize,
+					Concurrency: concurrency,
+					TimeoutSec:  timeoutSec,
+				},
+			}
+
+			_, err := commands.NewForecastCommand(options).Execute()
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&target, "target", "main", "Branch the other branches would be merged into")
+	cmd.Flags().StringVar(&branches, "branches", "*", "Glob of branches to forecast")
+	cmd.Flags().BoolVar(&pairwise, "pairwise", false, "Also forecast every pair of matching branches")
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file for the forecast (default: stdout)")
+	cmd.Flags().StringVar(&outputFormat, "format", "json", "Output format: json, text")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 10, "Number of merges previewed per batch")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 3, "Number of concurrent batches to process")
+	cmd.Flags().IntVar(&timeoutSec, "timeout", 300, "Timeout in seconds for the whole forecast")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	return cmd
+}
+
 // PayloadResult represents the output of the payload command
 type PayloadResult struct {
 	Conflicts []ConflictPayload `json:"conflicts"`
@@ -203,6 +320,13 @@ func newPayloadCmd() *cobra.Command {
 			// Convert SimplifiedConflictPayload to PayloadResult format
 			var conflicts []ConflictPayload
 			for _, file := range input.ConflictPayload.Files {
+				if fc := file.FileConflict; fc != nil {
+					conflicts = append(conflicts, ConflictPayload{
+						ConflictID: fmt.Sprintf("%s:file", file.Path),
+						Context:    fmt.Sprintf("File: %s [%s] %s (%s)", file.Path, file.Language, fc.Kind, fc.Status),
+						Options:    "keep, delete, merge",
+					})
+				}
 				for i, conflict := range file.Conflicts {
 					conflictPayload := ConflictPayload{
 						ConflictID: fmt.Sprintf("%s:%d", file.Path, i),
@@ -251,7 +375,8 @@ type AIApplyMetadata struct {
 }
 
 func newAIApplyCmd() *cobra.Command {
-	var inputFile, outputFile string
+	var inputFile, outputFile, suggestions, reviewFile string
+	var atomic, backupFiles, stage, review, proposeOnly, noStrategies bool
 
 	cmd := &cobra.Command{
 		Use:   "ai-apply",
@@ -294,9 +419,16 @@ func newAIApplyCmd() *cobra.Command {
 				Verbose:        true,
 				AutoApply:      false,
 				MinConfidence:  0.7,
-				BackupFiles:    true,
+				BackupFiles:    backupFiles,
 				MaxRetries:     3,
 				TimeoutSeconds: 300,
+				Atomic:         atomic,
+				StageResolved:  stage,
+				SuggestionMode: suggestions,
+				Review:         review,
+				ProposeOnly:    proposeOnly,
+				ReviewFile:     reviewFile,
+				SkipStrategies: noStrategies,
 			}
 
 			// Create temporary file for payload data
@@ -361,6 +493,14 @@ func newAIApplyCmd() *cobra.Command {
 
 	cmd.Flags().StringVarP(&inputFile, "in", "i", "", "Input file with payload data (default: stdin)")
 	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file for AI apply results (default: stdout)")
+	cmd.Flags().BoolVar(&atomic, "atomic", false, "Write the resolved files all or none, rolling back every file if one fails")
+	cmd.Flags().BoolVar(&backupFiles, "backup", true, "Snapshot the files before applying so the run can be undone")
+	cmd.Flags().BoolVar(&stage, "stage", false, "Add the files whose conflicts were all resolved to the index")
+	cmd.Flags().StringVar(&suggestions, "suggestions", "", "Place low-confidence proposals for review: inline (after each conflict) or sidecar (in .syncwright-suggestions)")
+	cmd.Flags().BoolVar(&review, "review", false, "Review every hunk interactively before it is applied")
+	cmd.Flags().BoolVar(&proposeOnly, "propose-only", false, "Write the proposals to a review file, pending approval, instead of applying them")
+	cmd.Flags().StringVar(&reviewFile, "review-file", gitutils.DefaultReviewFileName, "Review file written by --propose-only")
+	cmd.Flags().BoolVar(&noStrategies, "no-strategies", false, "Send every hunk to Claude instead of resolving the trivial ones by rule first")
 
 	return cmd
 }
@@ -539,8 +679,19 @@ func newCommitCmd() *cobra.Command {
 				return fmt.Errorf("not in a git repository")
 			}
 
+			// A rebase, cherry-pick or revert has to be continued rather than
+			// concluded with a plain commit
+			operation, err := gitutils.DetectOperation(repoPath)
+			if err != nil {
+				return fmt.Errorf("failed to detect operation: %w", err)
+			}
+			if operation.InProgress() && operation.Operation != gitutils.OperationMerge {
+				fmt.Printf("A %s is in progress, continuing it instead of committing...\n", operation.Operation)
+				return runContinue(repoPath, false)
+			}
+
 			// Check if there are any conflicted files remaining
-			conflictedFiles, err := gitutils.GetConflictedFiles()
+			conflictedFiles, err := gitutils.GetConflictedFiles(repoPath)
 			if err != nil {
 				return fmt.Errorf("failed to check for conflicted files: %w", err)
 			}
@@ -575,7 +726,7 @@ func newCommitCmd() *cobra.Command {
 			// Commit the changes
 			logging.Logger.InfoSafe("Committing resolved changes", zap.String("commit_message", strings.Split(commitMessage, "\n")[0]))
 			fmt.Println("Committing resolved changes...")
-			if err := gitutils.CommitChanges(commitMessage); err != nil {
+			if err := gitutils.CommitChanges(repoPath, commitMessage); err != nil {
 				logging.Logger.ErrorSafe("Failed to commit changes", zap.Error(err))
 				return fmt.Errorf("failed to commit changes: %w", err)
 			}
@@ -591,6 +742,69 @@ func newCommitCmd() *cobra.Command {
 	return cmd
 }
 
+func newContinueCmd() *cobra.Command {
+	var verbose bool
+
+	cmd := &cobra.Command{
+		Use:   "continue",
+		Short: "Stage resolved files and continue the merge, rebase, cherry-pick or revert",
+		Long: `Stages every conflicted file that no longer contains conflict markers and runs
+the matching git merge/rebase/cherry-pick/revert --continue.
+
+The operation is detected from MERGE_HEAD, REBASE_HEAD, CHERRY_PICK_HEAD,
+REVERT_HEAD and the rebase state directories. Nothing is staged while any
+conflicted file still contains markers. When a rebase stops again at the next
+conflicting commit, the new conflicts are reported.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			if !gitutils.IsGitRepositoryPath(repoPath) {
+				return fmt.Errorf("not in a git repository")
+			}
+
+			return runContinue(repoPath, verbose)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+
+	return cmd
+}
+
+// runContinue continues the in-progress operation and reports the outcome
+func runContinue(repoPath string, verbose bool) error {
+	result, err := commands.ContinueOperation(repoPath, verbose)
+	if err != nil {
+		if len(result.UnresolvedFiles) > 0 {
+			fmt.Println("Files still unresolved:")
+			for _, file := range result.UnresolvedFiles {
+				fmt.Printf("  - %s\n", file)
+			}
+		}
+		logging.Logger.ErrorSafe("Failed to continue operation", zap.Error(err))
+		return fmt.Errorf("failed to continue: %w", err)
+	}
+
+	fmt.Printf("Staged %d resolved files\n", len(result.StagedFiles))
+	switch {
+	case result.Completed:
+		fmt.Printf("✅ %s completed\n", result.Operation)
+	case result.NewConflicts > 0:
+		step := ""
+		if result.Rebase != nil && result.Rebase.TotalSteps > 0 {
+			step = fmt.Sprintf(" at step %d/%d", result.Rebase.Step, result.Rebase.TotalSteps)
+		}
+		fmt.Printf("⚠️  %s stopped%s with %d new conflicted files\n", result.Operation, step, result.NewConflicts)
+	default:
+		fmt.Printf("✅ %s continued\n", result.Operation)
+	}
+
+	return nil
+}
+
 func newResolveCmd() *cobra.Command {
 	var (
 		maxTokens    int
@@ -602,6 +816,13 @@ func newResolveCmd() *cobra.Command {
 		autoApply    bool
 		skipFormat   bool
 		skipValidate bool
+		isolated     bool
+		keepWorktree bool
+		stage        bool
+		suggestions  string
+		review       bool
+		goModTidy    bool
+		regenerate   bool
 	)
 
 	cmd := &cobra.Command{
@@ -624,18 +845,37 @@ Examples:
   syncwright resolve --ai --auto-apply --confidence 0.8
 
   # Skip formatting and validation steps
-  syncwright resolve --ai --skip-format --skip-validate`,
+  syncwright resolve --ai --skip-format --skip-validate
+
+  # Resolve in a temporary worktree and only update the checkout if it passes
+  syncwright resolve --ai --isolated
+
+  # Apply the confident resolutions and annotate the other conflicts
+  syncwright resolve --ai --auto-apply --suggestions inline
+
+  # Decide on every hunk interactively
+  syncwright resolve --ai --review
+
+  # Regenerate conflicted lockfiles from their resolved manifests
+  syncwright resolve --ai --regenerate-lockfiles`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return executeResolveCommand(resolveOptions{
-				maxTokens:    maxTokens,
-				aiMode:       aiMode,
-				verbose:      verbose,
-				dryRun:       dryRun,
-				confidence:   confidence,
-				apiKey:       apiKey,
-				autoApply:    autoApply,
-				skipFormat:   skipFormat,
-				skipValidate: skipValidate,
+			return executeResolveCommand(commands.ResolveOptions{
+				MaxTokens:           maxTokens,
+				AIMode:              aiMode,
+				Verbose:             verbose,
+				DryRun:              dryRun,
+				Confidence:          confidence,
+				APIKey:              apiKey,
+				AutoApply:           autoApply,
+				SkipFormat:          skipFormat,
+				SkipValidate:        skipValidate,
+				Isolated:            isolated,
+				KeepWorktree:        keepWorktree,
+				StageResolved:       stage,
+				SuggestionMode:      suggestions,
+				Review:              review,
+				GoModTidy:           goModTidy,
+				RegenerateLockfiles: regenerate,
 			})
 		},
 	}
@@ -649,246 +889,433 @@ Examples:
 	cmd.Flags().BoolVar(&autoApply, "auto-apply", false, "Automatically apply high-confidence resolutions without confirmation")
 	cmd.Flags().BoolVar(&skipFormat, "skip-format", false, "Skip code formatting step")
 	cmd.Flags().BoolVar(&skipValidate, "skip-validate", false, "Skip validation step")
+	cmd.Flags().BoolVar(&isolated, "isolated", false, "Resolve in a temporary worktree and only update the checkout when the run passes")
+	cmd.Flags().BoolVar(&keepWorktree, "keep-worktree", false, "Keep the temporary worktree of an isolated run for inspection")
+	cmd.Flags().BoolVar(&stage, "stage", true, "Add the files whose conflicts were all resolved to the index")
+	cmd.Flags().StringVar(&suggestions, "suggestions", "", "Place low-confidence proposals for review: inline (after each conflict) or sidecar (in .syncwright-suggestions)")
+	cmd.Flags().BoolVar(&review, "review", false, "Review every hunk interactively before it is applied")
+	cmd.Flags().BoolVar(&goModTidy, "go-mod-tidy", false, "Run go mod tidy offline in the modules whose go.mod or go.sum was resolved")
+	cmd.Flags().BoolVar(&regenerate, "regenerate-lockfiles", false, "Regenerate conflicted lockfiles from their resolved manifests (configure with syncwright.regenerate.<type>)")
 
 	return cmd
 }
 
-// resolveOptions contains all options for the resolve command
-type resolveOptions struct {
-	maxTokens    int
-	aiMode       bool
-	verbose      bool
-	dryRun       bool
-	confidence   float64
-	apiKey       string
-	autoApply    bool
-	skipFormat   bool
-	skipValidate bool
-}
-
-// resolveResult represents the complete result of the resolve pipeline
-type resolveResult struct {
-	Success           bool     `json:"success"`
-	Stage             string   `json:"stage"`
-	ConflictsDetected int      `json:"conflicts_detected"`
-	ConflictsResolved int      `json:"conflicts_resolved"`
-	FilesModified     []string `json:"files_modified"`
-	AIConfidence      float64  `json:"ai_confidence,omitempty"`
-	ValidationPassed  bool     `json:"validation_passed"`
-	FormattingApplied bool     `json:"formatting_applied"`
-	ErrorMessage      string   `json:"error_message,omitempty"`
-	Summary           string   `json:"summary"`
-}
-
-// executeResolveCommand implements the complete conflict resolution pipeline
-func executeResolveCommand(opts resolveOptions) error {
-	repoPath, err := os.Getwd()
+// executeResolveCommand runs the resolve pipeline in the current directory
+func executeResolveCommand(opts commands.ResolveOptions) error {
+	result, err := commands.ResolveConflicts(opts)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return outputResolveResult(result)
 	}
 
-	if opts.verbose {
-		fmt.Println("🔍 Starting automated conflict resolution pipeline...")
+	if opts.Verbose && result.Stage == "completed" {
+		fmt.Println("\n📝 Next steps:")
+		fmt.Println("   1. Review the resolved conflicts")
+		fmt.Println("   2. Test your changes")
+		fmt.Println("   3. Commit the resolved conflicts")
 	}
 
-	result := &resolveResult{
-		Stage: "detection",
-	}
+	return outputResolveResult(result)
+}
 
-	// Step 1: Detect conflicts
-	if opts.verbose {
-		fmt.Println("📋 Step 1: Detecting conflicts...")
+// outputResolveResult outputs the final result of the resolve pipeline
+func outputResolveResult(result *commands.ResolveResult) error {
+	if result.ErrorMessage != "" {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", result.ErrorMessage)
+		return errors.New(result.ErrorMessage)
 	}
 
-	detectResult, err := commands.DetectConflicts(repoPath)
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("conflict detection failed: %v", err)
-		return outputResolveResult(result)
+	// Always output the summary for user feedback
+	if result.Summary != "" {
+		fmt.Println(result.Summary)
 	}
 
-	if detectResult.ConflictReport == nil || len(detectResult.ConflictReport.ConflictedFiles) == 0 {
-		result.Success = true
-		result.Summary = "No conflicts detected"
-		if opts.verbose {
-			fmt.Println("✅ No conflicts detected")
-		}
-		return outputResolveResult(result)
-	}
+	return nil
+}
 
-	result.ConflictsDetected = len(detectResult.ConflictReport.ConflictedFiles)
-	if opts.verbose {
-		fmt.Printf("📋 Found %d conflicted files with %d total conflicts\n",
-			len(detectResult.ConflictReport.ConflictedFiles),
-			detectResult.ConflictReport.TotalConflicts)
-	}
+func newRebaseCmd() *cobra.Command {
+	var (
+		continueRun  bool
+		abortRun     bool
+		onFailure    string
+		confidence   float64
+		reportFile   string
+		apiKey       string
+		maxTokens    int
+		skipFormat   bool
+		skipValidate bool
+		verbose      bool
+	)
 
-	if !opts.aiMode {
-		result.Summary = fmt.Sprintf("Found %d conflicts. Use --ai flag to resolve with AI assistance.", result.ConflictsDetected)
-		if opts.verbose {
-			fmt.Println(result.Summary)
-		}
-		return outputResolveResult(result)
-	}
+	cmd := &cobra.Command{
+		Use:   "rebase [upstream]",
+		Short: "Rebase onto upstream, resolving the conflicts of every stopped commit",
+		Long: `Runs git rebase <upstream> and, at every commit that stops with conflicts, runs
+the resolve pipeline (detect, AI resolution, format, validate) and continues.
 
-	// Step 2: AI Resolution
-	if opts.verbose {
-		fmt.Println("🤖 Step 2: Generating AI resolutions...")
-	}
+A step fails when the AI confidence is below --confidence, when conflict
+markers remain or when project validation fails. The rebase is then paused at
+that commit (--on-failure pause, the default) or aborted (--on-failure abort).
+After fixing a paused commit by hand, resume with --continue.
 
-	result.Stage = "ai_resolution"
-	aiResult, err := resolveWithAI(detectResult, opts, repoPath)
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("AI resolution failed: %v", err)
-		return outputResolveResult(result)
-	}
+A per-commit report of which conflicts were resolved and how is kept in
+.git/syncwright/rebase-state.json and can also be written to --report.
 
-	result.ConflictsResolved = aiResult.AppliedResolutions
-	result.AIConfidence = aiResult.AIResponse.OverallConfidence
-	result.FilesModified = aiResult.ApplicationResult.ModifiedFiles
+Examples:
+  # Rebase the current branch onto main
+  syncwright rebase main
 
-	if opts.verbose {
-		fmt.Printf("🤖 Applied %d resolutions with overall confidence %.2f\n",
-			result.ConflictsResolved, result.AIConfidence)
-	}
+  # Abort instead of pausing when a step fails
+  syncwright rebase origin/main --on-failure abort --report rebase-report.json
 
-	if opts.dryRun {
-		result.Success = true
-		result.Summary = fmt.Sprintf("Dry run: Would resolve %d conflicts with AI assistance", result.ConflictsDetected)
-		return outputResolveResult(result)
-	}
+  # Resume after resolving a paused commit by hand
+  syncwright rebase --continue`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get current directory: %w", err)
+			}
 
-	// Step 3: Format files (optional)
-	if !opts.skipFormat && result.ConflictsResolved > 0 {
-		if opts.verbose {
-			fmt.Println("🎨 Step 3: Formatting resolved files...")
-		}
+			if !gitutils.IsGitRepositoryPath(repoPath) {
+				return fmt.Errorf("not in a git repository")
+			}
 
-		result.Stage = "formatting"
-		formatResult, err := commands.FormatFiles(repoPath, result.FilesModified)
-		if err != nil {
-			if opts.verbose {
-				fmt.Printf("⚠️  Formatting failed but continuing: %v\n", err)
+			options := commands.RebaseOptions{
+				RepoPath:      repoPath,
+				Continue:      continueRun,
+				Abort:         abortRun,
+				OnFailure:     onFailure,
+				MinConfidence: confidence,
+				ReportFile:    reportFile,
+				Verbose:       verbose,
+				Resolve: commands.ResolveOptions{
+					MaxTokens:    maxTokens,
+					Verbose:      verbose,
+					APIKey:       apiKey,
+					SkipFormat:   skipFormat,
+					SkipValidate: skipValidate,
+				},
 			}
-		} else {
-			result.FormattingApplied = formatResult.Success
-			if opts.verbose && formatResult.Success {
-				fmt.Printf("🎨 Formatted %d files\n", formatResult.Summary.FilesFormatted)
+			if len(args) == 1 {
+				options.Upstream = args[0]
 			}
-		}
-	}
-
-	// Step 4: Validate project (optional)
-	if !opts.skipValidate {
-		if opts.verbose {
-			fmt.Println("✅ Step 4: Validating project...")
-		}
-
-		result.Stage = "validation"
-		err = commands.ValidateProject(repoPath, "", 300, opts.verbose)
-		if err != nil {
-			if opts.verbose {
-				fmt.Printf("⚠️  Validation failed but continuing: %v\n", err)
+			if continueRun && abortRun {
+				return fmt.Errorf("--continue and --abort cannot be used together")
 			}
-			result.ValidationPassed = false
-		} else {
-			result.ValidationPassed = true
-			if opts.verbose {
-				fmt.Println("✅ Project validation completed")
+			if (continueRun || abortRun) && options.Upstream != "" {
+				return fmt.Errorf("an upstream cannot be given with --continue or --abort")
 			}
-		}
+
+			return runRebase(options)
+		},
 	}
 
-	// Final summary
-	result.Success = true
-	result.Stage = "completed"
-	result.Summary = fmt.Sprintf("Successfully resolved %d/%d conflicts", result.ConflictsResolved, result.ConflictsDetected)
+	cmd.Flags().BoolVar(&continueRun, "continue", false, "Resume a paused rebase")
+	cmd.Flags().BoolVar(&abortRun, "abort", false, "Abort the rebase and restore the original branch")
+	cmd.Flags().StringVar(&onFailure, "on-failure", commands.RebaseOnFailurePause, "What to do when a step fails (pause, abort)")
+	cmd.Flags().Float64Var(&confidence, "confidence", 0.7, "Minimum AI confidence for a step to continue")
+	cmd.Flags().StringVar(&reportFile, "report", "", "Also write the per-commit report to this file")
+	cmd.Flags().StringVar(&apiKey, "api-key", "", "Claude Code API key (or set CLAUDE_CODE_OAUTH_TOKEN env var)")
+	cmd.Flags().IntVar(&maxTokens, "max-tokens", -1, "Maximum tokens for AI processing (-1 for unlimited)")
+	cmd.Flags().BoolVar(&skipFormat, "skip-format", false, "Skip code formatting of resolved files")
+	cmd.Flags().BoolVar(&skipValidate, "skip-validate", false, "Skip project validation at every step")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
 
-	if opts.verbose {
-		fmt.Println("\n🎉 Conflict resolution pipeline completed!")
-		fmt.Printf("   Conflicts resolved: %d/%d\n", result.ConflictsResolved, result.ConflictsDetected)
-		fmt.Printf("   Files modified: %d\n", len(result.FilesModified))
-		if !opts.skipFormat {
-			fmt.Printf("   Formatting applied: %t\n", result.FormattingApplied)
+	return cmd
+}
+
+// runRebase drives the rebase and reports the outcome of every step
+func runRebase(options commands.RebaseOptions) error {
+	result, err := commands.NewRebaseCommand(options).Execute()
+	if result.Run != nil {
+		for _, step := range result.Run.Steps {
+			fmt.Printf("  [%d/%d] %s: %s, %d/%d conflicts resolved (%s)\n",
+				step.Step, step.TotalSteps, step.Subject, step.Outcome,
+				step.ConflictsResolved, step.ConflictsDetected, step.ResolvedBy)
 		}
-		if !opts.skipValidate {
-			fmt.Printf("   Validation passed: %t\n", result.ValidationPassed)
+	}
+	if err != nil {
+		logging.Logger.ErrorSafe("Rebase did not complete", zap.Error(err))
+		if result.Run != nil && result.Run.Status == commands.RebaseStatusPaused {
+			fmt.Println("Resolve the remaining conflicts, then run: syncwright rebase --continue")
 		}
-		fmt.Println("\n📝 Next steps:")
-		fmt.Println("   1. Review the resolved conflicts")
-		fmt.Println("   2. Test your changes")
-		fmt.Println("   3. Commit the resolved conflicts")
+		return err
 	}
 
-	return outputResolveResult(result)
+	fmt.Printf("✅ rebase %s\n", result.Run.Status)
+	return nil
 }
 
-// resolveWithAI handles the AI-powered conflict resolution
-func resolveWithAI(detectResult *commands.DetectResult, opts resolveOptions, repoPath string) (*commands.AIApplyResult, error) {
-	// Validate API key
-	apiKey := opts.apiKey
-	if apiKey == "" {
-		apiKey = os.Getenv("CLAUDE_CODE_OAUTH_TOKEN")
-		if apiKey == "" {
-			return nil, fmt.Errorf("API key not provided. Set CLAUDE_CODE_OAUTH_TOKEN environment variable or use --api-key flag")
-		}
+func newMergeDriverCmd() *cobra.Command {
+	var (
+		aiMode     bool
+		confidence float64
+		timeoutSec int
+		verbose    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merge-driver <base> <ours> <theirs> [marker-size] [path]",
+		Short: "Merge a single file as a git merge driver",
+		Long: `Merges one file on behalf of git, which invokes it as a custom merge driver
+with %O %A %B %L %P. The three versions are merged line by line, conflicts that
+only differ in whitespace are resolved deterministically and, with --ai, the
+remaining conflicts are sent to the AI resolver. The result is written to
+<ours>; the command exits with 0 when no conflicts remain and 1 otherwise.
+
+Register the driver with syncwright install-driver, or by hand:
+  git config merge.syncwright.driver "syncwright merge-driver %O %A %B %L %P"
+  echo "*.go merge=syncwright" >> .gitattributes`,
+		Args:         cobra.RangeArgs(3, 5),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options := commands.MergeDriverOptions{
+				BaseFile:       args[0],
+				OursFile:       args[1],
+				TheirsFile:     args[2],
+				AIMode:         aiMode,
+				MinConfidence:  confidence,
+				TimeoutSeconds: timeoutSec,
+				Verbose:        verbose,
+			}
+			if len(args) > 3 {
+				markerSize, err := strconv.Atoi(args[3])
+				if err != nil {
+					return fmt.Errorf("invalid conflict marker size %q", args[3])
+				}
+				options.MarkerSize = markerSize
+			}
+			if len(args) > 4 {
+				options.FilePath = args[4]
+			}
+
+			_, err := commands.RunMergeDriver(options)
+			return err
+		},
 	}
 
-	// Create AI apply options
-	aiOptions := commands.AIApplyOptions{
-		RepoPath:       repoPath,
-		DryRun:         opts.dryRun,
-		Verbose:        opts.verbose,
-		AutoApply:      opts.autoApply,
-		MinConfidence:  opts.confidence,
-		BackupFiles:    true,
-		MaxRetries:     3,
-		TimeoutSeconds: 120,
+	cmd.Flags().BoolVar(&aiMode, "ai", false, "Resolve the remaining conflicts with AI")
+	cmd.Flags().Float64Var(&confidence, "confidence", 0.7, "Minimum AI confidence for a resolution to be applied")
+	cmd.Flags().IntVar(&timeoutSec, "timeout", 120, "Timeout in seconds for AI resolution")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Report the merge outcome on stderr")
+
+	return cmd
+}
+
+func newInstallDriverCmd() *cobra.Command {
+	var (
+		name       string
+		command    string
+		local      bool
+		aiMode     bool
+		confidence float64
+		verbose    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install-driver [pattern...]",
+		Short: "Register syncwright as a git merge driver",
+		Long: `Writes the merge.<name>.name and merge.<name>.driver entries to the local git
+config and, for every pattern given, a "<pattern> merge=<name>" line to
+.gitattributes (or .git/info/attributes with --local). Patterns that already
+use the driver are not added twice.
+
+Examples:
+  # Merge Go and YAML files with syncwright
+  syncwright install-driver '*.go' '*.yaml'
+
+  # Use AI for the conflicts left, without committing the attributes
+  syncwright install-driver --ai --local 'src/**'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := commands.InstallMergeDriver(commands.InstallDriverOptions{
+				Name:          name,
+				Command:       command,
+				Patterns:      args,
+				Local:         local,
+				AIMode:        aiMode,
+				MinConfidence: confidence,
+				Verbose:       verbose,
+			})
+			if err != nil {
+				return err
+			}
+
+			if !verbose {
+				fmt.Printf("✅ Registered merge driver %q", result.Name)
+				if len(result.AddedPatterns) > 0 {
+					fmt.Printf(" for %s in %s", strings.Join(result.AddedPatterns, ", "), result.AttributesFile)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
 	}
 
-	// Create a temporary payload from detect result
-	payloadData, err := json.Marshal(detectResult.ConflictPayload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal conflict payload: %w", err)
+	cmd.Flags().StringVar(&name, "name", commands.DefaultMergeDriverName, "Driver name used in git config and attributes")
+	cmd.Flags().StringVar(&command, "command", "syncwright", "Executable git should invoke for the driver")
+	cmd.Flags().BoolVar(&local, "local", false, "Write the patterns to .git/info/attributes instead of .gitattributes")
+	cmd.Flags().BoolVar(&aiMode, "ai", false, "Let the driver resolve the remaining conflicts with AI")
+	cmd.Flags().Float64Var(&confidence, "confidence", 0, "Minimum AI confidence passed to the driver (default: the driver's default)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output")
+
+	return cmd
+}
+
+func newMemoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "memory",
+		Short: "Manage remembered conflict resolutions",
+		Long: `Every resolution syncwright applies is remembered under a hash of the
+conflict's ours, base and theirs content, in .git/syncwright/resolution-memory.json.
+When the same conflict comes up again, for example while re-merging or rebasing
+a long-lived branch, the remembered resolution is reused before any AI call.`,
 	}
 
-	// Write payload to temporary file
-	tmpFile, err := os.CreateTemp("", "syncwright-payload-*.json")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	cmd.AddCommand(newMemoryListCmd(), newMemoryForgetCmd(), newMemoryImportCmd())
+	return cmd
+}
+
+func newMemoryListCmd() *cobra.Command {
+	var (
+		filePath     string
+		outputFile   string
+		outputFormat string
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List remembered resolutions",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := commands.NewResolutionMemoryCommand(commands.ResolutionMemoryOptions{
+				Action:       commands.MemoryActionList,
+				FilePath:     filePath,
+				OutputFile:   outputFile,
+				OutputFormat: outputFormat,
+				Verbose:      verbose,
+			}).Execute()
+			return err
+		},
 	}
-	defer func() {
-		tmpFile.Close()
-		os.Remove(tmpFile.Name())
-	}()
 
-	if _, err := tmpFile.Write(payloadData); err != nil {
-		return nil, fmt.Errorf("failed to write payload data: %w", err)
+	cmd.Flags().StringVar(&filePath, "file", "", "Only list resolutions recorded for this file")
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: json, text")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show the resolved lines")
+
+	return cmd
+}
+
+func newMemoryForgetCmd() *cobra.Command {
+	var (
+		filePath string
+		all      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "forget [key...]",
+		Short: "Forget remembered resolutions",
+		Long: `Forgets the remembered resolutions whose key starts with one of the given
+prefixes, as shown by memory list. Use --file to forget the resolutions of a file
+and --all to clear the memory.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := commands.NewResolutionMemoryCommand(commands.ResolutionMemoryOptions{
+				Action:   commands.MemoryActionForget,
+				Keys:     args,
+				FilePath: filePath,
+				All:      all,
+			}).Execute()
+			return err
+		},
 	}
-	tmpFile.Close()
 
-	aiOptions.PayloadFile = tmpFile.Name()
+	cmd.Flags().StringVar(&filePath, "file", "", "Forget the resolutions recorded for this file")
+	cmd.Flags().BoolVar(&all, "all", false, "Forget every remembered resolution")
 
-	// Execute AI application
-	aiCmd, err := commands.NewAIApplyCommand(aiOptions)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create AI apply command: %w", err)
+	return cmd
+}
+
+func newMemoryImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import the resolutions recorded by git rerere",
+		Long: `Imports the resolutions git rerere recorded in .git/rr-cache. Records are
+imported once; records without a recorded resolution are skipped.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := commands.NewResolutionMemoryCommand(commands.ResolutionMemoryOptions{
+				Action: commands.MemoryActionImport,
+			}).Execute()
+			return err
+		},
 	}
-	return aiCmd.Execute()
+
+	return cmd
 }
 
-// outputResolveResult outputs the final result of the resolve pipeline
-func outputResolveResult(result *resolveResult) error {
-	if result.ErrorMessage != "" {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", result.ErrorMessage)
-		return fmt.Errorf(result.ErrorMessage)
+func newRunsCmd() *cobra.Command {
+	var (
+		outputFile   string
+		outputFormat string
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "List the apply runs that can be undone",
+		Long: `Before ai-apply and batch change any file, they snapshot the working tree
+content and index entries of the conflicted files into .git/syncwright/runs/<run-id>.
+This lists those runs, newest first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := commands.NewRunsCommand(commands.RunsOptions{
+				Action:       commands.RunsActionList,
+				OutputFile:   outputFile,
+				OutputFormat: outputFormat,
+				Verbose:      verbose,
+			}).Execute()
+			return err
+		},
 	}
 
-	// Always output the summary for user feedback
-	if result.Summary != "" {
-		fmt.Println(result.Summary)
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: json, text")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show the files of each run")
+
+	return cmd
+}
+
+func newUndoCmd() *cobra.Command {
+	var (
+		outputFormat string
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "undo [run-id]",
+		Short: "Restore the files of an apply run to their state before it",
+		Long: `Restores the files an apply run touched to the snapshot taken before it,
+including conflict markers and unmerged index stages, so the conflicts can be
+resolved again. Without a run ID the most recent run that was not undone is
+restored; a unique prefix of the ID as shown by runs is enough.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			runID := ""
+			if len(args) > 0 {
+				runID = args[0]
+			}
+			_, err := commands.NewRunsCommand(commands.RunsOptions{
+				Action:       commands.RunsActionUndo,
+				RunID:        runID,
+				OutputFormat: outputFormat,
+				Verbose:      verbose,
+			}).Execute()
+			return err
+		},
 	}
 
-	return nil
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: json, text")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List the restored files")
+
+	return cmd
 }
 
 func newBatchCmd() *cobra.Command {
@@ -909,6 +1336,13 @@ func newBatchCmd() *cobra.Command {
 		streaming     bool
 		backupFiles   bool
 		maxRetries    int
+		isolated      bool
+		keepWorktree  bool
+		skipValidate  bool
+		atomic        bool
+		review        bool
+		goModTidy     bool
+		regenerate    bool
 	)
 
 	cmd := &cobra.Command{
@@ -939,7 +1373,16 @@ Examples:
   syncwright batch --ai --group-by size --max-tokens 40000 --progress
 
   # Dry run to preview batch organization
-  syncwright batch --ai --dry-run --verbose --streaming`,
+  syncwright batch --ai --dry-run --verbose --streaming
+
+  # Process in a temporary worktree and only update the checkout if it validates
+  syncwright batch --ai --isolated
+
+  # Write the resolutions of all batches together, or none if any batch fails
+  syncwright batch --ai --atomic
+
+  # Decide on every hunk interactively
+  syncwright batch --ai --review`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate API key
 			if apiKey == "" {
@@ -956,21 +1399,28 @@ Examples:
 			}
 
 			options := commands.BatchOptions{
-				RepoPath:      repoPath,
-				OutputFile:    outputFile,
-				BatchSize:     batchSize,
-				Concurrency:   concurrency,
-				GroupBy:       groupBy,
-				MaxTokens:     maxTokens,
-				TimeoutSec:    timeoutSec,
-				MinConfidence: minConfidence,
-				AutoApply:     autoApply,
-				DryRun:        dryRun,
-				Verbose:       verbose,
-				Progress:      progress,
-				Streaming:     streaming,
-				BackupFiles:   backupFiles,
-				MaxRetries:    maxRetries,
+				RepoPath:            repoPath,
+				OutputFile:          outputFile,
+				BatchSize:           batchSize,
+				Concurrency:         concurrency,
+				GroupBy:             groupBy,
+				MaxTokens:           maxTokens,
+				TimeoutSec:          timeoutSec,
+				MinConfidence:       minConfidence,
+				AutoApply:           autoApply,
+				DryRun:              dryRun,
+				Verbose:             verbose,
+				Progress:            progress,
+				Streaming:           streaming,
+				BackupFiles:         backupFiles,
+				MaxRetries:          maxRetries,
+				Isolated:            isolated,
+				KeepWorktree:        keepWorktree,
+				SkipValidate:        skipValidate,
+				Atomic:              atomic,
+				Review:              review,
+				GoModTidy:           goModTidy,
+				RegenerateLockfiles: regenerate,
 			}
 
 			batchCmd := commands.NewBatchCommand(options)
@@ -1012,7 +1462,138 @@ Examples:
 	cmd.Flags().BoolVar(&autoApply, "auto-apply", false, "Automatically apply resolutions without confirmation")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview batch organization and processing without applying changes")
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Verbose output with detailed progress information")
-	cmd.Flags().BoolVar(&backupFiles, "backup", true, "Create backup files before applying resolutions")
+	cmd.Flags().BoolVar(&backupFiles, "backup", true, "Snapshot the conflicted files before applying so the run can be undone")
+	cmd.Flags().BoolVar(&isolated, "isolated", false, "Process in a temporary worktree and only update the checkout when the run passes")
+	cmd.Flags().BoolVar(&keepWorktree, "keep-worktree", false, "Keep the temporary worktree of an isolated run for inspection")
+	cmd.Flags().BoolVar(&skipValidate, "skip-validate", false, "Skip validating the worktree of an isolated or atomic run")
+	cmd.Flags().BoolVar(&atomic, "atomic", false, "Write the resolutions of all batches together, rolling back every file if one fails")
+	cmd.Flags().BoolVar(&review, "review", false, "Review every hunk interactively before it is applied")
+	cmd.Flags().BoolVar(&goModTidy, "go-mod-tidy", false, "Run go mod tidy offline in the modules whose go.mod or go.sum was resolved")
+	cmd.Flags().BoolVar(&regenerate, "regenerate-lockfiles", false, "Regenerate conflicted lockfiles from their resolved manifests (configure with syncwright.regenerate.<type>)")
+
+	return cmd
+}
+
+func newReviewCmd() *cobra.Command {
+	var (
+		reviewFile   string
+		filePath     string
+		comment      string
+		outputFormat string
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Accept, reject or edit the proposals of a review file",
+		Long: `ai-apply --propose-only writes every conflict hunk with its proposed
+resolution to a review file, with status pending. These commands record a
+decision on a hunk, so a human can approve proposals outside of a terminal
+session, for example in a later CI job. Hunks are selected by their ID or a
+unique prefix of it; use --path when two files have hunks with the same ID.
+
+Apply the accepted hunks with: syncwright apply --from <review-file>`,
+	}
+
+	run := func(action string) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			options := commands.ReviewFileOptions{
+				ReviewFile:   reviewFile,
+				Action:       action,
+				FilePath:     filePath,
+				Comment:      comment,
+				OutputFormat: outputFormat,
+				Verbose:      verbose,
+			}
+			if len(args) > 0 {
+				options.HunkID = args[0]
+			}
+			if len(args) > 1 {
+				options.LinesFile = args[1]
+			}
+			_, err := commands.NewReviewFileCommand(options).Execute()
+			return err
+		}
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the hunks of the review file and their status",
+			Args:  cobra.NoArgs,
+			RunE:  run(commands.ReviewActionList),
+		},
+		&cobra.Command{
+			Use:   "accept <hunk-id>",
+			Short: "Accept the proposed resolution of a hunk",
+			Args:  cobra.ExactArgs(1),
+			RunE:  run(commands.ReviewActionAccept),
+		},
+		&cobra.Command{
+			Use:   "reject <hunk-id>",
+			Short: "Reject the proposed resolution of a hunk",
+			Args:  cobra.ExactArgs(1),
+			RunE:  run(commands.ReviewActionReject),
+		},
+		&cobra.Command{
+			Use:   "edit <hunk-id> <lines-file>",
+			Short: "Replace the proposal of a hunk with the lines of a file (- for stdin) and accept it",
+			Args:  cobra.ExactArgs(2),
+			RunE:  run(commands.ReviewActionEdit),
+		},
+	)
+
+	cmd.PersistentFlags().StringVar(&reviewFile, "file", gitutils.DefaultReviewFileName, "Review file")
+	cmd.PersistentFlags().StringVar(&filePath, "path", "", "Only match hunks of this file")
+	cmd.PersistentFlags().StringVar(&comment, "comment", "", "Note recorded with the decision")
+	cmd.PersistentFlags().StringVar(&outputFormat, "format", "text", "Output format: json, text")
+	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Show the resolved lines when listing")
+
+	return cmd
+}
+
+func newApplyCmd() *cobra.Command {
+	var (
+		from         string
+		outputFile   string
+		outputFormat string
+		dryRun       bool
+		atomic       bool
+		stage        bool
+		backupFiles  bool
+		verbose      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the accepted resolutions of a review file",
+		Long: `Applies the hunks accepted in a review file written by ai-apply --propose-only.
+Pending and rejected hunks are skipped. Every resolution is anchored to its hunk
+by ID, so a hunk that changed since the proposal was made is refused instead of
+overwritten.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := commands.NewApplyCommand(commands.ApplyOptions{
+				From:          from,
+				DryRun:        dryRun,
+				Atomic:        atomic,
+				StageResolved: stage,
+				BackupFiles:   backupFiles,
+				OutputFile:    outputFile,
+				OutputFormat:  outputFormat,
+				Verbose:       verbose,
+			}).Execute()
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", gitutils.DefaultReviewFileName, "Review file to apply")
+	cmd.Flags().StringVarP(&outputFile, "out", "o", "", "Output file (default: stdout)")
+	cmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: json, text")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only check that every accepted hunk is still found")
+	cmd.Flags().BoolVar(&atomic, "atomic", false, "Write the resolved files all or none, rolling back every file if one fails")
+	cmd.Flags().BoolVar(&stage, "stage", false, "Add the files whose conflicts were all resolved to the index")
+	cmd.Flags().BoolVar(&backupFiles, "backup", true, "Snapshot the files before applying so the run can be undone")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "List the modified files")
 
 	return cmd
 }
@@ -28,6 +28,7 @@ type ConflictResolver struct {
 	enableMultiTurn    bool
 	maxTurns           int
 	multiTurnThreshold float64
+	disableMemory      bool
 }
 
 // ConflictResolverConfig contains configuration for the conflict resolver
@@ -41,6 +42,7 @@ type ConflictResolverConfig struct {
 	EnableMultiTurn    bool    // Enable multi-turn conversations for low-confidence conflicts
 	MaxTurns           int     // Maximum number of conversation turns
 	MultiTurnThreshold float64 // Confidence threshold below which to use multi-turn
+	DisableMemory      bool    // Do not reuse resolutions from the resolution memory
 }
 
 // ResolverResult contains the results of conflict resolution
@@ -49,8 +51,10 @@ type ResolverResult struct {
 	ProcessedFiles     int                           `json:"processed_files"`
 	ProcessedConflicts int                           `json:"processed_conflicts"`
 	Resolutions        []gitutils.ConflictResolution `json:"resolutions"`
+	FileResolutions    []gitutils.FileResolution     `json:"file_resolutions,omitempty"`
 	HighConfidence     []gitutils.ConflictResolution `json:"high_confidence"`
 	LowConfidence      []gitutils.ConflictResolution `json:"low_confidence"`
+	ReusedResolutions  int                           `json:"reused_resolutions"`
 	OverallConfidence  float64                       `json:"overall_confidence"`
 	ProcessingTime     time.Duration                 `json:"processing_time"`
 	ErrorMessage       string                        `json:"error_message,omitempty"`
@@ -104,6 +108,7 @@ func NewConflictResolver(config *ConflictResolverConfig) (*ConflictResolver, err
 		enableMultiTurn:    config.EnableMultiTurn,
 		maxTurns:           config.MaxTurns,
 		multiTurnThreshold: config.MultiTurnThreshold,
+		disableMemory:      config.DisableMemory,
 	}, nil
 }
 
@@ -120,10 +125,7 @@ func (r *ConflictResolver) ResolveConflicts(ctx context.Context, conflictPayload
 	}
 
 	// Count total conflicts
-	totalConflicts := 0
-	for _, file := range conflictPayload.Files {
-		totalConflicts += len(file.Conflicts)
-	}
+	totalConflicts := r.countConflictsInBatch(conflictPayload.Files)
 	result.ProcessedConflicts = totalConflicts
 
 	logging.Logger.ConflictResolution("conflict_resolution_started",
@@ -133,12 +135,26 @@ func (r *ConflictResolver) ResolveConflicts(ctx context.Context, conflictPayload
 		fmt.Printf("Resolving %d conflicts across %d files\n", totalConflicts, len(conflictPayload.Files))
 	}
 
+	// Remembered resolutions are reused before anything is sent to Claude
+	reused, files := r.reuseRemembered(conflictPayload.Files)
+	result.ReusedResolutions = len(reused)
+	if len(reused) > 0 {
+		logging.Logger.ConflictResolution("remembered_resolutions_reused", zap.Int("reused", len(reused)))
+		if r.verbose {
+			fmt.Printf("Reused %d remembered resolutions\n", len(reused))
+		}
+	}
+
 	// Process files in batches
-	batches := r.createBatches(conflictPayload.Files)
+	batches := r.createBatches(files)
 
-	var allResolutions []gitutils.ConflictResolution
+	allResolutions := reused
 	var totalConfidence float64
 	resolutionCount := 0
+	for _, resolution := range reused {
+		totalConfidence += resolution.Confidence
+		resolutionCount++
+	}
 
 	for i, batch := range batches {
 		logging.Logger.ConflictResolution("batch_processing",
@@ -149,19 +165,24 @@ func (r *ConflictResolver) ResolveConflicts(ctx context.Context, conflictPayload
 			fmt.Printf("Processing batch %d/%d (%d files)\n", i+1, len(batches), len(batch))
 		}
 
-		batchResolutions, err := r.processBatch(ctx, batch, conflictPayload.Metadata.RepoPath)
+		batchResolutions, fileResolutions, err := r.processBatch(ctx, batch, conflictPayload.Metadata.RepoPath)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("Batch %d failed: %v", i+1, err))
 			continue
 		}
 
 		allResolutions = append(allResolutions, batchResolutions...)
+		result.FileResolutions = append(result.FileResolutions, fileResolutions...)
 
 		// Calculate cumulative confidence
 		for _, resolution := range batchResolutions {
 			totalConfidence += resolution.Confidence
 			resolutionCount++
 		}
+		for _, resolution := range fileResolutions {
+			totalConfidence += resolution.Confidence
+			resolutionCount++
+		}
 	}
 
 	// Calculate overall confidence
@@ -180,7 +201,7 @@ func (r *ConflictResolver) ResolveConflicts(ctx context.Context, conflictPayload
 
 	result.Resolutions = allResolutions
 	result.ProcessingTime = time.Since(startTime)
-	result.Success = len(result.Resolutions) > 0
+	result.Success = len(result.Resolutions) > 0 || len(result.FileResolutions) > 0
 
 	if r.verbose {
 		fmt.Printf("Resolution complete: %d total, %d high confidence, %d low confidence (%.2f overall)\n",
@@ -190,6 +211,127 @@ func (r *ConflictResolver) ResolveConflicts(ctx context.Context, conflictPayload
 	return result, nil
 }
 
+// NeedsClaude reports whether any conflict of files is left for Claude once
+// the remembered resolutions are reused
+func (r *ConflictResolver) NeedsClaude(files []payload.ConflictFilePayload) bool {
+	_, remaining := r.reuseRemembered(files)
+	return len(remaining) > 0
+}
+
+// NeedsClaude reports whether any conflict of files is left for Claude once
+// the resolutions remembered in repoPath are reused, so callers can check for
+// credentials before creating a resolver
+func NeedsClaude(repoPath string, files []payload.ConflictFilePayload) bool {
+	_, remaining := reuseRemembered(repoPath, files)
+	return len(remaining) > 0
+}
+
+// reuseRemembered reuses the remembered resolutions unless the resolver was
+// configured without the resolution memory
+func (r *ConflictResolver) reuseRemembered(files []payload.ConflictFilePayload) ([]gitutils.ConflictResolution, []payload.ConflictFilePayload) {
+	if r.disableMemory {
+		return nil, files
+	}
+	return reuseRemembered(r.repoPath, files)
+}
+
+// reuseRemembered resolves the hunks found in the resolution memory of repoPath
+// and returns those resolutions along with the files that still need Claude.
+// Files whose hunks were all remembered are left out, unless they have a
+// file-level conflict.
+func reuseRemembered(repoPath string, files []payload.ConflictFilePayload) ([]gitutils.ConflictResolution, []payload.ConflictFilePayload) {
+	memory, err := gitutils.OpenResolutionMemory(repoPath)
+	if err != nil || len(memory.Entries) == 0 {
+		return nil, files
+	}
+
+	var reused []gitutils.ConflictResolution
+	remaining := make([]payload.ConflictFilePayload, 0, len(files))
+	for _, file := range files {
+		var conflicts []payload.ConflictHunkPayload
+		for _, conflict := range file.Conflicts {
+			// A reviewer asking for another resolution does not want the remembered one
+			if conflict.ReviewerHint != "" {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+			entry, found := memory.Lookup(gitutils.ConflictHunk{
+				OursLines:   conflict.OursLines,
+				BaseLines:   conflict.BaseLines,
+				TheirsLines: conflict.TheirsLines,
+			})
+			if !found {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+
+			reused = append(reused, gitutils.ConflictResolution{
+				FilePath:      file.Path,
+				HunkID:        conflict.HunkID,
+				StartLine:     conflict.StartLine,
+				EndLine:       conflict.EndLine,
+				ResolvedLines: entry.ResolvedLines,
+				Confidence:    entry.Confidence,
+				Reasoning:     fmt.Sprintf("Reused the %s resolution remembered as %.12s", entry.Source, entry.Key),
+				Reused:        true,
+			})
+		}
+
+		if len(conflicts) == 0 && file.FileConflict == nil {
+			continue
+		}
+		file.Conflicts = conflicts
+		remaining = append(remaining, file)
+	}
+
+	return reused, remaining
+}
+
+// attachHunkIDs sets the hunk ID of resolutions that refer to a conflict by line
+// numbers only. A resolution is tied to the conflict with exactly the same line
+// range; hunk IDs Claude echoed back are kept when they belong to a conflict of
+// the file. Key-level conflicts of files merged key by key have no lines, so
+// they are only matched by their echoed hunk ID. Resolutions matching no
+// conflict are dropped, since their lines may cover only part of a hunk or code
+// around it.
+func attachHunkIDs(resolutions []gitutils.ConflictResolution, files []payload.ConflictFilePayload) []gitutils.ConflictResolution {
+	conflictsByPath := make(map[string][]payload.ConflictHunkPayload, len(files))
+	for _, file := range files {
+		conflictsByPath[file.Path] = file.Conflicts
+	}
+
+	attached := make([]gitutils.ConflictResolution, 0, len(resolutions))
+	for _, resolution := range resolutions {
+		conflicts := conflictsByPath[resolution.FilePath]
+
+		hunkID := ""
+		for _, conflict := range conflicts {
+			if resolution.HunkID != "" && conflict.HunkID == resolution.HunkID {
+				hunkID = conflict.HunkID
+				break
+			}
+		}
+		for _, conflict := range conflicts {
+			if hunkID == "" && conflict.KeyPath == "" &&
+				conflict.StartLine == resolution.StartLine && conflict.EndLine == resolution.EndLine {
+				hunkID = conflict.HunkID
+				break
+			}
+		}
+		resolution.HunkID = hunkID
+		if hunkID == "" {
+			logging.Logger.WarnSafe("Dropped resolution that matches no conflict",
+				zap.String("file", resolution.FilePath),
+				zap.Int("start_line", resolution.StartLine),
+				zap.Int("end_line", resolution.EndLine))
+			continue
+		}
+		attached = append(attached, resolution)
+	}
+
+	return attached
+}
+
 // createBatches splits files into batches for processing
 func (r *ConflictResolver) createBatches(files []payload.ConflictFilePayload) [][]payload.ConflictFilePayload {
 	var batches [][]payload.ConflictFilePayload
@@ -206,7 +348,7 @@ func (r *ConflictResolver) createBatches(files []payload.ConflictFilePayload) []
 }
 
 // processBatch processes a batch of files for conflict resolution
-func (r *ConflictResolver) processBatch(ctx context.Context, files []payload.ConflictFilePayload, repoPath string) ([]gitutils.ConflictResolution, error) {
+func (r *ConflictResolver) processBatch(ctx context.Context, files []payload.ConflictFilePayload, repoPath string) ([]gitutils.ConflictResolution, []gitutils.FileResolution, error) {
 	// Build the prompt for Claude
 	prompt := r.buildConflictResolutionPrompt(files, repoPath)
 
@@ -221,19 +363,31 @@ func (r *ConflictResolver) processBatch(ctx context.Context, files []payload.Con
 	// Execute the command
 	response, err := r.client.ExecuteConflictResolution(ctx, prompt, contextData)
 	if err != nil {
-		return nil, fmt.Errorf("Claude execution failed: %w", err)
+		return nil, nil, fmt.Errorf("Claude execution failed: %w", err)
 	}
 
 	if !response.Success {
-		return nil, fmt.Errorf("Claude reported failure: %s", response.ErrorMessage)
+		return nil, nil, fmt.Errorf("Claude reported failure: %s", response.ErrorMessage)
+	}
+
+	// File-level resolutions only exist in the JSON response format
+	var fileResolutions []gitutils.FileResolution
+	if hasFileConflicts(files) {
+		fileResolutions = r.parseJSONFileResolutions(response.Content, files)
 	}
 
 	// Parse resolutions from Claude's response
 	resolutions, err := r.parseResolutionsFromResponse(response, files)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse resolutions: %w", err)
+		if len(fileResolutions) == 0 {
+			return nil, nil, fmt.Errorf("failed to parse resolutions: %w", err)
+		}
+		resolutions = nil
 	}
 
+	// Tie every resolution to the hunk it resolves, so it is applied by content
+	resolutions = attachHunkIDs(resolutions, files)
+
 	// Apply Go-specific confidence validation and adjustment
 	resolutions = r.adjustConfidenceWithGoValidation(resolutions, files)
 
@@ -241,11 +395,11 @@ func (r *ConflictResolver) processBatch(ctx context.Context, files []payload.Con
 	if r.enableMultiTurn {
 		resolutions, err = r.applyMultiTurnRefinement(ctx, resolutions, files, repoPath)
 		if err != nil {
-			return nil, fmt.Errorf("multi-turn refinement failed: %w", err)
+			return nil, nil, fmt.Errorf("multi-turn refinement failed: %w", err)
 		}
 	}
 
-	return resolutions, nil
+	return resolutions, fileResolutions, nil
 }
 
 // buildConflictResolutionPrompt builds a prompt for Claude to resolve conflicts
@@ -301,33 +455,36 @@ func (r *ConflictResolver) buildConflictResolutionPrompt(files []payload.Conflic
 	// Add conflict details
 	for _, file := range files {
 		prompt.WriteString(fmt.Sprintf("File: %s\n", file.Path))
-		prompt.WriteString("Conflicts:\n")
+		if file.Encoding != "" && file.Encoding != gitutils.EncodingUTF8 {
+			// Content is shown as UTF-8 and converted back when applied, so
+			// resolved lines must stay within the file's character set
+			prompt.WriteString(fmt.Sprintf("Encoding: %s (resolved lines must only use characters it can represent)\n", file.Encoding))
+		}
 
-		for i, conflict := range file.Conflicts {
-			prompt.WriteString(fmt.Sprintf("\nConflict %d (lines %d-%d):\n", i+1, conflict.StartLine, conflict.EndLine))
+		if file.FileConflict != nil {
+			r.writeFileConflictSection(&prompt, file)
+		}
 
-			// Show "ours" section
-			prompt.WriteString("<<<<<<< HEAD\n")
-			for _, line := range conflict.OursLines {
-				prompt.WriteString(line + "\n")
-			}
+		if len(file.Conflicts) > 0 {
+			prompt.WriteString("Conflicts:\n")
+		}
 
-			// Show base section if available
-			if len(conflict.BaseLines) > 0 {
-				prompt.WriteString("||||||| base\n")
-				for _, line := range conflict.BaseLines {
-					prompt.WriteString(line + "\n")
-				}
+		for i, conflict := range file.Conflicts {
+			if conflict.KeyPath != "" {
+				// The file was merged key by key; only this key is left
+				prompt.WriteString(fmt.Sprintf("\nConflict %d (key %s):\n", i+1, conflict.KeyPath))
+				prompt.WriteString("Each side shows only the value of this key, or its whole entry in YAML; resolve it in the same form. Empty resolved_lines remove the key. Echo its Hunk ID in hunk_id; a resolution without it is discarded.\n")
+			} else {
+				prompt.WriteString(fmt.Sprintf("\nConflict %d (lines %d-%d):\n", i+1, conflict.StartLine, conflict.EndLine))
 			}
-
-			// Show separator
-			prompt.WriteString("=======\n")
-
-			// Show "theirs" section
-			for _, line := range conflict.TheirsLines {
-				prompt.WriteString(line + "\n")
+			if conflict.HunkID != "" {
+				prompt.WriteString(fmt.Sprintf("Hunk ID: %s\n", conflict.HunkID))
+			}
+			if conflict.ReviewerHint != "" {
+				prompt.WriteString(fmt.Sprintf("Reviewer hint (an earlier resolution was rejected): %s\n", conflict.ReviewerHint))
 			}
-			prompt.WriteString(">>>>>>> branch\n")
+
+			writeConflictHunk(&prompt, conflict)
 		}
 
 		// Add enhanced Go-specific context
@@ -364,6 +521,7 @@ func (r *ConflictResolver) buildConflictResolutionPrompt(files []payload.Conflic
 	prompt.WriteString("  \"resolutions\": [\n")
 	prompt.WriteString("    {\n")
 	prompt.WriteString("      \"file_path\": \"path/to/file.go\",\n")
+	prompt.WriteString("      \"hunk_id\": \"the Hunk ID of the conflict, when given\",\n")
 	prompt.WriteString("      \"start_line\": 10,\n")
 	prompt.WriteString("      \"end_line\": 15,\n")
 	prompt.WriteString("      \"resolved_lines\": [\"// Resolved Go code here\", \"func example() error {\", \"  return nil\", \"}\"],\n")
@@ -375,17 +533,102 @@ func (r *ConflictResolver) buildConflictResolutionPrompt(files []payload.Conflic
 
 	prompt.WriteString(",\n      \"go_specific_notes\": \"Additional Go-specific observations about imports, types, or semantics\"")
 	prompt.WriteString("\n    }\n")
-	prompt.WriteString("  ]\n")
-	prompt.WriteString("}")
+	prompt.WriteString("  ]")
+
+	if hasFileConflicts(files) {
+		prompt.WriteString(",\n")
+		prompt.WriteString("  \"file_resolutions\": [\n")
+		prompt.WriteString("    {\n")
+		prompt.WriteString("      \"file_path\": \"path/to/deleted_or_added_file.go\",\n")
+		prompt.WriteString("      \"action\": \"keep | delete | merge\",\n")
+		prompt.WriteString("      \"resolved_lines\": [\"// Full file content, required for merge\"],\n")
+		prompt.WriteString("      \"confidence\": 0.8")
+		if r.includeReasoning {
+			prompt.WriteString(",\n      \"reasoning\": \"Why the file should be kept, deleted or merged\"")
+		}
+		prompt.WriteString("\n    }\n")
+		prompt.WriteString("  ]")
+	}
+
+	prompt.WriteString("\n}")
 
 	return prompt.String()
 }
 
+// writeFileConflictSection describes a file-level conflict and the available actions
+func (r *ConflictResolver) writeFileConflictSection(prompt *strings.Builder, file payload.ConflictFilePayload) {
+	fileConflict := file.FileConflict
+
+	prompt.WriteString(fmt.Sprintf("File-level conflict: %s (status %s)\n", fileConflict.Kind, fileConflict.Status))
+	if fileConflict.DeletedBy != "" {
+		prompt.WriteString(fmt.Sprintf("Deleted by: %s\n", fileConflict.DeletedBy))
+	}
+	if fileConflict.AddedBy != "" {
+		prompt.WriteString(fmt.Sprintf("Added by: %s\n", fileConflict.AddedBy))
+	}
+	if fileConflict.SurvivingSide != "" {
+		prompt.WriteString(fmt.Sprintf("Surviving content (%s):\n", fileConflict.SurvivingSide))
+		for _, line := range fileConflict.SurvivingContent {
+			prompt.WriteString(line + "\n")
+		}
+	}
+	prompt.WriteString("Resolve this file with a file_resolutions entry: \"keep\" keeps the surviving file, ")
+	prompt.WriteString("\"delete\" removes it, \"merge\" writes resolved_lines as the complete file content.\n")
+}
+
+// writeConflictHunk renders a conflict hunk with markers, using the labels git
+// recorded so it is clear which branch each side comes from
+func writeConflictHunk(prompt *strings.Builder, conflict payload.ConflictHunkPayload) {
+	// Show "ours" section
+	prompt.WriteString("<<<<<<< " + markerLabel(conflict.OursLabel, "HEAD") + "\n")
+	for _, line := range conflict.OursLines {
+		prompt.WriteString(line + "\n")
+	}
+
+	// Show base section if available
+	if len(conflict.BaseLines) > 0 {
+		prompt.WriteString("||||||| " + markerLabel(conflict.BaseLabel, "base") + "\n")
+		for _, line := range conflict.BaseLines {
+			prompt.WriteString(line + "\n")
+		}
+	}
+
+	// Show separator
+	prompt.WriteString("=======\n")
+
+	// Show "theirs" section
+	for _, line := range conflict.TheirsLines {
+		prompt.WriteString(line + "\n")
+	}
+	prompt.WriteString(">>>>>>> " + markerLabel(conflict.TheirsLabel, "branch") + "\n")
+}
+
+// markerLabel returns the conflict marker label, or a fallback when git recorded none
+func markerLabel(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
+
+// hasFileConflicts reports whether any file in the batch has a file-level conflict
+func hasFileConflicts(files []payload.ConflictFilePayload) bool {
+	for _, file := range files {
+		if file.FileConflict != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // countConflictsInBatch counts total conflicts in a batch
 func (r *ConflictResolver) countConflictsInBatch(files []payload.ConflictFilePayload) int {
 	total := 0
 	for _, file := range files {
 		total += len(file.Conflicts)
+		if file.FileConflict != nil {
+			total++
+		}
 	}
 	return total
 }
@@ -426,6 +669,7 @@ func (r *ConflictResolver) parseJSONResolutions(content string) ([]gitutils.Conf
 	var response struct {
 		Resolutions []struct {
 			FilePath        string   `json:"file_path"`
+			HunkID          string   `json:"hunk_id,omitempty"`
 			StartLine       int      `json:"start_line"`
 			EndLine         int      `json:"end_line"`
 			ResolvedLines   []string `json:"resolved_lines"`
@@ -444,6 +688,7 @@ func (r *ConflictResolver) parseJSONResolutions(content string) ([]gitutils.Conf
 	for _, res := range response.Resolutions {
 		resolution := gitutils.ConflictResolution{
 			FilePath:      res.FilePath,
+			HunkID:        res.HunkID,
 			StartLine:     res.StartLine,
 			EndLine:       res.EndLine,
 			ResolvedLines: res.ResolvedLines,
@@ -477,6 +722,48 @@ func (r *ConflictResolver) parseJSONResolutions(content string) ([]gitutils.Conf
 	return resolutions, nil
 }
 
+// parseJSONFileResolutions parses file-level resolutions from a JSON response.
+// Entries for files without a file-level conflict or with an invalid action are dropped.
+func (r *ConflictResolver) parseJSONFileResolutions(content string, files []payload.ConflictFilePayload) []gitutils.FileResolution {
+	jsonStart := strings.Index(content, "{")
+	jsonEnd := strings.LastIndex(content, "}")
+
+	if jsonStart == -1 || jsonEnd == -1 || jsonStart >= jsonEnd {
+		return nil
+	}
+
+	var response struct {
+		FileResolutions []gitutils.FileResolution `json:"file_resolutions"`
+	}
+
+	if err := json.Unmarshal([]byte(content[jsonStart:jsonEnd+1]), &response); err != nil {
+		return nil
+	}
+
+	fileConflicts := make(map[string]bool)
+	for _, file := range files {
+		if file.FileConflict != nil {
+			fileConflicts[file.Path] = true
+		}
+	}
+
+	var resolutions []gitutils.FileResolution
+	for _, resolution := range response.FileResolutions {
+		if !fileConflicts[resolution.FilePath] {
+			continue
+		}
+		if err := gitutils.ValidateFileResolution(resolution); err != nil {
+			if r.verbose {
+				fmt.Printf("Ignoring file resolution for %s: %v\n", resolution.FilePath, err)
+			}
+			continue
+		}
+		resolutions = append(resolutions, resolution)
+	}
+
+	return resolutions
+}
+
 // parseTextResolutions extracts resolutions from text format
 func (r *ConflictResolver) parseTextResolutions(content string, files []payload.ConflictFilePayload) ([]gitutils.ConflictResolution, error) {
 	var resolutions []gitutils.ConflictResolution
@@ -585,7 +872,7 @@ func (r *ConflictResolver) buildPayloadFromFiles(filePaths []string) (*payload.C
 
 	for _, filePath := range filePaths {
 		// Parse conflicts from the file
-		hunks, err := gitutils.ParseConflictHunks(filePath, r.repoPath)
+		hunks, err := gitutils.GetConflictHunks(filePath, r.repoPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse conflicts in %s: %w", filePath, err)
 		}
@@ -595,11 +882,15 @@ func (r *ConflictResolver) buildPayloadFromFiles(filePaths []string) (*payload.C
 		for i, hunk := range hunks {
 			conflicts = append(conflicts, payload.ConflictHunkPayload{
 				ID:          fmt.Sprintf("%s:%d", filePath, i),
+				HunkID:      hunk.ID,
 				StartLine:   hunk.StartLine,
 				EndLine:     hunk.EndLine,
 				OursLines:   hunk.OursLines,
 				TheirsLines: hunk.TheirsLines,
 				BaseLines:   hunk.BaseLines,
+				OursLabel:   hunk.OursLabel,
+				BaseLabel:   hunk.BaseLabel,
+				TheirsLabel: hunk.TheirsLabel,
 			})
 		}
 
@@ -1182,21 +1473,7 @@ func (r *ConflictResolver) buildRefinementPrompt(resolution gitutils.ConflictRes
 	// Find the specific conflict hunk
 	for _, conflict := range file.Conflicts {
 		if conflict.StartLine <= resolution.StartLine && conflict.EndLine >= resolution.EndLine {
-			prompt.WriteString("<<<<<<< HEAD\n")
-			for _, line := range conflict.OursLines {
-				prompt.WriteString(line + "\n")
-			}
-			if len(conflict.BaseLines) > 0 {
-				prompt.WriteString("||||||| base\n")
-				for _, line := range conflict.BaseLines {
-					prompt.WriteString(line + "\n")
-				}
-			}
-			prompt.WriteString("=======\n")
-			for _, line := range conflict.TheirsLines {
-				prompt.WriteString(line + "\n")
-			}
-			prompt.WriteString(">>>>>>> branch\n")
+			writeConflictHunk(&prompt, conflict)
 			break
 		}
 	}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
 	"github.com/NeuBlink/syncwright/internal/payload"
 	"github.com/NeuBlink/syncwright/internal/testutils"
 )
@@ -283,6 +284,37 @@ func TestConflictResolver_BuildConflictResolutionPrompt(t *testing.T) {
 	}
 }
 
+func TestConflictResolver_BuildConflictResolutionPrompt_Labels(t *testing.T) {
+	resolver := &ConflictResolver{repoPath: "/test/repo"}
+
+	files := []payload.ConflictFilePayload{
+		{
+			Path:     "README.md",
+			Language: "markdown",
+			Conflicts: []payload.ConflictHunkPayload{
+				{
+					StartLine:   1,
+					EndLine:     7,
+					OursLines:   []string{"ours"},
+					BaseLines:   []string{"base"},
+					TheirsLines: []string{"theirs"},
+					OursLabel:   "HEAD",
+					BaseLabel:   "3f2a9c1",
+					TheirsLabel: "feature/login",
+				},
+			},
+		},
+	}
+
+	prompt := resolver.buildConflictResolutionPrompt(files, "/test/repo")
+
+	for _, marker := range []string{"<<<<<<< HEAD\n", "||||||| 3f2a9c1\n", ">>>>>>> feature/login\n"} {
+		if !strings.Contains(prompt, marker) {
+			t.Errorf("buildConflictResolutionPrompt() missing labelled marker %q", marker)
+		}
+	}
+}
+
 func TestConflictResolver_ParseJSONResolutions(t *testing.T) {
 	resolver := &ConflictResolver{}
 
@@ -1002,3 +1034,96 @@ func BenchmarkConflictResolver_ValidateFunctionSignatures(b *testing.B) {
 		_ = resolver.validateFunctionSignatures(resolvedLines, file)
 	}
 }
+
+func TestConflictResolver_ReusesRememberedResolutions(t *testing.T) {
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	repoPath := t.TempDir()
+	if err := testutils.SetupTestGitRepository(repoPath); err != nil {
+		t.Skipf("git not available: %v", err)
+	}
+
+	remembered := gitutils.ConflictHunk{OursLines: []string{"our code"}, TheirsLines: []string{"their code"}}
+	err := gitutils.UpdateResolutionMemory(repoPath, func(memory *gitutils.ResolutionMemory) error {
+		memory.Record("main.go", remembered, []string{"merged code"}, 0.9, gitutils.MemorySourceSyncwright)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateResolutionMemory() error = %v", err)
+	}
+
+	// Every hunk is remembered, so the client is never needed
+	resolver := &ConflictResolver{repoPath: repoPath, minConfidence: 0.7, maxBatchSize: 10}
+	result, err := resolver.ResolveConflicts(context.Background(), &payload.ConflictPayload{
+		Metadata: payload.PayloadMetadata{RepoPath: repoPath},
+		Files: []payload.ConflictFilePayload{{
+			Path: "main.go",
+			Conflicts: []payload.ConflictHunkPayload{
+				{StartLine: 3, EndLine: 7, OursLines: []string{"our code"}, TheirsLines: []string{"their code"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("ResolveConflicts() error = %v", err)
+	}
+
+	if !result.Success || result.ReusedResolutions != 1 || len(result.HighConfidence) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	resolution := result.Resolutions[0]
+	if !resolution.Reused || resolution.StartLine != 3 || resolution.EndLine != 7 || resolution.ResolvedLines[0] != "merged code" {
+		t.Errorf("unexpected resolution %+v", resolution)
+	}
+}
+
+func TestAttachHunkIDs(t *testing.T) {
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	files := []payload.ConflictFilePayload{{
+		Path: "main.go",
+		Conflicts: []payload.ConflictHunkPayload{
+			{HunkID: "aaaa", StartLine: 3, EndLine: 7},
+			{HunkID: "bbbb", StartLine: 12, EndLine: 16},
+		},
+	}}
+
+	resolutions := attachHunkIDs([]gitutils.ConflictResolution{
+		{FilePath: "main.go", StartLine: 3, EndLine: 7},
+		{FilePath: "main.go", StartLine: 13, EndLine: 15},                // Inside the second hunk
+		{FilePath: "main.go", StartLine: 10, EndLine: 14},                // Partly overlaps the second hunk
+		{FilePath: "main.go", StartLine: 1, EndLine: 20},                 // Spans both hunks
+		{FilePath: "main.go", HunkID: "cccc", StartLine: 3, EndLine: 7},  // Unknown ID
+		{FilePath: "main.go", HunkID: "bbbb", StartLine: 3, EndLine: 7},  // Known ID
+		{FilePath: "other.go", HunkID: "aaaa", StartLine: 3, EndLine: 7}, // Not a conflicted file
+	}, files)
+
+	expected := []string{"aaaa", "aaaa", "bbbb"}
+	if len(resolutions) != len(expected) {
+		t.Fatalf("attachHunkIDs() = %+v, expected %d resolutions", resolutions, len(expected))
+	}
+	for i, resolution := range resolutions {
+		if resolution.HunkID != expected[i] {
+			t.Errorf("resolution %d has hunk ID %q, expected %q", i, resolution.HunkID, expected[i])
+		}
+	}
+
+	// Key-level conflicts all span lines 0-0, so only an echoed ID matches them
+	keyFiles := []payload.ConflictFilePayload{{
+		Path: "config.json",
+		Conflicts: []payload.ConflictHunkPayload{
+			{HunkID: "dddd", KeyPath: "server.port"},
+			{HunkID: "eeee", KeyPath: "server.host"},
+		},
+	}}
+	resolutions = attachHunkIDs([]gitutils.ConflictResolution{
+		{FilePath: "config.json"},
+		{FilePath: "config.json", HunkID: "eeee"},
+	}, keyFiles)
+	if len(resolutions) != 1 || resolutions[0].HunkID != "eeee" {
+		t.Errorf("attachHunkIDs() = %+v, expected only the resolution with an echoed ID", resolutions)
+	}
+}
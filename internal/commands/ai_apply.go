@@ -13,6 +13,7 @@ import (
 	"github.com/NeuBlink/syncwright/internal/gitutils"
 	"github.com/NeuBlink/syncwright/internal/logging"
 	"github.com/NeuBlink/syncwright/internal/payload"
+	"github.com/NeuBlink/syncwright/internal/strategy"
 	"github.com/NeuBlink/syncwright/internal/validation"
 	"go.uber.org/zap"
 )
@@ -26,9 +27,32 @@ type AIApplyOptions struct {
 	Verbose        bool
 	AutoApply      bool
 	MinConfidence  float64
-	BackupFiles    bool
+	BackupFiles    bool // Snapshot the files into .git/syncwright/runs so the run can be undone
 	MaxRetries     int
 	TimeoutSeconds int
+	// Atomic applies the resolutions to all files or to none of them
+	Atomic bool
+	// StageResolved adds the files whose conflicts were all resolved to the
+	// index. It has no effect with a Writer, whose owner writes the files.
+	StageResolved bool
+	// SuggestionMode places the proposals that were not confident enough to
+	// apply next to their conflicts for a human to review: "inline" inserts a
+	// suggestion block after each conflict, "sidecar" writes them to
+	// gitutils.SuggestionsFileName, and "" leaves them out
+	SuggestionMode string
+	// Review steps through every hunk interactively and applies what the
+	// reviewer decided instead of filtering the proposals by confidence
+	Review bool
+	// ProposeOnly writes the proposals to ReviewFile for a later, separate
+	// approval instead of applying anything
+	ProposeOnly bool
+	ReviewFile  string // Defaults to gitutils.DefaultReviewFileName
+	// SkipStrategies sends every hunk to Claude instead of resolving the ones
+	// the deterministic strategies recognise first
+	SkipStrategies bool
+	// Writer, when set, stages the resolutions instead of writing them; the
+	// owner of the writer commits them together with other staged changes
+	Writer *gitutils.TransactionalWriter
 }
 
 // AIApplyResult represents the result of the AI application
@@ -39,7 +63,14 @@ type AIApplyResult struct {
 	SkippedResolutions int                           `json:"skipped_resolutions"`
 	FailedResolutions  int                           `json:"failed_resolutions"`
 	Resolutions        []gitutils.ConflictResolution `json:"resolutions"`
+	FileResolutions    []gitutils.FileResolution     `json:"file_resolutions,omitempty"`
+	StrategyResolved   int                           `json:"strategy_resolved,omitempty"` // Hunks resolved without AI
 	ApplicationResult  *gitutils.ResolutionResult    `json:"application_result,omitempty"`
+	RunID              string                        `json:"run_id,omitempty"`
+	Suggestions        []gitutils.ConflictResolution `json:"suggestions,omitempty"`
+	NeedsReview        []PendingHunk                 `json:"needs_review,omitempty"`
+	Review             *ReviewOutcome                `json:"review,omitempty"`
+	ReviewFile         string                        `json:"review_file,omitempty"`
 	ErrorMessage       string                        `json:"error_message,omitempty"`
 	AIResponse         *AIResolveResponse            `json:"ai_response,omitempty"`
 	ValidationResult   *validation.ValidationResult  `json:"validation_result,omitempty"`
@@ -49,6 +80,7 @@ type AIApplyResult struct {
 type AIResolveResponse struct {
 	Success           bool                          `json:"success"`
 	Resolutions       []gitutils.ConflictResolution `json:"resolutions"`
+	FileResolutions   []gitutils.FileResolution     `json:"file_resolutions,omitempty"`
 	OverallConfidence float64                       `json:"overall_confidence"`
 	Reasoning         string                        `json:"reasoning,omitempty"`
 	Warnings          []string                      `json:"warnings,omitempty"`
@@ -57,6 +89,26 @@ type AIResolveResponse struct {
 	ProcessingTime    float64                       `json:"processing_time,omitempty"`
 }
 
+// PendingHunk is a conflict left for a human to resolve
+type PendingHunk struct {
+	FilePath   string  `json:"file_path"`
+	HunkID     string  `json:"hunk_id,omitempty"`
+	StartLine  int     `json:"start_line,omitempty"`
+	EndLine    int     `json:"end_line,omitempty"`
+	Reason     string  `json:"reason"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Suggested  bool    `json:"suggested"` // A proposed resolution was placed for review
+}
+
+// Reasons a conflict is left for a human
+const (
+	PendingNoProposal    = "no resolution proposed"
+	PendingLowConfidence = "low confidence"
+	PendingNotApplied    = "not applied"
+	PendingFailed        = "resolution failed"
+	PendingHumanOnly     = "not sent to AI; run go mod tidy or resolve by hand"
+)
+
 // AIApplyCommand implements the ai-apply subcommand
 type AIApplyCommand struct {
 	options  AIApplyOptions
@@ -80,6 +132,14 @@ func NewAIApplyCommand(options AIApplyOptions) (*AIApplyCommand, error) {
 			options.RepoPath = wd
 		}
 	}
+	if options.ReviewFile == "" {
+		options.ReviewFile = gitutils.DefaultReviewFileName
+	}
+	switch options.SuggestionMode {
+	case "", gitutils.SuggestionsInline, gitutils.SuggestionsSidecar:
+	default:
+		return nil, fmt.Errorf("unknown suggestion mode %q (expected inline or sidecar)", options.SuggestionMode)
+	}
 
 	// Create ConflictResolver with proper Claude CLI configuration
 	config := &claude.ConflictResolverConfig{
@@ -128,16 +188,59 @@ func (a *AIApplyCommand) Execute() (*AIApplyResult, error) {
 		return result, err
 	}
 
-	// Step 3: Process and filter resolutions
+	// Proposals only go to the review file; an approval job applies them later
+	if a.options.ProposeOnly {
+		if err := a.writeReviewFile(conflictPayload, aiResponse, result); err != nil {
+			return result, err
+		}
+		result.ProcessedFiles = len(conflictPayload.Files)
+		if err := a.outputResults(result); err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
+			return result, err
+		}
+		return result, nil
+	}
+
+	// Step 3: Process and filter resolutions, or let a human review them
 	filteredResolutions := a.processResolutions(aiResponse, result)
+	var reviewer *HunkReviewer
+	if a.options.Review && !a.options.DryRun {
+		reviewer, err = NewHunkReviewer(HunkReviewerOptions{
+			RepoPath: a.options.RepoPath,
+			Retry:    a.retryWithHint(conflictPayload),
+		})
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to start the review: %v", err)
+			return result, err
+		}
+		defer reviewer.Close()
+
+		filteredResolutions, err = a.reviewResolutions(reviewer, conflictPayload, aiResponse, result)
+		if err != nil {
+			return result, err
+		}
+	}
 
 	// Step 4: Apply resolutions if appropriate
 	err = a.applyResolutionsIfNeeded(filteredResolutions, result)
 	if err != nil {
 		return result, err
 	}
+	if reviewer != nil && !result.Review.Quit && resolutionsApplied(result) {
+		// The review is finished, so the next one starts afresh
+		if err := reviewer.Forget(result.Review); err != nil {
+			logging.Logger.WarnSafe("Failed to clear the review session", zap.Error(err))
+		}
+	}
+	if a.options.StageResolved && a.options.Writer == nil && result.ApplicationResult != nil {
+		a.stageResolvedFiles(result.ApplicationResult)
+	}
+
+	// Step 5: Report the conflicts left for a human, with the proposals for them
+	a.placeSuggestions(filteredResolutions, result)
+	a.collectPendingHunks(conflictPayload, aiResponse, result)
 
-	// Step 5: Finalize results
+	// Step 6: Finalize results
 	result.ProcessedFiles = len(conflictPayload.Files)
 	if err := a.outputResults(result); err != nil {
 		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
@@ -161,33 +264,66 @@ func (a *AIApplyCommand) prepareInput(result *AIApplyResult) (*payload.ConflictP
 		fmt.Printf("Loaded payload with %d conflicted files\n", len(conflictPayload.Files))
 	}
 
-	// Validate Claude CLI availability
-	if !a.resolver.IsAvailable() {
-		result.ErrorMessage = "Claude Code CLI is not available. Please ensure 'claude' is installed and in your PATH"
-		return nil, fmt.Errorf("Claude CLI not available")
-	}
-
-	// Create backup if requested
-	if a.options.BackupFiles {
-		if err := a.createBackups(conflictPayload); err != nil {
-			result.ErrorMessage = fmt.Sprintf("Failed to create backups: %v", err)
+	// Snapshot the files so the run can be undone
+	if a.options.BackupFiles && !a.options.DryRun && !a.options.ProposeOnly {
+		paths := make([]string, 0, len(conflictPayload.Files))
+		for _, file := range conflictPayload.Files {
+			paths = append(paths, file.Path)
+		}
+		if a.options.SuggestionMode == gitutils.SuggestionsSidecar {
+			paths = append(paths, gitutils.SuggestionsFileName)
+		}
+		runID, err := createRunSnapshot(a.options.RepoPath, "ai-apply", paths, a.options.Verbose)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to snapshot files: %v", err)
 			return nil, err
 		}
+		result.RunID = runID
 	}
 
 	return conflictPayload, nil
 }
 
-// getAIResolutions sends payload to AI and gets response
+// getAIResolutions resolves the hunks the deterministic strategies recognise,
+// sends the rest to AI and returns both sets of resolutions in one response
 func (a *AIApplyCommand) getAIResolutions(
 	conflictPayload *payload.ConflictPayload,
 	result *AIApplyResult,
 ) (*AIResolveResponse, error) {
-	aiResponse, err := a.sendToAI(conflictPayload)
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("Failed to get AI resolution: %v", err)
-		return nil, err
+	ruled := a.resolveByStrategy(conflictPayload)
+	result.StrategyResolved = len(ruled.Resolutions) + len(ruled.FileResolutions)
+
+	aiResponse := &AIResolveResponse{Success: true}
+	if remaining := ruled.Remaining.ForAI(); len(remaining.Files) > 0 {
+		// Validate Claude CLI availability, unless remembered resolutions cover
+		// every remaining conflict
+		if a.resolver.NeedsClaude(remaining.Files) && !a.resolver.IsAvailable() {
+			result.ErrorMessage = "Claude Code CLI is not available. Please ensure 'claude' is installed and in your PATH"
+			return nil, fmt.Errorf("Claude CLI not available")
+		}
+
+		var err error
+		aiResponse, err = a.sendToAI(remaining)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to get AI resolution: %v", err)
+			return nil, err
+		}
+		if aiResponse.Success {
+			// The keys of JSON and YAML files come back as hunks; merge those files
+			merged, rest, warnings := ruled.Complete(aiResponse.Resolutions)
+			aiResponse.Resolutions = rest
+			aiResponse.FileResolutions = append(aiResponse.FileResolutions, merged...)
+			aiResponse.Warnings = append(aiResponse.Warnings, warnings...)
+		}
+	}
+	if !aiResponse.Success && result.StrategyResolved > 0 {
+		// The hunks resolved by the strategies are still worth applying
+		aiResponse = &AIResolveResponse{
+			Success:  true,
+			Warnings: []string{fmt.Sprintf("AI resolution failed: %s", aiResponse.ErrorMessage)},
+		}
 	}
+	mergeStrategyResolutions(aiResponse, ruled)
 
 	result.AIResponse = aiResponse
 
@@ -207,6 +343,50 @@ func (a *AIApplyCommand) getAIResolutions(
 	return aiResponse, nil
 }
 
+// resolveByStrategy resolves the hunks that need no AI, merges JSON and YAML
+// files key by key, and returns a payload of the remaining conflicts
+func (a *AIApplyCommand) resolveByStrategy(conflictPayload *payload.ConflictPayload) *strategy.PayloadResult {
+	if a.options.SkipStrategies {
+		return &strategy.PayloadResult{Remaining: conflictPayload}
+	}
+
+	engine := strategy.NewEngine(a.options.RepoPath)
+	// Reviews decide hunk by hunk, which a whole-file merge would bypass
+	engine.LineOnly = a.options.Review || a.options.ProposeOnly
+	ruled := engine.ResolvePayload(conflictPayload)
+	if resolved := len(ruled.Resolutions) + len(ruled.FileResolutions); resolved > 0 {
+		logging.Logger.ConflictResolution("strategy_resolutions_generated",
+			zap.Int("resolved", len(ruled.Resolutions)),
+			zap.Int("merged_files", len(ruled.FileResolutions)),
+			zap.Int("remaining_files", len(ruled.Remaining.Files)))
+		if a.options.Verbose {
+			fmt.Printf("Resolved %d conflicts without AI\n", resolved)
+		}
+	}
+	return ruled
+}
+
+// mergeStrategyResolutions puts the strategy resolutions ahead of the AI ones
+// and folds their confidence into the overall confidence
+func mergeStrategyResolutions(aiResponse *AIResolveResponse, ruled *strategy.PayloadResult) {
+	ruledCount := len(ruled.Resolutions) + len(ruled.FileResolutions)
+	if ruledCount == 0 {
+		return
+	}
+
+	aiCount := len(aiResponse.Resolutions) + len(aiResponse.FileResolutions)
+	total := aiResponse.OverallConfidence * float64(aiCount)
+	for _, resolution := range ruled.Resolutions {
+		total += resolution.Confidence
+	}
+	for _, fileResolution := range ruled.FileResolutions {
+		total += fileResolution.Confidence
+	}
+	aiResponse.OverallConfidence = total / float64(aiCount+ruledCount)
+	aiResponse.Resolutions = append(ruled.Resolutions, aiResponse.Resolutions...)
+	aiResponse.FileResolutions = append(ruled.FileResolutions, aiResponse.FileResolutions...)
+}
+
 // processResolutions filters resolutions by confidence
 func (a *AIApplyCommand) processResolutions(
 	aiResponse *AIResolveResponse,
@@ -215,6 +395,21 @@ func (a *AIApplyCommand) processResolutions(
 	filteredResolutions := a.filterResolutionsByConfidence(aiResponse.Resolutions)
 	result.Resolutions = filteredResolutions
 	result.SkippedResolutions = len(aiResponse.Resolutions) - len(filteredResolutions)
+	if a.options.SuggestionMode != "" {
+		for _, resolution := range aiResponse.Resolutions {
+			if resolution.Confidence < a.options.MinConfidence {
+				result.Suggestions = append(result.Suggestions, resolution)
+			}
+		}
+	}
+
+	for _, fileResolution := range aiResponse.FileResolutions {
+		if fileResolution.Confidence >= a.options.MinConfidence {
+			result.FileResolutions = append(result.FileResolutions, fileResolution)
+		} else {
+			result.SkippedResolutions++
+		}
+	}
 
 	if result.SkippedResolutions > 0 {
 		logging.Logger.ConflictResolution("resolutions_skipped",
@@ -229,6 +424,163 @@ func (a *AIApplyCommand) processResolutions(
 	return filteredResolutions
 }
 
+// reviewResolutions lets a human decide on every hunk of the payload and
+// returns the resolutions they chose. Proposals for the hunks left conflicted
+// are kept as suggestions.
+func (a *AIApplyCommand) reviewResolutions(
+	reviewer *HunkReviewer,
+	conflictPayload *payload.ConflictPayload,
+	aiResponse *AIResolveResponse,
+	result *AIApplyResult,
+) ([]gitutils.ConflictResolution, error) {
+	proposals := make(map[string]gitutils.ConflictResolution)
+	for _, resolution := range aiResponse.Resolutions {
+		proposals[pendingKey(resolution.FilePath, resolution.HunkID, resolution.StartLine, resolution.EndLine)] = resolution
+		proposals[pendingKey(resolution.FilePath, "", resolution.StartLine, resolution.EndLine)] = resolution
+	}
+
+	var items []ReviewItem
+	for _, file := range conflictPayload.Files {
+		for _, conflict := range file.Conflicts {
+			item := ReviewItem{FilePath: file.Path, Conflict: conflict}
+			proposal, found := proposals[pendingKey(file.Path, conflict.HunkID, conflict.StartLine, conflict.EndLine)]
+			if !found {
+				proposal, found = proposals[pendingKey(file.Path, "", conflict.StartLine, conflict.EndLine)]
+			}
+			if found {
+				proposal.HunkID = conflict.HunkID
+				item.Proposal = &proposal
+			}
+			items = append(items, item)
+		}
+	}
+
+	outcome, err := reviewer.Review(items)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Review failed: %v", err)
+		return nil, err
+	}
+	result.Review = outcome
+	result.Resolutions = outcome.Resolutions
+	result.SkippedResolutions = len(items) - len(outcome.Resolutions)
+
+	resolved := make(map[string]bool, len(outcome.Resolutions))
+	for _, resolution := range outcome.Resolutions {
+		resolved[pendingKey(resolution.FilePath, resolution.HunkID, resolution.StartLine, resolution.EndLine)] = true
+	}
+	result.Suggestions = nil
+	if a.options.SuggestionMode != "" {
+		for _, item := range items {
+			if item.Proposal != nil && !resolved[pendingKey(item.FilePath, item.Conflict.HunkID, item.Conflict.StartLine, item.Conflict.EndLine)] {
+				result.Suggestions = append(result.Suggestions, *item.Proposal)
+			}
+		}
+	}
+
+	logging.Logger.ConflictResolution("review_completed",
+		zap.Int("decisions", len(outcome.Decisions)),
+		zap.Int("resumed", outcome.Resumed),
+		zap.Int("remaining", outcome.Remaining),
+		zap.Bool("quit", outcome.Quit))
+	return outcome.Resolutions, nil
+}
+
+// writeReviewFile writes every hunk of the payload with its proposal to the
+// review file, pending a decision
+func (a *AIApplyCommand) writeReviewFile(
+	conflictPayload *payload.ConflictPayload,
+	aiResponse *AIResolveResponse,
+	result *AIApplyResult,
+) error {
+	proposals := make(map[string]gitutils.ConflictResolution)
+	for _, resolution := range aiResponse.Resolutions {
+		proposals[pendingKey(resolution.FilePath, resolution.HunkID, resolution.StartLine, resolution.EndLine)] = resolution
+		proposals[pendingKey(resolution.FilePath, "", resolution.StartLine, resolution.EndLine)] = resolution
+	}
+
+	var entries []*gitutils.ReviewEntry
+	for _, file := range conflictPayload.Files {
+		for _, conflict := range file.Conflicts {
+			entry := &gitutils.ReviewEntry{
+				FilePath:    file.Path,
+				HunkID:      conflict.HunkID,
+				StartLine:   conflict.StartLine,
+				EndLine:     conflict.EndLine,
+				OursLines:   conflict.OursLines,
+				BaseLines:   conflict.BaseLines,
+				TheirsLines: conflict.TheirsLines,
+			}
+			proposal, found := proposals[pendingKey(file.Path, conflict.HunkID, conflict.StartLine, conflict.EndLine)]
+			if !found {
+				proposal, found = proposals[pendingKey(file.Path, "", conflict.StartLine, conflict.EndLine)]
+			}
+			if found {
+				entry.Proposed = true
+				entry.ProposedLines = proposal.ResolvedLines
+				entry.Confidence = proposal.Confidence
+				entry.Reasoning = proposal.Reasoning
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	if err := gitutils.NewReviewFile(entries).Save(a.options.ReviewFile); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to write review file: %v", err)
+		return err
+	}
+	result.ReviewFile = a.options.ReviewFile
+	result.Resolutions = aiResponse.Resolutions
+	result.Success = true
+
+	logging.Logger.ConflictResolution("review_file_written",
+		zap.String("review_file", a.options.ReviewFile),
+		zap.Int("hunk_count", len(entries)))
+	if a.options.Verbose {
+		fmt.Printf("Wrote %d hunks pending review to %s\n", len(entries), a.options.ReviewFile)
+	}
+	return nil
+}
+
+// retryWithHint returns a function that asks Claude to resolve a single hunk
+// again, with a hint from the reviewer
+func (a *AIApplyCommand) retryWithHint(conflictPayload *payload.ConflictPayload) ReviewRetryFunc {
+	return func(item ReviewItem, hint string) (*gitutils.ConflictResolution, error) {
+		if payload.IsHumanOnly(item.FilePath) {
+			return nil, fmt.Errorf("conflicts of %s are not sent to AI", item.FilePath)
+		}
+		file := payload.ConflictFilePayload{Path: item.FilePath}
+		for _, candidate := range conflictPayload.Files {
+			if candidate.Path == item.FilePath {
+				file = candidate
+				break
+			}
+		}
+		conflict := item.Conflict
+		conflict.ReviewerHint = hint
+		file.FileConflict = nil
+		file.Conflicts = []payload.ConflictHunkPayload{conflict}
+
+		retryPayload := &payload.ConflictPayload{
+			Metadata: conflictPayload.Metadata,
+			Files:    []payload.ConflictFilePayload{file},
+		}
+		retryPayload.Metadata.TotalFiles = 1
+		retryPayload.Metadata.TotalConflicts = 1
+
+		response, err := a.sendToAI(retryPayload)
+		if err != nil {
+			return nil, err
+		}
+		if !response.Success || len(response.Resolutions) == 0 {
+			return nil, fmt.Errorf("no resolution proposed: %s", response.ErrorMessage)
+		}
+
+		proposal := response.Resolutions[0]
+		proposal.HunkID = conflict.HunkID
+		return &proposal, nil
+	}
+}
+
 // applyResolutionsIfNeeded applies resolutions based on options
 func (a *AIApplyCommand) applyResolutionsIfNeeded(
 	filteredResolutions []gitutils.ConflictResolution,
@@ -237,16 +589,24 @@ func (a *AIApplyCommand) applyResolutionsIfNeeded(
 	if a.options.DryRun {
 		logging.Logger.ConflictResolution("dry_run_completed", zap.Int("would_apply_count", len(filteredResolutions)))
 		result.Success = true
-		fmt.Printf("Dry run: Would apply %d resolutions\n", len(filteredResolutions))
+		fmt.Printf("Dry run: Would apply %d resolutions\n", len(filteredResolutions)+len(result.FileResolutions))
+		return nil
+	}
+
+	if result.Review != nil && result.Review.Quit {
+		result.Success = true
+		fmt.Printf("Review paused with %d hunks left; nothing was applied. Run the command again to resume.\n",
+			result.Review.Remaining)
 		return nil
 	}
 
-	if len(filteredResolutions) == 0 {
+	if len(filteredResolutions) == 0 && len(result.FileResolutions) == 0 {
 		result.Success = true
 		return nil
 	}
 
-	if a.options.AutoApply {
+	// A reviewer already decided on every resolution
+	if a.options.AutoApply || result.Review != nil {
 		return a.applyResolutionsAutomatically(filteredResolutions, result)
 	}
 
@@ -258,17 +618,10 @@ func (a *AIApplyCommand) applyResolutionsAutomatically(
 	filteredResolutions []gitutils.ConflictResolution,
 	result *AIApplyResult,
 ) error {
-	applicationResult, err := gitutils.ApplyResolutions(a.options.RepoPath, filteredResolutions)
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("Failed to apply resolutions: %v", err)
+	if err := a.applyAllResolutions(filteredResolutions, result); err != nil {
 		return err
 	}
 
-	result.ApplicationResult = applicationResult
-	result.AppliedResolutions = applicationResult.AppliedCount
-	result.FailedResolutions = applicationResult.FailedCount
-	result.Success = applicationResult.Success
-
 	logging.Logger.ConflictResolution("resolutions_applied",
 		zap.Int("applied_count", result.AppliedResolutions),
 		zap.Int("failed_count", result.FailedResolutions))
@@ -285,17 +638,10 @@ func (a *AIApplyCommand) applyResolutionsInteractively(
 	filteredResolutions []gitutils.ConflictResolution,
 	result *AIApplyResult,
 ) error {
-	if a.askForConfirmation(filteredResolutions) {
-		applicationResult, err := gitutils.ApplyResolutions(a.options.RepoPath, filteredResolutions)
-		if err != nil {
-			result.ErrorMessage = fmt.Sprintf("Failed to apply resolutions: %v", err)
+	if a.askForConfirmation(filteredResolutions, result.FileResolutions) {
+		if err := a.applyAllResolutions(filteredResolutions, result); err != nil {
 			return err
 		}
-
-		result.ApplicationResult = applicationResult
-		result.AppliedResolutions = applicationResult.AppliedCount
-		result.FailedResolutions = applicationResult.FailedCount
-		result.Success = applicationResult.Success
 	} else {
 		logging.Logger.ConflictResolution("application_cancelled", zap.String("reason", "user_choice"))
 		result.Success = true
@@ -305,6 +651,315 @@ func (a *AIApplyCommand) applyResolutionsInteractively(
 	return nil
 }
 
+// applyAllResolutions applies hunk resolutions followed by file-level resolutions
+// and records the combined outcome in the result
+func (a *AIApplyCommand) applyAllResolutions(
+	filteredResolutions []gitutils.ConflictResolution,
+	result *AIApplyResult,
+) error {
+	if a.options.Atomic || a.options.Writer != nil {
+		return a.applyTransactionally(filteredResolutions, result)
+	}
+
+	applicationResult, err := gitutils.ApplyResolutions(a.options.RepoPath, filteredResolutions)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to apply resolutions: %v", err)
+		return err
+	}
+
+	if len(result.FileResolutions) > 0 {
+		fileResult, err := gitutils.ApplyFileResolutions(a.options.RepoPath, result.FileResolutions)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to apply file resolutions: %v", err)
+			return err
+		}
+		mergeResolutionResults(applicationResult, fileResult)
+	}
+
+	a.recordApplicationResult(applicationResult, result)
+	return nil
+}
+
+// applyTransactionally stages the resolutions on a transactional writer. A
+// writer passed in the options is committed by its owner; otherwise the
+// resolutions are committed here, to all files or to none.
+func (a *AIApplyCommand) applyTransactionally(
+	filteredResolutions []gitutils.ConflictResolution,
+	result *AIApplyResult,
+) error {
+	writer := a.options.Writer
+	if writer == nil {
+		writer = gitutils.NewTransactionalWriter(a.options.RepoPath, nil)
+	}
+
+	applicationResult := writer.StageResolutions(filteredResolutions)
+	var fileResult *gitutils.ResolutionResult
+	if len(result.FileResolutions) > 0 {
+		fileResult = writer.StageFileResolutions(result.FileResolutions)
+		mergeResolutionResults(applicationResult, fileResult)
+	}
+
+	if a.options.Writer == nil {
+		var err error
+		applicationResult, err = gitutils.CommitResolutions(writer, applicationResult)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to apply resolutions: %v", err)
+			return err
+		}
+		if transaction := applicationResult.Transaction; transaction != nil && !transaction.Committed() {
+			result.ErrorMessage = fmt.Sprintf("Resolutions were rolled back: %s", transaction.ErrorMessage)
+		} else if fileResult != nil {
+			// As with ApplyFileResolutions, staging is what marks a file-level
+			// conflict resolved
+			if err := gitutils.StageFiles(a.options.RepoPath, gitutils.AppliedFiles(fileResult)); err != nil {
+				applicationResult.Errors = append(applicationResult.Errors, err.Error())
+			}
+		}
+	}
+
+	a.recordApplicationResult(applicationResult, result)
+	return nil
+}
+
+// mergeResolutionResults adds the outcome of file-level resolutions to the
+// outcome of hunk resolutions
+func mergeResolutionResults(applicationResult, fileResult *gitutils.ResolutionResult) {
+	applicationResult.AppliedCount += fileResult.AppliedCount
+	applicationResult.FailedCount += fileResult.FailedCount
+	applicationResult.ModifiedFiles = append(applicationResult.ModifiedFiles, fileResult.ModifiedFiles...)
+	applicationResult.DeletedFiles = append(applicationResult.DeletedFiles, fileResult.DeletedFiles...)
+	applicationResult.FailedFiles = append(applicationResult.FailedFiles, fileResult.FailedFiles...)
+	applicationResult.Success = applicationResult.Success && fileResult.Success
+}
+
+// stageResolvedFiles adds the fully resolved files to the index. A failure to
+// stage is reported but leaves the applied resolutions in place.
+func (a *AIApplyCommand) stageResolvedFiles(applicationResult *gitutils.ResolutionResult) {
+	if err := gitutils.StageResolvedFiles(a.options.RepoPath, applicationResult); err != nil {
+		applicationResult.Errors = append(applicationResult.Errors, fmt.Sprintf("failed to stage resolved files: %v", err))
+		logging.Logger.WarnSafe("Failed to stage resolved files", zap.Error(err))
+		return
+	}
+
+	staged := countStaged(applicationResult.Staging)
+	logging.Logger.ConflictResolution("resolved_files_staged", zap.Int("staged_count", staged))
+	if a.options.Verbose {
+		fmt.Printf("Staged %d of %d resolved files\n", staged, len(applicationResult.Staging))
+	}
+}
+
+// placeSuggestions puts the proposals that were not applied next to their
+// conflicts and keeps the ones that were placed in the result. Nothing is placed on a dry run, when the user declined to apply
+// the resolutions, or when an atomic apply was rolled back.
+func (a *AIApplyCommand) placeSuggestions(filteredResolutions []gitutils.ConflictResolution, result *AIApplyResult) {
+	attempted := len(filteredResolutions) > 0 || len(result.FileResolutions) > 0
+	if a.options.DryRun || (attempted && !resolutionsApplied(result)) {
+		result.Suggestions = nil
+	}
+	if len(result.Suggestions) == 0 {
+		return
+	}
+
+	var placed *gitutils.ResolutionResult
+	switch {
+	case a.options.SuggestionMode == gitutils.SuggestionsSidecar:
+		if err := gitutils.WriteSuggestionsFile(a.options.RepoPath, result.Suggestions); err != nil {
+			result.Suggestions = nil
+			logging.Logger.WarnSafe("Failed to write suggestions", zap.Error(err))
+			return
+		}
+	case a.options.Writer != nil:
+		placed = a.options.Writer.StageSuggestions(result.Suggestions)
+	default:
+		placed, _ = gitutils.InsertSuggestions(a.options.RepoPath, result.Suggestions)
+	}
+
+	// Suggestions whose conflict was not found are left out of the result
+	if placed != nil && len(placed.FailedFiles) > 0 {
+		failed := make(map[string]bool, len(placed.FailedFiles))
+		for _, failure := range placed.FailedFiles {
+			failed[pendingKey(failure.FilePath, failure.HunkID, failure.StartLine, failure.EndLine)] = true
+		}
+		kept := result.Suggestions[:0]
+		for _, suggestion := range result.Suggestions {
+			if !failed[pendingKey(suggestion.FilePath, suggestion.HunkID, suggestion.StartLine, suggestion.EndLine)] {
+				kept = append(kept, suggestion)
+			}
+		}
+		result.Suggestions = kept
+		logging.Logger.WarnSafe("Some suggestions could not be placed", zap.Int("failed_count", placed.FailedCount))
+	}
+
+	logging.Logger.ConflictResolution("suggestions_placed",
+		zap.String("mode", a.options.SuggestionMode),
+		zap.Int("suggestion_count", len(result.Suggestions)))
+	if a.options.Verbose {
+		if a.options.SuggestionMode == gitutils.SuggestionsSidecar {
+			fmt.Printf("Wrote %d suggestions to %s\n", len(result.Suggestions), gitutils.SuggestionsFileName)
+		} else {
+			fmt.Printf("Inserted %d suggestions next to their conflicts\n", len(result.Suggestions))
+		}
+	}
+}
+
+// collectPendingHunks lists the conflicts of the payload that were not settled
+// by an applied resolution, with the reason and any proposal placed for them
+func (a *AIApplyCommand) collectPendingHunks(
+	conflictPayload *payload.ConflictPayload,
+	aiResponse *AIResolveResponse,
+	result *AIApplyResult,
+) {
+	// Proposals are found by hunk ID, or by lines when they have none
+	proposals := make(map[string]gitutils.ConflictResolution)
+	for _, resolution := range aiResponse.Resolutions {
+		proposals[pendingKey(resolution.FilePath, resolution.HunkID, resolution.StartLine, resolution.EndLine)] = resolution
+		proposals[pendingKey(resolution.FilePath, "", resolution.StartLine, resolution.EndLine)] = resolution
+	}
+	suggested := make(map[string]bool)
+	for _, suggestion := range result.Suggestions {
+		suggested[pendingKey(suggestion.FilePath, suggestion.HunkID, suggestion.StartLine, suggestion.EndLine)] = true
+	}
+	findProposal := func(filePath string, conflict payload.ConflictHunkPayload) (gitutils.ConflictResolution, bool) {
+		if proposal, found := proposals[pendingKey(filePath, conflict.HunkID, conflict.StartLine, conflict.EndLine)]; found {
+			return proposal, true
+		}
+		proposal, found := proposals[pendingKey(filePath, "", conflict.StartLine, conflict.EndLine)]
+		return proposal, found
+	}
+	fileProposals := make(map[string]gitutils.FileResolution)
+	for _, fileResolution := range aiResponse.FileResolutions {
+		fileProposals[fileResolution.FilePath] = fileResolution
+	}
+
+	applied := resolutionsApplied(result)
+	failedFiles := make(map[string]string)
+	if result.ApplicationResult != nil {
+		for _, failure := range result.ApplicationResult.FailedFiles {
+			failedFiles[failure.FilePath] = failure.ErrorMessage
+		}
+	}
+
+	// After a review the decisions tell which hunks were meant to be resolved
+	decided := make(map[string]string)
+	if result.Review != nil {
+		for _, decision := range result.Review.Decisions {
+			decided[pendingKey(decision.FilePath, decision.HunkID, 0, 0)] = decision.Action
+		}
+	}
+	reviewReason := func(filePath, hunkID string) string {
+		switch action, found := decided[pendingKey(filePath, hunkID, 0, 0)]; {
+		case !found:
+			return PendingNotReviewed
+		case action == gitutils.ReviewReject:
+			return PendingRejected
+		case !applied:
+			return PendingNotApplied
+		case failedFiles[filePath] != "":
+			return fmt.Sprintf("%s: %s", PendingFailed, failedFiles[filePath])
+		}
+		return ""
+	}
+
+	// reason returns why a proposal did not settle its conflict, or "" when it did
+	reason := func(filePath string, found bool, confidence float64) string {
+		switch {
+		case !found && payload.IsHumanOnly(filePath):
+			return PendingHumanOnly
+		case !found:
+			return PendingNoProposal
+		case confidence < a.options.MinConfidence:
+			return PendingLowConfidence
+		case !applied:
+			return PendingNotApplied
+		case failedFiles[filePath] != "":
+			return fmt.Sprintf("%s: %s", PendingFailed, failedFiles[filePath])
+		}
+		return ""
+	}
+
+	for _, file := range conflictPayload.Files {
+		if file.FileConflict != nil && len(file.Conflicts) == 0 {
+			proposal, found := fileProposals[file.Path]
+			if why := reason(file.Path, found, proposal.Confidence); why != "" {
+				result.NeedsReview = append(result.NeedsReview, PendingHunk{
+					FilePath: file.Path, Reason: why, Confidence: proposal.Confidence,
+				})
+			}
+			continue
+		}
+
+		// A file merged key by key settles all of its hunks at once
+		merged, mergedFound := fileProposals[file.Path]
+		for _, conflict := range file.Conflicts {
+			proposal, found := findProposal(file.Path, conflict)
+			why := reason(file.Path, found, proposal.Confidence)
+			switch {
+			case mergedFound:
+				proposal.Confidence = merged.Confidence
+				why = reason(file.Path, true, merged.Confidence)
+			case result.Review != nil:
+				why = reviewReason(file.Path, conflict.HunkID)
+			}
+			if why != "" {
+				result.NeedsReview = append(result.NeedsReview, PendingHunk{
+					FilePath:   file.Path,
+					HunkID:     conflict.HunkID,
+					StartLine:  conflict.StartLine,
+					EndLine:    conflict.EndLine,
+					Reason:     why,
+					Confidence: proposal.Confidence,
+					Suggested:  found && suggested[pendingKey(proposal.FilePath, proposal.HunkID, proposal.StartLine, proposal.EndLine)],
+				})
+			}
+		}
+	}
+
+	if len(result.NeedsReview) == 0 {
+		return
+	}
+	logging.Logger.ConflictResolution("conflicts_need_review", zap.Int("pending_count", len(result.NeedsReview)))
+	if a.options.Verbose {
+		fmt.Printf("%d conflicts still need a human:\n", len(result.NeedsReview))
+		for _, pending := range result.NeedsReview {
+			location := pending.FilePath
+			if pending.StartLine > 0 {
+				location = fmt.Sprintf("%s:%d-%d", pending.FilePath, pending.StartLine, pending.EndLine)
+			}
+			note := ""
+			if pending.Suggested {
+				note = " (suggestion placed)"
+			}
+			fmt.Printf("  - %s: %s%s\n", location, pending.Reason, note)
+		}
+	}
+}
+
+// resolutionsApplied reports whether the resolutions were written, or staged
+// on the writer of the options
+func resolutionsApplied(result *AIApplyResult) bool {
+	if result.ApplicationResult == nil {
+		return false
+	}
+	transaction := result.ApplicationResult.Transaction
+	return transaction == nil || transaction.Committed()
+}
+
+// pendingKey identifies a conflict by its hunk ID, or by its lines when it has none
+func pendingKey(filePath, hunkID string, startLine, endLine int) string {
+	if hunkID != "" {
+		return filePath + "#" + hunkID
+	}
+	return fmt.Sprintf("%s:%d-%d", filePath, startLine, endLine)
+}
+
+// recordApplicationResult records the outcome of applying resolutions
+func (a *AIApplyCommand) recordApplicationResult(applicationResult *gitutils.ResolutionResult, result *AIApplyResult) {
+	result.ApplicationResult = applicationResult
+	result.AppliedResolutions = applicationResult.AppliedCount
+	result.FailedResolutions = applicationResult.FailedCount
+	result.Success = applicationResult.Success
+}
+
 // loadPayload loads and validates the conflict payload from file or stdin
 func (a *AIApplyCommand) loadPayload() (*payload.ConflictPayload, error) {
 	var data []byte
@@ -375,20 +1030,36 @@ func (a *AIApplyCommand) convertValidatedPayload(validated *validation.Validated
 		file := payload.ConflictFilePayload{
 			Path:     validatedFile.Path,
 			Language: validatedFile.Language,
+			Encoding: validatedFile.Encoding,
 			Context: payload.FileContext{
 				BeforeLines: validatedFile.Context.BeforeLines,
 				AfterLines:  validatedFile.Context.AfterLines,
 			},
 		}
 
+		if fc := validatedFile.FileConflict; fc != nil {
+			file.FileConflict = &payload.FileConflictPayload{
+				Kind:             fc.Kind,
+				Status:           fc.Status,
+				DeletedBy:        fc.DeletedBy,
+				AddedBy:          fc.AddedBy,
+				SurvivingSide:    fc.SurvivingSide,
+				SurvivingContent: fc.SurvivingContent,
+			}
+		}
+
 		for _, validatedConflict := range validatedFile.Conflicts {
 			conflict := payload.ConflictHunkPayload{
 				ID:          validatedConflict.ID,
+				HunkID:      validatedConflict.HunkID,
 				StartLine:   validatedConflict.StartLine,
 				EndLine:     validatedConflict.EndLine,
 				OursLines:   validatedConflict.OursLines,
 				TheirsLines: validatedConflict.TheirsLines,
 				BaseLines:   validatedConflict.BaseLines,
+				OursLabel:   validatedConflict.OursLabel,
+				BaseLabel:   validatedConflict.BaseLabel,
+				TheirsLabel: validatedConflict.TheirsLabel,
 			}
 			file.Conflicts = append(file.Conflicts, conflict)
 		}
@@ -426,6 +1097,7 @@ func (a *AIApplyCommand) sendToAI(conflictPayload *payload.ConflictPayload) (*AI
 	aiResponse := &AIResolveResponse{
 		Success:           result.Success,
 		Resolutions:       result.Resolutions,
+		FileResolutions:   result.FileResolutions,
 		OverallConfidence: result.OverallConfidence,
 		ProcessingTime:    result.ProcessingTime.Seconds(),
 		Warnings:          result.Warnings,
@@ -471,31 +1143,12 @@ func (a *AIApplyCommand) filterResolutionsByConfidence(
 	return filtered
 }
 
-// createBackups creates backup files before applying resolutions
-func (a *AIApplyCommand) createBackups(conflictPayload *payload.ConflictPayload) error {
-	var filesToBackup []string
-
-	for _, file := range conflictPayload.Files {
-		filesToBackup = append(filesToBackup, file.Path)
-	}
-
-	for _, filePath := range filesToBackup {
-		if err := gitutils.CreateBackup(a.options.RepoPath, filePath); err != nil {
-			return fmt.Errorf("failed to backup %s: %w", filePath, err)
-		}
-	}
-
-	logging.Logger.InfoSafe("Backup files created", zap.Int("file_count", len(filesToBackup)))
-	if a.options.Verbose {
-		fmt.Printf("Created backups for %d files\n", len(filesToBackup))
-	}
-
-	return nil
-}
-
 // askForConfirmation asks the user for confirmation before applying resolutions
-func (a *AIApplyCommand) askForConfirmation(resolutions []gitutils.ConflictResolution) bool {
-	fmt.Printf("\nAI has generated %d conflict resolutions.\n", len(resolutions))
+func (a *AIApplyCommand) askForConfirmation(
+	resolutions []gitutils.ConflictResolution,
+	fileResolutions []gitutils.FileResolution,
+) bool {
+	fmt.Printf("\nAI has generated %d conflict resolutions.\n", len(resolutions)+len(fileResolutions))
 	fmt.Println("Preview of resolutions:")
 
 	for i, resolution := range resolutions {
@@ -524,6 +1177,14 @@ func (a *AIApplyCommand) askForConfirmation(resolutions []gitutils.ConflictResol
 		}
 	}
 
+	for _, fileResolution := range fileResolutions {
+		fmt.Printf("\n- %s: %s file (confidence: %.2f)\n",
+			fileResolution.FilePath, fileResolution.Action, fileResolution.Confidence)
+		if fileResolution.Reasoning != "" {
+			fmt.Printf("   Reasoning: %s\n", fileResolution.Reasoning)
+		}
+	}
+
 	fmt.Print("\nApply these resolutions? [y/N]: ")
 	var response string
 	if _, err := fmt.Scanln(&response); err != nil {
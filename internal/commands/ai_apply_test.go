@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"github.com/NeuBlink/syncwright/internal/payload"
+	"github.com/NeuBlink/syncwright/internal/strategy"
+)
+
+func TestAIApplyCommand_ResolvesTrivialHunksWithoutAI(t *testing.T) {
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	repoPath := t.TempDir()
+	content := "start\n" +
+		"<<<<<<< HEAD\nsame\n=======\nsame\n>>>>>>> feature\n" +
+		"middle\n" +
+		"<<<<<<< HEAD\nours\n||||||| base\n=======\ntheirs\n>>>>>>> feature\n"
+	if err := os.WriteFile(filepath.Join(repoPath, "trivial.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hunks, err := gitutils.ParseConflictHunks("trivial.txt", repoPath)
+	if err != nil || len(hunks) != 2 {
+		t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+	}
+	file := payload.ConflictFilePayload{Path: "trivial.txt", Language: "text"}
+	for i, hunk := range hunks {
+		file.Conflicts = append(file.Conflicts, payload.ConflictHunkPayload{
+			ID: fmt.Sprintf("trivial.txt:%d", i), HunkID: hunk.ID, StartLine: hunk.StartLine, EndLine: hunk.EndLine,
+			OursLines: hunk.OursLines, BaseLines: hunk.BaseLines, TheirsLines: hunk.TheirsLines,
+			OursLabel: hunk.OursLabel, BaseLabel: hunk.BaseLabel, TheirsLabel: hunk.TheirsLabel,
+		})
+	}
+	data, err := json.Marshal(payload.ConflictPayload{
+		Metadata: payload.PayloadMetadata{RepoPath: repoPath, TotalFiles: 1, TotalConflicts: 2, Version: "1.0"},
+		Files:    []payload.ConflictFilePayload{file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadFile := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(payloadFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every hunk is resolved by a strategy, so Claude is never asked
+	cmd, err := NewAIApplyCommand(AIApplyOptions{
+		PayloadFile: payloadFile,
+		RepoPath:    repoPath,
+		OutputFile:  filepath.Join(t.TempDir(), "result.json"),
+		AutoApply:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v (%s)", err, result.ErrorMessage)
+	}
+	if result.StrategyResolved != 2 || len(result.Resolutions) != 2 || result.FailedResolutions != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+	if result.Resolutions[0].Strategy != strategy.StrategyIdentical || result.Resolutions[1].Strategy != strategy.StrategyUnion {
+		t.Errorf("strategies = %q, %q", result.Resolutions[0].Strategy, result.Resolutions[1].Strategy)
+	}
+	if got, _ := os.ReadFile(filepath.Join(repoPath, "trivial.txt")); string(got) != "start\nsame\nmiddle\nours\ntheirs\n" {
+		t.Errorf("trivial.txt = %q", got)
+	}
+}
+
+func TestAIApplyCommand_KeepsGoSumFromAI(t *testing.T) {
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+	// Were the hunk sent to Claude, its confident answer would be applied
+	useFakeClaude(t, `Resolution: {"resolutions": [{"file_path": "go.sum", "start_line": 1, "end_line": 5, "resolved_lines": ["example.com/a v1.0.0 h1:guess="], "confidence": 0.99}]}`)
+
+	repoPath := t.TempDir()
+	content := "<<<<<<< HEAD\nexample.com/a v1.0.0 h1:ours=\n=======\nexample.com/a v1.0.0 h1:theirs=\n>>>>>>> feature\n"
+	if err := os.WriteFile(filepath.Join(repoPath, "go.sum"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hunks, err := gitutils.ParseConflictHunks("go.sum", repoPath)
+	if err != nil || len(hunks) != 1 {
+		t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+	}
+	hunk := hunks[0]
+	data, err := json.Marshal(payload.ConflictPayload{
+		Metadata: payload.PayloadMetadata{RepoPath: repoPath, TotalFiles: 1, TotalConflicts: 1, Version: "1.0"},
+		Files: []payload.ConflictFilePayload{{Path: "go.sum", Language: "text", Conflicts: []payload.ConflictHunkPayload{{
+			ID: "go.sum:0", HunkID: hunk.ID, StartLine: hunk.StartLine, EndLine: hunk.EndLine,
+			OursLines: hunk.OursLines, TheirsLines: hunk.TheirsLines,
+		}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payloadFile := filepath.Join(t.TempDir(), "payload.json")
+	if err := os.WriteFile(payloadFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, err := NewAIApplyCommand(AIApplyOptions{
+		PayloadFile: payloadFile,
+		RepoPath:    repoPath,
+		OutputFile:  filepath.Join(t.TempDir(), "result.json"),
+		AutoApply:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v (%s)", err, result.ErrorMessage)
+	}
+	if len(result.NeedsReview) != 1 || result.NeedsReview[0].Reason != PendingHumanOnly {
+		t.Errorf("needs review = %+v, expected the go.sum hunk for a human", result.NeedsReview)
+	}
+	if got, _ := os.ReadFile(filepath.Join(repoPath, "go.sum")); string(got) != content {
+		t.Errorf("go.sum = %q", got)
+	}
+}
@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// ApplyOptions contains options for the apply command, which applies the
+// accepted entries of a review file
+type ApplyOptions struct {
+	RepoPath      string
+	From          string // Review file written by ai-apply --propose-only
+	DryRun        bool   // Verify that every accepted hunk is still found without writing
+	Atomic        bool
+	StageResolved bool
+	BackupFiles   bool // Snapshot the files into .git/syncwright/runs so the run can be undone
+	OutputFile    string
+	OutputFormat  string // "json", "text"
+	Verbose       bool
+}
+
+// ApplyResult represents the result of the apply command
+type ApplyResult struct {
+	Success           bool                       `json:"success"`
+	ReviewFile        string                     `json:"review_file"`
+	AcceptedCount     int                        `json:"accepted_count"`
+	SkippedCount      int                        `json:"skipped_count"` // Pending and rejected entries
+	AppliedCount      int                        `json:"applied_count"`
+	FailedCount       int                        `json:"failed_count"`
+	ApplicationResult *gitutils.ResolutionResult `json:"application_result,omitempty"`
+	RunID             string                     `json:"run_id,omitempty"`
+	ErrorMessage      string                     `json:"error_message,omitempty"`
+}
+
+// ApplyCommand applies the accepted resolutions of a review file. Every
+// resolution is anchored to its hunk by ID, so a hunk that changed since the
+// proposal was made is refused instead of overwritten.
+type ApplyCommand struct {
+	options ApplyOptions
+}
+
+// NewApplyCommand creates a new apply command
+func NewApplyCommand(options ApplyOptions) *ApplyCommand {
+	if options.From == "" {
+		options.From = gitutils.DefaultReviewFileName
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = OutputFormatText
+	}
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &ApplyCommand{options: options}
+}
+
+// Execute applies the accepted resolutions
+func (a *ApplyCommand) Execute() (*ApplyResult, error) {
+	result := &ApplyResult{ReviewFile: a.options.From}
+
+	// Resolutions that could not be applied are still reported
+	err := a.apply(result)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		if result.ApplicationResult == nil {
+			return result, err
+		}
+	}
+
+	if outputErr := a.outputResults(result); outputErr != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", outputErr)
+		return result, outputErr
+	}
+	return result, err
+}
+
+// apply loads the review file and applies its accepted entries
+func (a *ApplyCommand) apply(result *ApplyResult) error {
+	reviewFile, err := gitutils.LoadReviewFile(a.options.From)
+	if err != nil {
+		return err
+	}
+
+	resolutions := reviewFile.AcceptedResolutions()
+	result.AcceptedCount = len(resolutions)
+	result.SkippedCount = len(reviewFile.Entries) - len(resolutions)
+	if len(resolutions) == 0 {
+		result.Success = true
+		return nil
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, resolution := range resolutions {
+		if !seen[resolution.FilePath] {
+			seen[resolution.FilePath] = true
+			paths = append(paths, resolution.FilePath)
+		}
+	}
+
+	if a.options.BackupFiles && !a.options.DryRun {
+		runID, err := createRunSnapshot(a.options.RepoPath, "apply", paths, a.options.Verbose)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot files: %w", err)
+		}
+		result.RunID = runID
+	}
+
+	var applicationResult *gitutils.ResolutionResult
+	switch {
+	case a.options.DryRun:
+		// Staged contents are discarded, so the hunks are only looked up
+		writer := gitutils.NewTransactionalWriter(a.options.RepoPath, nil)
+		applicationResult = writer.StageResolutions(resolutions)
+		writer.Discard("dry run")
+	case a.options.Atomic:
+		writer := gitutils.NewTransactionalWriter(a.options.RepoPath, nil)
+		applicationResult, err = gitutils.CommitResolutions(writer, writer.StageResolutions(resolutions))
+	default:
+		applicationResult, err = gitutils.ApplyResolutions(a.options.RepoPath, resolutions)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply resolutions: %w", err)
+	}
+
+	if a.options.StageResolved && !a.options.DryRun {
+		if err := gitutils.StageResolvedFiles(a.options.RepoPath, applicationResult); err != nil {
+			return fmt.Errorf("failed to stage resolved files: %w", err)
+		}
+	}
+
+	result.ApplicationResult = applicationResult
+	result.AppliedCount = applicationResult.AppliedCount
+	result.FailedCount = applicationResult.FailedCount
+	result.Success = applicationResult.Success
+
+	logging.Logger.ConflictResolution("review_file_applied",
+		zap.String("review_file", a.options.From),
+		zap.Int("applied_count", result.AppliedCount),
+		zap.Int("failed_count", result.FailedCount),
+		zap.Bool("dry_run", a.options.DryRun))
+	if !result.Success {
+		return fmt.Errorf("%d accepted resolutions could not be applied", result.FailedCount)
+	}
+	return nil
+}
+
+// outputResults outputs the result in the configured format
+func (a *ApplyCommand) outputResults(result *ApplyResult) error {
+	var output []byte
+
+	switch a.options.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = append(data, '\n')
+	case OutputFormatText:
+		output = []byte(a.formatTextOutput(result))
+	default:
+		return fmt.Errorf("unsupported output format: %s", a.options.OutputFormat)
+	}
+
+	if a.options.OutputFile != "" {
+		if err := os.WriteFile(a.options.OutputFile, output, 0600); err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", a.options.OutputFile, err)
+		}
+		return nil
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// formatTextOutput renders the result for humans
+func (a *ApplyCommand) formatTextOutput(result *ApplyResult) string {
+	var builder strings.Builder
+
+	switch {
+	case result.AcceptedCount == 0:
+		fmt.Fprintf(&builder, "No accepted resolutions in %s (%d pending or rejected)\n",
+			result.ReviewFile, result.SkippedCount)
+		return builder.String()
+	case a.options.DryRun:
+		fmt.Fprintf(&builder, "🔍 Dry run: %d of %d accepted resolutions still match their hunks\n",
+			result.AppliedCount, result.AcceptedCount)
+	default:
+		fmt.Fprintf(&builder, "✅ Applied %d of %d accepted resolutions; %d pending or rejected were skipped\n",
+			result.AppliedCount, result.AcceptedCount, result.SkippedCount)
+	}
+
+	if application := result.ApplicationResult; application != nil {
+		for _, failure := range application.FailedFiles {
+			fmt.Fprintf(&builder, "  ❌ %s: %s\n", failure.FilePath, failure.ErrorMessage)
+		}
+		if a.options.Verbose {
+			for _, path := range application.ModifiedFiles {
+				fmt.Fprintf(&builder, "  modified %s\n", path)
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// ApplyReviewFile is a convenience function that applies the accepted entries
+// of a review file to a repository
+func ApplyReviewFile(repoPath, reviewFile string) (*ApplyResult, error) {
+	cmd := NewApplyCommand(ApplyOptions{
+		RepoPath:     repoPath,
+		From:         reviewFile,
+		BackupFiles:  true,
+		OutputFormat: OutputFormatJSON,
+	})
+	return cmd.Execute()
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// writeTestReviewFile writes a review file proposing a resolution for every
+// hunk of conflict.txt
+func writeTestReviewFile(t *testing.T, repoPath string) string {
+	t.Helper()
+
+	hunks, err := gitutils.ParseConflictHunks("conflict.txt", repoPath)
+	if err != nil || len(hunks) != 1 {
+		t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+	}
+
+	var entries []*gitutils.ReviewEntry
+	for _, hunk := range hunks {
+		entries = append(entries, &gitutils.ReviewEntry{
+			FilePath: "conflict.txt", HunkID: hunk.ID, StartLine: hunk.StartLine, EndLine: hunk.EndLine,
+			OursLines: hunk.OursLines, TheirsLines: hunk.TheirsLines,
+			Proposed: true, ProposedLines: []string{"main and feature"}, Confidence: 0.6,
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "review.json")
+	if err := gitutils.NewReviewFile(entries).Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	return path
+}
+
+func TestApplyCommand_AppliesOnlyAcceptedHunks(t *testing.T) {
+	repoPath := newIsolatedTestRepo(t)
+	reviewFile := writeTestReviewFile(t, repoPath)
+	conflicted, err := os.ReadFile(filepath.Join(repoPath, "conflict.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply := func() (*ApplyResult, error) {
+		return NewApplyCommand(ApplyOptions{
+			RepoPath:     repoPath,
+			From:         reviewFile,
+			OutputFormat: OutputFormatJSON,
+			OutputFile:   filepath.Join(t.TempDir(), "result.json"),
+		}).Execute()
+	}
+
+	// Pending hunks are not applied
+	result, err := apply()
+	if err != nil || result.AcceptedCount != 0 || result.SkippedCount != 1 {
+		t.Fatalf("apply() = %+v, %v", result, err)
+	}
+
+	loaded, err := gitutils.LoadReviewFile(reviewFile)
+	if err != nil {
+		t.Fatalf("LoadReviewFile() error = %v", err)
+	}
+	hunkID := loaded.Entries[0].HunkID
+	if _, err := DecideReviewEntry(reviewFile, ReviewActionAccept, hunkID[:6]); err != nil {
+		t.Fatalf("DecideReviewEntry() error = %v", err)
+	}
+
+	// A hunk that changed after the proposal is refused
+	changed := strings.Replace(string(conflicted), "main\n", "main, changed\n", 1)
+	if err := os.WriteFile(filepath.Join(repoPath, "conflict.txt"), []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := apply(); err == nil || result.FailedCount != 1 {
+		t.Fatalf("expected the changed hunk to be refused, got %+v, %v", result, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(content) != changed {
+		t.Errorf("conflict.txt = %q, expected it untouched", content)
+	}
+
+	// The accepted hunk applies once it matches again
+	if err := os.WriteFile(filepath.Join(repoPath, "conflict.txt"), conflicted, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = apply()
+	if err != nil || result.AppliedCount != 1 {
+		t.Fatalf("apply() = %+v, %v", result, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(content) != "main and feature\n" {
+		t.Errorf("conflict.txt = %q", content)
+	}
+}
+
+func TestReviewFileCommand_EditAndReject(t *testing.T) {
+	repoPath := newIsolatedTestRepo(t)
+	reviewFile := writeTestReviewFile(t, repoPath)
+	loaded, err := gitutils.LoadReviewFile(reviewFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hunkID := loaded.Entries[0].HunkID
+
+	linesFile := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(linesFile, []byte("edited\r\nby hand\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewReviewFileCommand(ReviewFileOptions{
+		ReviewFile:   reviewFile,
+		Action:       ReviewActionEdit,
+		HunkID:       hunkID,
+		FilePath:     "conflict.txt",
+		LinesFile:    linesFile,
+		OutputFormat: OutputFormatJSON,
+		OutputFile:   filepath.Join(t.TempDir(), "result.json"),
+	}).Execute()
+	if err != nil {
+		t.Fatalf("edit error = %v", err)
+	}
+	resolution := result.Entry.Resolution()
+	if result.Entry.Status != gitutils.ReviewStatusAccepted || strings.Join(resolution.ResolvedLines, "|") != "edited|by hand" {
+		t.Errorf("edited entry = %+v", result.Entry)
+	}
+
+	if _, err := DecideReviewEntry(reviewFile, ReviewActionReject, hunkID); err != nil {
+		t.Fatalf("reject error = %v", err)
+	}
+	if _, err := DecideReviewEntry(reviewFile, ReviewActionAccept, "ffffffffffffffff"); err == nil {
+		t.Error("expected an unknown hunk ID to be refused")
+	}
+
+	loaded, err = gitutils.LoadReviewFile(reviewFile)
+	if err != nil || len(loaded.AcceptedResolutions()) != 0 || loaded.CountByStatus()[gitutils.ReviewStatusRejected] != 1 {
+		t.Errorf("review file after reject = %+v, %v", loaded, err)
+	}
+}
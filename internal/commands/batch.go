@@ -11,6 +11,7 @@ import (
 
 	"github.com/NeuBlink/syncwright/internal/gitutils"
 	"github.com/NeuBlink/syncwright/internal/payload"
+	"github.com/NeuBlink/syncwright/internal/validate"
 )
 
 // BatchOptions contains options for the batch command
@@ -28,28 +29,50 @@ type BatchOptions struct {
 	Verbose       bool
 	Progress      bool
 	Streaming     bool
-	BackupFiles   bool
+	BackupFiles   bool // Snapshot every conflicted file before the batches run, so the run can be undone
 	MaxRetries    int
+	// Isolated processes the batches in a temporary worktree and only brings
+	// the resolved files back when the run passes validation
+	Isolated     bool
+	KeepWorktree bool
+	SkipValidate bool
+	// Atomic stages the resolutions of every batch on one transactional writer
+	// and writes them all or none once every batch succeeded
+	Atomic bool
+	// Review steps through the hunks of every batch interactively; batches
+	// take turns at the terminal
+	Review bool
+	// GoModTidy runs go mod tidy offline in the modules whose go.mod or
+	// go.sum was conflicted once the resolutions are applied
+	GoModTidy bool
+	// RegenerateLockfiles leaves conflicted lockfiles out of the batches and
+	// regenerates them once the resolutions are applied, see DefaultLockfiles
+	RegenerateLockfiles bool
 }
 
 // BatchResult represents the result of batch processing
 type BatchResult struct {
-	Success            bool                    `json:"success"`
-	TotalConflicts     int                     `json:"total_conflicts"`
-	TotalBatches       int                     `json:"total_batches"`
-	ProcessedBatches   int                     `json:"processed_batches"`
-	SuccessfulBatches  int                     `json:"successful_batches"`
-	FailedBatches      int                     `json:"failed_batches"`
-	TotalResolutions   int                     `json:"total_resolutions"`
-	AppliedResolutions int                     `json:"applied_resolutions"`
-	SkippedResolutions int                     `json:"skipped_resolutions"`
-	FailedResolutions  int                     `json:"failed_resolutions"`
-	ProcessingTimeMs   int64                   `json:"processing_time_ms"`
-	AverageConfidence  float64                 `json:"average_confidence"`
-	BatchResults       []BatchItemResult       `json:"batch_results"`
-	ErrorMessage       string                  `json:"error_message,omitempty"`
-	Warnings           []string                `json:"warnings,omitempty"`
-	Performance        BatchPerformanceMetrics `json:"performance"`
+	Success            bool                        `json:"success"`
+	TotalConflicts     int                         `json:"total_conflicts"`
+	TotalBatches       int                         `json:"total_batches"`
+	ProcessedBatches   int                         `json:"processed_batches"`
+	SuccessfulBatches  int                         `json:"successful_batches"`
+	FailedBatches      int                         `json:"failed_batches"`
+	TotalResolutions   int                         `json:"total_resolutions"`
+	AppliedResolutions int                         `json:"applied_resolutions"`
+	SkippedResolutions int                         `json:"skipped_resolutions"`
+	FailedResolutions  int                         `json:"failed_resolutions"`
+	ProcessingTimeMs   int64                       `json:"processing_time_ms"`
+	AverageConfidence  float64                     `json:"average_confidence"`
+	BatchResults       []BatchItemResult           `json:"batch_results"`
+	ErrorMessage       string                      `json:"error_message,omitempty"`
+	Warnings           []string                    `json:"warnings,omitempty"`
+	RunID              string                      `json:"run_id,omitempty"`
+	Performance        BatchPerformanceMetrics     `json:"performance"`
+	Isolation          *IsolationResult            `json:"isolation,omitempty"`
+	Transaction        *gitutils.TransactionResult `json:"transaction,omitempty"`
+	TidiedModules      []string                    `json:"tidied_modules,omitempty"`
+	FileResolutions    []gitutils.FileResolution   `json:"file_resolutions,omitempty"` // Regenerated lockfiles
 }
 
 // BatchItemResult represents the result of processing a single batch
@@ -95,11 +118,29 @@ type BatchCommand struct {
 	options BatchOptions
 	ctx     context.Context
 	cancel  context.CancelFunc
+	// writer collects the resolutions of all batches in atomic mode
+	writer *gitutils.TransactionalWriter
+	// lockfiles are the conflicted lockfiles regenerated after the batches
+	lockfiles     []Lockfile
+	lockfilePaths []string
 }
 
 // NewBatchCommand creates a new batch command
 func NewBatchCommand(options BatchOptions) *BatchCommand {
-	// Set defaults
+	applyBatchDefaults(&options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(options.TimeoutSec)*time.Second)
+
+	return &BatchCommand{
+		options: options,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// applyBatchDefaults fills in the default batching, concurrency and timeout
+// settings, which are shared by the commands that process work in batches
+func applyBatchDefaults(options *BatchOptions) {
 	if options.BatchSize == 0 {
 		options.BatchSize = 10
 	}
@@ -126,14 +167,6 @@ func NewBatchCommand(options BatchOptions) *BatchCommand {
 			options.RepoPath = wd
 		}
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(options.TimeoutSec)*time.Second)
-
-	return &BatchCommand{
-		options: options,
-		ctx:     ctx,
-		cancel:  cancel,
-	}
 }
 
 // Execute runs the batch command
@@ -147,6 +180,24 @@ func (b *BatchCommand) Execute() (*BatchResult, error) {
 		},
 	}
 
+	// Isolated runs only write the checkout once they pass, so the snapshot is
+	// taken there rather than in the worktree
+	checkoutPath := b.options.RepoPath
+
+	var isolation *isolatedRun
+	if b.options.Isolated {
+		run, err := startIsolatedRun(b.options.RepoPath, b.options.KeepWorktree, b.options.Verbose)
+		if err != nil {
+			result.ErrorMessage = err.Error()
+			return result, err
+		}
+		defer run.close()
+
+		isolation = run
+		result.Isolation = run.result
+		b.options.RepoPath = run.path()
+	}
+
 	if b.options.Verbose {
 		fmt.Printf("🚀 Starting batch conflict resolution...\n")
 		fmt.Printf("   Batch size: %d conflicts per batch\n", b.options.BatchSize)
@@ -168,7 +219,7 @@ func (b *BatchCommand) Execute() (*BatchResult, error) {
 	result.Performance.DetectionTimeMs = time.Since(detectStart).Milliseconds()
 	result.TotalConflicts = b.countTotalConflicts(conflictPayload)
 
-	if result.TotalConflicts == 0 {
+	if result.TotalConflicts == 0 && len(b.lockfilePaths) == 0 {
 		result.Success = true
 		if b.options.Verbose {
 			fmt.Printf("✅ No conflicts detected\n")
@@ -198,6 +249,24 @@ func (b *BatchCommand) Execute() (*BatchResult, error) {
 		fmt.Printf("📦 Created %d batches for processing\n", len(batches))
 	}
 
+	if b.options.BackupFiles && !b.options.DryRun {
+		paths := make([]string, 0, len(conflictPayload.Files))
+		for _, file := range conflictPayload.Files {
+			paths = append(paths, file.Path)
+		}
+		paths = append(paths, b.lockfilePaths...)
+		runID, err := createRunSnapshot(checkoutPath, "batch", paths, b.options.Verbose)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to snapshot files: %v", err)
+			return result, err
+		}
+		result.RunID = runID
+	}
+
+	if b.options.Atomic && !b.options.DryRun {
+		b.writer = gitutils.NewTransactionalWriter(b.options.RepoPath, b.transactionValidator(isolation != nil))
+	}
+
 	// Step 3: Process batches concurrently
 	if b.options.Verbose {
 		fmt.Printf("🤖 Step 3: Processing batches with AI...\n")
@@ -224,13 +293,39 @@ func (b *BatchCommand) Execute() (*BatchResult, error) {
 		}
 
 		result.Performance.ApplicationTimeMs = time.Since(applicationStart).Milliseconds()
+
+		applied := result.Transaction == nil || result.Transaction.Committed()
+		if len(b.lockfilePaths) > 0 && applied {
+			regenerated, warnings := regenerateLockfiles(b.options.RepoPath, b.lockfiles, b.lockfilePaths, b.options.Verbose)
+			result.FileResolutions = regenerated
+			result.Warnings = append(result.Warnings, warnings...)
+		}
+
+		if b.options.GoModTidy && applied {
+			paths := make([]string, 0, len(conflictPayload.Files)+len(b.lockfilePaths))
+			for _, file := range conflictPayload.Files {
+				paths = append(paths, file.Path)
+			}
+			paths = append(paths, b.lockfilePaths...)
+			tidied, warnings := tidyGoModules(b.options.RepoPath, paths, b.options.Verbose)
+			result.TidiedModules = tidied
+			result.Warnings = append(result.Warnings, warnings...)
+		}
 	}
 
 	// Finalize results
 	result.Performance.TotalTimeMs = time.Since(startTime).Milliseconds()
-	result.Success = result.FailedBatches == 0
+	result.Success = result.FailedBatches == 0 && (result.Transaction == nil || result.Transaction.Committed())
 	b.calculatePerformanceMetrics(result)
 
+	if isolation != nil && !b.options.DryRun {
+		if err := b.finishIsolated(isolation, result); err != nil {
+			result.Success = false
+			result.ErrorMessage = err.Error()
+			return result, err
+		}
+	}
+
 	if b.options.Verbose {
 		b.printSummary(result)
 	}
@@ -244,6 +339,29 @@ func (b *BatchCommand) Execute() (*BatchResult, error) {
 	return result, nil
 }
 
+// finishIsolated validates the worktree of an isolated run and brings the
+// resolved files back into the checkout when every batch and the validation
+// passed
+func (b *BatchCommand) finishIsolated(run *isolatedRun, result *BatchResult) error {
+	passed, reason := true, ""
+	if result.FailedBatches > 0 {
+		passed, reason = false, fmt.Sprintf("%d batches failed", result.FailedBatches)
+	} else if result.Transaction != nil && !result.Transaction.Committed() {
+		passed, reason = false, "the resolutions were rolled back"
+	} else if !b.options.SkipValidate {
+		if b.options.Verbose {
+			fmt.Printf("✅ Validating isolated worktree...\n")
+		}
+		report, err := validate.RunValidation(run.path(), b.options.TimeoutSec)
+		if err != nil || !report.OverallSuccess {
+			passed, reason = false, "project validation failed"
+		}
+	}
+
+	run.track(tidiedFiles(result.TidiedModules)...)
+	return run.finish(passed, reason)
+}
+
 // detectConflicts detects conflicts in the repository
 func (b *BatchCommand) detectConflicts(result *BatchResult) (*payload.ConflictPayload, error) {
 	// Reuse existing detect functionality
@@ -258,8 +376,19 @@ func (b *BatchCommand) detectConflicts(result *BatchResult) (*payload.ConflictPa
 		return nil, fmt.Errorf("no conflict payload generated")
 	}
 
+	// Lockfiles are regenerated after the batches instead of being resolved
+	report := detectResult.ConflictReport
+	if b.options.RegenerateLockfiles {
+		b.lockfiles, err = configuredLockfiles(b.options.RepoPath)
+		if err != nil {
+			result.ErrorMessage = fmt.Sprintf("Failed to read the lockfile configuration: %v", err)
+			return nil, err
+		}
+		report, b.lockfilePaths = splitLockfiles(report, b.lockfiles)
+	}
+
 	// Convert to proper payload format
-	conflictPayload, err := payload.BuildSimplePayload(detectResult.ConflictReport)
+	conflictPayload, err := payload.BuildSimplePayload(report)
 	if err != nil {
 		result.ErrorMessage = fmt.Sprintf("Failed to build payload: %v", err)
 		return nil, err
@@ -592,9 +721,11 @@ func (b *BatchCommand) processSingleBatch(batch ConflictBatch) BatchItemResult {
 		Verbose:        false, // Suppress individual batch verbosity
 		AutoApply:      b.options.AutoApply,
 		MinConfidence:  b.options.MinConfidence,
-		BackupFiles:    b.options.BackupFiles,
+		BackupFiles:    false, // The whole run was snapshotted before the batches started
 		MaxRetries:     b.options.MaxRetries,
 		TimeoutSeconds: b.options.TimeoutSec,
+		Writer:         b.writer,
+		Review:         b.options.Review,
 	}
 
 	// Create temporary payload file
@@ -666,13 +797,54 @@ func (b *BatchCommand) processSingleBatch(batch ConflictBatch) BatchItemResult {
 	return result
 }
 
-// applyBatchResults applies all successful batch results (placeholder for now)
+// applyBatchResults commits the resolutions the batches staged in atomic mode.
+// Otherwise they were already applied by the individual batches.
 func (b *BatchCommand) applyBatchResults(result *BatchResult) error {
-	// Results are already applied by individual batches in processSingleBatch
-	// This method is a placeholder for any post-processing needed
+	if b.writer == nil {
+		return nil
+	}
+
+	if result.FailedBatches > 0 {
+		result.Transaction = b.writer.Discard(fmt.Sprintf("%d batches failed", result.FailedBatches))
+	} else {
+		transaction, err := b.writer.Commit()
+		if transaction == nil {
+			return err
+		}
+		result.Transaction = transaction
+	}
+
+	if !result.Transaction.Committed() {
+		result.AppliedResolutions = 0
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("No resolutions were written: %s", result.Transaction.ErrorMessage))
+	}
 	return nil
 }
 
+// transactionValidator returns the post-apply hook of atomic mode, which runs
+// the project validation unless it is skipped or an isolated run validates
+// the worktree anyway
+func (b *BatchCommand) transactionValidator(isolated bool) gitutils.TransactionValidator {
+	if b.options.SkipValidate || isolated {
+		return nil
+	}
+
+	return func(paths []string) error {
+		if b.options.Verbose {
+			fmt.Printf("✅ Validating %d resolved files...\n", len(paths))
+		}
+		report, err := validate.RunValidation(b.options.RepoPath, b.options.TimeoutSec)
+		if err != nil {
+			return fmt.Errorf("project validation failed: %w", err)
+		}
+		if !report.OverallSuccess {
+			return fmt.Errorf("project validation failed")
+		}
+		return nil
+	}
+}
+
 // calculatePerformanceMetrics calculates performance statistics
 func (b *BatchCommand) calculatePerformanceMetrics(result *BatchResult) {
 	if len(result.BatchResults) == 0 {
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// ContinueOptions contains options for the continue command
+type ContinueOptions struct {
+	RepoPath string
+	Verbose  bool
+}
+
+// ContinueResult represents the result of the continue command
+type ContinueResult struct {
+	Success         bool                     `json:"success"`
+	Operation       gitutils.Operation       `json:"operation"`
+	Rebase          *gitutils.RebaseProgress `json:"rebase,omitempty"`
+	StagedFiles     []string                 `json:"staged_files"`
+	UnresolvedFiles []string                 `json:"unresolved_files,omitempty"`
+	Completed       bool                     `json:"completed"`
+	NewConflicts    int                      `json:"new_conflicts"`
+	ErrorMessage    string                   `json:"error_message,omitempty"`
+}
+
+// ContinueCommand stages resolved files and continues the in-progress merge,
+// rebase, cherry-pick or revert
+type ContinueCommand struct {
+	options ContinueOptions
+}
+
+// NewContinueCommand creates a new continue command
+func NewContinueCommand(options ContinueOptions) *ContinueCommand {
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &ContinueCommand{options: options}
+}
+
+// Execute runs the continue command
+func (c *ContinueCommand) Execute() (*ContinueResult, error) {
+	result := &ContinueResult{StagedFiles: []string{}}
+
+	state, err := gitutils.DetectOperation(c.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to detect operation: %v", err)
+		return result, err
+	}
+	result.Operation = state.Operation
+	result.Rebase = state.Rebase
+
+	if !state.InProgress() {
+		err := fmt.Errorf("no merge, rebase, cherry-pick or revert in progress")
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	resolved, unresolved, err := classifyUnmergedFiles(c.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	if len(unresolved) > 0 {
+		result.UnresolvedFiles = unresolved
+		err := fmt.Errorf("%d files are still unresolved", len(unresolved))
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	if err := gitutils.StageFiles(c.options.RepoPath, resolved); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to stage resolved files: %v", err)
+		return result, err
+	}
+	result.StagedFiles = resolved
+
+	if c.options.Verbose {
+		fmt.Printf("Staged %d resolved files, running git %s --continue\n", len(resolved), state.Operation)
+	}
+	logging.Logger.InfoSafe("Continuing operation",
+		zap.String("operation", string(state.Operation)),
+		zap.Int("staged_files", len(resolved)))
+
+	continueErr := gitutils.ContinueOperation(c.options.RepoPath, state)
+
+	// A rebase or sequence of picks may stop again at the next conflicting commit
+	after, err := gitutils.DetectOperation(c.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to detect operation: %v", err)
+		return result, err
+	}
+	result.Completed = !after.InProgress()
+	if after.InProgress() {
+		result.Rebase = after.Rebase
+		conflicts, err := gitutils.DetectConflicts(c.options.RepoPath)
+		if err == nil {
+			result.NewConflicts = len(conflicts)
+		}
+	}
+
+	if continueErr != nil && result.NewConflicts == 0 {
+		result.ErrorMessage = continueErr.Error()
+		return result, continueErr
+	}
+
+	result.Success = true
+	return result, nil
+}
+
+// classifyUnmergedFiles splits the unmerged paths into those that can be staged
+// as resolved and those that still need resolving. Only text conflicts that
+// both sides have are resolved by removing their conflict markers, and any
+// suggestion block placed next to them; file-level
+// conflicts such as modify/delete and binary conflicts never have markers, so
+// they stay unresolved until they are staged, which applying a file-level
+// resolution does.
+func classifyUnmergedFiles(repoPath string) ([]string, []string, error) {
+	entries, err := gitutils.ListUnmergedEntries(repoPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list unmerged files: %w", err)
+	}
+
+	resolved := []string{}
+	var unresolved []string
+	for _, entry := range entries {
+		if !entry.HasStage(gitutils.StageOurs) || !entry.HasStage(gitutils.StageTheirs) {
+			unresolved = append(unresolved, entry.Path)
+			continue
+		}
+
+		versions, err := gitutils.ReadStageVersions(repoPath, entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		reason, err := gitutils.UnresolvedReason(repoPath, entry.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if versions.Binary || reason != "" {
+			unresolved = append(unresolved, entry.Path)
+		} else {
+			resolved = append(resolved, entry.Path)
+		}
+	}
+
+	return resolved, unresolved, nil
+}
+
+// ContinueOperation is a convenience function that continues the in-progress
+// operation of a repository
+func ContinueOperation(repoPath string, verbose bool) (*ContinueResult, error) {
+	cmd := NewContinueCommand(ContinueOptions{
+		RepoPath: repoPath,
+		Verbose:  verbose,
+	})
+	return cmd.Execute()
+}
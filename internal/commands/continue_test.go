@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/logging"
+)
+
+func TestContinueCommand_LeavesConflictsWithoutMarkers(t *testing.T) {
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runRebaseTestGit(t, dir, "init", "-q", "-b", "main")
+	runRebaseTestGit(t, dir, "config", "user.name", "Test User")
+	runRebaseTestGit(t, dir, "config", "user.email", "test@example.com")
+	write("removed.txt", "base\n")
+	write("image.bin", "base\x00\n")
+	write("text.txt", "base\n")
+	runRebaseTestGit(t, dir, "add", ".")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "base")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "-b", "feature")
+	runRebaseTestGit(t, dir, "rm", "-q", "removed.txt")
+	write("image.bin", "feature\x00\n")
+	write("text.txt", "feature\n")
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "feature")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "main")
+	write("removed.txt", "main\n")
+	write("image.bin", "main\x00\n")
+	write("text.txt", "main\n")
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "main")
+
+	cmd := exec.Command("git", "merge", "feature")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+	// The text conflict is resolved by hand; the others have no markers at all
+	write("text.txt", "merged\n")
+
+	result, err := NewContinueCommand(ContinueOptions{RepoPath: dir}).Execute()
+	if err == nil {
+		t.Fatal("expected continue to refuse the unresolved files")
+	}
+	if len(result.UnresolvedFiles) != 2 || result.UnresolvedFiles[0] != "image.bin" || result.UnresolvedFiles[1] != "removed.txt" {
+		t.Errorf("unresolved files = %v", result.UnresolvedFiles)
+	}
+
+	unmerged := runRebaseTestGit(t, dir, "diff", "--name-only", "--diff-filter=U")
+	if unmerged != "image.bin\nremoved.txt\ntext.txt\n" {
+		t.Errorf("unmerged files = %q", unmerged)
+	}
+}
+
+func TestContinueCommand_AfterFileResolutions(t *testing.T) {
+	useFakeClaude(t, `Resolution: {"file_resolutions": [{"file_path": "removed.txt", "action": "delete", "confidence": 0.95, "reasoning": "feature removed the file"}]}`)
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runRebaseTestGit(t, dir, "init", "-q", "-b", "main")
+	runRebaseTestGit(t, dir, "config", "user.name", "Test User")
+	runRebaseTestGit(t, dir, "config", "user.email", "test@example.com")
+	write("removed.txt", "base\n")
+	runRebaseTestGit(t, dir, "add", ".")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "base")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "-b", "feature")
+	runRebaseTestGit(t, dir, "rm", "-q", "removed.txt")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "feature")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "main")
+	write("removed.txt", "main\n")
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "main")
+
+	cmd := exec.Command("git", "merge", "feature")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+
+	// Resolving without --stage still marks the file-level conflict resolved
+	if _, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     dir,
+		AIMode:       true,
+		APIKey:       "test",
+		AutoApply:    true,
+		SkipFormat:   true,
+		SkipValidate: true,
+	}).Execute(); err != nil {
+		t.Fatalf("resolve error = %v", err)
+	}
+
+	result, err := NewContinueCommand(ContinueOptions{RepoPath: dir}).Execute()
+	if err != nil {
+		t.Fatalf("continue error = %v, unresolved %v", err, result.UnresolvedFiles)
+	}
+	if !result.Completed {
+		t.Errorf("merge was not completed: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "removed.txt")); !os.IsNotExist(err) {
+		t.Errorf("removed.txt was not deleted: %v", err)
+	}
+}
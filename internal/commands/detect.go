@@ -28,9 +28,12 @@ type SimplifiedConflictPayload struct {
 
 // SimplifiedFilePayload represents a single file's conflict data
 type SimplifiedFilePayload struct {
-	Path      string                   `json:"path"`
-	Language  string                   `json:"language"`
-	Conflicts []SimplifiedConflictHunk `json:"conflicts"`
+	Path         string                   `json:"path"`
+	Language     string                   `json:"language"`
+	Encoding     *gitutils.FileEncoding   `json:"encoding,omitempty"`
+	Conflicts    []SimplifiedConflictHunk `json:"conflicts"`
+	FileConflict *gitutils.FileConflict   `json:"file_conflict,omitempty"`
+	Error        string                   `json:"error,omitempty"`
 }
 
 // SimplifiedConflictHunk represents a conflict with minimal context
@@ -40,6 +43,10 @@ type SimplifiedConflictHunk struct {
 	EndLine     int      `json:"end_line"`
 	OursLines   []string `json:"ours_lines"`
 	TheirsLines []string `json:"theirs_lines"`
+	BaseLines   []string `json:"base_lines,omitempty"`
+	OursLabel   string   `json:"ours_label,omitempty"`
+	BaseLabel   string   `json:"base_label,omitempty"`
+	TheirsLabel string   `json:"theirs_label,omitempty"`
 	PreContext  []string `json:"pre_context"`
 	PostContext []string `json:"post_context"`
 }
@@ -89,37 +96,50 @@ func (d *DetectCommand) buildSimplifiedPayload(report *gitutils.ConflictReport)
 			continue
 		}
 
-		language := detectLanguage(conflictFile.Path)
-		filePayload := SimplifiedFilePayload{
-			Path:     conflictFile.Path,
-			Language: language,
-		}
-
-		// Convert conflict hunks to simplified format
-		for i, hunk := range conflictFile.Hunks {
-			conflictHunk := SimplifiedConflictHunk{
-				ID:          fmt.Sprintf("%s:%d", conflictFile.Path, i),
-				StartLine:   hunk.StartLine,
-				EndLine:     hunk.EndLine,
-				OursLines:   hunk.OursLines,
-				TheirsLines: hunk.TheirsLines,
-				PreContext:  d.extractPreContext(conflictFile.Context, hunk.StartLine),
-				PostContext: d.extractPostContext(conflictFile.Context, hunk.EndLine),
-			}
-			filePayload.Conflicts = append(filePayload.Conflicts, conflictHunk)
-		}
-
+		filePayload := d.buildFilePayload(conflictFile)
 		payload.Files = append(payload.Files, filePayload)
 	}
 
 	return payload, nil
 }
 
+// buildFilePayload converts a conflicted file into its simplified payload form
+func (d *DetectCommand) buildFilePayload(conflictFile gitutils.ConflictFile) SimplifiedFilePayload {
+	filePayload := SimplifiedFilePayload{
+		Path:         conflictFile.Path,
+		Language:     detectLanguage(conflictFile.Path),
+		Encoding:     conflictFile.Encoding,
+		FileConflict: conflictFile.FileConflict,
+		Error:        conflictFile.Error,
+	}
+
+	// Convert conflict hunks to simplified format
+	for i, hunk := range conflictFile.Hunks {
+		conflictHunk := SimplifiedConflictHunk{
+			ID:          fmt.Sprintf("%s:%d", conflictFile.Path, i),
+			StartLine:   hunk.StartLine,
+			EndLine:     hunk.EndLine,
+			OursLines:   hunk.OursLines,
+			TheirsLines: hunk.TheirsLines,
+			BaseLines:   hunk.BaseLines,
+			OursLabel:   hunk.OursLabel,
+			BaseLabel:   hunk.BaseLabel,
+			TheirsLabel: hunk.TheirsLabel,
+			PreContext:  d.extractPreContext(conflictFile.Context, hunk.StartLine),
+			PostContext: d.extractPostContext(conflictFile.Context, hunk.EndLine),
+		}
+		filePayload.Conflicts = append(filePayload.Conflicts, conflictHunk)
+	}
+
+	return filePayload
+}
+
 // shouldSkipFile determines if a file should be excluded from processing
 func (d *DetectCommand) shouldSkipFile(filePath string) bool {
-	// Skip binary files and common exclusions
+	// Skip binary files and common exclusions. Lockfiles are listed, since
+	// resolve and batch can regenerate them.
 	excludePatterns := []string{
-		".git/", ".gitignore", "package-lock.json", "yarn.lock",
+		".git/", ".gitignore",
 		".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".svg",
 		".exe", ".dll", ".so", ".dylib", ".o", ".obj",
 		".zip", ".tar", ".gz", ".rar", ".7z",
@@ -169,6 +189,10 @@ type DetectOptions struct {
 	OutputFile      string
 	MaxContextLines int
 	Verbose         bool
+	// PreviewOurs and PreviewTheirs select a preview of merging two refs in
+	// memory instead of inspecting the conflicts of the working tree
+	PreviewOurs   string
+	PreviewTheirs string
 	// Memory optimization options
 	MaxMemoryMB     int64
 	EnableStreaming bool
@@ -189,10 +213,16 @@ type DetectResult struct {
 type DetectSummary struct {
 	TotalFiles       int    `json:"total_files"`
 	TotalConflicts   int    `json:"total_conflicts"`
+	FileConflicts    int    `json:"file_conflicts"`
 	ExcludedFiles    int    `json:"excluded_files"`
 	ProcessableFiles int    `json:"processable_files"`
 	RepoPath         string `json:"repo_path"`
 	InMergeState     bool   `json:"in_merge_state"`
+	// Operation is the merge, rebase, cherry-pick or revert in progress
+	Operation gitutils.Operation       `json:"operation"`
+	Rebase    *gitutils.RebaseProgress `json:"rebase,omitempty"`
+	// Preview is set when the conflicts come from an in-memory merge preview
+	Preview *gitutils.MergePreview `json:"preview,omitempty"`
 }
 
 // DetectCommand implements the detect subcommand
@@ -263,16 +293,18 @@ func (d *DetectCommand) Execute() (*DetectResult, error) {
 		return result, err
 	}
 
-	// Check if repository is in merge state
-	inMerge, err := gitutils.IsInMergeState(d.options.RepoPath)
+	var conflictReport *gitutils.ConflictReport
+	var err error
+	if d.isPreview() {
+		conflictReport, err = d.previewConflictReport(result)
+	} else {
+		conflictReport, err = d.workingTreeConflictReport(result)
+	}
 	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("Failed to check merge state: %v", err)
 		return result, err
 	}
-	result.Summary.InMergeState = inMerge
 
-	if !inMerge {
-		result.ErrorMessage = "Repository is not in a merge state - no conflicts to detect"
+	if conflictReport == nil {
 		result.Success = true // This is not an error, just no conflicts
 
 		// Output results even when no conflicts
@@ -284,16 +316,10 @@ func (d *DetectCommand) Execute() (*DetectResult, error) {
 		return result, nil
 	}
 
-	// Generate conflict report
-	conflictReport, err := gitutils.GetConflictReport(d.options.RepoPath)
-	if err != nil {
-		result.ErrorMessage = fmt.Sprintf("Failed to generate conflict report: %v", err)
-		return result, err
-	}
-
 	result.ConflictReport = conflictReport
 	result.Summary.TotalFiles = len(conflictReport.ConflictedFiles)
 	result.Summary.TotalConflicts = conflictReport.TotalConflicts
+	result.Summary.FileConflicts = conflictReport.TotalFileConflicts
 
 	logging.Logger.ConflictResolution("conflicts_detected",
 		zap.Int("total_files", result.Summary.TotalFiles),
@@ -377,6 +403,75 @@ func (d *DetectCommand) Execute() (*DetectResult, error) {
 	return result, nil
 }
 
+// isPreview reports whether the command previews a merge of two refs
+func (d *DetectCommand) isPreview() bool {
+	return d.options.PreviewOurs != "" || d.options.PreviewTheirs != ""
+}
+
+// workingTreeConflictReport reports the conflicts of the merge, rebase,
+// cherry-pick or revert in progress. It returns a nil report when the
+// repository is not in a merge state.
+func (d *DetectCommand) workingTreeConflictReport(result *DetectResult) (*gitutils.ConflictReport, error) {
+	// Check if repository is in merge state
+	inMerge, err := gitutils.IsInMergeState(d.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to check merge state: %v", err)
+		return nil, err
+	}
+	result.Summary.InMergeState = inMerge
+
+	// Report which operation produced the conflicts
+	operation, err := gitutils.DetectOperation(d.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to detect operation: %v", err)
+		return nil, err
+	}
+	result.Summary.Operation = operation.Operation
+	result.Summary.Rebase = operation.Rebase
+
+	if !inMerge {
+		result.ErrorMessage = "Repository is not in a merge state - no conflicts to detect"
+		return nil, nil
+	}
+
+	// Generate conflict report
+	conflictReport, err := gitutils.GetConflictReport(d.options.RepoPath)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to generate conflict report: %v", err)
+		return nil, err
+	}
+
+	return conflictReport, nil
+}
+
+// previewConflictReport reports the conflicts a merge of the preview refs would
+// produce, leaving the working tree and index untouched. It returns a nil
+// report when the refs merge cleanly.
+func (d *DetectCommand) previewConflictReport(result *DetectResult) (*gitutils.ConflictReport, error) {
+	if d.options.PreviewOurs == "" || d.options.PreviewTheirs == "" {
+		err := fmt.Errorf("both refs are required to preview a merge")
+		result.ErrorMessage = err.Error()
+		return nil, err
+	}
+
+	preview, conflictReport, err := gitutils.GetPreviewConflictReport(d.options.RepoPath,
+		d.options.PreviewOurs, d.options.PreviewTheirs)
+	if err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to preview merge: %v", err)
+		return nil, err
+	}
+	result.Summary.Preview = preview
+	result.Summary.Operation = gitutils.OperationNone
+
+	if preview.Clean {
+		result.ErrorMessage = fmt.Sprintf("%s and %s merge cleanly - no conflicts to detect",
+			d.options.PreviewOurs, d.options.PreviewTheirs)
+		return nil, nil
+	}
+
+	return conflictReport, nil
+}
+
 // validateRepository validates that the given path is a git repository
 func (d *DetectCommand) validateRepository() error {
 	// Check if path exists
@@ -445,8 +540,12 @@ func (d *DetectCommand) formatTextOutput(result *DetectResult) []byte {
 
 	output = d.addBasicInfo(output, result)
 
-	if !result.Summary.InMergeState {
-		output = append(output, "✅ No conflicts detected - repository is not in merge state")
+	if result.ConflictReport == nil {
+		if result.Summary.Preview != nil {
+			output = append(output, "✅ No conflicts detected - the refs merge cleanly")
+		} else {
+			output = append(output, "✅ No conflicts detected - repository is not in merge state")
+		}
 		return d.joinOutput(output)
 	}
 
@@ -461,16 +560,40 @@ func (d *DetectCommand) formatTextOutput(result *DetectResult) []byte {
 // addBasicInfo adds repository and merge state information
 func (d *DetectCommand) addBasicInfo(output []string, result *DetectResult) []string {
 	output = append(output, fmt.Sprintf("Repository: %s", result.Summary.RepoPath))
-	output = append(output, fmt.Sprintf("In merge state: %t", result.Summary.InMergeState))
+	if preview := result.Summary.Preview; preview != nil {
+		output = append(output, fmt.Sprintf("Preview: merge of %s (%s) into %s (%s)",
+			d.options.PreviewTheirs, shortCommit(preview.Theirs), d.options.PreviewOurs, shortCommit(preview.Ours)))
+	} else {
+		output = append(output, fmt.Sprintf("In merge state: %t", result.Summary.InMergeState))
+	}
+	if result.Summary.Operation != "" && result.Summary.Operation != gitutils.OperationNone {
+		output = append(output, fmt.Sprintf("Operation: %s", d.describeOperation(result.Summary)))
+	}
 	output = append(output, "")
 	return output
 }
 
+// describeOperation renders the in-progress operation, including rebase progress
+func (d *DetectCommand) describeOperation(summary DetectSummary) string {
+	description := string(summary.Operation)
+	if rebase := summary.Rebase; rebase != nil && rebase.TotalSteps > 0 {
+		description += fmt.Sprintf(" (step %d/%d", rebase.Step, rebase.TotalSteps)
+		if rebase.HeadName != "" {
+			description += fmt.Sprintf(" of %s", rebase.HeadName)
+		}
+		description += ")"
+	}
+	return description
+}
+
 // addSummarySection adds the summary statistics
 func (d *DetectCommand) addSummarySection(output []string, result *DetectResult) []string {
 	output = append(output, "📊 Summary:")
 	output = append(output, fmt.Sprintf("  Total conflicted files: %d", result.Summary.TotalFiles))
 	output = append(output, fmt.Sprintf("  Total conflicts: %d", result.Summary.TotalConflicts))
+	if result.Summary.FileConflicts > 0 {
+		output = append(output, fmt.Sprintf("  File-level conflicts: %d", result.Summary.FileConflicts))
+	}
 	output = append(output, fmt.Sprintf("  Processable files: %d", result.Summary.ProcessableFiles))
 	output = append(output, fmt.Sprintf("  Excluded files: %d", result.Summary.ExcludedFiles))
 	output = append(output, "")
@@ -487,6 +610,9 @@ func (d *DetectCommand) addConflictedFilesSection(output []string, result *Detec
 	for _, file := range result.ConflictPayload.Files {
 		output = append(output, fmt.Sprintf("  %s (%s)", file.Path, file.Language))
 		output = append(output, fmt.Sprintf("    Conflicts: %d", len(file.Conflicts)))
+		if file.FileConflict != nil {
+			output = append(output, d.describeFileConflict(file.FileConflict))
+		}
 
 		if d.options.Verbose {
 			output = d.addVerboseConflictDetails(output, file.Conflicts)
@@ -496,6 +622,18 @@ func (d *DetectCommand) addConflictedFilesSection(output []string, result *Detec
 	return output
 }
 
+// describeFileConflict renders a one-line description of a file-level conflict
+func (d *DetectCommand) describeFileConflict(fileConflict *gitutils.FileConflict) string {
+	description := fmt.Sprintf("    File conflict: %s (%s)", fileConflict.Kind, fileConflict.Status)
+	if fileConflict.DeletedBy != "" {
+		description += fmt.Sprintf(", deleted by %s", fileConflict.DeletedBy)
+	}
+	if fileConflict.AddedBy != "" {
+		description += fmt.Sprintf(", added by %s", fileConflict.AddedBy)
+	}
+	return description
+}
+
 // addVerboseConflictDetails adds detailed conflict information when verbose mode is enabled
 func (d *DetectCommand) addVerboseConflictDetails(output []string, conflicts []SimplifiedConflictHunk) []string {
 	for i, conflict := range conflicts {
@@ -542,13 +680,25 @@ func (d *DetectCommand) findExcludedFiles(result *DetectResult) []string {
 
 // addNextStepsSection adds next steps information
 func (d *DetectCommand) addNextStepsSection(output []string, result *DetectResult) []string {
+	if result.ConflictPayload != nil && result.Summary.Preview != nil {
+		output = append(output, "🔧 Next Steps:")
+		output = append(output, "  1. Review the conflicts above")
+		output = append(output, "  2. Save this report as JSON and run 'syncwright payload' to prepare AI resolutions ahead of the merge")
+		output = append(output, "  3. Merge the refs and run 'syncwright resolve --ai'")
+		return output
+	}
 	if result.ConflictPayload != nil {
 		output = append(output, "🔧 Next Steps:")
 		output = append(output, "  1. Review the conflicts above")
 		output = append(output, "  2. Run 'syncwright ai-apply' to get AI-suggested resolutions")
 		output = append(output, "  3. Review and apply the suggested resolutions")
 		output = append(output, "  4. Test your changes")
-		output = append(output, "  5. Commit the resolved conflicts")
+		if result.Summary.Operation != "" && result.Summary.Operation != gitutils.OperationMerge &&
+			result.Summary.Operation != gitutils.OperationNone {
+			output = append(output, fmt.Sprintf("  5. Run 'syncwright continue' to continue the %s", result.Summary.Operation))
+		} else {
+			output = append(output, "  5. Commit the resolved conflicts")
+		}
 	}
 	return output
 }
@@ -665,6 +815,21 @@ func DetectConflicts(repoPath string) (*DetectResult, error) {
 	return cmd.Execute()
 }
 
+// PreviewConflicts is a convenience function that reports the conflicts a merge
+// of theirs into ours would produce without touching the working tree
+func PreviewConflicts(repoPath, ours, theirs string) (*DetectResult, error) {
+	options := DetectOptions{
+		RepoPath:      repoPath,
+		OutputFormat:  OutputFormatJSON,
+		PreviewOurs:   ours,
+		PreviewTheirs: theirs,
+	}
+
+	cmd := NewDetectCommand(options)
+	defer cmd.Close()
+	return cmd.Execute()
+}
+
 // DetectConflictsVerbose is a convenience function for verbose conflict detection
 func DetectConflictsVerbose(repoPath string, outputFile string) (*DetectResult, error) {
 	options := DetectOptions{
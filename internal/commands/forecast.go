@@ -0,0 +1,389 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// Complexity levels of a forecast pair
+const (
+	ComplexityNone   = "none"
+	ComplexityLow    = "low"
+	ComplexityMedium = "medium"
+	ComplexityHigh   = "high"
+)
+
+// ForecastOptions contains options for the forecast command
+type ForecastOptions struct {
+	RepoPath     string
+	Target       string
+	Branches     string // Glob matched against local and remote-tracking branches
+	Pairwise     bool   // Also preview every pair of matching branches
+	OutputFile   string
+	OutputFormat string // "json", "text"
+	Verbose      bool
+	// Batch supplies the batch size, concurrency and timeout used to run the
+	// previews; the AI related settings are not used
+	Batch BatchOptions
+}
+
+// ForecastPair is the predicted outcome of merging one branch into another
+type ForecastPair struct {
+	Ours             string                   `json:"ours"`
+	Theirs           string                   `json:"theirs"`
+	Clean            bool                     `json:"clean"`
+	ConflictingFiles []string                 `json:"conflicting_files"`
+	FileCount        int                      `json:"file_count"`
+	HunkCount        int                      `json:"hunk_count"`
+	FileConflicts    int                      `json:"file_conflicts"`
+	ConflictLines    int                      `json:"conflict_lines"`
+	ComplexityScore  int                      `json:"complexity_score"`
+	Complexity       string                   `json:"complexity"`
+	Report           *gitutils.ConflictReport `json:"report,omitempty"`
+	ErrorMessage     string                   `json:"error_message,omitempty"`
+}
+
+// ForecastSummary summarizes the forecast matrix
+type ForecastSummary struct {
+	TotalPairs       int   `json:"total_pairs"`
+	ConflictingPairs int   `json:"conflicting_pairs"`
+	CleanPairs       int   `json:"clean_pairs"`
+	FailedPairs      int   `json:"failed_pairs"`
+	TotalHunks       int   `json:"total_hunks"`
+	ProcessingTimeMs int64 `json:"processing_time_ms"`
+}
+
+// ForecastResult represents the result of the forecast command
+type ForecastResult struct {
+	Success      bool            `json:"success"`
+	Target       string          `json:"target"`
+	Branches     []string        `json:"branches"`
+	Pairs        []ForecastPair  `json:"pairs"`
+	Summary      ForecastSummary `json:"summary"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+	Warnings     []string        `json:"warnings,omitempty"`
+}
+
+// forecastJob is a single merge to preview
+type forecastJob struct {
+	index  int
+	ours   string
+	theirs string
+}
+
+// ForecastCommand previews the merges of matching branches into a target
+// branch, and optionally into each other, without touching the working tree
+type ForecastCommand struct {
+	options ForecastOptions
+}
+
+// NewForecastCommand creates a new forecast command
+func NewForecastCommand(options ForecastOptions) *ForecastCommand {
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+	if options.Target == "" {
+		options.Target = "main"
+	}
+	if options.Branches == "" {
+		options.Branches = "*"
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = OutputFormatJSON
+	}
+	options.Batch.RepoPath = options.RepoPath
+	applyBatchDefaults(&options.Batch)
+
+	return &ForecastCommand{options: options}
+}
+
+// Execute runs the forecast command
+func (f *ForecastCommand) Execute() (*ForecastResult, error) {
+	startTime := time.Now()
+	result := &ForecastResult{
+		Target:   f.options.Target,
+		Branches: []string{},
+		Pairs:    []ForecastPair{},
+	}
+
+	branches, err := f.matchingBranches()
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	result.Branches = branches
+
+	jobs := f.buildJobs(branches)
+	result.Pairs = make([]ForecastPair, len(jobs))
+	if f.options.Verbose {
+		fmt.Fprintf(os.Stderr, "🔮 Forecasting %d merges across %d branches\n", len(jobs), len(branches))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(f.options.Batch.TimeoutSec)*time.Second)
+	defer cancel()
+	f.runJobs(ctx, jobs, result)
+
+	f.summarize(result)
+	result.Summary.ProcessingTimeMs = time.Since(startTime).Milliseconds()
+	result.Success = result.Summary.FailedPairs == 0
+
+	if err := f.outputResults(result); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
+		return result, err
+	}
+
+	return result, nil
+}
+
+// matchingBranches lists the branches matching the glob, excluding the ones that
+// point at the target commit
+func (f *ForecastCommand) matchingBranches() ([]string, error) {
+	targetCommit, err := gitutils.ResolveCommit(f.options.RepoPath, f.options.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+
+	refs, err := gitutils.ListBranches(f.options.RepoPath, f.options.Branches)
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{}
+	for _, ref := range refs {
+		commit, err := gitutils.ResolveCommit(f.options.RepoPath, ref)
+		if err != nil || commit == targetCommit {
+			continue
+		}
+		branches = append(branches, gitutils.ShortRefName(ref))
+	}
+	sort.Strings(branches)
+
+	return branches, nil
+}
+
+// buildJobs lists the merges to preview: every branch into the target and,
+// in pairwise mode, every pair of branches
+func (f *ForecastCommand) buildJobs(branches []string) []forecastJob {
+	var jobs []forecastJob
+	for _, branch := range branches {
+		jobs = append(jobs, forecastJob{index: len(jobs), ours: f.options.Target, theirs: branch})
+	}
+	if f.options.Pairwise {
+		for i := range branches {
+			for j := i + 1; j < len(branches); j++ {
+				jobs = append(jobs, forecastJob{index: len(jobs), ours: branches[i], theirs: branches[j]})
+			}
+		}
+	}
+	return jobs
+}
+
+// runJobs previews the merges in batches of BatchSize, running up to
+// Concurrency batches at once. Merges not started before the timeout are
+// reported as failed.
+func (f *ForecastCommand) runJobs(ctx context.Context, jobs []forecastJob, result *ForecastResult) {
+	sem := make(chan struct{}, f.options.Batch.Concurrency)
+	var wg sync.WaitGroup
+
+	for start := 0; start < len(jobs); start += f.options.Batch.BatchSize {
+		batch := jobs[start:min(start+f.options.Batch.BatchSize, len(jobs))]
+
+		wg.Add(1)
+		go func(batch []forecastJob) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, job := range batch {
+				if ctx.Err() != nil {
+					result.Pairs[job.index] = ForecastPair{
+						Ours:         job.ours,
+						Theirs:       job.theirs,
+						ErrorMessage: "forecast timed out",
+					}
+					continue
+				}
+				result.Pairs[job.index] = f.forecastPair(job)
+			}
+		}(batch)
+	}
+
+	wg.Wait()
+}
+
+// forecastPair previews a single merge and estimates its complexity
+func (f *ForecastCommand) forecastPair(job forecastJob) ForecastPair {
+	pair := ForecastPair{
+		Ours:             job.ours,
+		Theirs:           job.theirs,
+		ConflictingFiles: []string{},
+	}
+
+	preview, report, err := gitutils.GetPreviewConflictReport(f.options.RepoPath, job.ours, job.theirs)
+	if err != nil {
+		pair.ErrorMessage = err.Error()
+		return pair
+	}
+	pair.Clean = preview.Clean
+
+	for i := range report.ConflictedFiles {
+		file := &report.ConflictedFiles[i]
+		// The merged file content is not needed to judge a collision
+		file.Context = nil
+
+		pair.ConflictingFiles = append(pair.ConflictingFiles, file.Path)
+		pair.HunkCount += len(file.Hunks)
+		if file.FileConflict != nil {
+			pair.FileConflicts++
+		}
+		for _, hunk := range file.Hunks {
+			pair.ConflictLines += len(hunk.OursLines) + len(hunk.TheirsLines)
+		}
+	}
+	pair.FileCount = len(pair.ConflictingFiles)
+	if !pair.Clean {
+		pair.Report = report
+	}
+
+	pair.ComplexityScore, pair.Complexity = estimateComplexity(pair)
+	return pair
+}
+
+// estimateComplexity scores how much work a merge is likely to take. Every
+// file and hunk counts, file-level conflicts weigh more because they need a
+// keep-or-delete decision, and large hunks add to the score.
+func estimateComplexity(pair ForecastPair) (int, string) {
+	score := pair.FileCount + 2*pair.HunkCount + 3*pair.FileConflicts + pair.ConflictLines/10
+
+	switch {
+	case score == 0:
+		return score, ComplexityNone
+	case score < 10:
+		return score, ComplexityLow
+	case score < 30:
+		return score, ComplexityMedium
+	default:
+		return score, ComplexityHigh
+	}
+}
+
+// summarize computes the totals of the forecast
+func (f *ForecastCommand) summarize(result *ForecastResult) {
+	result.Summary.TotalPairs = len(result.Pairs)
+	for _, pair := range result.Pairs {
+		switch {
+		case pair.ErrorMessage != "":
+			result.Summary.FailedPairs++
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("%s into %s: %s", pair.Theirs, pair.Ours, pair.ErrorMessage))
+		case pair.Clean:
+			result.Summary.CleanPairs++
+		default:
+			result.Summary.ConflictingPairs++
+			result.Summary.TotalHunks += pair.HunkCount
+		}
+	}
+}
+
+// outputResults outputs the forecast in the configured format
+func (f *ForecastCommand) outputResults(result *ForecastResult) error {
+	var output []byte
+
+	switch f.options.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = append(data, '\n')
+	case OutputFormatText:
+		output = []byte(f.formatTextOutput(result))
+	default:
+		return fmt.Errorf("unsupported output format: %s", f.options.OutputFormat)
+	}
+
+	if f.options.OutputFile != "" {
+		if err := os.WriteFile(f.options.OutputFile, output, 0600); err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", f.options.OutputFile, err)
+		}
+		if f.options.Verbose {
+			fmt.Fprintf(os.Stderr, "📄 Forecast written to: %s\n", f.options.OutputFile)
+		}
+		return nil
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// formatTextOutput renders the forecast as a table of merges followed by the
+// conflicting files of every colliding pair
+func (f *ForecastCommand) formatTextOutput(result *ForecastResult) string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "=== Syncwright Conflict Forecast (target: %s) ===\n\n", result.Target)
+	if len(result.Pairs) == 0 {
+		fmt.Fprintf(&builder, "No branches matching %q\n", f.options.Branches)
+		return builder.String()
+	}
+
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "OURS\tTHEIRS\tSTATUS\tFILES\tHUNKS\tCOMPLEXITY")
+	for _, pair := range result.Pairs {
+		status := "conflicts"
+		switch {
+		case pair.ErrorMessage != "":
+			status = "error"
+		case pair.Clean:
+			status = "clean"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\n",
+			pair.Ours, pair.Theirs, status, pair.FileCount, pair.HunkCount, pair.Complexity)
+	}
+	table.Flush()
+
+	fmt.Fprintf(&builder, "\n📊 %d merges: %d conflicting, %d clean, %d failed\n",
+		result.Summary.TotalPairs, result.Summary.ConflictingPairs,
+		result.Summary.CleanPairs, result.Summary.FailedPairs)
+
+	for _, pair := range result.Pairs {
+		if pair.Clean || pair.ErrorMessage != "" {
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s into %s:\n", pair.Theirs, pair.Ours)
+		for _, file := range pair.ConflictingFiles {
+			fmt.Fprintf(&builder, "  %s\n", file)
+		}
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(&builder, "\n⚠️  %s", warning)
+	}
+	if len(result.Warnings) > 0 {
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// ForecastConflicts is a convenience function that forecasts the merges of the
+// branches matching a glob into a target branch
+func ForecastConflicts(repoPath, target, branches string) (*ForecastResult, error) {
+	cmd := NewForecastCommand(ForecastOptions{
+		RepoPath: repoPath,
+		Target:   target,
+		Branches: branches,
+	})
+	return cmd.Execute()
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForecastCommand(t *testing.T) {
+	repoPath := newRebaseTestRepo(t)
+	// A second branch off the base that collides with feature but not main
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "-b", "feature-two", "main~1")
+	if err := os.WriteFile(filepath.Join(repoPath, "other.txt"), []byte("second feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runRebaseTestGit(t, repoPath, "add", "other.txt")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-m", "second feature")
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+
+	result, err := NewForecastCommand(ForecastOptions{
+		RepoPath:   repoPath,
+		Target:     "main",
+		Branches:   "feature*",
+		Pairwise:   true,
+		OutputFile: filepath.Join(t.TempDir(), "forecast.json"),
+		Batch:      BatchOptions{BatchSize: 1, Concurrency: 2},
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if len(result.Branches) != 2 || result.Branches[0] != "feature" || result.Branches[1] != "feature-two" {
+		t.Fatalf("unexpected branches %v", result.Branches)
+	}
+	if len(result.Pairs) != 3 {
+		t.Fatalf("expected 3 pairs, got %+v", result.Pairs)
+	}
+
+	expected := []struct {
+		ours, theirs string
+		clean        bool
+		files        int
+	}{
+		{"main", "feature", false, 1},
+		{"main", "feature-two", true, 0},
+		{"feature", "feature-two", false, 1},
+	}
+	for i, want := range expected {
+		pair := result.Pairs[i]
+		if pair.Ours != want.ours || pair.Theirs != want.theirs || pair.Clean != want.clean || pair.FileCount != want.files {
+			t.Errorf("pair %d = %+v, expected %+v", i, pair, want)
+		}
+		if !pair.Clean && (pair.HunkCount == 0 || pair.Complexity == ComplexityNone || pair.Report == nil) {
+			t.Errorf("pair %d lacks conflict details: %+v", i, pair)
+		}
+	}
+	if result.Summary.ConflictingPairs != 2 || result.Summary.CleanPairs != 1 {
+		t.Errorf("unexpected summary %+v", result.Summary)
+	}
+}
+
+func TestEstimateComplexity(t *testing.T) {
+	tests := []struct {
+		pair     ForecastPair
+		expected string
+	}{
+		{ForecastPair{}, ComplexityNone},
+		{ForecastPair{FileCount: 1, HunkCount: 1, ConflictLines: 4}, ComplexityLow},
+		{ForecastPair{FileCount: 3, HunkCount: 6, FileConflicts: 1}, ComplexityMedium},
+		{ForecastPair{FileCount: 10, HunkCount: 20, ConflictLines: 400}, ComplexityHigh},
+	}
+
+	for _, tt := range tests {
+		if _, complexity := estimateComplexity(tt.pair); complexity != tt.expected {
+			t.Errorf("estimateComplexity(%+v) = %s, expected %s", tt.pair, complexity, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// DefaultMergeDriverName is the name the merge driver is registered under
+const DefaultMergeDriverName = "syncwright"
+
+// InstallDriverOptions contains options for the install-driver command
+type InstallDriverOptions struct {
+	RepoPath string
+	// Name is the driver name used in merge.<name>.* and merge=<name>
+	Name string
+	// Command is the syncwright executable git should invoke
+	Command string
+	// Patterns are the .gitattributes patterns of the paths to merge with the driver
+	Patterns []string
+	// Local writes the patterns to .git/info/attributes instead of .gitattributes,
+	// so they are not committed
+	Local         bool
+	AIMode        bool
+	MinConfidence float64
+	Verbose       bool
+}
+
+// InstallDriverResult represents the result of installing the merge driver
+type InstallDriverResult struct {
+	Success        bool              `json:"success"`
+	Name           string            `json:"name"`
+	Config         map[string]string `json:"config"`
+	AttributesFile string            `json:"attributes_file,omitempty"`
+	AddedPatterns  []string          `json:"added_patterns"`
+	ErrorMessage   string            `json:"error_message,omitempty"`
+}
+
+// InstallDriverCommand registers syncwright as a custom git merge driver
+type InstallDriverCommand struct {
+	options InstallDriverOptions
+}
+
+// NewInstallDriverCommand creates a new install-driver command
+func NewInstallDriverCommand(options InstallDriverOptions) *InstallDriverCommand {
+	if options.Name == "" {
+		options.Name = DefaultMergeDriverName
+	}
+	if options.Command == "" {
+		options.Command = "syncwright"
+	}
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &InstallDriverCommand{options: options}
+}
+
+// Execute writes the merge.<name> config entries and the attribute lines that
+// route the given patterns to the driver. Running it again does not duplicate
+// attribute lines.
+func (i *InstallDriverCommand) Execute() (*InstallDriverResult, error) {
+	opts := i.options
+	result := &InstallDriverResult{
+		Name:          opts.Name,
+		Config:        make(map[string]string),
+		AddedPatterns: []string{},
+	}
+
+	if strings.ContainsAny(opts.Name, " \t\n=.") {
+		return i.fail(result, fmt.Errorf("invalid driver name %q", opts.Name))
+	}
+
+	result.Config["merge."+opts.Name+".name"] = "syncwright conflict resolution"
+	result.Config["merge."+opts.Name+".driver"] = i.driverCommand()
+
+	for _, key := range []string{"merge." + opts.Name + ".name", "merge." + opts.Name + ".driver"} {
+		if err := gitutils.SetConfig(opts.RepoPath, key, result.Config[key]); err != nil {
+			return i.fail(result, err)
+		}
+	}
+
+	if len(opts.Patterns) > 0 {
+		attributesFile, err := i.attributesFile()
+		if err != nil {
+			return i.fail(result, err)
+		}
+		result.AttributesFile = attributesFile
+
+		added, err := addAttributeLines(attributesFile, opts.Patterns, "merge="+opts.Name)
+		if err != nil {
+			return i.fail(result, err)
+		}
+		result.AddedPatterns = added
+	}
+
+	if opts.Verbose {
+		fmt.Printf("Registered merge driver %q: %s\n", opts.Name, result.Config["merge."+opts.Name+".driver"])
+		for _, pattern := range result.AddedPatterns {
+			fmt.Printf("  %s merge=%s (%s)\n", pattern, opts.Name, result.AttributesFile)
+		}
+	}
+
+	result.Success = true
+	return result, nil
+}
+
+// driverCommand builds the command line git runs for each file
+func (i *InstallDriverCommand) driverCommand() string {
+	command := i.options.Command + " merge-driver %O %A %B %L %P"
+	if i.options.AIMode {
+		command += " --ai"
+		if i.options.MinConfidence > 0 {
+			command += fmt.Sprintf(" --confidence %g", i.options.MinConfidence)
+		}
+	}
+	return command
+}
+
+// attributesFile returns the attributes file the patterns are written to
+func (i *InstallDriverCommand) attributesFile() (string, error) {
+	if i.options.Local {
+		return gitutils.GetGitPath(i.options.RepoPath, "info/attributes")
+	}
+
+	root, err := gitutils.GetWorktreeRoot(i.options.RepoPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, ".gitattributes"), nil
+}
+
+// fail records an installation error on the result
+func (i *InstallDriverCommand) fail(result *InstallDriverResult, err error) (*InstallDriverResult, error) {
+	result.ErrorMessage = err.Error()
+	return result, err
+}
+
+// addAttributeLines appends "<pattern> <attribute>" lines to an attributes file,
+// skipping patterns that already carry the attribute, and returns the patterns
+// that were added
+func addAttributeLines(path string, patterns []string, attribute string) ([]string, error) {
+	content, err := os.ReadFile(path) // #nosec G304 - path is the repository's attributes file
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if field == attribute {
+				existing[fields[0]] = true
+			}
+		}
+	}
+
+	added := []string{}
+	var lines strings.Builder
+	for _, pattern := range patterns {
+		if pattern == "" || strings.ContainsAny(pattern, " \t\n") {
+			return nil, fmt.Errorf("invalid attribute pattern %q", pattern)
+		}
+		if existing[pattern] {
+			continue
+		}
+		existing[pattern] = true
+		added = append(added, pattern)
+		lines.WriteString(pattern + " " + attribute + "\n")
+	}
+	if len(added) == 0 {
+		return added, nil
+	}
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, lines.String()...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	// #nosec G306 - attributes files are meant to be shared with the repository
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return added, nil
+}
+
+// InstallMergeDriver is a convenience function that registers the merge driver
+func InstallMergeDriver(options InstallDriverOptions) (*InstallDriverResult, error) {
+	cmd := NewInstallDriverCommand(options)
+	return cmd.Execute()
+}
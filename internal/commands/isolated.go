@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// IsolationResult describes a run performed inside a temporary worktree
+type IsolationResult struct {
+	Worktree    string   `json:"worktree"`
+	Kept        bool     `json:"kept"`
+	Passed      bool     `json:"passed"`
+	SyncedFiles []string `json:"synced_files"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// isolatedRun applies resolutions in a linked worktree reproducing the merge
+// state, so the real checkout is only written once the run has passed
+type isolatedRun struct {
+	worktree   *gitutils.MergeWorktree
+	keep       bool
+	verbose    bool
+	result     *IsolationResult
+	otherPaths []string
+}
+
+// startIsolatedRun creates the worktree for an isolated run of repoPath
+func startIsolatedRun(repoPath string, keep, verbose bool) (*isolatedRun, error) {
+	worktree, err := gitutils.CreateMergeWorktree(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create isolated worktree: %w", err)
+	}
+
+	if verbose {
+		fmt.Printf("🧪 Working in isolated worktree %s\n", worktree.Path)
+	}
+	logging.Logger.InfoSafe("Created isolated worktree",
+		zap.String("worktree", worktree.Path),
+		zap.Int("conflicted_files", len(worktree.ConflictedPaths)))
+
+	return &isolatedRun{
+		worktree: worktree,
+		keep:     keep,
+		verbose:  verbose,
+		result: &IsolationResult{
+			Worktree:    worktree.Path,
+			Kept:        keep,
+			SyncedFiles: []string{},
+		},
+	}, nil
+}
+
+// path returns the directory the run operates on
+func (r *isolatedRun) path() string {
+	return r.worktree.Path
+}
+
+// track adds files other than the conflicted ones that the run rewrote, so
+// they are brought back with them
+func (r *isolatedRun) track(paths ...string) {
+	r.otherPaths = append(r.otherPaths, paths...)
+}
+
+// finish brings the resolved files back into the real checkout when the run
+// passed. A failed run leaves the checkout untouched and reports why.
+func (r *isolatedRun) finish(passed bool, reason string) error {
+	r.result.Passed = passed
+	if !passed {
+		r.result.Reason = reason
+		if r.verbose {
+			fmt.Printf("⚠️  Isolated run did not pass (%s); the checkout was left untouched\n", reason)
+		}
+		return fmt.Errorf("isolated run did not pass: %s", reason)
+	}
+
+	synced, err := r.worktree.SyncBack(r.otherPaths...)
+	r.result.SyncedFiles = synced
+	if err != nil {
+		return fmt.Errorf("failed to bring resolved files back: %w", err)
+	}
+
+	if r.verbose {
+		fmt.Printf("📥 Brought %d resolved files back into the checkout\n", len(synced))
+	}
+	return nil
+}
+
+// close removes the worktree unless it was requested to be kept
+func (r *isolatedRun) close() {
+	if r.keep {
+		if r.verbose {
+			fmt.Printf("🧪 Kept isolated worktree at %s\n", r.worktree.Path)
+		}
+		return
+	}
+
+	if err := r.worktree.Remove(); err != nil {
+		logging.Logger.WarnSafe("Failed to remove isolated worktree",
+			zap.String("worktree", r.worktree.Path), zap.Error(err))
+	}
+}
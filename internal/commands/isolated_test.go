@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// newIsolatedTestRepo creates a repository stopped on a conflicting merge
+func newIsolatedTestRepo(t *testing.T) string {
+	t.Helper()
+
+	repoPath := newRebaseTestRepo(t)
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+	cmd := exec.Command("git", "merge", "feature")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+	return repoPath
+}
+
+func TestResolveCommand_IsolatedFailureLeavesCheckout(t *testing.T) {
+	// Without an API key the AI step fails inside the worktree
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newIsolatedTestRepo(t)
+	before, err := os.ReadFile(filepath.Join(repoPath, "conflict.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		AIMode:       true,
+		SkipValidate: true,
+		Isolated:     true,
+	}).Execute()
+	if err == nil {
+		t.Fatal("expected the isolated run to fail")
+	}
+	if result.Isolation == nil || result.Isolation.Passed {
+		t.Fatalf("unexpected isolation result %+v", result.Isolation)
+	}
+
+	if after, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(after) != string(before) {
+		t.Errorf("checkout changed to %q", after)
+	}
+	if _, err := os.Stat(result.Isolation.Worktree); !os.IsNotExist(err) {
+		t.Errorf("worktree was not removed: %v", err)
+	}
+}
+
+func TestResolveCommand_IsolatedKeepWorktree(t *testing.T) {
+	repoPath := newIsolatedTestRepo(t)
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		SkipValidate: true,
+		Isolated:     true,
+		KeepWorktree: true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.ConflictsDetected != 1 || result.Isolation == nil || !result.Isolation.Passed || !result.Isolation.Kept {
+		t.Fatalf("unexpected result %+v, isolation %+v", result, result.Isolation)
+	}
+	if len(result.FilesModified) != 0 {
+		t.Errorf("expected no files brought back, got %v", result.FilesModified)
+	}
+
+	if _, err := os.Stat(filepath.Join(result.Isolation.Worktree, "conflict.txt")); err != nil {
+		t.Errorf("kept worktree is missing: %v", err)
+	}
+	runRebaseTestGit(t, repoPath, "worktree", "remove", "--force", result.Isolation.Worktree)
+	if list := runRebaseTestGit(t, repoPath, "worktree", "list"); strings.Count(list, "\n") != 1 {
+		t.Errorf("worktree still registered:\n%s", list)
+	}
+}
+
+func TestResolveCommand_IsolatedGoModTidy(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+
+	repoPath := newRebaseTestRepo(t)
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// go.mod lacks the go directive tidy adds, and only go.sum conflicts
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+	write("go.mod", "module example.com/app\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	write("go.sum", "example.com/a v1.0.0 h1:a=\n")
+	runRebaseTestGit(t, repoPath, "add", ".")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-m", "add module")
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "-b", "deps")
+	write("go.sum", "example.com/b v1.0.0 h1:b=\n")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-am", "deps")
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+	write("go.sum", "example.com/c v1.0.0 h1:c=\n")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-am", "main deps")
+	cmd := exec.Command("git", "merge", "deps")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		AIMode:       true,
+		AutoApply:    true,
+		SkipFormat:   true,
+		SkipValidate: true,
+		GoModTidy:    true,
+		Isolated:     true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(result.TidiedModules) != 1 || !result.Isolation.Passed {
+		t.Fatalf("unexpected result %+v, isolation %+v", result, result.Isolation)
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(repoPath, "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(goMod), "\ngo ") {
+		t.Errorf("tidied go.mod was not brought back:\n%s", goMod)
+	}
+	if synced := strings.Join(result.Isolation.SyncedFiles, " "); !strings.Contains(synced, "go.mod") {
+		t.Errorf("synced files = %v", result.Isolation.SyncedFiles)
+	}
+}
+
+func TestResolveCommand_IsolatedRunCanBeUndone(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newIsolatedTestRepo(t)
+	before, err := os.ReadFile(filepath.Join(repoPath, "conflict.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A remembered resolution lets the run pass without an API key
+	err = gitutils.UpdateResolutionMemory(repoPath, func(memory *gitutils.ResolutionMemory) error {
+		hunk := gitutils.ConflictHunk{OursLines: []string{"main"}, TheirsLines: []string{"feature"}}
+		memory.Record("conflict.txt", hunk, []string{"merged"}, 1.0, "rerere")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		AIMode:       true,
+		AutoApply:    true,
+		SkipFormat:   true,
+		SkipValidate: true,
+		Isolated:     true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.RunID == "" {
+		t.Fatalf("isolated run has no run ID: %+v", result)
+	}
+
+	if _, err := UndoRun(repoPath, result.RunID); err != nil {
+		t.Fatalf("UndoRun() error = %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(after) != string(before) {
+		t.Errorf("undo restored %q, expected %q", after, before)
+	}
+}
@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NeuBlink/syncwright/internal/claude"
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"github.com/NeuBlink/syncwright/internal/payload"
+	"github.com/NeuBlink/syncwright/internal/strategy"
+	"go.uber.org/zap"
+)
+
+// MergeDriverOptions contains the arguments git passes to a custom merge driver
+// (%O %A %B %L %P) and the driver settings
+type MergeDriverOptions struct {
+	BaseFile       string // %O: the common ancestor version
+	OursFile       string // %A: our version, overwritten with the merge result
+	TheirsFile     string // %B: their version
+	MarkerSize     int    // %L: the conflict marker size
+	FilePath       string // %P: the path of the merged file in the repository
+	RepoPath       string
+	AIMode         bool
+	MinConfidence  float64
+	TimeoutSeconds int
+	Verbose        bool
+}
+
+// MergeDriverResult represents the result of merging a single file
+type MergeDriverResult struct {
+	FilePath           string                        `json:"file_path"`
+	Clean              bool                          `json:"clean"`
+	Binary             bool                          `json:"binary,omitempty"`
+	Conflicts          int                           `json:"conflicts"`
+	ResolvedByStrategy int                           `json:"resolved_by_strategy"`
+	ResolvedByAI       int                           `json:"resolved_by_ai"`
+	RemainingConflicts int                           `json:"remaining_conflicts"`
+	Resolutions        []gitutils.ConflictResolution `json:"resolutions,omitempty"`
+	Warnings           []string                      `json:"warnings,omitempty"`
+}
+
+// MergeDriverCommand merges one file on behalf of git, without a repository-wide
+// detection pass
+type MergeDriverCommand struct {
+	options MergeDriverOptions
+}
+
+// NewMergeDriverCommand creates a new merge driver command
+func NewMergeDriverCommand(options MergeDriverOptions) *MergeDriverCommand {
+	if options.MarkerSize <= 0 {
+		options.MarkerSize = gitutils.DefaultMarkerSize
+	}
+	if options.MinConfidence == 0 {
+		options.MinConfidence = 0.7
+	}
+	if options.TimeoutSeconds == 0 {
+		options.TimeoutSeconds = 120
+	}
+	if options.FilePath == "" {
+		options.FilePath = options.OursFile
+	}
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &MergeDriverCommand{options: options}
+}
+
+// Execute merges the three versions, resolves what it can and writes the result
+// to the ours file. Git treats the merge as clean only when no error is returned;
+// remaining conflicts are left in the file with markers.
+func (m *MergeDriverCommand) Execute() (*MergeDriverResult, error) {
+	opts := m.options
+	result := &MergeDriverResult{FilePath: opts.FilePath}
+
+	base, err := os.ReadFile(opts.BaseFile)
+	if err != nil {
+		return result, fmt.Errorf("failed to read base version: %w", err)
+	}
+	ours, err := os.ReadFile(opts.OursFile)
+	if err != nil {
+		return result, fmt.Errorf("failed to read our version: %w", err)
+	}
+	theirs, err := os.ReadFile(opts.TheirsFile)
+	if err != nil {
+		return result, fmt.Errorf("failed to read their version: %w", err)
+	}
+
+	merge := gitutils.MergeFileContents(base, ours, theirs, gitutils.MergeLabels{
+		Ours:   "ours",
+		Base:   "base",
+		Theirs: "theirs",
+	}, opts.MarkerSize)
+
+	if merge.Binary {
+		// Our version is left in place, as git's binary driver does
+		result.Binary = true
+		result.Conflicts = 1
+		result.RemainingConflicts = 1
+		return result, fmt.Errorf("cannot merge binary file %s", opts.FilePath)
+	}
+
+	result.Conflicts = len(merge.Hunks)
+	content := merge.Content()
+
+	if !merge.Clean() {
+		resolutions := m.resolveDeterministically(content, merge.Hunks)
+		result.ResolvedByStrategy = len(resolutions)
+
+		if opts.AIMode && len(resolutions) < len(merge.Hunks) {
+			aiResolutions, err := m.resolveWithAI(merge, resolutions)
+			if err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("AI resolution failed: %v", err))
+				logging.Logger.WarnSafe("Merge driver AI resolution failed",
+					zap.String("file", opts.FilePath), zap.Error(err))
+			}
+			result.ResolvedByAI = len(aiResolutions)
+			resolutions = append(resolutions, aiResolutions...)
+		}
+
+		content, err = gitutils.ApplyMultipleResolutions(content, resolutions)
+		if err != nil {
+			return result, fmt.Errorf("failed to apply resolutions to %s: %w", opts.FilePath, err)
+		}
+		result.Resolutions = resolutions
+	}
+
+	result.RemainingConflicts = result.Conflicts - result.ResolvedByStrategy - result.ResolvedByAI
+	result.Clean = result.RemainingConflicts == 0
+
+	// #nosec G306 - git recreates the working tree file with the proper mode
+	if err := os.WriteFile(opts.OursFile, []byte(content), 0644); err != nil {
+		return result, fmt.Errorf("failed to write merge result: %w", err)
+	}
+
+	if opts.Verbose {
+		fmt.Fprintf(os.Stderr, "syncwright: %s: %d conflicts, %d resolved by strategy, %d by AI, %d remaining\n",
+			opts.FilePath, result.Conflicts, result.ResolvedByStrategy, result.ResolvedByAI, result.RemainingConflicts)
+	}
+
+	if !result.Clean {
+		return result, fmt.Errorf("%d conflicts remain in %s", result.RemainingConflicts, opts.FilePath)
+	}
+	return result, nil
+}
+
+// resolveDeterministically resolves the hunks a strategy settles without
+// judgement, such as changes that only differ in whitespace
+func (m *MergeDriverCommand) resolveDeterministically(content string, hunks []gitutils.ConflictHunk) []gitutils.ConflictResolution {
+	engine := strategy.NewEngine(m.options.RepoPath)
+	file := strategy.NewFileFromContent(m.options.FilePath, []byte(content))
+
+	var resolutions []gitutils.ConflictResolution
+	for _, hunk := range hunks {
+		if resolution, ok := engine.ResolveFile(file, hunk); ok {
+			resolutions = append(resolutions, resolution)
+		}
+	}
+
+	return resolutions
+}
+
+// resolveWithAI sends the hunks the strategies left to the conflict resolver and
+// returns the confident resolutions that match one of them
+func (m *MergeDriverCommand) resolveWithAI(merge *gitutils.FileMerge, resolved []gitutils.ConflictResolution) ([]gitutils.ConflictResolution, error) {
+	opts := m.options
+
+	done := make(map[int]bool)
+	for _, resolution := range resolved {
+		done[resolution.StartLine] = true
+	}
+	pending := make(map[int]gitutils.ConflictHunk)
+	var hunks []gitutils.ConflictHunk
+	for _, hunk := range merge.Hunks {
+		if !done[hunk.StartLine] {
+			pending[hunk.StartLine] = hunk
+			hunks = append(hunks, hunk)
+		}
+	}
+
+	conflictPayload, err := payload.BuildSimplePayload(&gitutils.ConflictReport{
+		ConflictedFiles: []gitutils.ConflictFile{{Path: opts.FilePath, Hunks: hunks}},
+		TotalConflicts:  len(hunks),
+		RepoPath:        opts.RepoPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build conflict payload: %w", err)
+	}
+	conflictPayload = conflictPayload.ForAI()
+	if len(conflictPayload.Files) == 0 {
+		return nil, fmt.Errorf("%s is excluded from AI resolution", opts.FilePath)
+	}
+
+	resolver, err := claude.NewConflictResolver(&claude.ConflictResolverConfig{
+		ClaudeConfig: &claude.Config{
+			CLIPath:          "claude",
+			PrintMode:        true,
+			OutputFormat:     "json",
+			MaxTurns:         3,
+			TimeoutSeconds:   opts.TimeoutSeconds,
+			AllowedTools:     []string{"Read", "Grep", "Glob", "LS"},
+			WorkingDirectory: opts.RepoPath,
+			Verbose:          opts.Verbose,
+		},
+		RepoPath:         opts.RepoPath,
+		MinConfidence:    opts.MinConfidence,
+		IncludeReasoning: true,
+		Verbose:          opts.Verbose,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conflict resolver: %w", err)
+	}
+	defer resolver.Close()
+
+	if !resolver.IsAvailable() {
+		return nil, fmt.Errorf("Claude CLI not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	resolverResult, err := resolver.ResolveConflicts(ctx, conflictPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolutions []gitutils.ConflictResolution
+	for _, resolution := range resolverResult.HighConfidence {
+		hunk, exists := pending[resolution.StartLine]
+		if !exists || hunk.EndLine != resolution.EndLine {
+			continue
+		}
+		resolution.FilePath = opts.FilePath
+		resolution.HunkID = hunk.ID
+		if gitutils.ValidateResolution(resolution) != nil {
+			continue
+		}
+		delete(pending, resolution.StartLine)
+		resolutions = append(resolutions, resolution)
+	}
+
+	return resolutions, nil
+}
+
+// RunMergeDriver is a convenience function that merges a single file for git
+func RunMergeDriver(options MergeDriverOptions) (*MergeDriverResult, error) {
+	cmd := NewMergeDriverCommand(options)
+	return cmd.Execute()
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeDriverCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		base      string
+		ours      string
+		theirs    string
+		expected  string
+		remaining int
+	}{
+		{
+			name:     "clean merge",
+			base:     "a\nb\nc\n",
+			ours:     "A\nb\nc\n",
+			theirs:   "a\nb\nC\n",
+			expected: "A\nb\nC\n",
+		},
+		{
+			name:     "whitespace only on our side",
+			base:     "func() {\nreturn 1\n}\n",
+			ours:     "func() {\n\treturn 1\n}\n",
+			theirs:   "func() {\nreturn 2\n}\n",
+			expected: "func() {\nreturn 2\n}\n",
+		},
+		{
+			name:      "real conflict",
+			base:      "x\nvalue\n",
+			ours:      "x\nours\n",
+			theirs:    "x\ntheirs\n",
+			expected:  "x\n<<<<<<< ours\nours\n||||||| base\nvalue\n=======\ntheirs\n>>>>>>> theirs\n",
+			remaining: 1,
+		},
+		{
+			name:      "indentation change in python",
+			path:      "file.py",
+			base:      "if x:\n    a = 1\nb = 2\n",
+			ours:      "if x:\n    a = 1\n    b = 2\n",
+			theirs:    "if x:\n    a = 1\nb = 3\n",
+			expected:  "if x:\n    a = 1\n<<<<<<< ours\n    b = 2\n||||||| base\nb = 2\n=======\nb = 3\n>>>>>>> theirs\n",
+			remaining: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.path == "" {
+				tt.path = "file.go"
+			}
+			oursFile := write("ours", tt.ours)
+			result, err := NewMergeDriverCommand(MergeDriverOptions{
+				BaseFile:   write("base", tt.base),
+				OursFile:   oursFile,
+				TheirsFile: write("theirs", tt.theirs),
+				FilePath:   tt.path,
+				RepoPath:   dir,
+			}).Execute()
+
+			if (err != nil) != (tt.remaining > 0) {
+				t.Fatalf("Execute() error = %v, expected %d remaining conflicts", err, tt.remaining)
+			}
+			if result.RemainingConflicts != tt.remaining || result.Clean != (tt.remaining == 0) {
+				t.Errorf("unexpected result %+v", result)
+			}
+			if content, _ := os.ReadFile(oursFile); string(content) != tt.expected {
+				t.Errorf("merge result = %q, expected %q", content, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstallDriverCommand(t *testing.T) {
+	repoPath := newRebaseTestRepo(t)
+
+	for i := 0; i < 2; i++ {
+		result, err := NewInstallDriverCommand(InstallDriverOptions{
+			RepoPath: repoPath,
+			Patterns: []string{"*.go", "go.sum"},
+			AIMode:   true,
+		}).Execute()
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if expected := 2 - 2*i; len(result.AddedPatterns) != expected {
+			t.Errorf("run %d added %v, expected %d patterns", i, result.AddedPatterns, expected)
+		}
+	}
+
+	driver := strings.TrimSpace(runRebaseTestGit(t, repoPath, "config", "merge.syncwright.driver"))
+	if driver != "syncwright merge-driver %O %A %B %L %P --ai" {
+		t.Errorf("driver = %q", driver)
+	}
+
+	attributes, err := os.ReadFile(filepath.Join(repoPath, ".gitattributes"))
+	if err != nil || string(attributes) != "*.go merge=syncwright\ngo.sum merge=syncwright\n" {
+		t.Errorf(".gitattributes = %q, %v", attributes, err)
+	}
+	if attr := runRebaseTestGit(t, repoPath, "check-attr", "merge", "main.go"); !strings.Contains(attr, "merge: syncwright") {
+		t.Errorf("check-attr = %q", attr)
+	}
+}
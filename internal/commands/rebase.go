@@ -0,0 +1,466 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/iojson"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// Failure policies of the rebase driver
+const (
+	// RebaseOnFailurePause leaves the rebase stopped at the failing commit so it
+	// can be fixed by hand and resumed
+	RebaseOnFailurePause = "pause"
+	// RebaseOnFailureAbort aborts the rebase, restoring the original branch
+	RebaseOnFailureAbort = "abort"
+)
+
+// Statuses of a rebase run
+const (
+	RebaseStatusRunning   = "running"
+	RebaseStatusPaused    = "paused"
+	RebaseStatusCompleted = "completed"
+	RebaseStatusAborted   = "aborted"
+)
+
+// Outcomes of a single rebase step
+const (
+	RebaseStepContinued = "continued"
+	RebaseStepPaused    = "paused"
+	RebaseStepAborted   = "aborted"
+)
+
+// rebaseStateFile is the name of the run state file inside <git-dir>/syncwright
+const rebaseStateFile = "rebase-state.json"
+
+// RebaseOptions contains options for the rebase command
+type RebaseOptions struct {
+	RepoPath      string
+	Upstream      string
+	Continue      bool
+	Abort         bool
+	OnFailure     string
+	MinConfidence float64
+	ReportFile    string
+	Verbose       bool
+	// Resolve configures the resolve pipeline run at every stopped commit.
+	// AI resolution is always enabled and applied without confirmation.
+	Resolve ResolveOptions
+}
+
+// RebaseStepReport records how the conflicts of one rebased commit were handled
+type RebaseStepReport struct {
+	Step               int                           `json:"step"`
+	TotalSteps         int                           `json:"total_steps"`
+	Commit             string                        `json:"commit,omitempty"`
+	Subject            string                        `json:"subject,omitempty"`
+	ResolvedBy         string                        `json:"resolved_by"`
+	ConflictsDetected  int                           `json:"conflicts_detected"`
+	ConflictsResolved  int                           `json:"conflicts_resolved"`
+	SkippedResolutions int                           `json:"skipped_resolutions"`
+	Resolutions        []gitutils.ConflictResolution `json:"resolutions,omitempty"`
+	FileResolutions    []gitutils.FileResolution     `json:"file_resolutions,omitempty"`
+	UnresolvedFiles    []string                      `json:"unresolved_files,omitempty"`
+	AIConfidence       float64                       `json:"ai_confidence,omitempty"`
+	FormattingApplied  bool                          `json:"formatting_applied"`
+	ValidationPassed   bool                          `json:"validation_passed"`
+	Outcome            string                        `json:"outcome"`
+	Reason             string                        `json:"reason,omitempty"`
+}
+
+// RebaseRun is the persisted state and per-commit report of a rebase driven by
+// syncwright. It is kept in the git directory so a paused run can be resumed.
+type RebaseRun struct {
+	Upstream    string             `json:"upstream,omitempty"`
+	Branch      string             `json:"branch,omitempty"`
+	Status      string             `json:"status"`
+	PauseReason string             `json:"pause_reason,omitempty"`
+	StartedAt   time.Time          `json:"started_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Steps       []RebaseStepReport `json:"steps"`
+}
+
+// RebaseResult represents the result of the rebase command
+type RebaseResult struct {
+	Success      bool       `json:"success"`
+	Run          *RebaseRun `json:"run"`
+	StateFile    string     `json:"state_file,omitempty"`
+	ReportFile   string     `json:"report_file,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+}
+
+// RebaseCommand rebases onto an upstream and resolves the conflicts of every
+// stopped commit with the resolve pipeline before continuing
+type RebaseCommand struct {
+	options   RebaseOptions
+	stateFile string
+}
+
+// NewRebaseCommand creates a new rebase command
+func NewRebaseCommand(options RebaseOptions) *RebaseCommand {
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+	if options.OnFailure == "" {
+		options.OnFailure = RebaseOnFailurePause
+	}
+	if options.MinConfidence == 0 {
+		options.MinConfidence = 0.7
+	}
+
+	options.Resolve.RepoPath = options.RepoPath
+	options.Resolve.AIMode = true
+	options.Resolve.AutoApply = true
+	options.Resolve.DryRun = false
+	options.Resolve.Confidence = options.MinConfidence
+	// File-level resolutions leave the entry unmerged until it is staged, and
+	// the step only continues once nothing is unmerged
+	options.Resolve.StageResolved = true
+
+	return &RebaseCommand{options: options}
+}
+
+// Execute starts, resumes or aborts a rebase run
+func (r *RebaseCommand) Execute() (*RebaseResult, error) {
+	result := &RebaseResult{ReportFile: r.options.ReportFile}
+
+	if r.options.OnFailure != RebaseOnFailurePause && r.options.OnFailure != RebaseOnFailureAbort {
+		return r.fail(result, fmt.Errorf("invalid failure policy %q: use %s or %s",
+			r.options.OnFailure, RebaseOnFailurePause, RebaseOnFailureAbort))
+	}
+
+	gitDir, err := gitutils.GetGitDir(r.options.RepoPath)
+	if err != nil {
+		return r.fail(result, err)
+	}
+	r.stateFile = filepath.Join(gitDir, "syncwright", rebaseStateFile)
+	result.StateFile = r.stateFile
+
+	var run *RebaseRun
+	switch {
+	case r.options.Abort:
+		run, err = r.abort()
+	case r.options.Continue:
+		run, err = r.resume()
+	default:
+		run, err = r.start()
+	}
+	result.Run = run
+	if err != nil {
+		return r.fail(result, err)
+	}
+
+	result.Success = run.Status == RebaseStatusCompleted || run.Status == RebaseStatusAborted && r.options.Abort
+	if !result.Success {
+		err := fmt.Errorf("rebase %s: %s", run.Status, run.PauseReason)
+		return r.fail(result, err)
+	}
+	return result, nil
+}
+
+// start begins a new rebase and drives it
+func (r *RebaseCommand) start() (*RebaseRun, error) {
+	if r.options.Upstream == "" {
+		return nil, fmt.Errorf("an upstream to rebase onto is required")
+	}
+
+	state, err := gitutils.DetectOperation(r.options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	if state.InProgress() {
+		return nil, fmt.Errorf("a %s is already in progress; use --continue or --abort", state.Operation)
+	}
+
+	run := &RebaseRun{
+		Upstream:  r.options.Upstream,
+		Status:    RebaseStatusRunning,
+		StartedAt: time.Now(),
+		Steps:     []RebaseStepReport{},
+	}
+
+	if r.options.Verbose {
+		fmt.Printf("🔀 Rebasing onto %s...\n", r.options.Upstream)
+	}
+	logging.Logger.InfoSafe("Starting rebase", zap.String("upstream", r.options.Upstream))
+
+	if err := gitutils.StartRebase(r.options.RepoPath, r.options.Upstream); err != nil {
+		return nil, err
+	}
+
+	return run, r.drive(run, false)
+}
+
+// resume picks a paused run back up. A rebase started outside syncwright is
+// adopted with a fresh report.
+func (r *RebaseCommand) resume() (*RebaseRun, error) {
+	run, err := r.loadRun()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := gitutils.DetectOperation(r.options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Operation != gitutils.OperationRebase {
+		return nil, fmt.Errorf("no rebase in progress to continue")
+	}
+
+	if run == nil || run.Status != RebaseStatusPaused {
+		run = &RebaseRun{StartedAt: time.Now(), Steps: []RebaseStepReport{}}
+	}
+	run.Status = RebaseStatusRunning
+	run.PauseReason = ""
+
+	return run, r.drive(run, true)
+}
+
+// abort abandons the rebase and marks the run as aborted
+func (r *RebaseCommand) abort() (*RebaseRun, error) {
+	run, err := r.loadRun()
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		run = &RebaseRun{StartedAt: time.Now(), Steps: []RebaseStepReport{}}
+	}
+
+	state, err := gitutils.DetectOperation(r.options.RepoPath)
+	if err != nil {
+		return run, err
+	}
+	if state.Operation == gitutils.OperationRebase {
+		if err := gitutils.AbortOperation(r.options.RepoPath, state); err != nil {
+			return run, err
+		}
+	}
+
+	run.Status = RebaseStatusAborted
+	return run, r.saveRun(run)
+}
+
+// drive loops over the stopped commits of the rebase until it completes or a
+// step fails. When resumed, the first stopped commit may already have been
+// resolved by hand and is then continued without running the pipeline.
+func (r *RebaseCommand) drive(run *RebaseRun, resumed bool) error {
+	if run.Branch == "" {
+		if state, err := gitutils.DetectOperation(r.options.RepoPath); err == nil && state.Rebase != nil {
+			run.Branch = state.Rebase.HeadName
+		}
+	}
+
+	for first := true; ; first = false {
+		state, err := gitutils.DetectOperation(r.options.RepoPath)
+		if err != nil {
+			return err
+		}
+		if state.Operation != gitutils.OperationRebase {
+			run.Status = RebaseStatusCompleted
+			if r.options.Verbose {
+				fmt.Printf("✅ Rebase completed after %d stopped commits\n", len(run.Steps))
+			}
+			return r.saveRun(run)
+		}
+
+		step := r.newStepReport(state)
+		if r.options.Verbose {
+			fmt.Printf("📍 Step %d/%d: %s %s\n", step.Step, step.TotalSteps, shortCommit(step.Commit), step.Subject)
+		}
+
+		reason, err := r.resolveStep(step, resumed && first)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			reason = r.continueStep(step)
+		}
+		if reason != "" {
+			return r.handleFailure(run, step, state, reason)
+		}
+
+		step.Outcome = RebaseStepContinued
+		run.Steps = append(run.Steps, *step)
+		if err := r.saveRun(run); err != nil {
+			return err
+		}
+	}
+}
+
+// newStepReport starts the report of the commit the rebase stopped at
+func (r *RebaseCommand) newStepReport(state *gitutils.OperationState) *RebaseStepReport {
+	step := &RebaseStepReport{}
+	if state.Rebase != nil {
+		step.Step = state.Rebase.Step
+		step.TotalSteps = state.Rebase.TotalSteps
+		step.Commit = state.Rebase.Commit
+	}
+	if step.Commit != "" {
+		if subject, err := gitutils.GetCommitSubject(r.options.RepoPath, step.Commit); err == nil {
+			step.Subject = subject
+		}
+	}
+	return step
+}
+
+// resolveStep resolves the conflicts of the stopped commit and returns the
+// reason the step failed, or an empty string when it can be continued
+func (r *RebaseCommand) resolveStep(step *RebaseStepReport, allowManual bool) (string, error) {
+	resolved, unresolved, err := classifyUnmergedFiles(r.options.RepoPath)
+	if err != nil {
+		return "", err
+	}
+
+	if allowManual && len(unresolved) == 0 {
+		step.ResolvedBy = "manual"
+		step.ConflictsResolved = len(resolved)
+		return "", nil
+	}
+	if len(resolved) == 0 && len(unresolved) == 0 {
+		step.ResolvedBy = "none"
+		return "", nil
+	}
+
+	step.ResolvedBy = "ai"
+	resolveResult, err := NewResolveCommand(r.options.Resolve).Execute()
+	if resolveResult != nil {
+		step.ConflictsDetected = resolveResult.ConflictsDetected
+		step.ConflictsResolved = resolveResult.ConflictsResolved
+		step.SkippedResolutions = resolveResult.SkippedResolutions
+		step.Resolutions = resolveResult.Resolutions
+		step.FileResolutions = resolveResult.FileResolutions
+		step.AIConfidence = resolveResult.AIConfidence
+		step.FormattingApplied = resolveResult.FormattingApplied
+		step.ValidationPassed = resolveResult.ValidationPassed
+	}
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	_, unresolved, err = classifyUnmergedFiles(r.options.RepoPath)
+	if err != nil {
+		return "", err
+	}
+	step.UnresolvedFiles = unresolved
+
+	switch {
+	case step.AIConfidence < r.options.MinConfidence:
+		return fmt.Sprintf("AI confidence %.2f is below the threshold %.2f", step.AIConfidence, r.options.MinConfidence), nil
+	case step.SkippedResolutions > 0 || len(unresolved) > 0:
+		return fmt.Sprintf("%d resolutions skipped, %d files are still unresolved",
+			step.SkippedResolutions, len(unresolved)), nil
+	case !r.options.Resolve.SkipValidate && !step.ValidationPassed:
+		return "project validation failed", nil
+	}
+	return "", nil
+}
+
+// continueStep stages the resolved files and continues the rebase, returning
+// the reason it could not continue
+func (r *RebaseCommand) continueStep(step *RebaseStepReport) string {
+	continueResult, err := NewContinueCommand(ContinueOptions{RepoPath: r.options.RepoPath}).Execute()
+	if err != nil {
+		if continueResult != nil {
+			step.UnresolvedFiles = continueResult.UnresolvedFiles
+		}
+		return err.Error()
+	}
+	return ""
+}
+
+// handleFailure pauses or aborts the rebase at a failed step according to the
+// configured policy
+func (r *RebaseCommand) handleFailure(run *RebaseRun, step *RebaseStepReport, state *gitutils.OperationState, reason string) error {
+	step.Reason = reason
+	run.PauseReason = fmt.Sprintf("step %d/%d (%s): %s", step.Step, step.TotalSteps, shortCommit(step.Commit), reason)
+
+	logging.Logger.InfoSafe("Rebase step failed",
+		zap.Int("step", step.Step),
+		zap.String("policy", r.options.OnFailure),
+		zap.String("reason", reason))
+
+	if r.options.OnFailure == RebaseOnFailureAbort {
+		step.Outcome = RebaseStepAborted
+		run.Status = RebaseStatusAborted
+		run.Steps = append(run.Steps, *step)
+		if err := gitutils.AbortOperation(r.options.RepoPath, state); err != nil {
+			return err
+		}
+		return r.saveRun(run)
+	}
+
+	step.Outcome = RebaseStepPaused
+	run.Status = RebaseStatusPaused
+	run.Steps = append(run.Steps, *step)
+	if r.options.Verbose {
+		fmt.Printf("⏸️  Paused at %s\n", run.PauseReason)
+		fmt.Println("   Resolve the remaining conflicts, then run: syncwright rebase --continue")
+	}
+	return r.saveRun(run)
+}
+
+// loadRun reads the persisted run, returning nil when there is none
+func (r *RebaseCommand) loadRun() (*RebaseRun, error) {
+	if _, err := os.Stat(r.stateFile); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	var run RebaseRun
+	if err := iojson.ReadFile(r.stateFile, &run); err != nil {
+		return nil, fmt.Errorf("failed to load rebase state: %w", err)
+	}
+	return &run, nil
+}
+
+// saveRun persists the run state and writes the report file when requested
+func (r *RebaseCommand) saveRun(run *RebaseRun) error {
+	run.UpdatedAt = time.Now()
+
+	if err := os.MkdirAll(filepath.Dir(r.stateFile), 0750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := iojson.WriteFile(r.stateFile, run); err != nil {
+		return fmt.Errorf("failed to save rebase state: %w", err)
+	}
+
+	if r.options.ReportFile != "" {
+		if err := iojson.WriteFile(r.options.ReportFile, run); err != nil {
+			return fmt.Errorf("failed to write rebase report: %w", err)
+		}
+	}
+	return nil
+}
+
+// fail records an error on the result
+func (r *RebaseCommand) fail(result *RebaseResult, err error) (*RebaseResult, error) {
+	result.ErrorMessage = err.Error()
+	return result, err
+}
+
+// shortCommit abbreviates a commit id for display
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// RebaseOnto is a convenience function that rebases the current branch of a
+// repository onto upstream
+func RebaseOnto(repoPath, upstream string, verbose bool) (*RebaseResult, error) {
+	cmd := NewRebaseCommand(RebaseOptions{
+		RepoPath: repoPath,
+		Upstream: upstream,
+		Verbose:  verbose,
+	})
+	return cmd.Execute()
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+)
+
+// runRebaseTestGit runs git in dir and fails the test on error
+func runRebaseTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, output)
+	}
+	return string(output)
+}
+
+// newRebaseTestRepo creates a repository on branch feature whose first commit
+// conflicts with main and whose second commit applies cleanly
+func newRebaseTestRepo(t *testing.T) string {
+	t.Helper()
+
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runRebaseTestGit(t, dir, "init", "-q", "-b", "main")
+	runRebaseTestGit(t, dir, "config", "user.name", "Test User")
+	runRebaseTestGit(t, dir, "config", "user.email", "test@example.com")
+	write("conflict.txt", "base\n")
+	runRebaseTestGit(t, dir, "add", ".")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "base")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "-b", "feature")
+	write("conflict.txt", "feature\n")
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "feature change")
+	write("other.txt", "feature only\n")
+	runRebaseTestGit(t, dir, "add", "other.txt")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "feature addition")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "main")
+	write("conflict.txt", "main\n")
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "main change")
+	runRebaseTestGit(t, dir, "checkout", "-q", "feature")
+
+	return dir
+}
+
+func TestRebaseCommand_PauseAndResume(t *testing.T) {
+	// Without an API key the AI step fails, which must pause the rebase
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newRebaseTestRepo(t)
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+
+	result, err := NewRebaseCommand(RebaseOptions{
+		RepoPath:   repoPath,
+		Upstream:   "main",
+		ReportFile: reportFile,
+		Resolve:    ResolveOptions{SkipValidate: true},
+	}).Execute()
+	if err == nil {
+		t.Fatal("expected the rebase to pause")
+	}
+	if result.Run == nil || result.Run.Status != RebaseStatusPaused {
+		t.Fatalf("unexpected run %+v", result.Run)
+	}
+	if len(result.Run.Steps) != 1 {
+		t.Fatalf("expected one step report, got %+v", result.Run.Steps)
+	}
+	step := result.Run.Steps[0]
+	if step.Step != 1 || step.TotalSteps != 2 || step.Subject != "feature change" || step.Outcome != RebaseStepPaused {
+		t.Errorf("unexpected step report %+v", step)
+	}
+	if !strings.Contains(step.Reason, "API key") {
+		t.Errorf("step reason = %q, expected the AI failure", step.Reason)
+	}
+	if _, err := os.Stat(reportFile); err != nil {
+		t.Errorf("report file not written: %v", err)
+	}
+
+	// Resolve the paused commit by hand and resume
+	if err := os.WriteFile(filepath.Join(repoPath, "conflict.txt"), []byte("main and feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = NewRebaseCommand(RebaseOptions{RepoPath: repoPath, Continue: true}).Execute()
+	if err != nil {
+		t.Fatalf("resume error = %v", err)
+	}
+	if result.Run.Status != RebaseStatusCompleted {
+		t.Fatalf("run status = %s, expected completed", result.Run.Status)
+	}
+	if len(result.Run.Steps) != 2 || result.Run.Steps[1].ResolvedBy != "manual" || result.Run.Steps[1].Outcome != RebaseStepContinued {
+		t.Errorf("unexpected step reports %+v", result.Run.Steps)
+	}
+
+	log := runRebaseTestGit(t, repoPath, "log", "--format=%s", "main..feature")
+	if log != "feature addition\nfeature change\n" {
+		t.Errorf("unexpected rebased history %q", log)
+	}
+}
+
+func TestRebaseCommand_AbortOnFailure(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newRebaseTestRepo(t)
+	before := runRebaseTestGit(t, repoPath, "rev-parse", "HEAD")
+
+	result, err := NewRebaseCommand(RebaseOptions{
+		RepoPath:  repoPath,
+		Upstream:  "main",
+		OnFailure: RebaseOnFailureAbort,
+		Resolve:   ResolveOptions{SkipValidate: true},
+	}).Execute()
+	if err == nil {
+		t.Fatal("expected the rebase to abort")
+	}
+	if result.Run == nil || result.Run.Status != RebaseStatusAborted {
+		t.Fatalf("unexpected run %+v", result.Run)
+	}
+
+	state, err := gitutils.DetectOperation(repoPath)
+	if err != nil {
+		t.Fatalf("DetectOperation() error = %v", err)
+	}
+	if state.InProgress() {
+		t.Errorf("expected no operation after abort, found %s", state.Operation)
+	}
+	if after := runRebaseTestGit(t, repoPath, "rev-parse", "HEAD"); after != before {
+		t.Errorf("HEAD moved from %s to %s", before, after)
+	}
+}
+
+// useFakeClaude puts a claude CLI on PATH that answers every request with
+// response
+func useFakeClaude(t *testing.T, response string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := "#!/bin/sh\nif [ \"$1\" = --version ]; then echo '1.0.0 (Claude Code)'; exit 0; fi\ncat >/dev/null\ncat <<'EOF'\n" + response + "\nEOF\n"
+	if err := os.WriteFile(filepath.Join(dir, "claude"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRebaseCommand_StagesFileResolutions(t *testing.T) {
+	useFakeClaude(t, `Resolution: {"file_resolutions": [{"file_path": "removed.txt", "action": "delete", "confidence": 0.95, "reasoning": "main removed the file"}]}`)
+	if logging.Logger == nil {
+		logging.MustInitialize(logging.GetDefaultConfig())
+	}
+
+	dir := t.TempDir()
+	runRebaseTestGit(t, dir, "init", "-q", "-b", "main")
+	runRebaseTestGit(t, dir, "config", "user.name", "Test User")
+	runRebaseTestGit(t, dir, "config", "user.email", "test@example.com")
+	if err := os.WriteFile(filepath.Join(dir, "removed.txt"), []byte("base\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runRebaseTestGit(t, dir, "add", ".")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "base")
+
+	runRebaseTestGit(t, dir, "checkout", "-q", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(dir, "removed.txt"), []byte("feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runRebaseTestGit(t, dir, "commit", "-q", "-am", "modify")
+	runRebaseTestGit(t, dir, "checkout", "-q", "main")
+	runRebaseTestGit(t, dir, "rm", "-q", "removed.txt")
+	runRebaseTestGit(t, dir, "commit", "-q", "-m", "delete")
+	runRebaseTestGit(t, dir, "checkout", "-q", "feature")
+
+	result, err := NewRebaseCommand(RebaseOptions{
+		RepoPath: dir,
+		Upstream: "main",
+		Resolve:  ResolveOptions{APIKey: "test", SkipFormat: true, SkipValidate: true},
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v, run %+v", err, result.Run)
+	}
+	if result.Run.Status != RebaseStatusCompleted {
+		t.Fatalf("run status = %s, steps %+v", result.Run.Status, result.Run.Steps)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "removed.txt")); !os.IsNotExist(err) {
+		t.Errorf("removed.txt was not deleted: %v", err)
+	}
+}
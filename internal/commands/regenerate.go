@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/validate"
+)
+
+// StrategyRegenerate is recorded on the resolutions of lockfiles that were
+// regenerated from their manifest instead of merged
+const StrategyRegenerate = "regenerate"
+
+// regenerateTimeoutSeconds bounds a single regeneration command
+const regenerateTimeoutSeconds = 300
+
+// Lockfile describes how a kind of lockfile is regenerated from its manifest
+type Lockfile struct {
+	Type     string   // Name of the kind, as used in the syncwright.regenerate.<type> git config key
+	Name     string   // Base name of the lockfile
+	Manifest string   // Base name of the manifest in the same directory
+	Command  []string // Command run in that directory to regenerate the lockfile
+	Off      bool     // Regenerated only once its git config key turns it on
+}
+
+// DefaultLockfiles lists the lockfiles syncwright regenerates and the
+// commands it regenerates them with unless configured otherwise. go.sum is off
+// by default, since the go-sum strategy merges it; turned on, it is tidied
+// offline like --go-mod-tidy does.
+var DefaultLockfiles = []Lockfile{
+	{Type: "npm", Name: "package-lock.json", Manifest: "package.json",
+		Command: []string{"npm", "install", "--package-lock-only", "--ignore-scripts"}},
+	{Type: "yarn", Name: "yarn.lock", Manifest: "package.json",
+		Command: []string{"yarn", "install", "--ignore-scripts"}},
+	{Type: "pnpm", Name: "pnpm-lock.yaml", Manifest: "package.json",
+		Command: []string{"pnpm", "install", "--lockfile-only", "--ignore-scripts"}},
+	{Type: "cargo", Name: "Cargo.lock", Manifest: "Cargo.toml",
+		Command: []string{"cargo", "update", "--workspace"}},
+	{Type: "poetry", Name: "poetry.lock", Manifest: "pyproject.toml",
+		Command: []string{"poetry", "lock", "--no-update"}},
+	{Type: "gosum", Name: "go.sum", Manifest: "go.mod", Off: true,
+		Command: []string{"env", "GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "go", "mod", "tidy"}},
+}
+
+// configuredLockfiles returns the lockfiles to regenerate in a repository.
+// The git config key syncwright.regenerate.<type> replaces the command of a
+// kind, turns its regeneration off when set to "off", or on with the default
+// command when set to "on".
+func configuredLockfiles(repoPath string) ([]Lockfile, error) {
+	var lockfiles []Lockfile
+	for _, lockfile := range DefaultLockfiles {
+		value, err := gitutils.GetConfig(repoPath, "syncwright.regenerate."+lockfile.Type)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case value == "off":
+			continue
+		case value == "on":
+		case value != "":
+			lockfile.Command = strings.Fields(value)
+		case lockfile.Off:
+			continue
+		}
+		lockfiles = append(lockfiles, lockfile)
+	}
+	return lockfiles, nil
+}
+
+// lockfileFor returns the lockfile kind a path is of
+func lockfileFor(lockfiles []Lockfile, filePath string) (Lockfile, bool) {
+	for _, lockfile := range lockfiles {
+		if path.Base(filePath) == lockfile.Name {
+			return lockfile, true
+		}
+	}
+	return Lockfile{}, false
+}
+
+// splitLockfiles separates the conflicted files of a report into the
+// lockfiles to regenerate and a report of the remaining files
+func splitLockfiles(report *gitutils.ConflictReport, lockfiles []Lockfile) (*gitutils.ConflictReport, []string) {
+	if report == nil {
+		return nil, nil
+	}
+	remaining := *report
+	remaining.ConflictedFiles = nil
+	remaining.TotalConflicts = 0
+
+	var paths []string
+	for _, file := range report.ConflictedFiles {
+		if _, ok := lockfileFor(lockfiles, file.Path); ok && file.FileConflict == nil {
+			paths = append(paths, file.Path)
+			continue
+		}
+		remaining.ConflictedFiles = append(remaining.ConflictedFiles, file)
+		remaining.TotalConflicts += len(file.Hunks)
+	}
+	return &remaining, paths
+}
+
+// regenerateLockfiles regenerates conflicted lockfiles once their manifests
+// are resolved. Each lockfile starts from our side, or their side when ours
+// deleted it, and the configured command brings it in line with the manifest.
+// A lockfile whose manifest still has conflict markers, whose command fails or
+// that is left with conflict markers keeps its conflicted content and gets a
+// warning instead of a resolution.
+func regenerateLockfiles(repoPath string, lockfiles []Lockfile, paths []string, verbose bool) ([]gitutils.FileResolution, []string) {
+	var resolutions []gitutils.FileResolution
+	var warnings []string
+	for _, filePath := range paths {
+		lockfile, ok := lockfileFor(lockfiles, filePath)
+		if !ok {
+			continue
+		}
+
+		reasoning, err := regenerateLockfile(repoPath, lockfile, filePath)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s was not regenerated: %v", filePath, err))
+			continue
+		}
+		resolutions = append(resolutions, gitutils.FileResolution{
+			FilePath:   filePath,
+			Action:     gitutils.FileActionKeep,
+			Confidence: 1.0,
+			Reasoning:  reasoning,
+			Strategy:   StrategyRegenerate,
+		})
+		if verbose {
+			fmt.Printf("🔒 Regenerated %s\n", filePath)
+		}
+	}
+	return resolutions, warnings
+}
+
+// regenerateLockfile regenerates a single lockfile, restoring its conflicted
+// content when regeneration fails
+func regenerateLockfile(repoPath string, lockfile Lockfile, filePath string) (string, error) {
+	if len(lockfile.Command) == 0 {
+		return "", fmt.Errorf("no command is configured for %s lockfiles", lockfile.Type)
+	}
+
+	manifestPath := path.Join(path.Dir(filePath), lockfile.Manifest)
+	conflicted, err := gitutils.HasConflictMarkers(repoPath, manifestPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", manifestPath, err)
+	}
+	if conflicted {
+		return "", fmt.Errorf("%s still has conflicts", manifestPath)
+	}
+	fullManifestPath, err := gitutils.ResolveRepoPath(repoPath, manifestPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(fullManifestPath); err != nil {
+		return "", fmt.Errorf("%s not found", manifestPath)
+	}
+
+	fullPath, err := gitutils.ResolveRepoPath(repoPath, filePath)
+	if err != nil {
+		return "", err
+	}
+	original, err := os.ReadFile(fullPath) // #nosec G304 - path is resolved inside the repository
+	if err != nil {
+		return "", fmt.Errorf("failed to read the lockfile: %w", err)
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the lockfile: %w", err)
+	}
+
+	versions, err := gitutils.GetStageVersions(repoPath, filePath)
+	if err != nil {
+		return "", err
+	}
+	if versions.Binary || !versions.HasOurs && !versions.HasTheirs {
+		return "", fmt.Errorf("neither side has a text lockfile")
+	}
+	side, stage := "our", gitutils.StageOurs
+	if !versions.HasOurs {
+		side, stage = "their", gitutils.StageTheirs
+	}
+
+	restore := func(reason error) (string, error) {
+		if err := os.WriteFile(fullPath, original, info.Mode().Perm()); err != nil {
+			return "", fmt.Errorf("%v, and restoring the conflicted lockfile failed: %w", reason, err)
+		}
+		return "", reason
+	}
+
+	if err := gitutils.CheckoutStage(repoPath, filePath, stage); err != nil {
+		return restore(fmt.Errorf("failed to write %s side: %w", side, err))
+	}
+
+	result := validate.ExecuteValidationCommands([]validate.ValidationCommand{{
+		Name:        "regenerate_" + lockfile.Type,
+		Command:     lockfile.Command[0],
+		Args:        lockfile.Command[1:],
+		WorkingDir:  filepath.Dir(fullPath),
+		Description: fmt.Sprintf("Regenerate %s from %s", filePath, manifestPath),
+		Required:    true,
+	}}, regenerateTimeoutSeconds)[0]
+	command := strings.Join(lockfile.Command, " ")
+	switch {
+	case result.Skipped:
+		return restore(errors.New(result.SkipReason))
+	case !result.Success:
+		return restore(fmt.Errorf("%s: %s", command, firstLine([]byte(result.Stderr), errors.New(result.Error))))
+	}
+
+	conflicted, err = gitutils.HasConflictMarkers(repoPath, filePath)
+	if err != nil {
+		return restore(fmt.Errorf("failed to read the regenerated lockfile: %w", err))
+	}
+	if conflicted {
+		return restore(fmt.Errorf("%s left conflict markers", command))
+	}
+
+	return fmt.Sprintf("Regenerated from %s with %s, starting from %s side", manifestPath, command, side), nil
+}
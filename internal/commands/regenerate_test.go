@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// newLockfileTestRepo creates a repository stopped on a merge where only
+// package-lock.json conflicts and package.json merged cleanly
+func newLockfileTestRepo(t *testing.T, command string) string {
+	t.Helper()
+
+	repoPath := newRebaseTestRepo(t)
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(repoPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+	write("package.json", "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n")
+	write("package-lock.json", "{\n  \"lockfileVersion\": 3\n}\n")
+	runRebaseTestGit(t, repoPath, "add", ".")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-m", "add package")
+
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "-b", "deps")
+	write("package.json", "{\n  \"name\": \"app\",\n  \"version\": \"1.1.0\"\n}\n")
+	write("package-lock.json", "{\n  \"lockfileVersion\": 3,\n  \"deps\": true\n}\n")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-am", "bump")
+
+	runRebaseTestGit(t, repoPath, "checkout", "-q", "main")
+	write("package-lock.json", "{\n  \"lockfileVersion\": 3,\n  \"main\": true\n}\n")
+	runRebaseTestGit(t, repoPath, "commit", "-q", "-am", "relock")
+
+	runRebaseTestGit(t, repoPath, "config", "syncwright.regenerate.npm", command)
+	cmd := exec.Command("git", "merge", "deps")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+	return repoPath
+}
+
+func TestResolveCommand_RegenerateLockfiles(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newLockfileTestRepo(t, "cp package.json package-lock.json")
+	before, err := os.ReadFile(filepath.Join(repoPath, "package-lock.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:            repoPath,
+		AIMode:              true,
+		SkipFormat:          true,
+		SkipValidate:        true,
+		StageResolved:       true,
+		RegenerateLockfiles: true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Regenerated != 1 || len(result.FileResolutions) != 1 || result.FileResolutions[0].Strategy != StrategyRegenerate {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	lock, err := os.ReadFile(filepath.Join(repoPath, "package-lock.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(lock), "1.1.0") {
+		t.Errorf("lockfile was not regenerated from the merged manifest:\n%s", lock)
+	}
+	if unmerged := runRebaseTestGit(t, repoPath, "ls-files", "-u"); unmerged != "" {
+		t.Errorf("lockfile was not staged:\n%s", unmerged)
+	}
+
+	// Only the lockfile conflicted, and undo still restores it
+	if result.RunID == "" {
+		t.Fatalf("regeneration was not snapshotted: %+v", result)
+	}
+	if _, err := UndoRun(repoPath, result.RunID); err != nil {
+		t.Fatalf("UndoRun() error = %v", err)
+	}
+	if after, _ := os.ReadFile(filepath.Join(repoPath, "package-lock.json")); string(after) != string(before) {
+		t.Errorf("undo restored %q, expected %q", after, before)
+	}
+}
+
+func TestResolveCommand_RegenerateLockfilesFailure(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newLockfileTestRepo(t, "false")
+	before, err := os.ReadFile(filepath.Join(repoPath, "package-lock.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:            repoPath,
+		AIMode:              true,
+		SkipFormat:          true,
+		SkipValidate:        true,
+		RegenerateLockfiles: true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Regenerated != 0 || len(result.Warnings) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if after, _ := os.ReadFile(filepath.Join(repoPath, "package-lock.json")); string(after) != string(before) {
+		t.Errorf("conflicted lockfile changed to %q", after)
+	}
+}
+
+func TestSplitLockfiles_GoSumOffByDefault(t *testing.T) {
+	repoPath := t.TempDir()
+	runRebaseTestGit(t, repoPath, "init", "-q")
+	report := &gitutils.ConflictReport{ConflictedFiles: []gitutils.ConflictFile{
+		{Path: "go.sum", Hunks: []gitutils.ConflictHunk{{}}},
+		{Path: "web/package-lock.json", Hunks: []gitutils.ConflictHunk{{}}},
+	}}
+
+	lockfiles, err := configuredLockfiles(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remaining, paths := splitLockfiles(report, lockfiles)
+	if len(paths) != 1 || paths[0] != "web/package-lock.json" {
+		t.Errorf("lockfiles = %v", paths)
+	}
+	if len(remaining.ConflictedFiles) != 1 || remaining.ConflictedFiles[0].Path != "go.sum" || remaining.TotalConflicts != 1 {
+		t.Errorf("remaining = %+v", remaining)
+	}
+
+	runRebaseTestGit(t, repoPath, "config", "syncwright.regenerate.gosum", "on")
+	if lockfiles, err = configuredLockfiles(repoPath); err != nil {
+		t.Fatal(err)
+	}
+	gosum, ok := lockfileFor(lockfiles, "go.sum")
+	if !ok || gosum.Command[len(gosum.Command)-1] != "tidy" {
+		t.Fatalf("go.sum lockfile = %+v, %v", gosum, ok)
+	}
+	if _, paths = splitLockfiles(report, lockfiles); len(paths) != 2 {
+		t.Errorf("lockfiles = %v, expected go.sum once turned on", paths)
+	}
+}
+
+func TestDetectCommand_ListsLockfiles(t *testing.T) {
+	detect := &DetectCommand{}
+	for _, filePath := range []string{"package-lock.json", "web/yarn.lock", "go.sum"} {
+		if detect.shouldSkipFile(filePath) {
+			t.Errorf("%s is skipped, but it can be regenerated", filePath)
+		}
+	}
+}
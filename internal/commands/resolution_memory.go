@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// Resolution memory actions
+const (
+	MemoryActionList   = "list"
+	MemoryActionForget = "forget"
+	MemoryActionImport = "import"
+)
+
+// ResolutionMemoryOptions contains options for the memory command
+type ResolutionMemoryOptions struct {
+	RepoPath string
+	Action   string // "list", "forget" or "import"
+	// Keys are the key prefixes of the entries to forget
+	Keys []string
+	// FilePath restricts list and forget to the entries recorded for a file
+	FilePath     string
+	All          bool
+	OutputFile   string
+	OutputFormat string // "json", "text"
+	Verbose      bool
+}
+
+// ResolutionMemoryResult represents the result of the memory command
+type ResolutionMemoryResult struct {
+	Success      bool                         `json:"success"`
+	Action       string                       `json:"action"`
+	StorePath    string                       `json:"store_path"`
+	Entries      []gitutils.MemoryEntry       `json:"entries,omitempty"`
+	Forgotten    int                          `json:"forgotten,omitempty"`
+	Import       *gitutils.RerereImportResult `json:"import,omitempty"`
+	ErrorMessage string                       `json:"error_message,omitempty"`
+}
+
+// ResolutionMemoryCommand lists, forgets and imports remembered resolutions
+type ResolutionMemoryCommand struct {
+	options ResolutionMemoryOptions
+}
+
+// NewResolutionMemoryCommand creates a new memory command
+func NewResolutionMemoryCommand(options ResolutionMemoryOptions) *ResolutionMemoryCommand {
+	if options.Action == "" {
+		options.Action = MemoryActionList
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = OutputFormatText
+	}
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &ResolutionMemoryCommand{options: options}
+}
+
+// Execute runs the memory action
+func (m *ResolutionMemoryCommand) Execute() (*ResolutionMemoryResult, error) {
+	result := &ResolutionMemoryResult{Action: m.options.Action}
+
+	var err error
+	switch m.options.Action {
+	case MemoryActionList:
+		err = m.list(result)
+	case MemoryActionForget:
+		err = m.forget(result)
+	case MemoryActionImport:
+		err = m.importRerere(result)
+	default:
+		err = fmt.Errorf("unknown memory action %q (expected list, forget or import)", m.options.Action)
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	result.Success = true
+	if err := m.outputResults(result); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+// list collects the remembered resolutions
+func (m *ResolutionMemoryCommand) list(result *ResolutionMemoryResult) error {
+	memory, err := gitutils.OpenResolutionMemory(m.options.RepoPath)
+	if err != nil {
+		return err
+	}
+	result.StorePath = memory.Path()
+
+	result.Entries = []gitutils.MemoryEntry{}
+	for _, entry := range memory.List() {
+		if m.options.FilePath == "" || entry.FilePath == m.options.FilePath {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return nil
+}
+
+// forget removes the selected resolutions
+func (m *ResolutionMemoryCommand) forget(result *ResolutionMemoryResult) error {
+	opts := m.options
+	if !opts.All && len(opts.Keys) == 0 && opts.FilePath == "" {
+		return fmt.Errorf("select the resolutions to forget by key, by file or with --all")
+	}
+
+	return gitutils.UpdateResolutionMemory(opts.RepoPath, func(memory *gitutils.ResolutionMemory) error {
+		result.StorePath = memory.Path()
+		result.Forgotten = memory.ForgetMatching(func(entry *gitutils.MemoryEntry) bool {
+			if opts.All {
+				return true
+			}
+			if opts.FilePath != "" && entry.FilePath != opts.FilePath {
+				return false
+			}
+			if len(opts.Keys) == 0 {
+				return true
+			}
+			for _, key := range opts.Keys {
+				if key != "" && strings.HasPrefix(entry.Key, key) {
+					return true
+				}
+			}
+			return false
+		})
+		return nil
+	})
+}
+
+// importRerere imports the resolutions recorded by git rerere
+func (m *ResolutionMemoryCommand) importRerere(result *ResolutionMemoryResult) error {
+	return gitutils.UpdateResolutionMemory(m.options.RepoPath, func(memory *gitutils.ResolutionMemory) error {
+		result.StorePath = memory.Path()
+		imported, err := memory.ImportRerere(m.options.RepoPath)
+		if err != nil {
+			return err
+		}
+		result.Import = imported
+		return nil
+	})
+}
+
+// outputResults outputs the result in the configured format
+func (m *ResolutionMemoryCommand) outputResults(result *ResolutionMemoryResult) error {
+	var output []byte
+
+	switch m.options.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = append(data, '\n')
+	case OutputFormatText:
+		output = []byte(m.formatTextOutput(result))
+	default:
+		return fmt.Errorf("unsupported output format: %s", m.options.OutputFormat)
+	}
+
+	if m.options.OutputFile != "" {
+		if err := os.WriteFile(m.options.OutputFile, output, 0600); err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", m.options.OutputFile, err)
+		}
+		return nil
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// formatTextOutput renders the result for humans
+func (m *ResolutionMemoryCommand) formatTextOutput(result *ResolutionMemoryResult) string {
+	var builder strings.Builder
+
+	switch result.Action {
+	case MemoryActionForget:
+		fmt.Fprintf(&builder, "🧹 Forgot %d remembered resolutions\n", result.Forgotten)
+	case MemoryActionImport:
+		fmt.Fprintf(&builder, "📥 Imported %d of %d rerere records (%d skipped)\n",
+			result.Import.Imported, result.Import.Records, result.Import.Skipped)
+	default:
+		if len(result.Entries) == 0 {
+			builder.WriteString("No remembered resolutions\n")
+			break
+		}
+
+		table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "KEY\tFILE\tSOURCE\tLINES\tUSES\tRECORDED")
+		for _, entry := range result.Entries {
+			filePath := entry.FilePath
+			if filePath == "" {
+				filePath = "-"
+			}
+			fmt.Fprintf(table, "%.12s\t%s\t%s\t%d\t%d\t%s\n", entry.Key, filePath, entry.Source,
+				len(entry.ResolvedLines), entry.UseCount, entry.RecordedAt.Format("2006-01-02 15:04"))
+		}
+		table.Flush()
+
+		if m.options.Verbose {
+			for _, entry := range result.Entries {
+				fmt.Fprintf(&builder, "\n%.12s resolves to:\n", entry.Key)
+				for _, line := range entry.ResolvedLines {
+					fmt.Fprintf(&builder, "  %s\n", line)
+				}
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// ListRememberedResolutions is a convenience function that returns the
+// resolutions remembered for a repository
+func ListRememberedResolutions(repoPath string) (*ResolutionMemoryResult, error) {
+	cmd := NewResolutionMemoryCommand(ResolutionMemoryOptions{
+		RepoPath:     repoPath,
+		Action:       MemoryActionList,
+		OutputFormat: OutputFormatJSON,
+	})
+	return cmd.Execute()
+}
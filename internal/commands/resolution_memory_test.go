@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+func TestResolveCommand_RememberedWithoutAPIKey(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newIsolatedTestRepo(t)
+
+	err := gitutils.UpdateResolutionMemory(repoPath, func(memory *gitutils.ResolutionMemory) error {
+		hunk := gitutils.ConflictHunk{OursLines: []string{"main"}, TheirsLines: []string{"feature"}}
+		memory.Record("conflict.txt", hunk, []string{"merged"}, 1.0, "rerere")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		AIMode:       true,
+		AutoApply:    true,
+		SkipFormat:   true,
+		SkipValidate: true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.ConflictsResolved != 1 || len(result.Resolutions) != 1 || !result.Resolutions[0].Reused {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(content) != "merged\n" {
+		t.Errorf("conflict.txt = %q", content)
+	}
+}
+
+func TestResolveCommand_IsolatedSharesMemory(t *testing.T) {
+	t.Setenv("CLAUDE_CODE_OAUTH_TOKEN", "")
+	repoPath := newIsolatedTestRepo(t)
+
+	hunk := gitutils.ConflictHunk{OursLines: []string{"main"}, TheirsLines: []string{"feature"}}
+	err := gitutils.UpdateResolutionMemory(repoPath, func(memory *gitutils.ResolutionMemory) error {
+		memory.Record("conflict.txt", hunk, []string{"merged"}, 1.0, "rerere")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewResolveCommand(ResolveOptions{
+		RepoPath:     repoPath,
+		AIMode:       true,
+		AutoApply:    true,
+		SkipFormat:   true,
+		SkipValidate: true,
+		Isolated:     true,
+	}).Execute()
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(result.Resolutions) != 1 || !result.Resolutions[0].Reused {
+		t.Fatalf("remembered resolution was not found from the worktree: %+v", result)
+	}
+
+	// The resolution applied in the removed worktree is still remembered
+	memory, err := gitutils.OpenResolutionMemory(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := memory.List(); len(entries) != 2 {
+		t.Errorf("memory entries = %+v, expected the imported and the applied resolution", entries)
+	}
+}
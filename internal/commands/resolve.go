@@ -0,0 +1,497 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/NeuBlink/syncwright/internal/claude"
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+	"github.com/NeuBlink/syncwright/internal/strategy"
+)
+
+// ResolveOptions contains options for the resolve pipeline
+type ResolveOptions struct {
+	RepoPath     string
+	MaxTokens    int
+	AIMode       bool
+	Verbose      bool
+	DryRun       bool
+	Confidence   float64
+	APIKey       string
+	AutoApply    bool
+	SkipFormat   bool
+	SkipValidate bool
+	// Isolated runs the pipeline in a temporary worktree and only brings the
+	// resolved files back when it passes
+	Isolated     bool
+	KeepWorktree bool
+	// StageResolved adds the files whose conflicts were all resolved to the
+	// index once formatting and validation ran
+	StageResolved bool
+	// SuggestionMode places the proposals that were not confident enough to
+	// apply next to their conflicts, see AIApplyOptions.SuggestionMode
+	SuggestionMode string
+	// Review steps through every hunk interactively, see AIApplyOptions.Review
+	Review bool
+	// GoModTidy runs go mod tidy offline in the modules whose go.mod or
+	// go.sum was resolved
+	GoModTidy bool
+	// RegenerateLockfiles regenerates conflicted lockfiles from their
+	// resolved manifests instead of merging them, see DefaultLockfiles
+	RegenerateLockfiles bool
+}
+
+// ResolveResult represents the complete result of the resolve pipeline
+type ResolveResult struct {
+	Success            bool                          `json:"success"`
+	Stage              string                        `json:"stage"`
+	ConflictsDetected  int                           `json:"conflicts_detected"`
+	ConflictsResolved  int                           `json:"conflicts_resolved"`
+	StrategyResolved   int                           `json:"strategy_resolved,omitempty"` // Resolved without AI
+	Regenerated        int                           `json:"regenerated,omitempty"`       // Lockfiles regenerated from their manifests
+	SkippedResolutions int                           `json:"skipped_resolutions"`
+	FilesModified      []string                      `json:"files_modified"`
+	Resolutions        []gitutils.ConflictResolution `json:"resolutions,omitempty"`
+	FileResolutions    []gitutils.FileResolution     `json:"file_resolutions,omitempty"`
+	AIConfidence       float64                       `json:"ai_confidence,omitempty"`
+	ValidationPassed   bool                          `json:"validation_passed"`
+	FormattingApplied  bool                          `json:"formatting_applied"`
+	ErrorMessage       string                        `json:"error_message,omitempty"`
+	Summary            string                        `json:"summary"`
+	Isolation          *IsolationResult              `json:"isolation,omitempty"`
+	RunID              string                        `json:"run_id,omitempty"`
+	Staging            []gitutils.FileStaging        `json:"staging,omitempty"`
+	NeedsReview        []PendingHunk                 `json:"needs_review,omitempty"`
+	TidiedModules      []string                      `json:"tidied_modules,omitempty"`
+	Warnings           []string                      `json:"warnings,omitempty"`
+}
+
+// ResolveCommand runs the detect, AI resolution, format and validate steps as
+// a single pipeline
+type ResolveCommand struct {
+	options ResolveOptions
+	// checkoutPath is the checkout run snapshots are taken of, which is not the
+	// worktree of an isolated run
+	checkoutPath string
+}
+
+// NewResolveCommand creates a new resolve command
+func NewResolveCommand(options ResolveOptions) *ResolveCommand {
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &ResolveCommand{options: options, checkoutPath: options.RepoPath}
+}
+
+// Execute runs the resolve pipeline. Failures of a pipeline stage are reported
+// through ErrorMessage as well as the returned error.
+func (r *ResolveCommand) Execute() (*ResolveResult, error) {
+	if r.options.Isolated {
+		return r.executeIsolated()
+	}
+	return r.execute()
+}
+
+// executeIsolated runs the pipeline in a temporary worktree at the merge state.
+// The resolved files are brought back only when formatting and validation
+// passed, so a failed run leaves the checkout as it was.
+func (r *ResolveCommand) executeIsolated() (*ResolveResult, error) {
+	run, err := startIsolatedRun(r.options.RepoPath, r.options.KeepWorktree, r.options.Verbose)
+	if err != nil {
+		return r.fail(&ResolveResult{Stage: "isolation", FilesModified: []string{}}, err)
+	}
+	defer run.close()
+
+	options := r.options
+	options.RepoPath = run.path()
+	options.Isolated = false
+
+	inner := NewResolveCommand(options)
+	inner.checkoutPath = r.options.RepoPath
+	result, err := inner.Execute()
+	result.Isolation = run.result
+	if err != nil || options.DryRun {
+		return result, err
+	}
+
+	passed, reason := true, ""
+	switch {
+	case result.Stage != "completed":
+	case !options.SkipFormat && result.ConflictsResolved > 0 && !result.FormattingApplied:
+		passed, reason = false, "formatting failed"
+	case !options.SkipValidate && !result.ValidationPassed:
+		passed, reason = false, "project validation failed"
+	}
+
+	run.track(tidiedFiles(result.TidiedModules)...)
+	if err := run.finish(passed, reason); err != nil {
+		result.Success = false
+		return r.fail(result, err)
+	}
+	result.FilesModified = run.result.SyncedFiles
+
+	// The sidecar file is not a conflicted file, so it is brought back here
+	if options.SuggestionMode == gitutils.SuggestionsSidecar {
+		if err := copySuggestionsFile(run.path(), r.options.RepoPath); err != nil {
+			return r.fail(result, err)
+		}
+	}
+
+	// The files were staged in the worktree; stage them in the checkout too
+	var staged []string
+	for _, staging := range result.Staging {
+		if staging.Staged {
+			staged = append(staged, staging.FilePath)
+		}
+	}
+	if err := gitutils.StageFiles(r.options.RepoPath, staged); err != nil {
+		return r.fail(result, fmt.Errorf("failed to stage resolved files: %w", err))
+	}
+
+	return result, nil
+}
+
+// execute runs the pipeline steps in the repository
+func (r *ResolveCommand) execute() (*ResolveResult, error) {
+	opts := r.options
+	repoPath := opts.RepoPath
+
+	if opts.Verbose {
+		fmt.Println("🔍 Starting automated conflict resolution pipeline...")
+	}
+
+	result := &ResolveResult{
+		Stage:         "detection",
+		FilesModified: []string{},
+	}
+
+	// Step 1: Detect conflicts
+	if opts.Verbose {
+		fmt.Println("📋 Step 1: Detecting conflicts...")
+	}
+
+	detectResult, err := DetectConflicts(repoPath)
+	if err != nil {
+		return r.fail(result, fmt.Errorf("conflict detection failed: %w", err))
+	}
+
+	if detectResult.ConflictReport == nil || len(detectResult.ConflictReport.ConflictedFiles) == 0 {
+		result.Success = true
+		result.Summary = "No conflicts detected"
+		if opts.Verbose {
+			fmt.Println("✅ No conflicts detected")
+		}
+		return result, nil
+	}
+
+	result.ConflictsDetected = len(detectResult.ConflictReport.ConflictedFiles)
+	if opts.Verbose {
+		fmt.Printf("📋 Found %d conflicted files with %d total conflicts\n",
+			len(detectResult.ConflictReport.ConflictedFiles),
+			detectResult.ConflictReport.TotalConflicts)
+	}
+
+	if !opts.AIMode {
+		result.Summary = fmt.Sprintf("Found %d conflicts. Use --ai flag to resolve with AI assistance.", result.ConflictsDetected)
+		if opts.Verbose {
+			fmt.Println(result.Summary)
+		}
+		return result, nil
+	}
+
+	// Step 2: AI Resolution
+	if opts.Verbose {
+		fmt.Println("🤖 Step 2: Generating AI resolutions...")
+	}
+
+	// Lockfiles are left out of the AI step and regenerated once their
+	// manifests are resolved
+	var lockfiles []Lockfile
+	var lockfilePaths []string
+	if opts.RegenerateLockfiles {
+		lockfiles, err = configuredLockfiles(repoPath)
+		if err != nil {
+			return r.fail(result, fmt.Errorf("failed to read the lockfile configuration: %w", err))
+		}
+		var remaining *gitutils.ConflictReport
+		remaining, lockfilePaths = splitLockfiles(detectResult.ConflictReport, lockfiles)
+		detectResult.ConflictReport = remaining
+	}
+
+	// The files are snapshotted in the checkout, so undo restores them there
+	// even when an isolated run resolved them. Lockfiles are snapshotted too,
+	// since regenerating them rewrites them.
+	if !opts.DryRun {
+		paths := make([]string, 0, len(detectResult.ConflictReport.ConflictedFiles)+len(lockfilePaths)+1)
+		for _, file := range detectResult.ConflictReport.ConflictedFiles {
+			paths = append(paths, file.Path)
+		}
+		if len(paths) > 0 && opts.SuggestionMode == gitutils.SuggestionsSidecar {
+			paths = append(paths, gitutils.SuggestionsFileName)
+		}
+		paths = append(paths, lockfilePaths...)
+		if len(paths) > 0 {
+			result.RunID, err = createRunSnapshot(r.checkoutPath, "resolve", paths, opts.Verbose)
+			if err != nil {
+				return r.fail(result, fmt.Errorf("failed to snapshot files: %w", err))
+			}
+		}
+	}
+
+	result.Stage = "ai_resolution"
+	aiResult := &AIApplyResult{}
+	if len(lockfilePaths) == 0 || len(detectResult.ConflictReport.ConflictedFiles) > 0 {
+		aiResult, err = r.resolveWithAI(detectResult)
+		if err != nil {
+			return r.fail(result, fmt.Errorf("AI resolution failed: %w", err))
+		}
+	}
+
+	result.ConflictsResolved = aiResult.AppliedResolutions
+	result.StrategyResolved = aiResult.StrategyResolved
+	result.SkippedResolutions = aiResult.SkippedResolutions
+	result.Resolutions = aiResult.Resolutions
+	result.FileResolutions = aiResult.FileResolutions
+	result.NeedsReview = aiResult.NeedsReview
+	if aiResult.AIResponse != nil {
+		result.AIConfidence = aiResult.AIResponse.OverallConfidence
+	}
+	application := aiResult.ApplicationResult
+	if application != nil {
+		result.FilesModified = application.ModifiedFiles
+	}
+
+	if opts.Verbose {
+		fmt.Printf("🤖 Applied %d resolutions with overall confidence %.2f\n",
+			result.ConflictsResolved, result.AIConfidence)
+	}
+
+	if opts.DryRun {
+		result.Success = true
+		result.Summary = fmt.Sprintf("Dry run: Would resolve %d conflicts with AI assistance", result.ConflictsDetected)
+		return result, nil
+	}
+
+	if len(lockfilePaths) > 0 {
+		result.Stage = "regeneration"
+		regenerated, warnings := regenerateLockfiles(repoPath, lockfiles, lockfilePaths, opts.Verbose)
+		result.FileResolutions = append(result.FileResolutions, regenerated...)
+		result.Warnings = append(result.Warnings, warnings...)
+		if len(regenerated) > 0 {
+			// The regenerated lockfiles are staged with the applied files
+			if application == nil {
+				application = &gitutils.ResolutionResult{ModifiedFiles: result.FilesModified}
+			}
+			for _, resolution := range regenerated {
+				application.ModifiedFiles = append(application.ModifiedFiles, resolution.FilePath)
+			}
+			result.FilesModified = application.ModifiedFiles
+		}
+		result.Regenerated = len(regenerated)
+	}
+
+	if opts.GoModTidy {
+		result.Stage = "tidy"
+		var warnings []string
+		result.TidiedModules, warnings = tidyGoModules(repoPath, result.FilesModified, opts.Verbose)
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+	if opts.Verbose {
+		for _, warning := range result.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+	}
+
+	// Step 3: Format files (optional)
+	if !opts.SkipFormat && result.ConflictsResolved > 0 {
+		if opts.Verbose {
+			fmt.Println("🎨 Step 3: Formatting resolved files...")
+		}
+
+		result.Stage = "formatting"
+		formatResult, err := FormatFiles(repoPath, result.FilesModified)
+		if err != nil {
+			if opts.Verbose {
+				fmt.Printf("⚠️  Formatting failed but continuing: %v\n", err)
+			}
+		} else {
+			result.FormattingApplied = formatResult.Success
+			if opts.Verbose && formatResult.Success {
+				fmt.Printf("🎨 Formatted %d files\n", formatResult.Summary.FilesFormatted)
+			}
+		}
+	}
+
+	// Step 4: Validate project (optional)
+	if !opts.SkipValidate {
+		if opts.Verbose {
+			fmt.Println("✅ Step 4: Validating project...")
+		}
+
+		result.Stage = "validation"
+		validateCmd := NewValidateCommand(ValidateOptions{
+			RootPath:       repoPath,
+			TimeoutSeconds: 300,
+			Verbose:        opts.Verbose,
+		})
+		report, err := validateCmd.ExecuteWithReport()
+		if err != nil {
+			if opts.Verbose {
+				fmt.Printf("⚠️  Validation failed but continuing: %v\n", err)
+			}
+		}
+		result.ValidationPassed = err == nil && report != nil && report.OverallSuccess
+		if opts.Verbose && result.ValidationPassed {
+			fmt.Println("✅ Project validation completed")
+		}
+	}
+
+	// Step 5: Stage the fully resolved files (optional)
+	if opts.StageResolved && application != nil {
+		result.Stage = "staging"
+		if err := gitutils.StageResolvedFiles(repoPath, application); err != nil {
+			return r.fail(result, fmt.Errorf("failed to stage resolved files: %w", err))
+		}
+		result.Staging = application.Staging
+		if opts.Verbose {
+			fmt.Printf("➕ Staged %d resolved files\n", countStaged(result.Staging))
+		}
+	}
+
+	// Final summary
+	result.Success = true
+	result.Stage = "completed"
+	result.Summary = fmt.Sprintf("Successfully resolved %d/%d conflicts", result.ConflictsResolved, result.ConflictsDetected)
+	if result.StrategyResolved > 0 {
+		result.Summary += fmt.Sprintf(" (%d without AI)", result.StrategyResolved)
+	}
+	if result.Regenerated > 0 {
+		result.Summary += fmt.Sprintf("; regenerated %d lockfiles", result.Regenerated)
+	}
+	if len(result.NeedsReview) > 0 {
+		result.Summary += fmt.Sprintf("; %d conflicts need review", len(result.NeedsReview))
+	}
+
+	if opts.Verbose {
+		fmt.Println("\n🎉 Conflict resolution pipeline completed!")
+		fmt.Printf("   Conflicts resolved: %d/%d\n", result.ConflictsResolved, result.ConflictsDetected)
+		fmt.Printf("   Files modified: %d\n", len(result.FilesModified))
+		if len(result.NeedsReview) > 0 {
+			fmt.Printf("   Conflicts needing review: %d\n", len(result.NeedsReview))
+		}
+		if !opts.SkipFormat {
+			fmt.Printf("   Formatting applied: %t\n", result.FormattingApplied)
+		}
+		if !opts.SkipValidate {
+			fmt.Printf("   Validation passed: %t\n", result.ValidationPassed)
+		}
+	}
+
+	return result, nil
+}
+
+// fail records a pipeline error on the result
+func (r *ResolveCommand) fail(result *ResolveResult, err error) (*ResolveResult, error) {
+	result.ErrorMessage = err.Error()
+	return result, err
+}
+
+// resolveWithAI builds the conflict payload from the detection report and runs
+// it through the AI apply command
+func (r *ResolveCommand) resolveWithAI(detectResult *DetectResult) (*AIApplyResult, error) {
+	opts := r.options
+
+	// Detection streams its output, so the payload is built from the report
+	conflictPayload, err := payload.BuildSimplePayload(detectResult.ConflictReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build conflict payload: %w", err)
+	}
+
+	// Validate API key, unless the strategies and the resolution memory resolve
+	// every conflict without AI
+	apiKey := opts.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("CLAUDE_CODE_OAUTH_TOKEN")
+	}
+	ruled := strategy.NewEngine(opts.RepoPath).ResolvePayload(conflictPayload)
+	if apiKey == "" && claude.NeedsClaude(opts.RepoPath, ruled.Remaining.ForAI().Files) {
+		return nil, fmt.Errorf("API key not provided. Set CLAUDE_CODE_OAUTH_TOKEN environment variable or use --api-key flag")
+	}
+	payloadData, err := conflictPayload.ToJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal conflict payload: %w", err)
+	}
+
+	// Write payload to temporary file
+	tmpFile, err := os.CreateTemp("", "syncwright-payload-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+	}()
+
+	if _, err := tmpFile.Write(payloadData); err != nil {
+		return nil, fmt.Errorf("failed to write payload data: %w", err)
+	}
+	tmpFile.Close()
+
+	// The pipeline snapshots the files itself, so ai-apply takes no backup
+	aiCmd, err := NewAIApplyCommand(AIApplyOptions{
+		PayloadFile:    tmpFile.Name(),
+		RepoPath:       opts.RepoPath,
+		DryRun:         opts.DryRun,
+		Verbose:        opts.Verbose,
+		AutoApply:      opts.AutoApply,
+		MinConfidence:  opts.Confidence,
+		MaxRetries:     3,
+		TimeoutSeconds: 120,
+		SuggestionMode: opts.SuggestionMode,
+		Review:         opts.Review,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI apply command: %w", err)
+	}
+	return aiCmd.Execute()
+}
+
+// copySuggestionsFile copies the suggestions file of an isolated worktree into
+// the checkout, if the run wrote one
+func copySuggestionsFile(worktreePath, repoPath string) error {
+	content, err := os.ReadFile(filepath.Join(worktreePath, gitutils.SuggestionsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err == nil {
+		err = os.WriteFile(filepath.Join(repoPath, gitutils.SuggestionsFileName), content, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bring back %s: %w", gitutils.SuggestionsFileName, err)
+	}
+	return nil
+}
+
+// countStaged counts the files that were added to the index
+func countStaged(staging []gitutils.FileStaging) int {
+	staged := 0
+	for _, file := range staging {
+		if file.Staged {
+			staged++
+		}
+	}
+	return staged
+}
+
+// ResolveConflicts is a convenience function that runs the resolve pipeline
+func ResolveConflicts(options ResolveOptions) (*ResolveResult, error) {
+	cmd := NewResolveCommand(options)
+	return cmd.Execute()
+}
@@ -0,0 +1,424 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+// reviewMu serializes interactive reviews, so concurrent batches take turns at
+// the terminal instead of interleaving their prompts
+var reviewMu sync.Mutex
+
+// Reasons a conflict is left for a human after an interactive review
+const (
+	PendingRejected    = "rejected in review"
+	PendingNotReviewed = "not reviewed"
+)
+
+// ReviewItem is a conflict hunk to review, with the resolution proposed for it
+type ReviewItem struct {
+	FilePath string
+	Conflict payload.ConflictHunkPayload
+	Proposal *gitutils.ConflictResolution // nil when no resolution was proposed
+}
+
+// ReviewRetryFunc asks for a new proposal for a hunk, guided by a hint the
+// reviewer typed
+type ReviewRetryFunc func(item ReviewItem, hint string) (*gitutils.ConflictResolution, error)
+
+// ReviewEditFunc lets the reviewer edit lines and returns the edited lines
+type ReviewEditFunc func(filePath string, lines []string) ([]string, error)
+
+// HunkReviewerOptions contains options for the interactive reviewer
+type HunkReviewerOptions struct {
+	RepoPath string
+	In       io.Reader      // Defaults to the terminal, or stdin without one
+	Out      io.Writer      // Defaults to stdout
+	Width    int            // Defaults to $COLUMNS, or 120
+	Edit     ReviewEditFunc // Defaults to opening $VISUAL or $EDITOR
+	Retry    ReviewRetryFunc
+}
+
+// ReviewOutcome is the result of an interactive review
+type ReviewOutcome struct {
+	Decisions   []gitutils.ReviewDecision     `json:"decisions"`
+	Resolutions []gitutils.ConflictResolution `json:"-"`
+	Resumed     int                           `json:"resumed"`   // Decisions taken in an earlier session
+	Remaining   int                           `json:"remaining"` // Hunks left without a decision
+	Quit        bool                          `json:"quit"`
+}
+
+// HunkReviewer steps through conflict hunks one at a time and lets a human
+// accept or reject the proposed resolution, take a side, edit the resolution or
+// ask for another one. Decisions are kept in the review session of the
+// repository, so a review that was quit resumes where it stopped.
+type HunkReviewer struct {
+	options HunkReviewerOptions
+	in      *bufio.Reader
+	tty     *os.File
+	session *gitutils.ReviewSession
+}
+
+// NewHunkReviewer creates a reviewer and opens the review session of the repository
+func NewHunkReviewer(options HunkReviewerOptions) (*HunkReviewer, error) {
+	session, err := gitutils.OpenReviewSession(options.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	reviewer := &HunkReviewer{options: options, session: session}
+
+	// The payload may arrive on stdin, so keys are read from the terminal
+	if reviewer.options.In == nil {
+		reviewer.options.In = os.Stdin
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			reviewer.tty = tty
+			reviewer.options.In = tty
+		}
+	}
+	if reviewer.options.Out == nil {
+		reviewer.options.Out = os.Stdout
+	}
+	if reviewer.options.Width <= 0 {
+		reviewer.options.Width = 120
+		if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 40 {
+			reviewer.options.Width = columns
+		}
+	}
+	if reviewer.options.Edit == nil {
+		reviewer.options.Edit = editInEditor
+	}
+	reviewer.in = bufio.NewReader(reviewer.options.In)
+
+	return reviewer, nil
+}
+
+// Close releases the terminal the reviewer read from
+func (r *HunkReviewer) Close() error {
+	if r.tty != nil {
+		return r.tty.Close()
+	}
+	return nil
+}
+
+// Review steps through the items. Hunks decided in an earlier session are not
+// asked again. Quitting keeps the decisions taken so far for the next review
+// and leaves the remaining hunks undecided.
+func (r *HunkReviewer) Review(items []ReviewItem) (*ReviewOutcome, error) {
+	reviewMu.Lock()
+	defer reviewMu.Unlock()
+
+	outcome := &ReviewOutcome{}
+	for i, item := range items {
+		if outcome.Quit {
+			outcome.Remaining++
+			continue
+		}
+
+		if decision, found := r.session.Decision(item.FilePath, item.Conflict.HunkID); found && item.Conflict.HunkID != "" {
+			fmt.Fprintf(r.options.Out, "[%d/%d] %s already reviewed: %s\n",
+				i+1, len(items), hunkLocation(item), decision.Action)
+			outcome.Resumed++
+			outcome.add(item, *decision)
+			continue
+		}
+
+		decision, err := r.reviewItem(i, len(items), item)
+		if err != nil {
+			return outcome, err
+		}
+		if decision == nil {
+			outcome.Quit = true
+			outcome.Remaining++
+			continue
+		}
+
+		if item.Conflict.HunkID != "" {
+			if err := r.session.Record(*decision); err != nil {
+				return outcome, err
+			}
+		}
+		outcome.add(item, *decision)
+	}
+
+	return outcome, nil
+}
+
+// Forget drops the decisions of a finished review from the session
+func (r *HunkReviewer) Forget(outcome *ReviewOutcome) error {
+	return r.session.Forget(outcome.Decisions)
+}
+
+// add records a decision and the resolution it leads to
+func (o *ReviewOutcome) add(item ReviewItem, decision gitutils.ReviewDecision) {
+	o.Decisions = append(o.Decisions, decision)
+	if resolution, resolved := decision.Resolution(); resolved {
+		resolution.StartLine = item.Conflict.StartLine
+		resolution.EndLine = item.Conflict.EndLine
+		o.Resolutions = append(o.Resolutions, resolution)
+	}
+}
+
+// reviewItem shows a hunk and reads keys until a decision is taken. It returns
+// nil when the reviewer quits.
+func (r *HunkReviewer) reviewItem(index, total int, item ReviewItem) (*gitutils.ReviewDecision, error) {
+	for {
+		r.render(index, total, item)
+
+		choices := "[r]eject  take [o]urs  take [t]heirs  [e]dit  [h]int and retry  [q]uit"
+		if item.Proposal != nil {
+			choices = "[a]ccept  " + choices
+		}
+		fmt.Fprintf(r.options.Out, "%s > ", choices)
+
+		key, err := r.readLine()
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(r.options.Out)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		decision := gitutils.ReviewDecision{FilePath: item.FilePath, HunkID: item.Conflict.HunkID, Confidence: 1}
+		switch strings.ToLower(key) {
+		case "a":
+			if item.Proposal == nil {
+				continue
+			}
+			decision.Action = gitutils.ReviewAccept
+			decision.ResolvedLines = item.Proposal.ResolvedLines
+			decision.Confidence = item.Proposal.Confidence
+			decision.Reasoning = item.Proposal.Reasoning
+		case "r":
+			decision.Action = gitutils.ReviewReject
+			decision.Confidence = 0
+		case "o":
+			decision.Action = gitutils.ReviewOurs
+			decision.ResolvedLines = item.Conflict.OursLines
+			decision.Reasoning = "Took our side in review"
+		case "t":
+			decision.Action = gitutils.ReviewTheirs
+			decision.ResolvedLines = item.Conflict.TheirsLines
+			decision.Reasoning = "Took their side in review"
+		case "e":
+			lines := item.Conflict.OursLines
+			if item.Proposal != nil {
+				lines = item.Proposal.ResolvedLines
+			}
+			edited, err := r.options.Edit(item.FilePath, lines)
+			if err != nil {
+				fmt.Fprintf(r.options.Out, "Edit failed: %v\n", err)
+				continue
+			}
+			decision.Action = gitutils.ReviewEdit
+			decision.ResolvedLines = edited
+			decision.Reasoning = "Edited in review"
+		case "h":
+			item.Proposal = r.retry(item)
+			continue
+		case "q":
+			return nil, nil
+		default:
+			continue
+		}
+		return &decision, nil
+	}
+}
+
+// retry reads a hint and asks for a new proposal, keeping the current one when
+// that fails
+func (r *HunkReviewer) retry(item ReviewItem) *gitutils.ConflictResolution {
+	if r.options.Retry == nil {
+		fmt.Fprintln(r.options.Out, "Retrying is not available here")
+		return item.Proposal
+	}
+
+	fmt.Fprint(r.options.Out, "Hint for the AI: ")
+	hint, err := r.readLine()
+	if err != nil || hint == "" {
+		return item.Proposal
+	}
+
+	fmt.Fprintln(r.options.Out, "Asking for another resolution...")
+	proposal, err := r.options.Retry(item, hint)
+	if err != nil {
+		fmt.Fprintf(r.options.Out, "Retry failed: %v\n", err)
+		return item.Proposal
+	}
+	return proposal
+}
+
+// readLine reads a line of input without its line ending
+func (r *HunkReviewer) readLine() (string, error) {
+	line, err := r.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// render shows a hunk with its sides and the proposal side by side
+func (r *HunkReviewer) render(index, total int, item ReviewItem) {
+	out := r.options.Out
+	fmt.Fprintf(out, "\n[%d/%d] %s", index+1, total, hunkLocation(item))
+	if item.Proposal != nil {
+		fmt.Fprintf(out, "  confidence %.2f", item.Proposal.Confidence)
+	}
+	fmt.Fprintln(out)
+	if item.Proposal != nil && item.Proposal.Reasoning != "" {
+		fmt.Fprintf(out, "Reasoning: %s\n", strings.Join(strings.Fields(item.Proposal.Reasoning), " "))
+	}
+
+	conflict := item.Conflict
+	titles := []string{"OURS (" + markerLabelOr(conflict.OursLabel, "HEAD") + ")"}
+	columns := [][]string{conflict.OursLines}
+	if len(conflict.BaseLines) > 0 {
+		titles = append(titles, "BASE")
+		columns = append(columns, conflict.BaseLines)
+	}
+	titles = append(titles, "THEIRS ("+markerLabelOr(conflict.TheirsLabel, "branch")+")")
+	columns = append(columns, conflict.TheirsLines)
+	titles = append(titles, "PROPOSED")
+	if item.Proposal != nil {
+		columns = append(columns, item.Proposal.ResolvedLines)
+	} else {
+		columns = append(columns, []string{"(no resolution proposed)"})
+	}
+
+	fmt.Fprint(out, sideBySide(titles, columns, r.options.Width))
+}
+
+// sideBySide lays out columns of lines next to each other within width
+func sideBySide(titles []string, columns [][]string, width int) string {
+	const separator = " │ "
+	columnWidth := (width - utf8.RuneCountInString(separator)*(len(columns)-1)) / len(columns)
+	if columnWidth < 8 {
+		columnWidth = 8
+	}
+
+	rows := 0
+	for _, column := range columns {
+		if len(column) > rows {
+			rows = len(column)
+		}
+	}
+
+	var builder strings.Builder
+	writeRow := func(cells []string) {
+		for i, cell := range cells {
+			if i > 0 {
+				builder.WriteString(separator)
+			}
+			cell = fitCell(cell, columnWidth)
+			builder.WriteString(cell)
+			if i < len(cells)-1 {
+				builder.WriteString(strings.Repeat(" ", columnWidth-utf8.RuneCountInString(cell)))
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	writeRow(titles)
+	rule := make([]string, len(columns))
+	for i := range rule {
+		rule[i] = strings.Repeat("─", columnWidth)
+	}
+	writeRow(rule)
+	for row := 0; row < rows; row++ {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			if row < len(column) {
+				cells[i] = column[row]
+			}
+		}
+		writeRow(cells)
+	}
+	return builder.String()
+}
+
+// fitCell expands tabs and cuts a cell to width runes
+func fitCell(cell string, width int) string {
+	cell = strings.ReplaceAll(strings.TrimRight(cell, "\r"), "\t", "    ")
+	if utf8.RuneCountInString(cell) <= width {
+		return cell
+	}
+	runes := []rune(cell)
+	return string(runes[:width-1]) + "…"
+}
+
+// hunkLocation describes where a hunk is
+func hunkLocation(item ReviewItem) string {
+	return fmt.Sprintf("%s lines %d-%d", item.FilePath, item.Conflict.StartLine, item.Conflict.EndLine)
+}
+
+// markerLabelOr returns a conflict marker label, or a fallback when git recorded none
+func markerLabelOr(label, fallback string) string {
+	if label == "" {
+		return fallback
+	}
+	return label
+}
+
+// editInEditor opens lines in $VISUAL or $EDITOR, falling back to vi, and
+// returns the saved lines
+func editInEditor(filePath string, lines []string) ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The extension lets the editor pick the syntax of the file
+	tmpFile, err := os.CreateTemp("", "syncwright-review-*"+filepath.Ext(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	if _, err := tmpFile.WriteString(content); err != nil {
+		tmpFile.Close()
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	tmpFile.Close()
+
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], tmpFile.Name())...) // #nosec G204 - the editor is chosen by the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		cmd.Stdin, cmd.Stdout = tty, tty
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+
+	edited, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read edited file: %w", err)
+	}
+	text := strings.TrimSuffix(string(edited), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
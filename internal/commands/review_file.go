@@ -0,0 +1,241 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// Review file actions
+const (
+	ReviewActionList   = "list"
+	ReviewActionAccept = "accept"
+	ReviewActionReject = "reject"
+	ReviewActionEdit   = "edit"
+)
+
+// ReviewFileOptions contains options for the review command, which records
+// decisions in a review file written by ai-apply --propose-only
+type ReviewFileOptions struct {
+	ReviewFile string // Defaults to gitutils.DefaultReviewFileName
+	Action     string // "list", "accept", "reject" or "edit"
+	HunkID     string // Hunk ID or a unique prefix of it
+	FilePath   string // Narrows HunkID to the hunks of a file
+	// LinesFile holds the lines an edited hunk resolves to; "-" reads stdin
+	LinesFile    string
+	Comment      string
+	OutputFile   string
+	OutputFormat string // "json", "text"
+	Verbose      bool
+}
+
+// ReviewFileResult represents the result of the review command
+type ReviewFileResult struct {
+	Success      bool                    `json:"success"`
+	Action       string                  `json:"action"`
+	ReviewFile   string                  `json:"review_file"`
+	Entry        *gitutils.ReviewEntry   `json:"entry,omitempty"`
+	Entries      []*gitutils.ReviewEntry `json:"entries,omitempty"`
+	Counts       map[string]int          `json:"counts,omitempty"`
+	ErrorMessage string                  `json:"error_message,omitempty"`
+}
+
+// ReviewFileCommand lists and decides the entries of a review file
+type ReviewFileCommand struct {
+	options ReviewFileOptions
+}
+
+// NewReviewFileCommand creates a new review command
+func NewReviewFileCommand(options ReviewFileOptions) *ReviewFileCommand {
+	if options.Action == "" {
+		options.Action = ReviewActionList
+	}
+	if options.ReviewFile == "" {
+		options.ReviewFile = gitutils.DefaultReviewFileName
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = OutputFormatText
+	}
+
+	return &ReviewFileCommand{options: options}
+}
+
+// Execute runs the action
+func (r *ReviewFileCommand) Execute() (*ReviewFileResult, error) {
+	result := &ReviewFileResult{Action: r.options.Action, ReviewFile: r.options.ReviewFile}
+
+	reviewFile, err := gitutils.LoadReviewFile(r.options.ReviewFile)
+	if err == nil {
+		switch r.options.Action {
+		case ReviewActionList:
+			result.Entries = reviewFile.Entries
+		case ReviewActionAccept, ReviewActionReject, ReviewActionEdit:
+			err = r.decide(reviewFile, result)
+		default:
+			err = fmt.Errorf("unknown review action %q (expected list, accept, reject or edit)", r.options.Action)
+		}
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+	result.Counts = reviewFile.CountByStatus()
+
+	result.Success = true
+	if err := r.outputResults(result); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+// decide records the decision on the selected entry and saves the review file
+func (r *ReviewFileCommand) decide(reviewFile *gitutils.ReviewFile, result *ReviewFileResult) error {
+	entry, err := reviewFile.Find(r.options.HunkID, r.options.FilePath)
+	if err != nil {
+		return err
+	}
+
+	switch r.options.Action {
+	case ReviewActionAccept:
+		err = entry.Accept(r.options.Comment)
+	case ReviewActionReject:
+		entry.Reject(r.options.Comment)
+	case ReviewActionEdit:
+		var lines []string
+		lines, err = r.readLines()
+		if err == nil {
+			entry.Edit(lines, r.options.Comment)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := reviewFile.Save(r.options.ReviewFile); err != nil {
+		return err
+	}
+	result.Entry = entry
+
+	logging.Logger.InfoSafe("Review decision recorded",
+		zap.String("file_path", entry.FilePath),
+		zap.String("hunk_id", entry.HunkID),
+		zap.String("status", entry.Status),
+		zap.Bool("edited", entry.Edited))
+	return nil
+}
+
+// readLines reads the lines of an edited hunk
+func (r *ReviewFileCommand) readLines() ([]string, error) {
+	var data []byte
+	var err error
+	switch r.options.LinesFile {
+	case "":
+		return nil, fmt.Errorf("edit needs the resolved lines; pass a file or - for stdin")
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(r.options.LinesFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the resolved lines: %w", err)
+	}
+
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// outputResults outputs the result in the configured format
+func (r *ReviewFileCommand) outputResults(result *ReviewFileResult) error {
+	var output []byte
+
+	switch r.options.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = append(data, '\n')
+	case OutputFormatText:
+		output = []byte(r.formatTextOutput(result))
+	default:
+		return fmt.Errorf("unsupported output format: %s", r.options.OutputFormat)
+	}
+
+	if r.options.OutputFile != "" {
+		if err := os.WriteFile(r.options.OutputFile, output, 0600); err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", r.options.OutputFile, err)
+		}
+		return nil
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// formatTextOutput renders the result for humans
+func (r *ReviewFileCommand) formatTextOutput(result *ReviewFileResult) string {
+	var builder strings.Builder
+
+	if entry := result.Entry; entry != nil {
+		status := entry.Status
+		if entry.Edited {
+			status += " (edited)"
+		}
+		fmt.Fprintf(&builder, "📝 %s lines %d-%d (hunk %s): %s\n",
+			entry.FilePath, entry.StartLine, entry.EndLine, entry.HunkID, status)
+	} else {
+		table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "HUNK\tFILE\tLINES\tCONFIDENCE\tSTATUS")
+		for _, entry := range result.Entries {
+			confidence := "-"
+			if entry.Proposed {
+				confidence = fmt.Sprintf("%.2f", entry.Confidence)
+			}
+			status := entry.Status
+			if entry.Edited {
+				status += " (edited)"
+			}
+			fmt.Fprintf(table, "%s\t%s\t%d-%d\t%s\t%s\n", entry.HunkID, entry.FilePath,
+				entry.StartLine, entry.EndLine, confidence, status)
+		}
+		table.Flush()
+
+		if r.options.Verbose {
+			for _, entry := range result.Entries {
+				fmt.Fprintf(&builder, "\n%s %s:\n", entry.HunkID, entry.FilePath)
+				for _, line := range entry.Resolution().ResolvedLines {
+					fmt.Fprintf(&builder, "  + %s\n", line)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(&builder, "%d pending, %d accepted, %d rejected\n",
+		result.Counts[gitutils.ReviewStatusPending],
+		result.Counts[gitutils.ReviewStatusAccepted],
+		result.Counts[gitutils.ReviewStatusRejected])
+	return builder.String()
+}
+
+// DecideReviewEntry is a convenience function that accepts or rejects an entry
+// of a review file
+func DecideReviewEntry(reviewFile, action, hunkID string) (*ReviewFileResult, error) {
+	cmd := NewReviewFileCommand(ReviewFileOptions{
+		ReviewFile:   reviewFile,
+		Action:       action,
+		HunkID:       hunkID,
+		OutputFormat: OutputFormatJSON,
+	})
+	return cmd.Execute()
+}
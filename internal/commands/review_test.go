@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+// reviewTestItems returns three hunks, the last one without a proposal
+func reviewTestItems() []ReviewItem {
+	hunk := func(id string, start int) payload.ConflictHunkPayload {
+		return payload.ConflictHunkPayload{
+			HunkID: id, StartLine: start, EndLine: start + 4,
+			OursLines: []string{"ours " + id}, TheirsLines: []string{"theirs " + id},
+		}
+	}
+	return []ReviewItem{
+		{FilePath: "a.go", Conflict: hunk("h1", 1), Proposal: &gitutils.ConflictResolution{
+			FilePath: "a.go", HunkID: "h1", ResolvedLines: []string{"merged h1"}, Confidence: 0.4, Reasoning: "both",
+		}},
+		{FilePath: "a.go", Conflict: hunk("h2", 10), Proposal: &gitutils.ConflictResolution{
+			FilePath: "a.go", HunkID: "h2", ResolvedLines: []string{"merged h2"}, Confidence: 0.9,
+		}},
+		{FilePath: "b.go", Conflict: hunk("h3", 3)},
+	}
+}
+
+func TestHunkReviewer_DecidesAndResumes(t *testing.T) {
+	repoPath := newRebaseTestRepo(t)
+	items := reviewTestItems()
+
+	// Retry the first hunk with a hint and accept the new proposal, then quit
+	var out bytes.Buffer
+	reviewer, err := NewHunkReviewer(HunkReviewerOptions{
+		RepoPath: repoPath,
+		In:       strings.NewReader("h\nkeep both\na\nq\n"),
+		Out:      &out,
+		Retry: func(item ReviewItem, hint string) (*gitutils.ConflictResolution, error) {
+			return &gitutils.ConflictResolution{
+				FilePath: item.FilePath, HunkID: item.Conflict.HunkID,
+				ResolvedLines: []string{"retried: " + hint}, Confidence: 0.8,
+			}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHunkReviewer() error = %v", err)
+	}
+	outcome, err := reviewer.Review(items)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if !outcome.Quit || outcome.Remaining != 2 || len(outcome.Resolutions) != 1 {
+		t.Fatalf("unexpected outcome %+v", outcome)
+	}
+	if lines := outcome.Resolutions[0].ResolvedLines; !reflect.DeepEqual(lines, []string{"retried: keep both"}) {
+		t.Errorf("resolved lines = %q", lines)
+	}
+	for _, expected := range []string{"OURS (HEAD)", "THEIRS (branch)", "PROPOSED", "merged h1", "confidence 0.40"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("review output does not contain %q:\n%s", expected, out.String())
+		}
+	}
+
+	// A second review resumes after the first hunk
+	reviewer, err = NewHunkReviewer(HunkReviewerOptions{
+		RepoPath: repoPath,
+		In:       strings.NewReader("r\ne\n"),
+		Out:      &bytes.Buffer{},
+		Edit: func(filePath string, lines []string) ([]string, error) {
+			if !reflect.DeepEqual(lines, []string{"ours h3"}) {
+				t.Errorf("edit started from %q, expected our side", lines)
+			}
+			return []string{"edited h3"}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHunkReviewer() error = %v", err)
+	}
+	outcome, err = reviewer.Review(items)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if outcome.Quit || outcome.Resumed != 1 || outcome.Remaining != 0 {
+		t.Fatalf("unexpected outcome %+v", outcome)
+	}
+
+	var actions []string
+	for _, decision := range outcome.Decisions {
+		actions = append(actions, decision.Action)
+	}
+	expected := []string{gitutils.ReviewAccept, gitutils.ReviewReject, gitutils.ReviewEdit}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("actions = %v, expected %v", actions, expected)
+	}
+	if len(outcome.Resolutions) != 2 || outcome.Resolutions[1].StartLine != 3 {
+		t.Errorf("unexpected resolutions %+v", outcome.Resolutions)
+	}
+
+	// A finished review clears the session
+	if err := reviewer.Forget(outcome); err != nil {
+		t.Fatalf("Forget() error = %v", err)
+	}
+	session, err := gitutils.OpenReviewSession(repoPath)
+	if err != nil || len(session.Decisions) != 0 {
+		t.Errorf("session after Forget() = %+v, %v", session, err)
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	rendered := sideBySide([]string{"A", "B"}, [][]string{{"short", "x"}, {"a much longer line"}}, 23)
+	expected := "A          │ B\n" +
+		"────────── │ ──────────\n" +
+		"short      │ a much lo…\n" +
+		"x          │ \n"
+	if rendered != expected {
+		t.Errorf("sideBySide() = %q, expected %q", rendered, expected)
+	}
+}
+
+func TestReviewSession_OutlivesIsolatedWorktree(t *testing.T) {
+	repoPath := newIsolatedTestRepo(t)
+	worktree, err := gitutils.CreateMergeWorktree(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := gitutils.OpenReviewSession(worktree.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decision := gitutils.ReviewDecision{FilePath: "conflict.txt", HunkID: "h1", Action: gitutils.ReviewReject}
+	if err := session.Record(decision); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := worktree.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	session, err = gitutils.OpenReviewSession(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := session.Decision("conflict.txt", "h1"); !found {
+		t.Errorf("decision taken in the worktree was lost: %+v", session.Decisions)
+	}
+}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/logging"
+	"go.uber.org/zap"
+)
+
+// Run history actions
+const (
+	RunsActionList = "list"
+	RunsActionUndo = "undo"
+)
+
+// RunsOptions contains options for the runs and undo commands
+type RunsOptions struct {
+	RepoPath string
+	Action   string // "list" or "undo"
+	// RunID selects the run to undo by ID or ID prefix; empty selects the
+	// most recent run that was not undone
+	RunID        string
+	OutputFile   string
+	OutputFormat string // "json", "text"
+	Verbose      bool
+}
+
+// RunsResult represents the result of the runs and undo commands
+type RunsResult struct {
+	Success      bool                    `json:"success"`
+	Action       string                  `json:"action"`
+	StorePath    string                  `json:"store_path"`
+	Runs         []*gitutils.RunManifest `json:"runs,omitempty"`
+	Undo         *gitutils.UndoResult    `json:"undo,omitempty"`
+	ErrorMessage string                  `json:"error_message,omitempty"`
+}
+
+// RunsCommand lists the snapshots taken before apply runs and undoes runs
+type RunsCommand struct {
+	options RunsOptions
+}
+
+// NewRunsCommand creates a new runs command
+func NewRunsCommand(options RunsOptions) *RunsCommand {
+	if options.Action == "" {
+		options.Action = RunsActionList
+	}
+	if options.OutputFormat == "" {
+		options.OutputFormat = OutputFormatText
+	}
+	if options.RepoPath == "" {
+		if wd, err := os.Getwd(); err == nil {
+			options.RepoPath = wd
+		}
+	}
+
+	return &RunsCommand{options: options}
+}
+
+// Execute runs the action
+func (r *RunsCommand) Execute() (*RunsResult, error) {
+	result := &RunsResult{Action: r.options.Action}
+
+	storePath, err := gitutils.GetRunsDir(r.options.RepoPath)
+	if err == nil {
+		result.StorePath = storePath
+		switch r.options.Action {
+		case RunsActionList:
+			result.Runs, err = gitutils.ListRuns(r.options.RepoPath)
+		case RunsActionUndo:
+			err = r.undo(result)
+		default:
+			err = fmt.Errorf("unknown runs action %q (expected list or undo)", r.options.Action)
+		}
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result, err
+	}
+
+	result.Success = true
+	if err := r.outputResults(result); err != nil {
+		result.ErrorMessage = fmt.Sprintf("Failed to output results: %v", err)
+		return result, err
+	}
+	return result, nil
+}
+
+// undo restores the files of the selected run
+func (r *RunsCommand) undo(result *RunsResult) error {
+	run, err := gitutils.FindRun(r.options.RepoPath, r.options.RunID)
+	if err != nil {
+		return err
+	}
+
+	undo, err := gitutils.UndoRun(r.options.RepoPath, run)
+	result.Undo = undo
+	if err != nil {
+		return fmt.Errorf("failed to undo run %s: %w", run.ID, err)
+	}
+
+	logging.Logger.InfoSafe("Run undone",
+		zap.String("run_id", run.ID),
+		zap.Int("restored_files", len(undo.RestoredFiles)),
+		zap.Int("removed_files", len(undo.RemovedFiles)))
+	return nil
+}
+
+// outputResults outputs the result in the configured format
+func (r *RunsCommand) outputResults(result *RunsResult) error {
+	var output []byte
+
+	switch r.options.OutputFormat {
+	case OutputFormatJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = append(data, '\n')
+	case OutputFormatText:
+		output = []byte(r.formatTextOutput(result))
+	default:
+		return fmt.Errorf("unsupported output format: %s", r.options.OutputFormat)
+	}
+
+	if r.options.OutputFile != "" {
+		if err := os.WriteFile(r.options.OutputFile, output, 0600); err != nil {
+			return fmt.Errorf("failed to write to file %s: %w", r.options.OutputFile, err)
+		}
+		return nil
+	}
+
+	fmt.Print(string(output))
+	return nil
+}
+
+// formatTextOutput renders the result for humans
+func (r *RunsCommand) formatTextOutput(result *RunsResult) string {
+	var builder strings.Builder
+
+	if result.Action == RunsActionUndo {
+		fmt.Fprintf(&builder, "⏪ Undid run %s: restored %d files, removed %d files\n",
+			result.Undo.RunID, len(result.Undo.RestoredFiles), len(result.Undo.RemovedFiles))
+		if r.options.Verbose {
+			for _, path := range result.Undo.RestoredFiles {
+				fmt.Fprintf(&builder, "  restored %s\n", path)
+			}
+			for _, path := range result.Undo.RemovedFiles {
+				fmt.Fprintf(&builder, "  removed  %s\n", path)
+			}
+		}
+		return builder.String()
+	}
+
+	if len(result.Runs) == 0 {
+		builder.WriteString("No recorded runs\n")
+		return builder.String()
+	}
+
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RUN\tCOMMAND\tFILES\tCREATED\tSTATE")
+	for _, run := range result.Runs {
+		state := "applied"
+		if run.Undone() {
+			state = "undone"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t%s\n", run.ID, run.Command, len(run.Files),
+			run.CreatedAt.Local().Format("2006-01-02 15:04"), state)
+	}
+	table.Flush()
+
+	if r.options.Verbose {
+		for _, run := range result.Runs {
+			fmt.Fprintf(&builder, "\n%s touched:\n", run.ID)
+			for _, file := range run.Files {
+				fmt.Fprintf(&builder, "  %s\n", file.Path)
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// createRunSnapshot snapshots the files an apply run may change, so the run
+// can be undone, and returns the run ID
+func createRunSnapshot(repoPath, command string, paths []string, verbose bool) (string, error) {
+	manifest, err := gitutils.CreateRunSnapshot(repoPath, command, paths)
+	if err != nil {
+		return "", err
+	}
+
+	logging.Logger.InfoSafe("Run snapshot created",
+		zap.String("run_id", manifest.ID),
+		zap.Int("file_count", len(manifest.Files)))
+	if verbose {
+		fmt.Printf("Snapshotted %d files as run %s (undo with: syncwright undo %s)\n",
+			len(manifest.Files), manifest.ID, manifest.ID)
+	}
+	return manifest.ID, nil
+}
+
+// UndoRun is a convenience function that undoes a run of a repository; an empty
+// run ID undoes the most recent run
+func UndoRun(repoPath, runID string) (*RunsResult, error) {
+	cmd := NewRunsCommand(RunsOptions{
+		RepoPath:     repoPath,
+		Action:       RunsActionUndo,
+		RunID:        runID,
+		OutputFormat: OutputFormatJSON,
+	})
+	return cmd.Execute()
+}
@@ -304,25 +304,7 @@ func (fp *FileProcessor) worker(
 		}
 
 		// Process the file
-		language := detectLanguage(job.file.Path)
-		filePayload := SimplifiedFilePayload{
-			Path:     job.file.Path,
-			Language: language,
-		}
-
-		// Convert conflict hunks to simplified format
-		for i, hunk := range job.file.Hunks {
-			conflictHunk := SimplifiedConflictHunk{
-				ID:          fmt.Sprintf("%s:%d", job.file.Path, i),
-				StartLine:   hunk.StartLine,
-				EndLine:     hunk.EndLine,
-				OursLines:   hunk.OursLines,
-				TheirsLines: hunk.TheirsLines,
-				PreContext:  detectCmd.extractPreContext(job.file.Context, hunk.StartLine),
-				PostContext: detectCmd.extractPostContext(job.file.Context, hunk.EndLine),
-			}
-			filePayload.Conflicts = append(filePayload.Conflicts, conflictHunk)
-		}
+		filePayload := detectCmd.buildFilePayload(job.file)
 
 		result.FilePayload = &filePayload
 		results <- result
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// goModTidyTimeout bounds a single go mod tidy run
+const goModTidyTimeout = 2 * time.Minute
+
+// tidyGoModules runs go mod tidy in the modules whose go.mod or go.sum is
+// among filePaths. It works offline: modules missing from the module cache
+// make tidy fail rather than download them, and the toolchain is not
+// switched. It returns the module directories it tidied and a warning for
+// each one it could not.
+func tidyGoModules(repoPath string, filePaths []string, verbose bool) ([]string, []string) {
+	dirs := make(map[string]bool)
+	for _, filePath := range filePaths {
+		if base := path.Base(filePath); base == "go.mod" || base == "go.sum" {
+			dirs[path.Dir(filePath)] = true
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		return nil, []string{"go mod tidy skipped: go is not installed"}
+	}
+
+	var tidied, warnings []string
+	for _, dir := range sortedKeys(dirs) {
+		// The module root itself may be the repository root, which does not
+		// name a file, so go.mod is resolved instead
+		goModPath, err := gitutils.ResolveRepoPath(repoPath, path.Join(dir, "go.mod"))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("go mod tidy skipped in %s: %v", dir, err))
+			continue
+		}
+		if _, err := os.Stat(goModPath); err != nil {
+			continue
+		}
+		fullPath := filepath.Dir(goModPath)
+
+		ctx, cancel := context.WithTimeout(context.Background(), goModTidyTimeout)
+		cmd := exec.CommandContext(ctx, "go", "mod", "tidy")
+		cmd.Dir = fullPath
+		cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+		output, err := cmd.CombinedOutput()
+		cancel()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("go mod tidy failed in %s: %s", dir, firstLine(output, err)))
+			continue
+		}
+		tidied = append(tidied, dir)
+		if verbose {
+			fmt.Printf("🧹 Tidied the Go module in %s\n", dir)
+		}
+	}
+	return tidied, warnings
+}
+
+// tidiedFiles returns the go.mod and go.sum paths of tidied module directories
+func tidiedFiles(dirs []string) []string {
+	files := make([]string, 0, 2*len(dirs))
+	for _, dir := range dirs {
+		files = append(files, path.Join(dir, "go.mod"), path.Join(dir, "go.sum"))
+	}
+	return files
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// firstLine returns the first line of a command's output, or its error when
+// it printed nothing
+func firstLine(output []byte, err error) string {
+	if line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n"); line != "" {
+		return line
+	}
+	return err.Error()
+}
@@ -41,6 +41,13 @@ func NewValidateCommand(options ValidateOptions) *ValidateCommand {
 
 // Execute runs the validate command
 func (v *ValidateCommand) Execute() error {
+	_, err := v.ExecuteWithReport()
+	return err
+}
+
+// ExecuteWithReport runs the validate command and also returns the report, so
+// callers can act on the overall outcome
+func (v *ValidateCommand) ExecuteWithReport() (*validate.ValidationReport, error) {
 	if v.options.Verbose {
 		fmt.Fprintf(os.Stderr, "Running validation on project at: %s\n", v.options.RootPath)
 		fmt.Fprintf(os.Stderr, "Timeout: %d seconds\n", v.options.TimeoutSeconds)
@@ -86,7 +93,7 @@ func (v *ValidateCommand) Execute() error {
 		// But still print the summary to stderr to provide some feedback
 		fmt.Fprintf(os.Stderr, "Error: Failed to output results: %v\n", err)
 		v.printSummary(report)
-		return fmt.Errorf("failed to output results: %w", err)
+		return report, fmt.Errorf("failed to output results: %w", err)
 	}
 
 	// Print summary to stderr if verbose or if outputting to file
@@ -94,7 +101,7 @@ func (v *ValidateCommand) Execute() error {
 		v.printSummary(report)
 	}
 
-	return nil
+	return report, nil
 }
 
 // outputResults outputs the validation report
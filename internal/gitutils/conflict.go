@@ -1,12 +1,12 @@
 package gitutils
 
 import (
-	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -18,30 +18,83 @@ type ConflictStatus struct {
 
 // ConflictHunk represents a single conflict region in a file
 type ConflictHunk struct {
+	ID          string   `json:"id,omitempty"` // Content hash of the marker block, see HunkID
 	StartLine   int      `json:"start_line"`
 	EndLine     int      `json:"end_line"`
 	OursLines   []string `json:"ours_lines"`
 	TheirsLines []string `json:"theirs_lines"`
-	BaseLines   []string `json:"base_lines,omitempty"` // For diff3 style conflicts
+	BaseLines   []string `json:"base_lines,omitempty"`   // For diff3 style conflicts
+	OursLabel   string   `json:"ours_label,omitempty"`   // Text after <<<<<<<, e.g. HEAD
+	BaseLabel   string   `json:"base_label,omitempty"`   // Text after |||||||, e.g. a commit id
+	TheirsLabel string   `json:"theirs_label,omitempty"` // Text after >>>>>>>, e.g. a branch name
+	MarkerSize  int      `json:"marker_size,omitempty"`
+}
+
+// DefaultMarkerSize is the conflict marker length git uses unless the
+// conflict-marker-size attribute says otherwise
+const DefaultMarkerSize = 7
+
+// ConflictKind describes how a path ended up unmerged
+type ConflictKind string
+
+const (
+	// ConflictKindBothModified is a regular content conflict (UU)
+	ConflictKindBothModified ConflictKind = "both_modified"
+	// ConflictKindBothAdded means both sides added the path independently (AA)
+	ConflictKindBothAdded ConflictKind = "both_added"
+	// ConflictKindBothDeleted means both sides deleted the path (DD)
+	ConflictKindBothDeleted ConflictKind = "both_deleted"
+	// ConflictKindAddedByUs means only our side has the path (AU)
+	ConflictKindAddedByUs ConflictKind = "added_by_us"
+	// ConflictKindAddedByThem means only their side has the path (UA)
+	ConflictKindAddedByThem ConflictKind = "added_by_them"
+	// ConflictKindDeletedByUs means we deleted the path and they modified it (DU)
+	ConflictKindDeletedByUs ConflictKind = "deleted_by_us"
+	// ConflictKindDeletedByThem means they deleted the path and we modified it (UD)
+	ConflictKindDeletedByThem ConflictKind = "deleted_by_them"
+)
+
+// Conflict sides used in file-level conflict descriptions
+const (
+	SideOurs   = "ours"
+	SideTheirs = "theirs"
+	SideBoth   = "both"
+)
+
+// FileConflict describes a conflict that concerns the whole file rather than
+// individual hunks, such as modify/delete, add/add or delete/delete
+type FileConflict struct {
+	Kind             ConflictKind `json:"kind"`
+	Status           string       `json:"status"`
+	DeletedBy        string       `json:"deleted_by,omitempty"`     // "ours", "theirs" or "both"
+	AddedBy          string       `json:"added_by,omitempty"`       // "ours", "theirs" or "both"
+	SurvivingSide    string       `json:"surviving_side,omitempty"` // Side whose content still exists
+	SurvivingContent []string     `json:"surviving_content,omitempty"`
 }
 
 // ConflictFile represents a file with merge conflicts
 type ConflictFile struct {
-	Path    string         `json:"path"`
-	Hunks   []ConflictHunk `json:"hunks"`
-	Context []string       `json:"context,omitempty"` // Surrounding lines for AI context
+	Path         string         `json:"path"`
+	Encoding     *FileEncoding  `json:"encoding,omitempty"` // Encoding of the working tree file
+	Hunks        []ConflictHunk `json:"hunks"`
+	Context      []string       `json:"context,omitempty"`       // Surrounding lines for AI context
+	FileConflict *FileConflict  `json:"file_conflict,omitempty"` // Set for non content-only conflicts
+	Error        string         `json:"error,omitempty"`         // Why the file could only be analysed in part
 }
 
 // ConflictReport represents the overall conflict detection report
 type ConflictReport struct {
-	ConflictedFiles []ConflictFile `json:"conflicted_files"`
-	TotalConflicts  int            `json:"total_conflicts"`
-	RepoPath        string         `json:"repo_path"`
+	ConflictedFiles    []ConflictFile `json:"conflicted_files"`
+	TotalConflicts     int            `json:"total_conflicts"`
+	TotalFileConflicts int            `json:"total_file_conflicts"`
+	RepoPath           string         `json:"repo_path"`
 }
 
-// DetectConflicts identifies files with merge conflicts from git status
+// DetectConflicts identifies files with merge conflicts from git status. The
+// NUL-delimited porcelain v2 format is used so that paths containing spaces,
+// newlines or non-ASCII characters are reported verbatim instead of quoted.
 func DetectConflicts(repoPath string) ([]ConflictStatus, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
+	cmd := exec.Command("git", "status", "--porcelain=v2", "-z", "--untracked-files=no")
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -49,29 +102,45 @@ func DetectConflicts(repoPath string) ([]ConflictStatus, error) {
 		return nil, fmt.Errorf("failed to run git status: %w", err)
 	}
 
+	return parseStatusV2(output)
+}
+
+// parseStatusV2 extracts the unmerged entries from git status --porcelain=v2 -z
+// output. Unmerged records have the form
+// "u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>".
+func parseStatusV2(output []byte) ([]ConflictStatus, error) {
 	var conflicts []ConflictStatus
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) < 3 {
+	records := strings.Split(string(output), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
 			continue
 		}
 
-		// Check for conflict markers in git status
-		// UU = both modified, AA = both added, DD = both deleted
-		// AU = added by us, UA = added by them, DU = deleted by us, UD = deleted by them
-		status := line[:2]
-		if isConflictStatus(status) {
-			filePath := strings.TrimSpace(line[3:])
-			conflicts = append(conflicts, ConflictStatus{
-				FilePath: filePath,
-				Status:   status,
-			})
+		switch record[0] {
+		case 'u':
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 {
+				return nil, fmt.Errorf("malformed unmerged status record: %q", record)
+			}
+
+			// UU = both modified, AA = both added, DD = both deleted
+			// AU = added by us, UA = added by them, DU = deleted by us, UD = deleted by them
+			status := fields[1]
+			if isConflictStatus(status) {
+				conflicts = append(conflicts, ConflictStatus{
+					FilePath: fields[10],
+					Status:   status,
+				})
+			}
+		case '2':
+			// Renamed or copied entries are followed by a record holding the original path
+			i++
 		}
 	}
 
-	return conflicts, scanner.Err()
+	return conflicts, nil
 }
 
 // isConflictStatus checks if the git status indicates a merge conflict
@@ -85,6 +154,125 @@ func isConflictStatus(status string) bool {
 	return false
 }
 
+// ConflictKindForStatus maps a two-letter porcelain status to a conflict kind
+func ConflictKindForStatus(status string) ConflictKind {
+	switch status {
+	case "AA":
+		return ConflictKindBothAdded
+	case "DD":
+		return ConflictKindBothDeleted
+	case "AU":
+		return ConflictKindAddedByUs
+	case "UA":
+		return ConflictKindAddedByThem
+	case "DU":
+		return ConflictKindDeletedByUs
+	case "UD":
+		return ConflictKindDeletedByThem
+	default:
+		return ConflictKindBothModified
+	}
+}
+
+// IsFileLevel reports whether the conflict kind concerns the existence of the file
+// itself rather than only its content
+func (k ConflictKind) IsFileLevel() bool {
+	return k != ConflictKindBothModified
+}
+
+// BuildFileConflict describes a file-level conflict and reads the surviving
+// content from the index stages. It returns nil for plain content conflicts.
+func BuildFileConflict(repoPath string, status ConflictStatus) (*FileConflict, error) {
+	return buildFileConflict(status, func(stage int) ([]byte, error) {
+		return readIndexStage(repoPath, status.FilePath, stage)
+	})
+}
+
+// buildFileConflict describes a file-level conflict, reading the surviving
+// content through readStage so that stages need not come from the index
+func buildFileConflict(status ConflictStatus, readStage func(stage int) ([]byte, error)) (*FileConflict, error) {
+	kind := ConflictKindForStatus(status.Status)
+	if !kind.IsFileLevel() {
+		return nil, nil
+	}
+
+	fileConflict := &FileConflict{
+		Kind:   kind,
+		Status: status.Status,
+	}
+
+	// Stage 2 holds our version and stage 3 holds theirs
+	survivingStage := 0
+	switch kind {
+	case ConflictKindBothAdded:
+		fileConflict.AddedBy = SideBoth
+	case ConflictKindBothDeleted:
+		fileConflict.DeletedBy = SideBoth
+	case ConflictKindAddedByUs:
+		fileConflict.AddedBy = SideOurs
+		fileConflict.SurvivingSide = SideOurs
+		survivingStage = 2
+	case ConflictKindAddedByThem:
+		fileConflict.AddedBy = SideTheirs
+		fileConflict.SurvivingSide = SideTheirs
+		survivingStage = 3
+	case ConflictKindDeletedByUs:
+		fileConflict.DeletedBy = SideOurs
+		fileConflict.SurvivingSide = SideTheirs
+		survivingStage = 3
+	case ConflictKindDeletedByThem:
+		fileConflict.DeletedBy = SideTheirs
+		fileConflict.SurvivingSide = SideOurs
+		survivingStage = 2
+	}
+
+	if survivingStage != 0 {
+		content, err := readStage(survivingStage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s version of %s: %w",
+				fileConflict.SurvivingSide, status.FilePath, err)
+		}
+		fileConflict.SurvivingContent = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	}
+
+	return fileConflict, nil
+}
+
+// readIndexStage reads the blob stored for a path at the given index stage
+// (1 = base, 2 = ours, 3 = theirs)
+func readIndexStage(repoPath, filePath string, stage int) ([]byte, error) {
+	if err := validateGitPath(repoPath); err != nil {
+		return nil, fmt.Errorf("invalid repository path: %w", err)
+	}
+
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G204 - stage is an integer and cleanPath is validated above
+	cmd := exec.Command("git", "show", fmt.Sprintf(":%d:%s", stage, cleanPath))
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index stage %d: %w", stage, err)
+	}
+
+	return decodeBlob(output), nil
+}
+
+// GetConflictHunks returns the conflict hunks of a file. Index stages are the
+// primary source; conflict markers in the working tree are the fallback for paths
+// without usable stages (e.g. binary content or an already staged resolution).
+func GetConflictHunks(filePath, repoPath string) ([]ConflictHunk, error) {
+	hunks, err := ParseConflictHunksFromStages(filePath, repoPath)
+	if err != nil {
+		return ParseConflictHunks(filePath, repoPath)
+	}
+	return hunks, nil
+}
+
 // ParseConflictHunks extracts conflict hunks from a file's content
 func ParseConflictHunks(filePath, repoPath string) ([]ConflictHunk, error) {
 	if err := validateGitPath(repoPath); err != nil {
@@ -101,65 +289,74 @@ func ParseConflictHunks(filePath, repoPath string) ([]ConflictHunk, error) {
 		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	return parseConflictMarkers(string(content))
+	return parseConflictMarkersWithSize(string(content), GetConflictMarkerSize(repoPath, cleanPath))
 }
 
-// validateConflictFilePath validates file path for conflict parsing
+// validateConflictFilePath validates a repository-relative path for conflict
+// parsing and returns its cleaned form
 func validateConflictFilePath(filePath string) (string, error) {
-	cleanPath := filepath.Clean(filePath)
-	if strings.Contains(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") ||
-		strings.Contains(cleanPath, ";") || strings.Contains(cleanPath, "&") ||
-		strings.Contains(cleanPath, "|") || strings.Contains(cleanPath, "`") ||
-		strings.Contains(cleanPath, "$") {
-		return "", fmt.Errorf("invalid file path: %s", filePath)
-	}
-
-	// Additional validation to ensure path contains only safe characters
-	matched, err := regexp.MatchString(`^[a-zA-Z0-9._/\-]+$`, cleanPath)
+	cleanPath, err := CleanRepoPath(filePath)
 	if err != nil {
-		return "", fmt.Errorf("error validating file path format: %w", err)
+		return "", fmt.Errorf("invalid file path: %w", err)
 	}
-	if !matched {
-		return "", fmt.Errorf("file path contains unsafe characters: %s", filePath)
-	}
-
 	return cleanPath, nil
 }
 
-// readConflictFileContent reads file content for conflict parsing
+// readConflictFileContent reads the working tree file, which is where git writes
+// conflict markers, as UTF-8. Unmerged paths have no stage 0 entry to read from
+// the index.
 func readConflictFileContent(cleanPath, repoPath string) ([]byte, error) {
-	cmd := exec.Command("git", "show", ":"+cleanPath) // #nosec G204 - cleanPath validated with regex above
-	cmd.Dir = repoPath
+	fullPath, err := ResolveRepoPath(repoPath, cleanPath)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+	if err != nil {
+		return nil, err
+	}
 
-	// If the file doesn't exist in git, read from filesystem
-	content, err := cmd.Output()
+	decoded, _, err := decodeFileContent(repoPath, cleanPath, content)
 	if err != nil {
-		// Try reading from filesystem using Go's standard library instead of shell command
-		fullPath := filepath.Join(repoPath, cleanPath)
-		content, err = os.ReadFile(fullPath) // #nosec G304 - repoPath and cleanPath are validated above
-		if err != nil {
-			return nil, err
-		}
+		return content, nil
 	}
+	return decoded, nil
+}
 
-	return content, nil
+// GetFileEncoding detects the encoding of a file in the working tree
+func GetFileEncoding(repoPath, filePath string) (FileEncoding, error) {
+	fullPath, err := ResolveRepoPath(repoPath, filePath)
+	if err != nil {
+		return FileEncoding{}, fmt.Errorf("invalid file path: %w", err)
+	}
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+	if err != nil {
+		return FileEncoding{}, err
+	}
+	return DetectFileEncoding(repoPath, filePath, content), nil
 }
 
-// parseConflictMarkers parses conflict markers from file content
+// parseConflictMarkers parses conflict markers of the default size from file content
 func parseConflictMarkers(content string) ([]ConflictHunk, error) {
-	lines := strings.Split(content, "\n")
-	var hunks []ConflictHunk
+	return parseConflictMarkersWithSize(content, DefaultMarkerSize)
+}
 
-	parser := &conflictParser{
-		startMarker:  regexp.MustCompile(`^<{7}\s*(.*)$`),  // <<<<<<< HEAD or <<<<<<< branch
-		middleMarker: regexp.MustCompile(`^={7}$`),         // =======
-		baseMarker:   regexp.MustCompile(`^\|{7}\s*(.*)$`), // ||||||| base (diff3 style)
-		endMarker:    regexp.MustCompile(`^>{7}\s*(.*)$`),  // >>>>>>> branch
+// parseConflictMarkersWithSize parses conflict markers of exactly markerSize
+// characters. Longer runs (e.g. inner conflicts of a recursive merge) are content.
+// Malformed blocks are skipped and parsing resumes right after their start marker.
+func parseConflictMarkersWithSize(content string, markerSize int) ([]ConflictHunk, error) {
+	if markerSize <= 0 {
+		markerSize = DefaultMarkerSize
 	}
 
+	// A byte order mark would hide a conflict starting on the first line
+	lines := strings.Split(strings.TrimPrefix(content, utf8BOM), "\n")
+	var hunks []ConflictHunk
+
+	parser := &conflictParser{size: markerSize}
+
 	i := 0
 	for i < len(lines) {
-		if parser.startMarker.MatchString(lines[i]) {
+		if kind, _ := parser.classify(lines[i]); kind == markerStart {
 			hunk, nextIndex := parser.parseConflictHunk(lines, i)
 			if hunk != nil {
 				hunks = append(hunks, *hunk)
@@ -170,76 +367,192 @@ func parseConflictMarkers(content string) ([]ConflictHunk, error) {
 		}
 	}
 
+	assignHunkIDs(hunks, lines)
 	return hunks, nil
 }
 
-// conflictParser contains regex patterns and logic for parsing conflict markers
+// HunkID returns the stable ID of a conflict: a hash of its marker block, from
+// the start marker to the end marker, with carriage returns trimmed. Unlike line
+// numbers it does not change when lines are added or removed elsewhere in the
+// file, so a resolution can find its hunk again by content.
+func HunkID(block []string) string {
+	hash := sha256.New()
+	for _, line := range block {
+		hash.Write([]byte(strings.TrimSuffix(line, "\r")))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// assignHunkIDs sets the IDs of hunks parsed from lines. Identical blocks in a
+// file get an occurrence suffix ("-2", "-3", ...) so IDs stay unique per file.
+func assignHunkIDs(hunks []ConflictHunk, lines []string) {
+	seen := make(map[string]int)
+	for i := range hunks {
+		if hunks[i].StartLine < 1 || hunks[i].EndLine > len(lines) {
+			continue
+		}
+		id := HunkID(lines[hunks[i].StartLine-1 : hunks[i].EndLine])
+		seen[id]++
+		if seen[id] > 1 {
+			id = fmt.Sprintf("%s-%d", id, seen[id])
+		}
+		hunks[i].ID = id
+	}
+}
+
+// markerKind identifies a conflict marker line
+type markerKind int
+
+const (
+	markerNone markerKind = iota
+	markerStart
+	markerBase
+	markerSeparator
+	markerEnd
+)
+
+// conflictParser recognizes conflict markers of an exact size
 type conflictParser struct {
-	startMarker  *regexp.Regexp
-	middleMarker *regexp.Regexp
-	baseMarker   *regexp.Regexp
-	endMarker    *regexp.Regexp
+	size int
 }
 
-// parseConflictHunk parses a single conflict hunk starting at the given index
-func (p *conflictParser) parseConflictHunk(lines []string, startIndex int) (*ConflictHunk, int) {
-	hunk := &ConflictHunk{
-		StartLine: startIndex + 1, // 1-based line numbers
+// classify determines whether a line is a conflict marker and returns its label
+func (p *conflictParser) classify(line string) (markerKind, string) {
+	line = strings.TrimSuffix(line, "\r")
+	if len(line) < p.size {
+		return markerNone, ""
 	}
 
-	i := startIndex + 1 // Move past start marker
+	var kind markerKind
+	switch line[0] {
+	case '<':
+		kind = markerStart // <<<<<<< HEAD
+	case '|':
+		kind = markerBase // ||||||| base (diff3 and zdiff3 style)
+	case '=':
+		kind = markerSeparator // =======
+	case '>':
+		kind = markerEnd // >>>>>>> branch
+	default:
+		return markerNone, ""
+	}
 
-	// Collect "ours" lines
-	i = p.collectOursLines(lines, i, hunk)
+	for i := 1; i < p.size; i++ {
+		if line[i] != line[0] {
+			return markerNone, ""
+		}
+	}
 
-	// Check for base section (diff3 style)
-	if i < len(lines) && p.baseMarker.MatchString(lines[i]) {
-		i = p.collectBaseLines(lines, i+1, hunk) // Move past base marker
+	rest := line[p.size:]
+	if rest == "" {
+		return kind, ""
+	}
+	// The separator never carries a label, and a longer run is not a marker of this size
+	if kind == markerSeparator || (rest[0] != ' ' && rest[0] != '\t') {
+		return markerNone, ""
+	}
+
+	return kind, strings.TrimSpace(rest)
+}
+
+// parseConflictHunk parses a single conflict hunk starting at the given index and
+// returns the index to resume scanning from. Nested blocks using the same marker
+// size are kept as content of the enclosing side. Malformed or unterminated blocks
+// return a nil hunk and resume right after the start marker.
+func (p *conflictParser) parseConflictHunk(lines []string, startIndex int) (*ConflictHunk, int) {
+	_, oursLabel := p.classify(lines[startIndex])
+	hunk := &ConflictHunk{
+		StartLine:  startIndex + 1, // 1-based line numbers
+		OursLabel:  oursLabel,
+		MarkerSize: p.size,
 	}
 
-	// Process middle marker and collect "theirs" lines
-	if i < len(lines) && p.middleMarker.MatchString(lines[i]) {
-		i = p.collectTheirsLines(lines, i+1, hunk) // Move past middle marker
+	section := markerStart
+	depth := 0
+	for i := startIndex + 1; i < len(lines); i++ {
+		kind, label := p.classify(lines[i])
+
+		if depth > 0 || kind == markerNone {
+			// Track nested blocks so their markers are not taken for ours
+			switch kind {
+			case markerStart:
+				depth++
+			case markerEnd:
+				depth--
+			}
+			p.appendLine(hunk, section, lines[i])
+			continue
+		}
 
-		// Check for end marker
-		if i < len(lines) && p.endMarker.MatchString(lines[i]) {
+		switch kind {
+		case markerStart:
+			depth++
+			p.appendLine(hunk, section, lines[i])
+		case markerBase:
+			if section != markerStart {
+				return nil, startIndex + 1
+			}
+			section = markerBase
+			hunk.BaseLabel = label
+			hunk.BaseLines = []string{} // Non-nil marks an explicit (possibly empty) base section
+		case markerSeparator:
+			if section == markerSeparator {
+				return nil, startIndex + 1
+			}
+			section = markerSeparator
+		case markerEnd:
+			if section != markerSeparator {
+				return nil, startIndex + 1
+			}
+			hunk.TheirsLabel = label
 			hunk.EndLine = i + 1 // 1-based line numbers
 			return hunk, i + 1
 		}
 	}
 
-	// Invalid conflict hunk
-	return nil, i
+	// Unterminated conflict block
+	return nil, startIndex + 1
 }
 
-// collectOursLines collects lines until we hit middle or base marker
-func (p *conflictParser) collectOursLines(lines []string, startIndex int, hunk *ConflictHunk) int {
-	i := startIndex
-	for i < len(lines) && !p.middleMarker.MatchString(lines[i]) && !p.baseMarker.MatchString(lines[i]) {
-		hunk.OursLines = append(hunk.OursLines, lines[i])
-		i++
+// appendLine adds a line to the section of the hunk currently being collected
+func (p *conflictParser) appendLine(hunk *ConflictHunk, section markerKind, line string) {
+	switch section {
+	case markerStart:
+		hunk.OursLines = append(hunk.OursLines, line)
+	case markerBase:
+		hunk.BaseLines = append(hunk.BaseLines, line)
+	default:
+		hunk.TheirsLines = append(hunk.TheirsLines, line)
 	}
-	return i
 }
 
-// collectBaseLines collects base lines until middle marker
-func (p *conflictParser) collectBaseLines(lines []string, startIndex int, hunk *ConflictHunk) int {
-	i := startIndex
-	for i < len(lines) && !p.middleMarker.MatchString(lines[i]) {
-		hunk.BaseLines = append(hunk.BaseLines, lines[i])
-		i++
+// GetConflictMarkerSize returns the conflict marker size configured for a path
+// through the conflict-marker-size gitattribute, or DefaultMarkerSize
+func GetConflictMarkerSize(repoPath, filePath string) int {
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return DefaultMarkerSize
 	}
-	return i
-}
 
-// collectTheirsLines collects "theirs" lines until end marker
-func (p *conflictParser) collectTheirsLines(lines []string, startIndex int, hunk *ConflictHunk) int {
-	i := startIndex
-	for i < len(lines) && !p.endMarker.MatchString(lines[i]) {
-		hunk.TheirsLines = append(hunk.TheirsLines, lines[i])
-		i++
+	// #nosec G204 - cleanPath is validated above
+	cmd := exec.Command("git", "check-attr", "conflict-marker-size", "--", cleanPath)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return DefaultMarkerSize
+	}
+
+	// Output format: "<path>: conflict-marker-size: <value>"
+	line := strings.TrimSpace(string(output))
+	value := line[strings.LastIndex(line, ": ")+2:]
+	size, err := strconv.Atoi(value)
+	if err != nil || size <= 0 {
+		return DefaultMarkerSize
 	}
-	return i
+
+	return size
 }
 
 // ExtractFileContext extracts surrounding context lines for AI processing
@@ -249,16 +562,19 @@ func ExtractFileContext(filePath, repoPath string, contextLines int) ([]string,
 		return nil, fmt.Errorf("invalid repository path: %w", err)
 	}
 
-	// Validate file path for security
-	if err := validateGitPath(filePath); err != nil {
+	// Only read files contained in the repository
+	fullPath, err := ResolveRepoPath(repoPath, filePath)
+	if err != nil {
 		return nil, fmt.Errorf("invalid file path: %w", err)
 	}
 
-	fullPath := filepath.Join(repoPath, filePath)
-	content, err := os.ReadFile(fullPath) // #nosec G304 - repoPath and filePath are validated above
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
+	if decoded, _, err := decodeFileContent(repoPath, filePath, content); err == nil {
+		content = decoded
+	}
 
 	lines := strings.Split(string(content), "\n")
 
@@ -270,26 +586,12 @@ func ExtractFileContext(filePath, repoPath string, contextLines int) ([]string,
 
 // IsInMergeState checks if the repository is currently in a merge state
 func IsInMergeState(repoPath string) (bool, error) {
-	cmd := exec.Command("git", "status", "--porcelain=v1")
-	cmd.Dir = repoPath
-
-	output, err := cmd.Output()
+	conflicts, err := DetectConflicts(repoPath)
 	if err != nil {
 		return false, fmt.Errorf("failed to check git status: %w", err)
 	}
 
-	// Check for any conflict indicators
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if len(line) >= 2 {
-			status := line[:2]
-			if isConflictStatus(status) {
-				return true, nil
-			}
-		}
-	}
-
-	return false, nil
+	return len(conflicts) > 0, nil
 }
 
 // GetConflictReport generates a comprehensive conflict report
@@ -305,10 +607,21 @@ func GetConflictReport(repoPath string) (*ConflictReport, error) {
 	}
 
 	for _, conflict := range conflicts {
-		hunks, err := ParseConflictHunks(conflict.FilePath, repoPath)
+		// A file that cannot be analysed fully is still reported, so that it is
+		// never mistaken for one without conflicts
+		var problems []string
+		fileConflict, err := BuildFileConflict(repoPath, conflict)
 		if err != nil {
-			// Log error but continue with other files
-			continue
+			problems = append(problems, fmt.Sprintf("failed to classify the conflict: %v", err))
+		}
+
+		hunks, err := GetConflictHunks(conflict.FilePath, repoPath)
+		if err != nil {
+			// File-level conflicts may have no working tree file at all
+			if fileConflict == nil {
+				problems = append(problems, fmt.Sprintf("failed to read the conflict hunks: %v", err))
+			}
+			hunks = nil
 		}
 
 		context, err := ExtractFileContext(conflict.FilePath, repoPath, 5)
@@ -318,9 +631,18 @@ func GetConflictReport(repoPath string) (*ConflictReport, error) {
 		}
 
 		conflictFile := ConflictFile{
-			Path:    conflict.FilePath,
-			Hunks:   hunks,
-			Context: context,
+			Path:         conflict.FilePath,
+			Hunks:        hunks,
+			Context:      context,
+			FileConflict: fileConflict,
+			Error:        strings.Join(problems, "; "),
+		}
+		if encoding, err := GetFileEncoding(repoPath, conflict.FilePath); err == nil {
+			conflictFile.Encoding = &encoding
+		}
+
+		if fileConflict != nil {
+			report.TotalFileConflicts++
 		}
 
 		report.ConflictedFiles = append(report.ConflictedFiles, conflictFile)
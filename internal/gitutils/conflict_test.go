@@ -0,0 +1,458 @@
+package gitutils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGitCommand builds a git command with a fixed identity for test repositories
+func testGitCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	return cmd
+}
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	output, err := testGitCommand(dir, args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+	}
+	return string(output)
+}
+
+// writeRepoFile writes a file relative to the repository root
+func writeRepoFile(t *testing.T, repoPath, filePath, content string) {
+	t.Helper()
+
+	fullPath := filepath.Join(repoPath, filePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", filePath, err)
+	}
+}
+
+// newTestRepo creates a repository with a single commit containing files
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q", "-b", "main")
+	for filePath, content := range files {
+		writeRepoFile(t, repoPath, filePath, content)
+	}
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "base")
+	return repoPath
+}
+
+// mergeExpectingConflict merges branch into the current branch and expects it to stop
+func mergeExpectingConflict(t *testing.T, repoPath, branch string) {
+	t.Helper()
+
+	if output, err := testGitCommand(repoPath, "merge", "-q", "--no-edit", branch).CombinedOutput(); err == nil {
+		t.Fatalf("expected merge of %s to conflict, output: %s", branch, output)
+	}
+}
+
+func TestGetConflictReport_FileLevelConflicts(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		"modified.txt": "line 1\nline 2\n",
+		"removed.txt":  "keep me\n",
+	})
+
+	// Theirs modifies modified.txt and deletes removed.txt, ours does the opposite
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "modified.txt", "line 1\nfeature change\n")
+	runGit(t, repoPath, "rm", "-q", "removed.txt")
+	writeRepoFile(t, repoPath, "added.txt", "feature version\n")
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "feature")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	runGit(t, repoPath, "rm", "-q", "modified.txt")
+	writeRepoFile(t, repoPath, "removed.txt", "keep me\nmain change\n")
+	writeRepoFile(t, repoPath, "added.txt", "main version\n")
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "main")
+
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	report, err := GetConflictReport(repoPath)
+	if err != nil {
+		t.Fatalf("GetConflictReport() error = %v", err)
+	}
+
+	if report.TotalFileConflicts != 3 {
+		t.Errorf("TotalFileConflicts = %d, expected 3", report.TotalFileConflicts)
+	}
+
+	files := make(map[string]ConflictFile)
+	for _, file := range report.ConflictedFiles {
+		files[file.Path] = file
+	}
+
+	tests := []struct {
+		path          string
+		kind          ConflictKind
+		deletedBy     string
+		addedBy       string
+		survivingSide string
+		surviving     []string
+		expectHunks   bool
+	}{
+		{"modified.txt", ConflictKindDeletedByUs, SideOurs, "", SideTheirs, []string{"line 1", "feature change"}, false},
+		{"removed.txt", ConflictKindDeletedByThem, SideTheirs, "", SideOurs, []string{"keep me", "main change"}, false},
+		{"added.txt", ConflictKindBothAdded, "", SideBoth, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			file, ok := files[tt.path]
+			if !ok {
+				t.Fatalf("%s missing from conflict report", tt.path)
+			}
+			if file.FileConflict == nil {
+				t.Fatalf("%s has no file-level conflict", tt.path)
+			}
+
+			fc := file.FileConflict
+			if fc.Kind != tt.kind || fc.DeletedBy != tt.deletedBy || fc.AddedBy != tt.addedBy ||
+				fc.SurvivingSide != tt.survivingSide {
+				t.Errorf("unexpected file conflict %+v", fc)
+			}
+			if strings.Join(fc.SurvivingContent, "\n") != strings.Join(tt.surviving, "\n") {
+				t.Errorf("SurvivingContent = %q, expected %q", fc.SurvivingContent, tt.surviving)
+			}
+			if tt.expectHunks != (len(file.Hunks) > 0) {
+				t.Errorf("hunks = %d, expectHunks = %v", len(file.Hunks), tt.expectHunks)
+			}
+		})
+	}
+}
+
+func TestGetConflictReport_KeepsUnreadableFiles(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{"removed.txt": "keep me\n"})
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	runGit(t, repoPath, "rm", "-q", "removed.txt")
+	runGit(t, repoPath, "commit", "-q", "-m", "feature")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "removed.txt", "only on main\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	// Losing the surviving version makes the conflict impossible to classify
+	object := strings.TrimSpace(runGit(t, repoPath, "rev-parse", ":2:removed.txt"))
+	if err := os.Remove(filepath.Join(repoPath, ".git", "objects", object[:2], object[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := GetConflictReport(repoPath)
+	if err != nil {
+		t.Fatalf("GetConflictReport() error = %v", err)
+	}
+	if len(report.ConflictedFiles) != 1 || report.ConflictedFiles[0].Path != "removed.txt" {
+		t.Fatalf("unreadable file was dropped from the report: %+v", report.ConflictedFiles)
+	}
+	if report.ConflictedFiles[0].Error == "" {
+		t.Error("expected the report to say why the file could not be classified")
+	}
+}
+
+func TestApplyFileResolutions(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "keep.txt", "existing\n")
+	writeRepoFile(t, repoPath, "delete.txt", "obsolete\n")
+
+	resolutions := []FileResolution{
+		{FilePath: "keep.txt", Action: FileActionKeep, Confidence: 0.9},
+		{FilePath: "delete.txt", Action: FileActionDelete, Confidence: 0.9},
+		{FilePath: "dir/merged.txt", Action: FileActionMerge, ResolvedLines: []string{"a", "b"}, Confidence: 0.8},
+		{FilePath: "missing.txt", Action: FileActionKeep, Confidence: 0.9},
+		{FilePath: "bad.txt", Action: FileActionMerge, ResolvedLines: []string{"<<<<<<< HEAD"}, Confidence: 0.8},
+	}
+
+	result, err := ApplyFileResolutions(repoPath, resolutions)
+	if err != nil {
+		t.Fatalf("ApplyFileResolutions() error = %v", err)
+	}
+
+	if result.AppliedCount != 3 || result.FailedCount != 2 {
+		t.Errorf("applied = %d, failed = %d; expected 3 and 2", result.AppliedCount, result.FailedCount)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "delete.txt")); !os.IsNotExist(err) {
+		t.Errorf("delete.txt should have been removed")
+	}
+
+	content, err := os.ReadFile(filepath.Join(repoPath, "dir", "merged.txt"))
+	if err != nil {
+		t.Fatalf("failed to read merged file: %v", err)
+	}
+	if string(content) != "a\nb\n" {
+		t.Errorf("merged content = %q", content)
+	}
+}
+
+func TestGetConflictReport_BaseLinesFromStages(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		"config.txt": "header\nvalue = 1\nfooter\n",
+	})
+	// Use the default marker style so the working tree has no base section
+	runGit(t, repoPath, "config", "merge.conflictStyle", "merge")
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "config.txt", "header\nvalue = 3\nfooter\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "feature")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "config.txt", "header\nvalue = 2\nfooter\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	report, err := GetConflictReport(repoPath)
+	if err != nil {
+		t.Fatalf("GetConflictReport() error = %v", err)
+	}
+	if len(report.ConflictedFiles) != 1 || len(report.ConflictedFiles[0].Hunks) != 1 {
+		t.Fatalf("expected one file with one hunk, got %+v", report.ConflictedFiles)
+	}
+
+	hunk := report.ConflictedFiles[0].Hunks[0]
+	if strings.Join(hunk.BaseLines, "\n") != "value = 1" {
+		t.Errorf("BaseLines = %q, expected base version", hunk.BaseLines)
+	}
+	if hunk.StartLine != 2 || hunk.EndLine != 6 {
+		t.Errorf("hunk spans lines %d-%d, expected 2-6", hunk.StartLine, hunk.EndLine)
+	}
+
+	// Without markers in the working tree the conflict was resolved by hand
+	writeRepoFile(t, repoPath, "config.txt", "header\nvalue = ?\nfooter\n")
+
+	hunks, err := ParseConflictHunksFromStages("config.txt", repoPath)
+	if err != nil {
+		t.Fatalf("ParseConflictHunksFromStages() error = %v", err)
+	}
+	if len(hunks) != 0 {
+		t.Errorf("expected no hunks for a file without markers, got %+v", hunks)
+	}
+}
+
+func TestCheckoutStage(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		"lock.txt": "a\r\nb",
+	})
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "lock.txt", "a\r\nc")
+	runGit(t, repoPath, "commit", "-q", "-am", "feature")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "lock.txt", "a\r\nd")
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	// CRLF and the missing final newline of the side survive
+	if err := CheckoutStage(repoPath, "lock.txt", StageOurs); err != nil {
+		t.Fatalf("CheckoutStage() error = %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "lock.txt")); string(content) != "a\r\nd" {
+		t.Errorf("content = %q", content)
+	}
+	if unmerged := runGit(t, repoPath, "ls-files", "-u"); strings.Count(unmerged, "\n") != 3 {
+		t.Errorf("index changed:\n%s", unmerged)
+	}
+}
+
+func TestGetConflictReport_ArbitraryPaths(t *testing.T) {
+	paths := []string{"docs/Guía de uso.md", "src/My Component.tsx", "notes [draft] $1;x.txt", "*.txt"}
+
+	files := make(map[string]string)
+	for _, path := range paths {
+		files[path] = "shared\nbase\n"
+	}
+	repoPath := newTestRepo(t, files)
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	for _, path := range paths {
+		writeRepoFile(t, repoPath, path, "shared\nfeature\n")
+	}
+	runGit(t, repoPath, "commit", "-q", "-am", "feature")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	for _, path := range paths {
+		writeRepoFile(t, repoPath, path, "shared\nmain\n")
+	}
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	report, err := GetConflictReport(repoPath)
+	if err != nil {
+		t.Fatalf("GetConflictReport() error = %v", err)
+	}
+	if len(report.ConflictedFiles) != len(paths) {
+		t.Fatalf("expected %d conflicted files, got %+v", len(paths), report.ConflictedFiles)
+	}
+
+	for _, file := range report.ConflictedFiles {
+		if len(file.Hunks) != 1 {
+			t.Errorf("%s: expected one hunk, got %d", file.Path, len(file.Hunks))
+			continue
+		}
+		// "*.txt" is read as a literal path, not as a pathspec
+		if strings.Join(file.Hunks[0].BaseLines, "") != "base" {
+			t.Errorf("%s: BaseLines = %q, expected base version", file.Path, file.Hunks[0].BaseLines)
+		}
+	}
+
+	result, err := ApplyFileResolutions(repoPath, []FileResolution{
+		{FilePath: "docs/Guía de uso.md", Action: FileActionMerge, ResolvedLines: []string{"shared", "resolved"}, Confidence: 0.9},
+		{FilePath: "../outside.md", Action: FileActionMerge, ResolvedLines: []string{"nope"}, Confidence: 0.9},
+	})
+	if err != nil {
+		t.Fatalf("ApplyFileResolutions() error = %v", err)
+	}
+	if result.AppliedCount != 1 || result.FailedCount != 1 {
+		t.Errorf("expected one applied and one rejected resolution, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(repoPath), "outside.md")); err == nil {
+		t.Error("resolution outside the repository was written")
+	}
+}
+
+func TestParseConflictMarkersWithSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		markerSize int
+		expected   []ConflictHunk
+	}{
+		{
+			name:       "labels are preserved",
+			content:    "a\n<<<<<<< HEAD\nours\n||||||| 1a2b3c4\nbase\n=======\ntheirs\n>>>>>>> feature/login\nb",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 2, EndLine: 8,
+				OursLines: []string{"ours"}, BaseLines: []string{"base"}, TheirsLines: []string{"theirs"},
+				OursLabel: "HEAD", BaseLabel: "1a2b3c4", TheirsLabel: "feature/login",
+			}},
+		},
+		{
+			name:       "zdiff3 with empty base section",
+			content:    "<<<<<<< HEAD\nours\n||||||| base\n=======\ntheirs\n>>>>>>> main\n",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 1, EndLine: 6,
+				OursLines: []string{"ours"}, BaseLines: []string{}, TheirsLines: []string{"theirs"},
+				OursLabel: "HEAD", BaseLabel: "base", TheirsLabel: "main",
+			}},
+		},
+		{
+			name:       "custom marker size ignores shorter markers",
+			content:    "<<<<<<<<< ours\n<<<<<<< not a marker\n=======\n=========\ntheirs\n>>>>>>>>> theirs",
+			markerSize: 9,
+			expected: []ConflictHunk{{
+				StartLine: 1, EndLine: 6,
+				OursLines: []string{"<<<<<<< not a marker", "======="}, TheirsLines: []string{"theirs"},
+				OursLabel: "ours", TheirsLabel: "theirs",
+			}},
+		},
+		{
+			name:       "longer runs are content",
+			content:    "<<<<<<< HEAD\n<<<<<<<<< inner\nx\n=========\ny\n>>>>>>>>> inner\n=======\nz\n>>>>>>> topic",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 1, EndLine: 9,
+				OursLines:   []string{"<<<<<<<<< inner", "x", "=========", "y", ">>>>>>>>> inner"},
+				TheirsLines: []string{"z"},
+				OursLabel:   "HEAD", TheirsLabel: "topic",
+			}},
+		},
+		{
+			name:       "nested block of the same size",
+			content:    "<<<<<<< HEAD\n<<<<<<< inner\nx\n=======\ny\n>>>>>>> inner\n=======\nz\n>>>>>>> topic",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 1, EndLine: 9,
+				OursLines:   []string{"<<<<<<< inner", "x", "=======", "y", ">>>>>>> inner"},
+				TheirsLines: []string{"z"},
+				OursLabel:   "HEAD", TheirsLabel: "topic",
+			}},
+		},
+		{
+			name:       "unterminated block does not hide later hunks",
+			content:    "<<<<<<< stray\nx\n<<<<<<< HEAD\na\n=======\nb\n>>>>>>> topic",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 3, EndLine: 7,
+				OursLines: []string{"a"}, TheirsLines: []string{"b"},
+				OursLabel: "HEAD", TheirsLabel: "topic",
+			}},
+		},
+		{
+			name:       "block without separator is skipped",
+			content:    "<<<<<<< HEAD\na\n>>>>>>> topic\n<<<<<<< HEAD\nc\n=======\nd\n>>>>>>> topic",
+			markerSize: 7,
+			expected: []ConflictHunk{{
+				StartLine: 4, EndLine: 8,
+				OursLines: []string{"c"}, TheirsLines: []string{"d"},
+				OursLabel: "HEAD", TheirsLabel: "topic",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks, err := parseConflictMarkersWithSize(tt.content, tt.markerSize)
+			if err != nil {
+				t.Fatalf("parseConflictMarkersWithSize() error = %v", err)
+			}
+			if len(hunks) != len(tt.expected) {
+				t.Fatalf("got %d hunks, expected %d: %+v", len(hunks), len(tt.expected), hunks)
+			}
+
+			lines := strings.Split(tt.content, "\n")
+			for i, expected := range tt.expected {
+				expected.MarkerSize = tt.markerSize
+				expected.ID = HunkID(lines[expected.StartLine-1 : expected.EndLine])
+				if !reflect.DeepEqual(hunks[i], expected) {
+					t.Errorf("hunk %d = %+v, expected %+v", i, hunks[i], expected)
+				}
+			}
+		})
+	}
+}
+
+func TestGetConflictMarkerSize(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		".gitattributes": "*.md conflict-marker-size=12\n",
+	})
+
+	if size := GetConflictMarkerSize(repoPath, "docs/readme.md"); size != 12 {
+		t.Errorf("GetConflictMarkerSize(*.md) = %d, expected 12", size)
+	}
+	if size := GetConflictMarkerSize(repoPath, "main.go"); size != DefaultMarkerSize {
+		t.Errorf("GetConflictMarkerSize(main.go) = %d, expected %d", size, DefaultMarkerSize)
+	}
+}
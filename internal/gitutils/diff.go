@@ -3,7 +3,6 @@ package gitutils
 import (
 	"fmt"
 	"os/exec"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -57,8 +56,8 @@ func validateAllFilePaths(filePaths []string) ([]string, error) {
 
 // executeGitDiff executes git diff command with validated paths
 func executeGitDiff(repoPath string, filePaths []string) ([]DiffFile, error) {
-	cmdArgs := make([]string, 0, len(filePaths)+3)
-	cmdArgs = append(cmdArgs, "diff", "--no-index", "--no-prefix")
+	cmdArgs := make([]string, 0, len(filePaths)+4)
+	cmdArgs = append(cmdArgs, "diff", "--no-index", "--no-prefix", "--")
 	cmdArgs = append(cmdArgs, filePaths...)
 	cmd := exec.Command("git", cmdArgs...) // #nosec G204 - filePaths are validated above
 	cmd.Dir = repoPath
@@ -116,33 +115,19 @@ func getMergeBase(repoPath string) (string, error) {
 	return base, nil
 }
 
-// validateFilePath validates and sanitizes file path to prevent command injection
+// validateFilePath validates a repository-relative path passed to git diff
 func validateFilePath(filePath string) (string, error) {
-	cleanPath := filepath.Clean(filePath)
-
-	if strings.Contains(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") ||
-		strings.Contains(cleanPath, ";") || strings.Contains(cleanPath, "&") ||
-		strings.Contains(cleanPath, "|") || strings.Contains(cleanPath, "`") ||
-		strings.Contains(cleanPath, "$") {
-		return "", fmt.Errorf("invalid file path: %s", filePath)
-	}
-
-	// Additional validation to ensure path contains only safe characters
-	matched, err := regexp.MatchString(`^[a-zA-Z0-9._/\-]+$`, cleanPath)
+	cleanPath, err := CleanRepoPath(filePath)
 	if err != nil {
-		return "", fmt.Errorf("error validating file path format: %w", err)
+		return "", fmt.Errorf("invalid file path: %w", err)
 	}
-	if !matched {
-		return "", fmt.Errorf("file path contains unsafe characters: %s", filePath)
-	}
-
 	return cleanPath, nil
 }
 
 // getDiffFromBase gets diff from base commit to working tree
 func getDiffFromBase(repoPath, base, cleanPath string) (*DiffFile, error) {
 	// #nosec G204 - base and cleanPath are validated above
-	cmd := exec.Command("git", "diff", "--no-prefix", base, "--", cleanPath)
+	cmd := exec.Command("git", "--literal-pathspecs", "diff", "--no-prefix", base, "--", cleanPath)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -333,25 +318,12 @@ func GetFileAtRevision(repoPath, filePath, revision string) ([]string, error) {
 		return nil, fmt.Errorf("invalid revision format: %s", revision)
 	}
 
-	// Validate and sanitize file path to prevent command injection
-	cleanPath := filepath.Clean(filePath)
-	if strings.Contains(cleanPath, "..") || strings.HasPrefix(cleanPath, "/") ||
-		strings.Contains(cleanPath, ";") || strings.Contains(cleanPath, "&") ||
-		strings.Contains(cleanPath, "|") || strings.Contains(cleanPath, "`") ||
-		strings.Contains(cleanPath, "$") {
-		return nil, fmt.Errorf("invalid file path: %s", filePath)
-	}
-
-	// Additional validation to ensure path contains only safe characters
-	matched, err = regexp.MatchString(`^[a-zA-Z0-9._/\-]+$`, cleanPath)
+	cleanPath, err := validateFilePath(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("error validating file path format: %w", err)
-	}
-	if !matched {
-		return nil, fmt.Errorf("file path contains unsafe characters: %s", filePath)
+		return nil, err
 	}
 
-	// #nosec G204 - revision and cleanPath validated with regex above
+	// #nosec G204 - revision validated with regex above, cleanPath is contained in the repository
 	cmd := exec.Command("git", "show", revision+":"+cleanPath)
 	cmd.Dir = repoPath
 
@@ -363,38 +335,19 @@ func GetFileAtRevision(repoPath, filePath, revision string) ([]string, error) {
 	return strings.Split(string(output), "\n"), nil
 }
 
-// GetConflictVersions retrieves all versions of a conflicted file
+// GetConflictVersions retrieves all versions of a conflicted file from the index
+// stages. Base is nil when the file has no common ancestor version (add/add).
 func GetConflictVersions(repoPath, filePath string) (ours, theirs, base []string, err error) {
-	// Get our version (HEAD)
-	ours, err = GetFileAtRevision(repoPath, filePath, "HEAD")
+	versions, err := GetStageVersions(repoPath, filePath)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to get our version: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to read conflict versions: %w", err)
 	}
 
-	// Get their version (MERGE_HEAD)
-	theirs, err = GetFileAtRevision(repoPath, filePath, "MERGE_HEAD")
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to get their version: %w", err)
-	}
-
-	// Get base version (merge base)
-	cmd := exec.Command("git", "merge-base", "HEAD", "MERGE_HEAD")
-	cmd.Dir = repoPath
-
-	baseOutput, err := cmd.Output()
-	if err != nil {
-		// Base might not exist for some conflicts, that's ok
-		return ours, theirs, nil, nil
-	}
-
-	baseRevision := strings.TrimSpace(string(baseOutput))
-	base, err = GetFileAtRevision(repoPath, filePath, baseRevision)
-	if err != nil {
-		// Base might not exist for this file, that's ok
-		return ours, theirs, nil, nil
+	if versions.HasBase {
+		base = versions.BaseLines
 	}
 
-	return ours, theirs, base, nil
+	return versions.OursLines, versions.TheirsLines, base, nil
 }
 
 // IsTextFile checks if a file is likely to be a text file (not binary)
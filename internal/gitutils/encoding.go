@@ -0,0 +1,237 @@
+package gitutils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings detected without help from gitattributes
+const (
+	EncodingUTF8    = "UTF-8"
+	EncodingUTF16LE = "UTF-16LE"
+	EncodingUTF16BE = "UTF-16BE"
+	EncodingLatin1  = "ISO-8859-1"
+)
+
+// How an encoding was detected
+const (
+	EncodingSourceBOM       = "bom"       // Byte order mark
+	EncodingSourceAttribute = "attribute" // working-tree-encoding in gitattributes
+	EncodingSourceHeuristic = "heuristic" // Content analysis
+)
+
+// FileEncoding is the character encoding of a file in the working tree
+type FileEncoding struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// IsUTF8 reports whether the file needs no transcoding
+func (e FileEncoding) IsUTF8() bool {
+	return e.Name == "" || strings.EqualFold(e.Name, EncodingUTF8)
+}
+
+// String returns the encoding name
+func (e FileEncoding) String() string {
+	if e.Name == "" {
+		return EncodingUTF8
+	}
+	return e.Name
+}
+
+// DetectEncoding determines the encoding of content. A byte order mark wins,
+// then the working-tree-encoding attribute (empty when unset), then heuristics:
+// valid UTF-8 is UTF-8, text with every other byte zero is UTF-16, and anything
+// else without NUL bytes is read as ISO-8859-1, which maps every byte. Content
+// with other NUL bytes is binary and reported as UTF-8 so it is left alone.
+func DetectEncoding(content []byte, attribute string) FileEncoding {
+	switch {
+	case bytes.HasPrefix(content, []byte(utf8BOM)):
+		return FileEncoding{Name: EncodingUTF8, Source: EncodingSourceBOM}
+	case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+		return FileEncoding{Name: EncodingUTF16LE, Source: EncodingSourceBOM}
+	case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+		return FileEncoding{Name: EncodingUTF16BE, Source: EncodingSourceBOM}
+	}
+
+	if name := normalizeEncodingName(attribute); name != "" {
+		if _, err := lookupEncoding(name); err == nil {
+			return FileEncoding{Name: name, Source: EncodingSourceAttribute}
+		}
+	}
+
+	if utf8.Valid(content) && bytes.IndexByte(content, 0) < 0 {
+		return FileEncoding{Name: EncodingUTF8, Source: EncodingSourceHeuristic}
+	}
+	if name := detectUTF16(content); name != "" {
+		return FileEncoding{Name: name, Source: EncodingSourceHeuristic}
+	}
+	if bytes.IndexByte(content, 0) < 0 {
+		return FileEncoding{Name: EncodingLatin1, Source: EncodingSourceHeuristic}
+	}
+	return FileEncoding{Name: EncodingUTF8, Source: EncodingSourceHeuristic}
+}
+
+// DetectFileEncoding determines the encoding of a file of a repository, taking
+// its working-tree-encoding attribute into account
+func DetectFileEncoding(repoPath, filePath string, content []byte) FileEncoding {
+	return DetectEncoding(content, GetWorkingTreeEncoding(repoPath, filePath))
+}
+
+// GetWorkingTreeEncoding returns the working-tree-encoding attribute of a path,
+// or an empty string when it is not set
+func GetWorkingTreeEncoding(repoPath, filePath string) string {
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return ""
+	}
+
+	// #nosec G204 - cleanPath is validated above
+	cmd := exec.Command("git", "check-attr", "working-tree-encoding", "--", cleanPath)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	// Output format: "<path>: working-tree-encoding: <value>"
+	line := strings.TrimSpace(string(output))
+	value := line[strings.LastIndex(line, ": ")+2:]
+	switch value {
+	case "unspecified", "unset", "set":
+		return ""
+	}
+	return value
+}
+
+// DecodeToUTF8 transcodes content in the given encoding to UTF-8. A byte order
+// mark is kept as U+FEFF so EncodeFromUTF8 writes it back.
+func DecodeToUTF8(content []byte, enc FileEncoding) ([]byte, error) {
+	if enc.IsUTF8() {
+		return content, nil
+	}
+
+	textEncoding, err := lookupEncoding(enc.Name)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := textEncoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s content: %w", enc.Name, err)
+	}
+	return decoded, nil
+}
+
+// EncodeFromUTF8 transcodes UTF-8 text back to the given encoding. It fails when
+// the text has characters the encoding cannot represent.
+func EncodeFromUTF8(text []byte, enc FileEncoding) ([]byte, error) {
+	if enc.IsUTF8() {
+		return text, nil
+	}
+
+	textEncoding, err := lookupEncoding(enc.Name)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := textEncoding.NewEncoder().Bytes(text)
+	if err != nil {
+		return nil, fmt.Errorf("content cannot be encoded as %s: %w", enc.Name, err)
+	}
+	return encoded, nil
+}
+
+// decodeFileContent reads content of a repository file as UTF-8
+func decodeFileContent(repoPath, filePath string, content []byte) ([]byte, FileEncoding, error) {
+	enc := DetectFileEncoding(repoPath, filePath, content)
+	decoded, err := DecodeToUTF8(content, enc)
+	return decoded, enc, err
+}
+
+// decodeBlob reads content from the object database as UTF-8. Files with a
+// working-tree-encoding attribute are stored as UTF-8 already, so only the
+// content itself is looked at.
+func decodeBlob(content []byte) []byte {
+	decoded, err := DecodeToUTF8(content, DetectEncoding(content, ""))
+	if err != nil {
+		return content
+	}
+	return decoded
+}
+
+// normalizeEncodingName maps the spellings of common encodings used in
+// gitattributes to their canonical names
+func normalizeEncodingName(name string) string {
+	name = strings.TrimSpace(name)
+	switch strings.ToUpper(strings.ReplaceAll(name, "_", "-")) {
+	case "":
+		return ""
+	case "UTF-8", "UTF8":
+		return EncodingUTF8
+	case "UTF-16LE", "UTF16LE":
+		return EncodingUTF16LE
+	case "UTF-16BE", "UTF16BE", "UTF-16", "UTF16":
+		// Without a byte order mark UTF-16 is big endian
+		return EncodingUTF16BE
+	case "LATIN1", "LATIN-1", "ISO-8859-1", "ISO8859-1":
+		return EncodingLatin1
+	}
+	return name
+}
+
+// lookupEncoding returns the x/text encoding of a canonical encoding name
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	switch name {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	case EncodingLatin1:
+		return charmap.ISO8859_1, nil
+	}
+
+	textEncoding, err := ianaindex.IANA.Encoding(name)
+	if err != nil || textEncoding == nil {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return textEncoding, nil
+}
+
+// detectUTF16 recognizes UTF-16 text without a byte order mark by the zero high
+// bytes of ASCII characters, which land on every other byte
+func detectUTF16(content []byte) string {
+	sample := content
+	if len(sample) > 4096 {
+		sample = sample[:4096]
+	}
+	if len(sample) < 2 || len(sample)%2 != 0 {
+		return ""
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros*10 >= pairs*9 && evenZeros == 0:
+		return EncodingUTF16LE
+	case evenZeros*10 >= pairs*9 && oddZeros == 0:
+		return EncodingUTF16BE
+	}
+	return ""
+}
@@ -0,0 +1,135 @@
+package gitutils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   []byte
+		attribute string
+		expected  FileEncoding
+	}{
+		{"plain utf-8", []byte("héllo\n"), "", FileEncoding{EncodingUTF8, EncodingSourceHeuristic}},
+		{"utf-8 bom", []byte("\ufeffhello\n"), "", FileEncoding{EncodingUTF8, EncodingSourceBOM}},
+		{"utf-16le bom", []byte{0xFF, 0xFE, 'h', 0, '\n', 0}, "", FileEncoding{EncodingUTF16LE, EncodingSourceBOM}},
+		{"utf-16be bom", []byte{0xFE, 0xFF, 0, 'h', 0, '\n'}, "", FileEncoding{EncodingUTF16BE, EncodingSourceBOM}},
+		{"attribute", []byte("hello\n"), "latin1", FileEncoding{EncodingLatin1, EncodingSourceAttribute}},
+		{"unknown attribute", []byte("hello\n"), "no-such-encoding", FileEncoding{EncodingUTF8, EncodingSourceHeuristic}},
+		{"utf-16le without bom", []byte{'h', 0, 'i', 0, '\n', 0}, "", FileEncoding{EncodingUTF16LE, EncodingSourceHeuristic}},
+		{"latin-1", []byte("caf\xe9\n"), "", FileEncoding{EncodingLatin1, EncodingSourceHeuristic}},
+		{"binary", []byte("\x00\x01\x02\xff\x00"), "", FileEncoding{EncodingUTF8, EncodingSourceHeuristic}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectEncoding(tt.content, tt.attribute); got != tt.expected {
+				t.Errorf("DetectEncoding() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodingRoundTrip(t *testing.T) {
+	latin1 := FileEncoding{Name: EncodingLatin1}
+	decoded, err := DecodeToUTF8([]byte("caf\xe9\n"), latin1)
+	if err != nil || string(decoded) != "café\n" {
+		t.Fatalf("DecodeToUTF8() = %q, %v", decoded, err)
+	}
+	encoded, err := EncodeFromUTF8(decoded, latin1)
+	if err != nil || string(encoded) != "caf\xe9\n" {
+		t.Errorf("EncodeFromUTF8() = %q, %v", encoded, err)
+	}
+
+	if _, err := EncodeFromUTF8([]byte("日本\n"), latin1); err == nil {
+		t.Error("expected characters outside ISO-8859-1 to be rejected")
+	}
+}
+
+func TestGetWorkingTreeEncoding(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		".gitattributes": "*.txt working-tree-encoding=ISO-8859-1\n",
+	})
+
+	if got := GetWorkingTreeEncoding(repoPath, "notes.txt"); got != "ISO-8859-1" {
+		t.Errorf("GetWorkingTreeEncoding(notes.txt) = %q", got)
+	}
+	if got := GetWorkingTreeEncoding(repoPath, "main.go"); got != "" {
+		t.Errorf("GetWorkingTreeEncoding(main.go) = %q, expected unset", got)
+	}
+}
+
+func TestApplyResolutions_KeepsFileEncoding(t *testing.T) {
+	utf16 := func(text string) []byte {
+		encoded, err := EncodeFromUTF8([]byte(text), FileEncoding{Name: EncodingUTF16LE})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected []byte
+	}{
+		{
+			name:     "latin-1",
+			content:  []byte("<<<<<<< HEAD\ncaf\xe9\n=======\nth\xe9\n>>>>>>> topic\n"),
+			expected: []byte("caf\xe9 th\xe9\n"),
+		},
+		{
+			name:     "utf-16le with bom",
+			content:  utf16("\ufeff<<<<<<< HEAD\r\ncafé\r\n=======\r\nthé\r\n>>>>>>> topic\r\n"),
+			expected: utf16("\ufeffcafé thé\r\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			writeRepoFile(t, repoPath, "menu.txt", string(tt.content))
+
+			// Hunks are read as UTF-8
+			hunks, err := ParseConflictHunks("menu.txt", repoPath)
+			if err != nil || len(hunks) != 1 || strings.TrimSuffix(hunks[0].OursLines[0], "\r") != "café" {
+				t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+			}
+
+			result, err := ApplyResolutions(repoPath, []ConflictResolution{{
+				FilePath:      "menu.txt",
+				HunkID:        hunks[0].ID,
+				ResolvedLines: []string{"café thé"},
+				Confidence:    0.9,
+			}})
+			if err != nil || !result.Success {
+				t.Fatalf("ApplyResolutions() = %+v, %v", result, err)
+			}
+			if content := readTestFile(t, repoPath, "menu.txt"); !bytes.Equal([]byte(content), tt.expected) {
+				t.Errorf("menu.txt = %q, expected %q", content, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyResolutions_RefusesUnencodableResolution(t *testing.T) {
+	repoPath := t.TempDir()
+	content := "<<<<<<< HEAD\ncaf\xe9\n=======\nth\xe9\n>>>>>>> topic\n"
+	writeRepoFile(t, repoPath, "menu.txt", content)
+
+	result, err := ApplyResolutions(repoPath, []ConflictResolution{{
+		FilePath: "menu.txt", StartLine: 1, EndLine: 5, ResolvedLines: []string{"茶"}, Confidence: 0.9,
+	}})
+	if err != nil {
+		t.Fatalf("ApplyResolutions() error = %v", err)
+	}
+	if result.Success || result.FailedCount != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if got := readTestFile(t, repoPath, "menu.txt"); got != content {
+		t.Errorf("menu.txt was changed: %q", got)
+	}
+}
@@ -2,6 +2,7 @@
 package gitutils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -46,31 +47,137 @@ func IsGitRepositoryPath(repoPath string) bool {
 }
 
 // GetConflictedFiles returns a list of files with merge conflicts
-func GetConflictedFiles() ([]string, error) {
-	cmd := exec.Command("git", "diff", "--name-only", "--diff-filter=U")
+func GetConflictedFiles(repoPath string) ([]string, error) {
+	cmd := exec.Command("git", "diff", "--name-only", "-z", "--diff-filter=U")
+	cmd.Dir = repoPath
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conflicted files: %w", err)
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return []string{}, nil
+	return splitNulRecords(output), nil
+}
+
+// splitNulRecords splits NUL-terminated git output into its non-empty records.
+// Paths in -z output are not quoted, so they are returned verbatim.
+func splitNulRecords(output []byte) []string {
+	records := []string{}
+	for _, record := range strings.Split(string(output), "\x00") {
+		if record != "" {
+			records = append(records, record)
+		}
 	}
+	return records
+}
 
-	return files, nil
+// ResolveCommit resolves a revision to the full id of the commit it names. Revisions
+// that could be taken for an option are rejected.
+func ResolveCommit(repoPath, rev string) (string, error) {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return "", fmt.Errorf("invalid revision %q", rev)
+	}
+
+	// #nosec G204 - rev cannot be taken for an option
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%q is not a commit", rev)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ListBranches returns the full names of the local and remote-tracking branches
+// matching a glob such as "feature/*" or "origin/release-*". Symbolic refs like
+// origin/HEAD are left out.
+func ListBranches(repoPath, pattern string) ([]string, error) {
+	if pattern == "" || strings.HasPrefix(pattern, "-") {
+		return nil, fmt.Errorf("invalid branch pattern %q", pattern)
+	}
+
+	// #nosec G204 - the pattern is anchored below refs/ and cannot be taken for an option
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname)%00%(symref)",
+		"refs/heads/"+pattern, "refs/remotes/"+pattern)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		refName, symref, _ := strings.Cut(line, "\x00")
+		if refName == "" || symref != "" {
+			continue
+		}
+		branches = append(branches, refName)
+	}
+	return branches, nil
+}
+
+// ShortRefName strips the refs/heads/ or refs/remotes/ prefix of a ref name
+func ShortRefName(refName string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/remotes/"} {
+		if strings.HasPrefix(refName, prefix) {
+			return strings.TrimPrefix(refName, prefix)
+		}
+	}
+	return refName
+}
+
+// SetConfig sets a key in the repository's local git configuration
+func SetConfig(repoPath, key, value string) error {
+	if key == "" || strings.HasPrefix(key, "-") {
+		return fmt.Errorf("invalid config key %q", key)
+	}
+
+	// #nosec G204 - the key cannot be taken for an option and the value follows it
+	cmd := exec.Command("git", "config", "--local", key, value)
+	cmd.Dir = repoPath
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set %s: %w: %s", key, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// GetConfig reads a key from the repository's git configuration, returning an
+// empty string when the key is not set
+func GetConfig(repoPath, key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "-") {
+		return "", fmt.Errorf("invalid config key %q", key)
+	}
+
+	// #nosec G204 - the key cannot be taken for an option
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // CommitChanges creates a commit with the provided message
-func CommitChanges(message string) error {
+func CommitChanges(repoPath, message string) error {
 	// Add all changes
 	addCmd := exec.Command("git", "add", ".")
+	addCmd.Dir = repoPath
 	if err := addCmd.Run(); err != nil {
 		return fmt.Errorf("failed to add changes: %w", err)
 	}
 
 	// Create commit
 	commitCmd := exec.Command("git", "commit", "-m", message)
+	commitCmd.Dir = repoPath
 	if err := commitCmd.Run(); err != nil {
 		return fmt.Errorf("failed to commit changes: %w", err)
 	}
@@ -94,7 +201,7 @@ func GetRecentlyModifiedFiles(repoPath string, days int) ([]string, error) {
 	}
 
 	// #nosec G204 - since parameter validated with regex above
-	cmd := exec.Command("git", "log", "--name-only", "--pretty=format:", "--since="+since)
+	cmd := exec.Command("git", "log", "-z", "--name-only", "--pretty=format:", "--since="+since)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -103,13 +210,11 @@ func GetRecentlyModifiedFiles(repoPath string, days int) ([]string, error) {
 	}
 
 	// Parse the output and deduplicate files
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
 	fileMap := make(map[string]bool)
 	var files []string
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !fileMap[line] {
+	for _, line := range splitNulRecords(output) {
+		if !fileMap[line] {
 			// Check if file still exists
 			fullPath := filepath.Join(repoPath, line)
 			if _, err := os.Stat(fullPath); err == nil {
@@ -124,7 +229,7 @@ func GetRecentlyModifiedFiles(repoPath string, days int) ([]string, error) {
 
 // GetAllTrackedFiles returns all files tracked by git
 func GetAllTrackedFiles(repoPath string) ([]string, error) {
-	cmd := exec.Command("git", "ls-files")
+	cmd := exec.Command("git", "ls-files", "-z")
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
@@ -132,9 +237,9 @@ func GetAllTrackedFiles(repoPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to get tracked files: %w", err)
 	}
 
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return []string{}, nil
+	files := splitNulRecords(output)
+	if len(files) == 0 {
+		return files, nil
 	}
 
 	// Filter out files that don't exist
@@ -151,22 +151,20 @@ func TestIsGitRepositoryPath(t *testing.T) {
 }
 
 func TestGetConflictedFiles(t *testing.T) {
-	// This test depends on git being available and in a conflicted state
-	files, err := GetConflictedFiles()
+	repoPath := newDivergedRepo(t)
 
+	files, err := GetConflictedFiles(repoPath)
 	if err != nil {
-		// Skip if git is not available
-		if strings.Contains(err.Error(), "failed to get conflicted files") {
-			t.Skip("Git not available or not in a repository")
-		}
-		t.Errorf("GetConflictedFiles() unexpected error = %v", err)
-		return
+		t.Fatalf("GetConflictedFiles() unexpected error = %v", err)
+	}
+	if files == nil || len(files) != 0 {
+		t.Errorf("GetConflictedFiles() = %v, expected an empty slice", files)
 	}
 
-	// In most test environments, we expect no conflicted files
-	// The main test is that it doesn't crash and returns a slice
-	if files == nil {
-		t.Errorf("GetConflictedFiles() returned nil, expected empty slice")
+	mergeExpectingConflict(t, repoPath, "feature")
+	files, err = GetConflictedFiles(repoPath)
+	if err != nil || len(files) != 1 || files[0] != "conflict.txt" {
+		t.Errorf("GetConflictedFiles() = %v, %v; expected [conflict.txt]", files, err)
 	}
 }
 
@@ -178,18 +176,6 @@ func TestCommitChanges(t *testing.T) {
 	}
 	defer cleanup()
 
-	// Change to the temporary directory
-	oldDir, err := os.Getwd()
-	if err != nil {
-		t.Fatalf("Failed to get current directory: %v", err)
-	}
-	defer os.Chdir(oldDir)
-
-	err = os.Chdir(tempDir)
-	if err != nil {
-		t.Fatalf("Failed to change to temp directory: %v", err)
-	}
-
 	// Create a test file to commit
 	testFile := filepath.Join(tempDir, "test.txt")
 	err = os.WriteFile(testFile, []byte("test content"), 0644)
@@ -198,7 +184,7 @@ func TestCommitChanges(t *testing.T) {
 	}
 
 	// Test commit (will likely fail in test environment without proper git setup)
-	err = CommitChanges("Test commit")
+	err = CommitChanges(tempDir, "Test commit")
 	if err != nil {
 		// Skip if git operations fail (expected in test environment)
 		if strings.Contains(err.Error(), "failed to add changes") ||
@@ -381,18 +367,23 @@ func TestGetAllTrackedFiles_FileFiltering(t *testing.T) {
 func TestGitPath_SecurityValidation(t *testing.T) {
 	securityData := testutils.GetSecurityTestData()
 
-	// Test validateGitPath with malicious paths
+	// Repository paths with shell metacharacters are rejected by validateGitPath;
+	// file paths that leave the repository are rejected by the containment check
 	for _, maliciousPath := range securityData.MaliciousPaths {
 		t.Run("Malicious path: "+maliciousPath, func(t *testing.T) {
-			err := validateGitPath(maliciousPath)
-
-			if err == nil {
-				t.Errorf("validateGitPath() with malicious path %s should have failed", maliciousPath)
+			if strings.ContainsAny(maliciousPath, ";|&`$") {
+				err := validateGitPath(maliciousPath)
+				if err == nil {
+					t.Fatalf("validateGitPath() with malicious path %s should have failed", maliciousPath)
+				}
+				if !strings.Contains(err.Error(), "dangerous characters") {
+					t.Errorf("validateGitPath() error should mention dangerous characters, got: %v", err)
+				}
+				return
 			}
 
-			if !strings.Contains(err.Error(), "dangerous characters") &&
-				!strings.Contains(err.Error(), "path cannot be empty") {
-				t.Errorf("validateGitPath() error should mention dangerous characters or empty path, got: %v", err)
+			if _, err := CleanRepoPath(maliciousPath); err == nil {
+				t.Errorf("CleanRepoPath() with traversal path %s should have failed", maliciousPath)
 			}
 		})
 	}
@@ -0,0 +1,283 @@
+package gitutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of the resolutions kept in the resolution memory
+const (
+	MemorySourceSyncwright = "syncwright"
+	MemorySourceRerere     = "rerere"
+)
+
+// memoryFileName is the resolution memory file inside <gitdir>/syncwright
+const memoryFileName = "resolution-memory.json"
+
+// memoryMu serializes updates of resolution memory files within the process, so
+// concurrent batches do not lose each other's records
+var memoryMu sync.Mutex
+
+// MemoryEntry is a remembered resolution of a conflict hunk
+type MemoryEntry struct {
+	Key           string    `json:"key"`
+	FilePath      string    `json:"file_path"`
+	OursLines     []string  `json:"ours_lines"`
+	BaseLines     []string  `json:"base_lines,omitempty"`
+	TheirsLines   []string  `json:"theirs_lines"`
+	ResolvedLines []string  `json:"resolved_lines"`
+	Confidence    float64   `json:"confidence"`
+	Source        string    `json:"source"`
+	RecordedAt    time.Time `json:"recorded_at"`
+	LastUsedAt    time.Time `json:"last_used_at,omitempty"`
+	UseCount      int       `json:"use_count"`
+}
+
+// ResolutionMemory stores resolutions keyed by a normalized hash of the hunk
+// they resolved, like git rerere, so recurring conflicts are resolved the same
+// way again. It is kept in <gitdir>/syncwright/resolution-memory.json.
+type ResolutionMemory struct {
+	Entries map[string]*MemoryEntry `json:"entries"`
+	// ImportedRerere lists the rr-cache records already imported, so entries
+	// that were forgotten are not imported again
+	ImportedRerere []string `json:"imported_rerere,omitempty"`
+
+	path string
+}
+
+// HunkKey returns the memory key of a conflict: a hash of the ours, base and
+// theirs lines with line endings and trailing whitespace normalized. Without
+// base lines the sides are ordered, as rerere does, so the key does not depend
+// on which side was merged into which.
+func HunkKey(ours, base, theirs []string) string {
+	normalize := func(lines []string) string {
+		normalized := make([]string, len(lines))
+		for i, line := range lines {
+			normalized[i] = strings.TrimRight(line, " \t\r")
+		}
+		return strings.Join(normalized, "\n")
+	}
+
+	sides := []string{normalize(ours), normalize(base), normalize(theirs)}
+	if len(base) == 0 && sides[0] > sides[2] {
+		sides[0], sides[2] = sides[2], sides[0]
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(sides, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// OpenResolutionMemory loads the resolution memory of a repository. A missing
+// memory file yields an empty memory. All worktrees share one memory, so
+// resolutions accepted in an isolated worktree outlive it.
+func OpenResolutionMemory(repoPath string) (*ResolutionMemory, error) {
+	gitDir, err := GetCommonGitDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	memory := &ResolutionMemory{
+		Entries: make(map[string]*MemoryEntry),
+		path:    filepath.Join(gitDir, "syncwright", memoryFileName),
+	}
+
+	data, err := os.ReadFile(memory.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resolution memory: %w", err)
+	}
+
+	if err := json.Unmarshal(data, memory); err != nil {
+		return nil, fmt.Errorf("failed to parse resolution memory %s: %w", memory.path, err)
+	}
+	if memory.Entries == nil {
+		memory.Entries = make(map[string]*MemoryEntry)
+	}
+	return memory, nil
+}
+
+// UpdateResolutionMemory loads the resolution memory, lets update change it and
+// saves it again while holding the process-wide memory lock
+func UpdateResolutionMemory(repoPath string, update func(memory *ResolutionMemory) error) error {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+
+	memory, err := OpenResolutionMemory(repoPath)
+	if err != nil {
+		return err
+	}
+	if err := update(memory); err != nil {
+		return err
+	}
+	return memory.Save()
+}
+
+// Path returns the file the memory is stored in
+func (m *ResolutionMemory) Path() string {
+	return m.path
+}
+
+// Save writes the memory to its file, replacing it atomically
+func (m *ResolutionMemory) Save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal resolution memory: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.path), 0750); err != nil {
+		return fmt.Errorf("failed to create resolution memory directory: %w", err)
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write resolution memory: %w", err)
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write resolution memory: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the remembered resolution of a hunk. Resolutions imported from
+// rerere carry no base, so a hunk that is not found with its base lines is looked
+// up again without them.
+func (m *ResolutionMemory) Lookup(hunk ConflictHunk) (*MemoryEntry, bool) {
+	if entry, exists := m.Entries[HunkKey(hunk.OursLines, hunk.BaseLines, hunk.TheirsLines)]; exists {
+		return entry, true
+	}
+	if len(hunk.BaseLines) > 0 {
+		if entry, exists := m.Entries[HunkKey(hunk.OursLines, nil, hunk.TheirsLines)]; exists {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// Record remembers the resolution of a hunk. Recording the resolution a hunk
+// already has counts as a use of it.
+func (m *ResolutionMemory) Record(filePath string, hunk ConflictHunk, resolved []string, confidence float64, source string) *MemoryEntry {
+	key := HunkKey(hunk.OursLines, hunk.BaseLines, hunk.TheirsLines)
+	now := time.Now()
+
+	if entry, exists := m.Entries[key]; exists && equalLines(entry.ResolvedLines, resolved) {
+		entry.UseCount++
+		entry.LastUsedAt = now
+		return entry
+	}
+
+	entry := &MemoryEntry{
+		Key:           key,
+		FilePath:      filePath,
+		OursLines:     hunk.OursLines,
+		BaseLines:     hunk.BaseLines,
+		TheirsLines:   hunk.TheirsLines,
+		ResolvedLines: resolved,
+		Confidence:    confidence,
+		Source:        source,
+		RecordedAt:    now,
+	}
+	m.Entries[key] = entry
+	return entry
+}
+
+// Forget removes the entries whose key starts with one of the given prefixes and
+// returns how many were removed
+func (m *ResolutionMemory) Forget(prefixes ...string) int {
+	return m.ForgetMatching(func(entry *MemoryEntry) bool {
+		for _, prefix := range prefixes {
+			if prefix != "" && strings.HasPrefix(entry.Key, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ForgetMatching removes the entries matching a predicate and returns how many
+// were removed
+func (m *ResolutionMemory) ForgetMatching(match func(entry *MemoryEntry) bool) int {
+	removed := 0
+	for key, entry := range m.Entries {
+		if match(entry) {
+			delete(m.Entries, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// List returns the entries ordered by file path and recording time
+func (m *ResolutionMemory) List() []MemoryEntry {
+	entries := make([]MemoryEntry, 0, len(m.Entries))
+	for _, entry := range m.Entries {
+		entries = append(entries, *entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].FilePath != entries[j].FilePath {
+			return entries[i].FilePath < entries[j].FilePath
+		}
+		if !entries[i].RecordedAt.Equal(entries[j].RecordedAt) {
+			return entries[i].RecordedAt.Before(entries[j].RecordedAt)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// RecordAppliedResolutions remembers resolutions that were applied to the
+// conflicts of a file. hunks are the conflicts of the file before the
+// resolutions were applied; resolutions that match none of them are skipped.
+// It returns the number of resolutions recorded.
+func RecordAppliedResolutions(repoPath, filePath string, hunks []ConflictHunk, resolutions []ConflictResolution) (int, error) {
+	type record struct {
+		hunk       ConflictHunk
+		resolution ConflictResolution
+	}
+
+	var records []record
+	for _, resolution := range resolutions {
+		for _, hunk := range hunks {
+			if matchesHunk(hunk, resolution) {
+				records = append(records, record{hunk: hunk, resolution: resolution})
+				break
+			}
+		}
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	err := UpdateResolutionMemory(repoPath, func(memory *ResolutionMemory) error {
+		for _, r := range records {
+			memory.Record(filePath, r.hunk, r.resolution.ResolvedLines, r.resolution.Confidence, MemorySourceSyncwright)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// matchesHunk reports whether a resolution resolves a hunk, by hunk ID when both
+// have one and by line range otherwise
+func matchesHunk(hunk ConflictHunk, resolution ConflictResolution) bool {
+	if hunk.ID != "" && resolution.HunkID != "" {
+		return hunk.ID == resolution.HunkID
+	}
+	return hunk.StartLine == resolution.StartLine && hunk.EndLine == resolution.EndLine
+}
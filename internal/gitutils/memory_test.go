@@ -0,0 +1,113 @@
+package gitutils
+
+import (
+	"testing"
+)
+
+func TestHunkKey(t *testing.T) {
+	base := []string{"value"}
+	key := HunkKey([]string{"ours"}, base, []string{"theirs"})
+
+	if HunkKey([]string{"ours  \r"}, base, []string{"theirs"}) != key {
+		t.Error("trailing whitespace and line endings should not change the key")
+	}
+	if HunkKey([]string{"theirs"}, base, []string{"ours"}) == key {
+		t.Error("swapping sides with a base should change the key")
+	}
+	if HunkKey([]string{"a"}, nil, []string{"b"}) != HunkKey([]string{"b"}, nil, []string{"a"}) {
+		t.Error("without a base the key should not depend on the side order")
+	}
+}
+
+func TestApplyResolutions_RemembersResolutions(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	hunks, err := GetConflictHunks("conflict.txt", repoPath)
+	if err != nil || len(hunks) != 1 {
+		t.Fatalf("GetConflictHunks() = %+v, %v", hunks, err)
+	}
+
+	result, err := ApplyResolutions(repoPath, []ConflictResolution{{
+		FilePath:      "conflict.txt",
+		StartLine:     hunks[0].StartLine,
+		EndLine:       hunks[0].EndLine,
+		ResolvedLines: []string{"main and feature"},
+		Confidence:    0.9,
+	}})
+	if err != nil || !result.Success || len(result.Errors) != 0 {
+		t.Fatalf("ApplyResolutions() = %+v, %v", result, err)
+	}
+
+	memory, err := OpenResolutionMemory(repoPath)
+	if err != nil {
+		t.Fatalf("OpenResolutionMemory() error = %v", err)
+	}
+	entry, found := memory.Lookup(hunks[0])
+	if !found {
+		t.Fatalf("resolution was not remembered, memory has %+v", memory.List())
+	}
+	if entry.FilePath != "conflict.txt" || len(entry.ResolvedLines) != 1 || entry.ResolvedLines[0] != "main and feature" ||
+		entry.Source != MemorySourceSyncwright || len(entry.BaseLines) != 1 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if removed := memory.Forget(entry.Key[:8]); removed != 1 || len(memory.Entries) != 0 {
+		t.Errorf("Forget() removed %d, %d entries left", removed, len(memory.Entries))
+	}
+}
+
+func TestResolutionMemory_ImportRerere(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	writeRepoFile(t, repoPath, "conflict.txt", "first\nmain\nlast\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "context on main")
+	runGit(t, repoPath, "config", "rerere.enabled", "true")
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	writeRepoFile(t, repoPath, "conflict.txt", "first\nmerged\nlast\n")
+	runGit(t, repoPath, "rerere")
+
+	memory, err := OpenResolutionMemory(repoPath)
+	if err != nil {
+		t.Fatalf("OpenResolutionMemory() error = %v", err)
+	}
+	result, err := memory.ImportRerere(repoPath)
+	if err != nil {
+		t.Fatalf("ImportRerere() error = %v", err)
+	}
+	if result.Records != 1 || result.Imported != 1 {
+		t.Fatalf("unexpected import result %+v", result)
+	}
+
+	// rerere records no base, but the resolution is found for a hunk with one
+	entry, found := memory.Lookup(ConflictHunk{
+		OursLines:   []string{"first", "main", "last"},
+		BaseLines:   []string{"base"},
+		TheirsLines: []string{"feature"},
+	})
+	if !found || entry.Source != MemorySourceRerere || len(entry.ResolvedLines) != 3 {
+		t.Fatalf("Lookup() = %+v, %v; memory has %+v", entry, found, memory.List())
+	}
+
+	// Records are imported once, even after they were forgotten
+	memory.Forget(entry.Key)
+	if result, err := memory.ImportRerere(repoPath); err != nil || result.Imported != 0 {
+		t.Errorf("second ImportRerere() = %+v, %v", result, err)
+	}
+}
+
+func TestAlignRerereImages(t *testing.T) {
+	preimage := "a\n<<<<<<<\nx\n=======\ny\n>>>>>>>\nb\nc\n<<<<<<<\np\n=======\nq\n>>>>>>>\nd\n"
+
+	hunks, resolved, ok := alignRerereImages(preimage, "a\nxy\nxy2\nb\nc\npq\nd\n")
+	if !ok || len(hunks) != 2 {
+		t.Fatalf("alignRerereImages() = %+v, %v, %v", hunks, resolved, ok)
+	}
+	if !equalLines(resolved[0], []string{"xy", "xy2"}) || !equalLines(resolved[1], []string{"pq"}) {
+		t.Errorf("unexpected resolutions %q", resolved)
+	}
+
+	if _, _, ok := alignRerereImages(preimage, "a\nxy\nchanged context\npq\nd\n"); ok {
+		t.Error("expected a postimage with changed context not to align")
+	}
+}
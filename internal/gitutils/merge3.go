@@ -0,0 +1,270 @@
+package gitutils
+
+// RegionKind classifies a region produced by a three-way merge
+type RegionKind string
+
+const (
+	// RegionUnchanged means all three versions agree
+	RegionUnchanged RegionKind = "unchanged"
+	// RegionOurs means only our side changed the region
+	RegionOurs RegionKind = "ours"
+	// RegionTheirs means only their side changed the region
+	RegionTheirs RegionKind = "theirs"
+	// RegionSame means both sides made the identical change
+	RegionSame RegionKind = "same"
+	// RegionConflict means both sides changed the region differently
+	RegionConflict RegionKind = "conflict"
+)
+
+// MergeRegion is a contiguous region of a three-way merge
+type MergeRegion struct {
+	Kind        RegionKind
+	BaseLines   []string
+	OursLines   []string
+	TheirsLines []string
+}
+
+// MergedLines returns the lines the region contributes to a merge result.
+// Conflict regions have no merged form and return nil.
+func (r MergeRegion) MergedLines() []string {
+	switch r.Kind {
+	case RegionTheirs:
+		return r.TheirsLines
+	case RegionConflict:
+		return nil
+	default:
+		return r.OursLines
+	}
+}
+
+// matchBlock is a run of identical lines: a[A:A+Size] == b[B:B+Size]
+type matchBlock struct {
+	A, B, Size int
+}
+
+// Merge3 performs a line-based three-way merge of ours and theirs against base
+// and returns the resulting regions in file order
+func Merge3(base, ours, theirs []string) []MergeRegion {
+	var regions []MergeRegion
+
+	iz, ia, ib := 0, 0, 0
+	for _, sync := range findSyncRegions(base, ours, theirs) {
+		if sync.baseStart > iz || sync.oursStart > ia || sync.theirsStart > ib {
+			baseLines := base[iz:sync.baseStart]
+			oursLines := ours[ia:sync.oursStart]
+			theirsLines := theirs[ib:sync.theirsStart]
+
+			oursUnchanged := equalLines(oursLines, baseLines)
+			theirsUnchanged := equalLines(theirsLines, baseLines)
+
+			kind := RegionConflict
+			switch {
+			case equalLines(oursLines, theirsLines):
+				kind = RegionSame
+			case oursUnchanged:
+				kind = RegionTheirs
+			case theirsUnchanged:
+				kind = RegionOurs
+			}
+
+			regions = append(regions, MergeRegion{
+				Kind:        kind,
+				BaseLines:   baseLines,
+				OursLines:   oursLines,
+				TheirsLines: theirsLines,
+			})
+		}
+
+		if sync.size > 0 {
+			regions = append(regions, MergeRegion{
+				Kind:        RegionUnchanged,
+				BaseLines:   base[sync.baseStart : sync.baseStart+sync.size],
+				OursLines:   ours[sync.oursStart : sync.oursStart+sync.size],
+				TheirsLines: theirs[sync.theirsStart : sync.theirsStart+sync.size],
+			})
+		}
+
+		iz = sync.baseStart + sync.size
+		ia = sync.oursStart + sync.size
+		ib = sync.theirsStart + sync.size
+	}
+
+	return regions
+}
+
+// syncRegion is a run of lines shared by all three versions
+type syncRegion struct {
+	baseStart, oursStart, theirsStart, size int
+}
+
+// findSyncRegions intersects the base/ours and base/theirs matches into regions
+// that are identical in all three versions, terminated by a zero-length sentinel
+func findSyncRegions(base, ours, theirs []string) []syncRegion {
+	oursMatches := matchingBlocks(base, ours)
+	theirsMatches := matchingBlocks(base, theirs)
+
+	var regions []syncRegion
+	ia, ib := 0, 0
+	for ia < len(oursMatches) && ib < len(theirsMatches) {
+		am := oursMatches[ia]
+		bm := theirsMatches[ib]
+
+		start := max(am.A, bm.A)
+		end := min(am.A+am.Size, bm.A+bm.Size)
+		if start < end {
+			regions = append(regions, syncRegion{
+				baseStart:   start,
+				oursStart:   am.B + (start - am.A),
+				theirsStart: bm.B + (start - bm.A),
+				size:        end - start,
+			})
+		}
+
+		if am.A+am.Size < bm.A+bm.Size {
+			ia++
+		} else {
+			ib++
+		}
+	}
+
+	return append(regions, syncRegion{
+		baseStart:   len(base),
+		oursStart:   len(ours),
+		theirsStart: len(theirs),
+	})
+}
+
+// matchingBlocks returns the runs of lines common to a and b in order, based on
+// a shortest edit script
+func matchingBlocks(a, b []string) []matchBlock {
+	// Strip the common prefix and suffix so the diff only runs on the changed middle
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var blocks []matchBlock
+	if prefix > 0 {
+		blocks = append(blocks, matchBlock{A: 0, B: 0, Size: prefix})
+	}
+
+	for _, block := range myersMatches(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		block.A += prefix
+		block.B += prefix
+		blocks = appendMatch(blocks, block)
+	}
+
+	if suffix > 0 {
+		blocks = appendMatch(blocks, matchBlock{A: len(a) - suffix, B: len(b) - suffix, Size: suffix})
+	}
+
+	return blocks
+}
+
+// appendMatch appends a block, coalescing it with the previous one when adjacent
+func appendMatch(blocks []matchBlock, block matchBlock) []matchBlock {
+	if n := len(blocks); n > 0 {
+		last := &blocks[n-1]
+		if last.A+last.Size == block.A && last.B+last.Size == block.B {
+			last.Size += block.Size
+			return blocks
+		}
+	}
+	return append(blocks, block)
+}
+
+// myersMatches computes the matching blocks of a and b with Myers' O(ND) algorithm
+func myersMatches(a, b []string) []matchBlock {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	finalD := -1
+	for d := 0; d <= maxD && finalD < 0; d++ {
+		// Snapshot the furthest reaching paths of the previous round for backtracking
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				finalD = d
+				break
+			}
+		}
+	}
+
+	// Walk the trace backwards collecting diagonal moves
+	var reversed []matchBlock
+	x, y := n, m
+	for d := finalD; d > 0; d-- {
+		previous := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && previous[k-1+d] < previous[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := previous[prevK+d]
+		prevY := prevX - prevK
+
+		// The edit moves one step right (deletion) or down (insertion), followed by a snake
+		midX, midY := prevX+1, prevY
+		if prevK == k+1 {
+			midX, midY = prevX, prevY+1
+		}
+		if size := x - midX; size > 0 {
+			reversed = append(reversed, matchBlock{A: midX, B: midY, Size: size})
+		}
+
+		x, y = prevX, prevY
+	}
+	if x > 0 {
+		reversed = append(reversed, matchBlock{A: 0, B: 0, Size: x})
+	}
+
+	blocks := make([]matchBlock, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		blocks = appendMatch(blocks, reversed[i])
+	}
+	return blocks
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package gitutils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		conflicts int
+		merged    string // Expected merge when there are no conflicts
+	}{
+		{
+			name:   "identical",
+			base:   "a b c",
+			ours:   "a b c",
+			theirs: "a b c",
+			merged: "a b c",
+		},
+		{
+			name:   "one side changed",
+			base:   "a b c",
+			ours:   "a B c",
+			theirs: "a b c",
+			merged: "a B c",
+		},
+		{
+			name:   "disjoint changes",
+			base:   "a b c d e",
+			ours:   "A b c d e",
+			theirs: "a b c d E",
+			merged: "A b c d E",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "a b c",
+			ours:   "a x c",
+			theirs: "a x c",
+			merged: "a x c",
+		},
+		{
+			name:      "overlapping change",
+			base:      "a b c",
+			ours:      "a x c",
+			theirs:    "a y c",
+			conflicts: 1,
+		},
+		{
+			name:      "two conflicts",
+			base:      "a b c d e",
+			ours:      "a x c y e",
+			theirs:    "a 1 c 2 e",
+			conflicts: 2,
+		},
+		{
+			name:      "no base",
+			base:      "",
+			ours:      "a",
+			theirs:    "b",
+			conflicts: 1,
+		},
+		{
+			name:   "insertions at different places",
+			base:   "a b c",
+			ours:   "a 1 b c",
+			theirs: "a b c 2",
+			merged: "a 1 b c 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions := Merge3(strings.Fields(tt.base), strings.Fields(tt.ours), strings.Fields(tt.theirs))
+
+			conflicts := 0
+			var merged []string
+			for _, region := range regions {
+				if region.Kind == RegionConflict {
+					conflicts++
+				}
+				merged = append(merged, region.MergedLines()...)
+			}
+
+			if conflicts != tt.conflicts {
+				t.Fatalf("Merge3() produced %d conflicts, expected %d (%+v)", conflicts, tt.conflicts, regions)
+			}
+			if tt.conflicts == 0 && strings.Join(merged, " ") != tt.merged {
+				t.Errorf("Merge3() merged = %q, expected %q", strings.Join(merged, " "), tt.merged)
+			}
+		})
+	}
+}
+
+func TestMatchingBlocks(t *testing.T) {
+	a := strings.Fields("a b c d e f g")
+	b := strings.Fields("a x c d y f g z")
+
+	blocks := matchingBlocks(a, b)
+
+	matched := 0
+	for _, block := range blocks {
+		for i := 0; i < block.Size; i++ {
+			if a[block.A+i] != b[block.B+i] {
+				t.Fatalf("block %+v does not match", block)
+			}
+		}
+		matched += block.Size
+	}
+
+	if matched != 5 {
+		t.Errorf("matchingBlocks() matched %d lines, expected 5", matched)
+	}
+}
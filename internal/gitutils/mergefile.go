@@ -0,0 +1,100 @@
+package gitutils
+
+import (
+	"bytes"
+	"strings"
+)
+
+// MergeLabels are the labels written after the conflict markers of a merge
+type MergeLabels struct {
+	Ours   string
+	Base   string
+	Theirs string
+}
+
+// FileMerge is the result of a three-way merge of file contents. Conflicts are
+// rendered into Lines with diff3 style markers and described by Hunks, whose
+// line numbers refer to Lines.
+type FileMerge struct {
+	Lines        []string
+	Hunks        []ConflictHunk
+	FinalNewline bool
+	Binary       bool
+}
+
+// Clean reports whether the merge produced no conflicts
+func (m *FileMerge) Clean() bool {
+	return len(m.Hunks) == 0
+}
+
+// Content returns the merged content, including the conflict markers
+func (m *FileMerge) Content() string {
+	content := strings.Join(m.Lines, "\n")
+	if m.FinalNewline && len(m.Lines) > 0 {
+		content += "\n"
+	}
+	return content
+}
+
+// MergeFileContents merges ours and theirs against base without a repository,
+// the way a merge driver has to. Binary content is not merged: the result keeps
+// our side and is reported as Binary.
+func MergeFileContents(base, ours, theirs []byte, labels MergeLabels, markerSize int) *FileMerge {
+	if markerSize <= 0 {
+		markerSize = DefaultMarkerSize
+	}
+
+	if bytes.IndexByte(base, 0) >= 0 || bytes.IndexByte(ours, 0) >= 0 || bytes.IndexByte(theirs, 0) >= 0 {
+		return &FileMerge{Binary: true}
+	}
+
+	merge := &FileMerge{
+		FinalNewline: len(ours) == 0 || bytes.HasSuffix(ours, []byte("\n")),
+	}
+
+	regions := Merge3(
+		splitContentLines(string(base)),
+		splitContentLines(string(ours)),
+		splitContentLines(string(theirs)),
+	)
+
+	marker := func(char string, label string) string {
+		line := strings.Repeat(char, markerSize)
+		if label != "" {
+			line += " " + label
+		}
+		return line
+	}
+
+	for _, region := range regions {
+		if region.Kind != RegionConflict {
+			merge.Lines = append(merge.Lines, region.MergedLines()...)
+			continue
+		}
+
+		hunk := ConflictHunk{
+			StartLine:   len(merge.Lines) + 1,
+			OursLines:   region.OursLines,
+			TheirsLines: region.TheirsLines,
+			BaseLines:   region.BaseLines,
+			OursLabel:   labels.Ours,
+			BaseLabel:   labels.Base,
+			TheirsLabel: labels.Theirs,
+			MarkerSize:  markerSize,
+		}
+
+		merge.Lines = append(merge.Lines, marker("<", labels.Ours))
+		merge.Lines = append(merge.Lines, region.OursLines...)
+		merge.Lines = append(merge.Lines, marker("|", labels.Base))
+		merge.Lines = append(merge.Lines, region.BaseLines...)
+		merge.Lines = append(merge.Lines, marker("=", ""))
+		merge.Lines = append(merge.Lines, region.TheirsLines...)
+		merge.Lines = append(merge.Lines, marker(">", labels.Theirs))
+
+		hunk.EndLine = len(merge.Lines)
+		merge.Hunks = append(merge.Hunks, hunk)
+	}
+
+	assignHunkIDs(merge.Hunks, merge.Lines)
+	return merge
+}
@@ -0,0 +1,33 @@
+package gitutils
+
+import (
+	"testing"
+)
+
+func TestMergeFileContents(t *testing.T) {
+	labels := MergeLabels{Ours: "ours", Base: "base", Theirs: "theirs"}
+
+	merge := MergeFileContents([]byte("a\nb\nc\n"), []byte("A\nb\nc\n"), []byte("a\nb\nC\n"), labels, 0)
+	if !merge.Clean() || merge.Content() != "A\nb\nC\n" {
+		t.Errorf("clean merge = %q, hunks %+v", merge.Content(), merge.Hunks)
+	}
+
+	merge = MergeFileContents([]byte("x\nb\n"), []byte("x\nours\n"), []byte("x\ntheirs\n"), labels, 0)
+	expected := "x\n<<<<<<< ours\nours\n||||||| base\nb\n=======\ntheirs\n>>>>>>> theirs\n"
+	if merge.Content() != expected {
+		t.Fatalf("conflicted merge = %q, expected %q", merge.Content(), expected)
+	}
+	if len(merge.Hunks) != 1 || merge.Hunks[0].StartLine != 2 || merge.Hunks[0].EndLine != 8 {
+		t.Fatalf("unexpected hunks %+v", merge.Hunks)
+	}
+
+	// The rendered markers parse back into the same hunk
+	parsed, err := parseConflictMarkers(merge.Content())
+	if err != nil || len(parsed) != 1 || parsed[0].StartLine != 2 || parsed[0].EndLine != 8 {
+		t.Errorf("parsed hunks = %+v, %v", parsed, err)
+	}
+
+	if merge := MergeFileContents([]byte("a"), []byte("a\x00"), []byte("b"), labels, 0); !merge.Binary {
+		t.Error("expected binary content to be detected")
+	}
+}
@@ -0,0 +1,364 @@
+package gitutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Operation identifies the git operation a repository is in the middle of
+type Operation string
+
+const (
+	// OperationNone means no merge, rebase, cherry-pick or revert is in progress
+	OperationNone Operation = "none"
+	// OperationMerge means a merge stopped before committing
+	OperationMerge Operation = "merge"
+	// OperationRebase means a rebase stopped at one of its steps
+	OperationRebase Operation = "rebase"
+	// OperationCherryPick means a cherry-pick stopped before committing
+	OperationCherryPick Operation = "cherry-pick"
+	// OperationRevert means a revert stopped before committing
+	OperationRevert Operation = "revert"
+)
+
+// RebaseProgress describes how far a rebase has progressed
+type RebaseProgress struct {
+	Step        int    `json:"step"`
+	TotalSteps  int    `json:"total_steps"`
+	HeadName    string `json:"head_name,omitempty"`
+	Onto        string `json:"onto,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	Interactive bool   `json:"interactive"`
+}
+
+// OperationState describes the in-progress operation of a repository
+type OperationState struct {
+	Operation Operation       `json:"operation"`
+	Rebase    *RebaseProgress `json:"rebase,omitempty"`
+}
+
+// InProgress reports whether an operation is in progress
+func (s *OperationState) InProgress() bool {
+	return s != nil && s.Operation != OperationNone
+}
+
+// GetGitDir returns the absolute path of the repository's git directory. Linked
+// worktrees have a .git file, so the directory is asked from git rather than
+// assumed to be <repo>/.git.
+func GetGitDir(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetCommonGitDir returns the absolute path of the git directory shared by all
+// worktrees of the repository. Linked worktrees get their own git directory,
+// which git worktree remove deletes, so state that outlives a worktree is kept
+// in the common one.
+func GetCommonGitDir(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate common git directory: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetGitPath returns the absolute path git uses for a file inside its git
+// directory, such as info/attributes, which linked worktrees share with the
+// main repository
+func GetGitPath(repoPath, name string) (string, error) {
+	// #nosec G204 - name follows --git-path and cannot be taken for an option
+	cmd := exec.Command("git", "rev-parse", "--path-format=absolute", "--git-path", name)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate %s in the git directory: %w", name, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// GetWorktreeRoot returns the absolute path of the top-level directory of the
+// working tree containing repoPath
+func GetWorktreeRoot(repoPath string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate working tree root: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DetectOperation determines which operation the repository is in the middle of
+// from the state files git keeps in its git directory
+func DetectOperation(repoPath string) (*OperationState, error) {
+	gitDir, err := GetGitDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// A rebase drives cherry-picks internally, so its state directories are
+	// checked before the single-commit markers
+	if isDir(filepath.Join(gitDir, "rebase-merge")) {
+		return &OperationState{
+			Operation: OperationRebase,
+			Rebase:    readRebaseProgress(gitDir, "rebase-merge", "msgnum", "end"),
+		}, nil
+	}
+	applyDir := filepath.Join(gitDir, "rebase-apply")
+	if isDir(applyDir) && !fileExists(filepath.Join(applyDir, "applying")) {
+		// rebase-apply/applying marks a git am session rather than a rebase
+		return &OperationState{
+			Operation: OperationRebase,
+			Rebase:    readRebaseProgress(gitDir, "rebase-apply", "next", "last"),
+		}, nil
+	}
+
+	// REBASE_HEAD is not a marker: git can leave it behind after a rebase
+	// finished, such as when the last commit was resolved to nothing
+	markers := []struct {
+		file      string
+		operation Operation
+	}{
+		{"MERGE_HEAD", OperationMerge},
+		{"CHERRY_PICK_HEAD", OperationCherryPick},
+		{"REVERT_HEAD", OperationRevert},
+	}
+	for _, marker := range markers {
+		if fileExists(filepath.Join(gitDir, marker.file)) {
+			return &OperationState{Operation: marker.operation}, nil
+		}
+	}
+
+	return &OperationState{Operation: OperationNone}, nil
+}
+
+// readRebaseProgress reads the step counters and branch information of a rebase
+// state directory, along with the commit the rebase stopped at
+func readRebaseProgress(gitDir, stateDirName, stepFile, totalFile string) *RebaseProgress {
+	stateDir := filepath.Join(gitDir, stateDirName)
+	progress := &RebaseProgress{
+		Step:        readStateInt(filepath.Join(stateDir, stepFile)),
+		TotalSteps:  readStateInt(filepath.Join(stateDir, totalFile)),
+		HeadName:    strings.TrimPrefix(readStateFile(filepath.Join(stateDir, "head-name")), "refs/heads/"),
+		Onto:        readStateFile(filepath.Join(stateDir, "onto")),
+		Commit:      readStateFile(filepath.Join(gitDir, "REBASE_HEAD")),
+		Interactive: fileExists(filepath.Join(stateDir, "interactive")),
+	}
+	if progress.HeadName == "detached HEAD" {
+		progress.HeadName = ""
+	}
+	return progress
+}
+
+// ContinueOperation finishes the current step of the in-progress operation with
+// git <operation> --continue. The editor is disabled so the prepared commit
+// message is used as-is.
+func ContinueOperation(repoPath string, state *OperationState) error {
+	if !state.InProgress() {
+		return fmt.Errorf("no merge, rebase, cherry-pick or revert in progress")
+	}
+
+	// #nosec G204 - the subcommand is one of the fixed Operation constants
+	cmd := exec.Command("git", string(state.Operation), "--continue")
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s --continue failed: %w: %s", state.Operation, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// StartRebase rebases the current branch onto upstream. Stopping at a
+// conflicting commit is not an error; callers inspect DetectOperation to find
+// out whether the rebase finished.
+func StartRebase(repoPath, upstream string) error {
+	if _, err := ResolveCommit(repoPath, upstream); err != nil {
+		return fmt.Errorf("invalid upstream: %w", err)
+	}
+
+	// #nosec G204 - upstream is verified as a commit above
+	cmd := exec.Command("git", "rebase", upstream)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		state, detectErr := DetectOperation(repoPath)
+		if detectErr == nil && state.Operation == OperationRebase {
+			return nil
+		}
+		return fmt.Errorf("git rebase failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// AbortOperation abandons the in-progress operation with git <operation> --abort,
+// restoring the branch to where it was before the operation started
+func AbortOperation(repoPath string, state *OperationState) error {
+	if !state.InProgress() {
+		return fmt.Errorf("no merge, rebase, cherry-pick or revert in progress")
+	}
+
+	// #nosec G204 - the subcommand is one of the fixed Operation constants
+	cmd := exec.Command("git", string(state.Operation), "--abort")
+	cmd.Dir = repoPath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s --abort failed: %w: %s", state.Operation, err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// GetCommitSubject returns the subject line of a commit
+func GetCommitSubject(repoPath, commit string) (string, error) {
+	if commit == "" || strings.HasPrefix(commit, "-") {
+		return "", fmt.Errorf("invalid commit %q", commit)
+	}
+
+	// #nosec G204 - commit cannot be taken for an option
+	cmd := exec.Command("git", "log", "-1", "--format=%s", commit, "--")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read commit %s: %w", commit, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// StageFiles records the working tree state of the given paths in the index.
+// Paths missing from the working tree are staged as deletions, including
+// paths whose deletion is already staged.
+func StageFiles(repoPath string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var present, missing []string
+	for _, path := range paths {
+		cleanPath, err := validateConflictFilePath(path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(repoPath, cleanPath)); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, cleanPath)
+		} else {
+			present = append(present, cleanPath)
+		}
+	}
+
+	if err := runStageCommand(repoPath, []string{"add", "-A"}, present); err != nil {
+		return err
+	}
+	// git add refuses a path that is in neither the working tree nor the index
+	return runStageCommand(repoPath, []string{"rm", "-q", "--cached", "--ignore-unmatch"}, missing)
+}
+
+// runStageCommand runs a git command that updates the index for the given paths
+func runStageCommand(repoPath string, command, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	args := append([]string{"--literal-pathspecs"}, command...)
+	args = append(append(args, "--"), paths...)
+	cmd := exec.Command("git", args...) // #nosec G204 - paths are validated by StageFiles
+	cmd.Dir = repoPath
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage files: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// HasConflictMarkers reports whether the working tree version of a file still
+// contains conflict markers. Missing files have none.
+func HasConflictMarkers(repoPath, filePath string) (bool, error) {
+	hunks, err := ParseConflictHunks(filePath, repoPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(hunks) > 0, nil
+}
+
+// UnresolvedReason returns why the working tree version of a file cannot be
+// staged as resolved yet, or "" when it can: conflict markers remain, or an
+// inline suggestion block was left in place
+func UnresolvedReason(repoPath, filePath string) (string, error) {
+	hasMarkers, err := HasConflictMarkers(repoPath, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for conflict markers: %w", err)
+	}
+	if hasMarkers {
+		return "conflict markers remain", nil
+	}
+	hasSuggestions, err := HasSuggestionBlocks(repoPath, filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for suggestion blocks: %w", err)
+	}
+	if hasSuggestions {
+		return "a suggestion block remains", nil
+	}
+	return "", nil
+}
+
+// readStateFile reads a git state file, returning an empty string when absent
+func readStateFile(path string) string {
+	content, err := os.ReadFile(path) // #nosec G304 - path is inside the git directory
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+// readStateInt reads a numeric git state file, returning 0 when absent
+func readStateInt(path string) int {
+	value, err := strconv.Atoi(readStateFile(path))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
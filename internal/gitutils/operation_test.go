@@ -0,0 +1,124 @@
+package gitutils
+
+import (
+	"testing"
+)
+
+// newDivergedRepo creates a repository whose main and feature branches change the
+// same line of conflict.txt, with feature carrying two commits
+func newDivergedRepo(t *testing.T) string {
+	t.Helper()
+
+	repoPath := newTestRepo(t, map[string]string{"conflict.txt": "base\n"})
+	// git --continue commits without the test identity environment
+	runGit(t, repoPath, "config", "user.name", "Test User")
+	runGit(t, repoPath, "config", "user.email", "test@example.com")
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "conflict.txt", "feature\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "feature change")
+	writeRepoFile(t, repoPath, "other.txt", "feature only\n")
+	runGit(t, repoPath, "add", "other.txt")
+	runGit(t, repoPath, "commit", "-q", "-m", "feature addition")
+
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "conflict.txt", "main\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "main change")
+
+	return repoPath
+}
+
+func TestDetectOperation(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    []string
+		expected Operation
+	}{
+		{"merge", []string{"merge", "-q", "--no-edit", "feature"}, OperationMerge},
+		{"cherry-pick", []string{"cherry-pick", "feature~1"}, OperationCherryPick},
+		{"revert", []string{"revert", "--no-edit", "HEAD~2"}, OperationRevert},
+		{"rebase", []string{"rebase", "-q", "main", "feature"}, OperationRebase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := newDivergedRepo(t)
+			if tt.name == "revert" {
+				// Revert the base-to-feature change on top of a later edit
+				runGit(t, repoPath, "checkout", "-q", "feature")
+				writeRepoFile(t, repoPath, "conflict.txt", "feature edited\n")
+				runGit(t, repoPath, "commit", "-q", "-am", "edit")
+			}
+
+			if output, err := testGitCommand(repoPath, tt.start...).CombinedOutput(); err == nil {
+				t.Fatalf("expected git %v to stop with conflicts, output: %s", tt.start, output)
+			}
+
+			state, err := DetectOperation(repoPath)
+			if err != nil {
+				t.Fatalf("DetectOperation() error = %v", err)
+			}
+			if state.Operation != tt.expected {
+				t.Errorf("DetectOperation() = %s, expected %s", state.Operation, tt.expected)
+			}
+			if tt.expected == OperationRebase {
+				if state.Rebase == nil || state.Rebase.Step != 1 || state.Rebase.TotalSteps != 2 {
+					t.Errorf("unexpected rebase progress %+v", state.Rebase)
+				} else if state.Rebase.HeadName != "feature" {
+					t.Errorf("rebase head name = %q, expected feature", state.Rebase.HeadName)
+				}
+			}
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		repoPath := newDivergedRepo(t)
+
+		state, err := DetectOperation(repoPath)
+		if err != nil {
+			t.Fatalf("DetectOperation() error = %v", err)
+		}
+		if state.InProgress() {
+			t.Errorf("DetectOperation() = %s, expected none", state.Operation)
+		}
+	})
+}
+
+func TestContinueOperation_Rebase(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+
+	if output, err := testGitCommand(repoPath, "rebase", "-q", "main", "feature").CombinedOutput(); err == nil {
+		t.Fatalf("expected rebase to stop with conflicts, output: %s", output)
+	}
+
+	hasMarkers, err := HasConflictMarkers(repoPath, "conflict.txt")
+	if err != nil || !hasMarkers {
+		t.Fatalf("HasConflictMarkers() = %t, %v, expected markers", hasMarkers, err)
+	}
+
+	writeRepoFile(t, repoPath, "conflict.txt", "main and feature\n")
+	if err := StageFiles(repoPath, []string{"conflict.txt"}); err != nil {
+		t.Fatalf("StageFiles() error = %v", err)
+	}
+
+	state, err := DetectOperation(repoPath)
+	if err != nil {
+		t.Fatalf("DetectOperation() error = %v", err)
+	}
+	if err := ContinueOperation(repoPath, state); err != nil {
+		t.Fatalf("ContinueOperation() error = %v", err)
+	}
+
+	state, err = DetectOperation(repoPath)
+	if err != nil {
+		t.Fatalf("DetectOperation() error = %v", err)
+	}
+	if state.InProgress() {
+		t.Errorf("expected the rebase to complete, still in %s", state.Operation)
+	}
+
+	log := runGit(t, repoPath, "log", "--format=%s", "main..feature")
+	if log != "feature addition\nfeature change\n" {
+		t.Errorf("unexpected rebased history %q", log)
+	}
+}
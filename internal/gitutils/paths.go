@@ -0,0 +1,120 @@
+package gitutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CleanRepoPath validates a repository-relative path and returns it in cleaned,
+// slash-separated form. Any character other than NUL is accepted: paths are only
+// ever passed to git as separate arguments, never through a shell, so safety is
+// enforced by requiring the path to stay inside the repository instead.
+func CleanRepoPath(filePath string) (string, error) {
+	if filePath == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	if strings.ContainsRune(filePath, 0) {
+		return "", fmt.Errorf("path contains a NUL byte: %q", filePath)
+	}
+
+	slashed := filepath.ToSlash(filePath)
+	if filepath.IsAbs(filePath) || strings.HasPrefix(slashed, "/") || strings.HasPrefix(filePath, `\`) {
+		return "", fmt.Errorf("absolute path not allowed: %s", filePath)
+	}
+
+	// Backslashes are separators on Windows, so a ".." between them is treated
+	// as traversal on every platform
+	for _, component := range strings.FieldsFunc(slashed, isPathSeparator) {
+		if component == ".." {
+			return "", fmt.Errorf("path escapes the repository: %s", filePath)
+		}
+	}
+
+	cleanPath := path.Clean(slashed)
+	if cleanPath == "." {
+		return "", fmt.Errorf("path does not name a file: %s", filePath)
+	}
+
+	first := strings.FieldsFunc(cleanPath, isPathSeparator)[0]
+	if strings.EqualFold(first, ".git") {
+		return "", fmt.Errorf("path inside the .git directory: %s", filePath)
+	}
+
+	return cleanPath, nil
+}
+
+// isPathSeparator reports whether r separates path components on any platform
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
+}
+
+// ResolveRepoPath joins a repository-relative path onto the repository root and
+// verifies that the result, with symbolic links resolved, is contained in the
+// repository. The path need not exist; its nearest existing ancestor is checked.
+func ResolveRepoPath(repoPath, filePath string) (string, error) {
+	cleanPath, err := CleanRepoPath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := filepath.Abs(repoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve repository path: %w", err)
+	}
+
+	fullPath := filepath.Join(root, filepath.FromSlash(cleanPath))
+
+	realPath, err := evalExistingPrefix(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", filePath, err)
+	}
+	if !isWithin(realRoot, realPath) {
+		return "", fmt.Errorf("path escapes the repository through a symbolic link: %s", filePath)
+	}
+
+	return fullPath, nil
+}
+
+// evalExistingPrefix resolves symbolic links in the longest existing prefix of
+// fullPath and re-attaches the components that do not exist yet
+func evalExistingPrefix(fullPath string) (string, error) {
+	existing := fullPath
+	var missing []string
+
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			for i := len(missing) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, missing[i])
+			}
+			return resolved, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return "", err
+		}
+		missing = append(missing, filepath.Base(existing))
+		existing = parent
+	}
+}
+
+// isWithin reports whether target is root or lies below it
+func isWithin(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
@@ -0,0 +1,114 @@
+package gitutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanRepoPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{path: "src/main.go", expected: "src/main.go"},
+		{path: "docs/Guía de uso.md", expected: "docs/Guía de uso.md"},
+		{path: "src/My Component.tsx", expected: "src/My Component.tsx"},
+		{path: "file;rm -rf $HOME", expected: "file;rm -rf $HOME"},
+		{path: "-rf", expected: "-rf"},
+		{path: "a/./b//c.go", expected: "a/b/c.go"},
+		{path: "notes..txt", expected: "notes..txt"},
+		{path: "", wantErr: true},
+		{path: ".", wantErr: true},
+		{path: "../outside.go", wantErr: true},
+		{path: "a/../../outside.go", wantErr: true},
+		{path: "a/../b.go", wantErr: true},
+		{path: `..\..\windows\system32`, wantErr: true},
+		{path: "/etc/passwd", wantErr: true},
+		{path: ".git/config", wantErr: true},
+		{path: ".GIT/hooks/pre-commit", wantErr: true},
+		{path: "file\x00name", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cleanPath, err := CleanRepoPath(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("CleanRepoPath(%q) = %q, expected an error", tt.path, cleanPath)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CleanRepoPath(%q) unexpected error = %v", tt.path, err)
+			}
+			if cleanPath != tt.expected {
+				t.Errorf("CleanRepoPath(%q) = %q, expected %q", tt.path, cleanPath, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveRepoPath(t *testing.T) {
+	repoPath := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.Symlink(outside, filepath.Join(repoPath, "escape")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(repoPath, "inside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("inside", filepath.Join(repoPath, "alias")); err != nil {
+		t.Fatal(err)
+	}
+
+	fullPath, err := ResolveRepoPath(repoPath, "inside/new file.txt")
+	if err != nil {
+		t.Fatalf("ResolveRepoPath() unexpected error = %v", err)
+	}
+	if fullPath != filepath.Join(repoPath, "inside", "new file.txt") {
+		t.Errorf("ResolveRepoPath() = %q", fullPath)
+	}
+
+	if _, err := ResolveRepoPath(repoPath, "alias/missing/dir/file.txt"); err != nil {
+		t.Errorf("ResolveRepoPath() through an internal symlink unexpected error = %v", err)
+	}
+
+	for _, escaping := range []string{"escape/secret.txt", "escape/missing/secret.txt", "../secret.txt"} {
+		if _, err := ResolveRepoPath(repoPath, escaping); err == nil {
+			t.Errorf("ResolveRepoPath(%q) expected an error", escaping)
+		}
+	}
+}
+
+func TestParseStatusV2(t *testing.T) {
+	const zeros = "0000000000000000000000000000000000000000"
+	output := "1 .M N... 100644 100644 100644 " + zeros + " " + zeros + " clean.go\x00" +
+		"2 R. N... 100644 100644 100644 " + zeros + " " + zeros + " R100 new name.go\x00old name.go\x00" +
+		"u UU N... 100644 100644 100644 100644 " + zeros + " " + zeros + " " + zeros + " docs/Guía de uso.md\x00" +
+		"u DU N... 100644 000000 100644 100644 " + zeros + " " + zeros + " " + zeros + " src/My Component.tsx\x00"
+
+	conflicts, err := parseStatusV2([]byte(output))
+	if err != nil {
+		t.Fatalf("parseStatusV2() error = %v", err)
+	}
+
+	expected := []ConflictStatus{
+		{FilePath: "docs/Guía de uso.md", Status: "UU"},
+		{FilePath: "src/My Component.tsx", Status: "DU"},
+	}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("parseStatusV2() = %+v, expected %+v", conflicts, expected)
+	}
+	for i := range expected {
+		if conflicts[i] != expected[i] {
+			t.Errorf("conflict %d = %+v, expected %+v", i, conflicts[i], expected[i])
+		}
+	}
+
+	if _, err := parseStatusV2([]byte("u UU truncated\x00")); err == nil {
+		t.Error("parseStatusV2() expected an error for a malformed record")
+	}
+}
@@ -0,0 +1,232 @@
+package gitutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MergePreview describes the result of merging two commits in memory with git
+// merge-tree. Neither the working tree nor the index is touched.
+type MergePreview struct {
+	Ours   string `json:"ours"`
+	Theirs string `json:"theirs"`
+	// Tree is the merged tree; conflicted files in it contain conflict markers
+	Tree  string `json:"tree"`
+	Clean bool   `json:"clean"`
+}
+
+// PreviewMerge merges theirs into ours with git merge-tree --write-tree and
+// returns the resulting tree along with the unmerged entries it would leave in
+// the index
+func PreviewMerge(repoPath, ours, theirs string) (*MergePreview, []UnmergedEntry, error) {
+	oursCommit, err := ResolveCommit(repoPath, ours)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirsCommit, err := ResolveCommit(repoPath, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The ref names are passed on so conflict markers are labelled as in a
+	// merge; both have been verified as commits above
+	// #nosec G204 - ours and theirs cannot be taken for options
+	cmd := exec.Command("git", "merge-tree", "--write-tree", "-z", "--no-messages", ours, theirs)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	clean := err == nil
+	if err != nil {
+		// Exit status 1 reports a conflicted merge, anything else a failure
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, nil, fmt.Errorf("git merge-tree failed (git 2.38 or later is required): %w", err)
+		}
+	}
+
+	tree, entries, err := parseMergeTreeOutput(output)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &MergePreview{
+		Ours:   oursCommit,
+		Theirs: theirsCommit,
+		Tree:   tree,
+		Clean:  clean,
+	}, entries, nil
+}
+
+// parseMergeTreeOutput parses git merge-tree --write-tree -z output: the tree id
+// followed by "<mode> <object> <stage>\t<path>" records for conflicted files
+func parseMergeTreeOutput(output []byte) (string, []UnmergedEntry, error) {
+	tree, rest, _ := bytes.Cut(output, []byte{0})
+	treeID := strings.TrimSpace(string(tree))
+	if !objectIDPattern.MatchString(treeID) {
+		return "", nil, fmt.Errorf("unexpected merge-tree output: %q", tree)
+	}
+
+	// An empty record ends the conflicted file information
+	if end := bytes.Index(rest, []byte{0, 0}); end >= 0 {
+		rest = rest[:end+1]
+	}
+
+	entries, err := parseUnmergedEntries(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	return treeID, entries, nil
+}
+
+// GetPreviewConflictReport builds the conflict report a merge of theirs into
+// ours would produce, without modifying the working tree or index. Hunks are
+// computed from the stages merge-tree reports and located in the merged tree.
+func GetPreviewConflictReport(repoPath, ours, theirs string) (*MergePreview, *ConflictReport, error) {
+	preview, entries, err := PreviewMerge(repoPath, ours, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &ConflictReport{
+		RepoPath:       repoPath,
+		TotalConflicts: len(entries),
+	}
+
+	for _, entry := range entries {
+		status := ConflictStatus{FilePath: entry.Path, Status: statusForStages(entry)}
+
+		fileConflict, err := buildFileConflict(status, func(stage int) ([]byte, error) {
+			stageEntry, exists := entry.Stages[stage]
+			if !exists {
+				return nil, fmt.Errorf("no stage %d for %s", stage, entry.Path)
+			}
+			return readBlob(repoPath, stageEntry.Object)
+		})
+		// Report the file as detection does for conflicts it cannot analyse
+		var problems []string
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to classify the conflict: %v", err))
+		}
+
+		encoding, err := previewEncoding(repoPath, entry)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("failed to detect the encoding: %v", err))
+		}
+
+		// Deleted paths are absent from the merged tree and have no content
+		merged, _ := readTreeFile(repoPath, preview.Tree, entry.Path)
+
+		hunks, err := previewConflictHunks(repoPath, entry, merged)
+		if err != nil && fileConflict == nil {
+			problems = append(problems, fmt.Sprintf("failed to read the conflict hunks: %v", err))
+		}
+
+		var context []string
+		if merged != nil {
+			context = strings.Split(string(merged), "\n")
+		}
+
+		if fileConflict != nil {
+			report.TotalFileConflicts++
+		}
+		report.ConflictedFiles = append(report.ConflictedFiles, ConflictFile{
+			Path:         entry.Path,
+			Encoding:     encoding,
+			Hunks:        hunks,
+			Context:      context,
+			FileConflict: fileConflict,
+			Error:        strings.Join(problems, "; "),
+		})
+	}
+
+	return preview, report, nil
+}
+
+// previewEncoding detects the encoding a conflicted file would have in the
+// working tree from the blob of our version, or of their or the base version
+// when ours is missing
+func previewEncoding(repoPath string, entry UnmergedEntry) (*FileEncoding, error) {
+	for _, stage := range []int{StageOurs, StageTheirs, StageBase} {
+		stageEntry, exists := entry.Stages[stage]
+		if !exists {
+			continue
+		}
+		content, err := readBlob(repoPath, stageEntry.Object)
+		if err != nil {
+			return nil, err
+		}
+		encoding := DetectFileEncoding(repoPath, entry.Path, content)
+		return &encoding, nil
+	}
+	return nil, nil
+}
+
+// previewConflictHunks computes the hunks of an unmerged entry with a three-way
+// merge of its stages and locates them in the merged file content
+func previewConflictHunks(repoPath string, entry UnmergedEntry, merged []byte) ([]ConflictHunk, error) {
+	versions, err := ReadStageVersions(repoPath, entry)
+	if err != nil {
+		return nil, err
+	}
+	if versions.Binary {
+		return nil, fmt.Errorf("cannot compute hunks for binary file %s", entry.Path)
+	}
+	if !versions.HasOurs || !versions.HasTheirs {
+		return nil, nil
+	}
+
+	regions := Merge3(versions.BaseLines, versions.OursLines, versions.TheirsLines)
+
+	markerHunks, _ := parseConflictMarkersWithSize(string(merged), GetConflictMarkerSize(repoPath, entry.Path))
+	if len(markerHunks) == 0 {
+		return renderedConflictHunks(regions), nil
+	}
+	return locateStageHunks(regions, markerHunks), nil
+}
+
+// statusForStages derives the two-letter porcelain status git status would show
+// for an unmerged entry from the stages it has
+func statusForStages(entry UnmergedEntry) string {
+	base, ours, theirs := entry.HasStage(StageBase), entry.HasStage(StageOurs), entry.HasStage(StageTheirs)
+
+	switch {
+	case ours && theirs && base:
+		return "UU"
+	case ours && theirs:
+		return "AA"
+	case base && ours:
+		return "UD"
+	case base && theirs:
+		return "DU"
+	case ours:
+		return "AU"
+	case theirs:
+		return "UA"
+	default:
+		return "DD"
+	}
+}
+
+// readTreeFile reads a file from a tree object
+func readTreeFile(repoPath, tree, filePath string) ([]byte, error) {
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !objectIDPattern.MatchString(tree) {
+		return nil, fmt.Errorf("invalid tree id: %s", tree)
+	}
+
+	// #nosec G204 - tree is an object id and cleanPath is validated above
+	cmd := exec.Command("git", "cat-file", "blob", tree+":"+cleanPath)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from tree %s: %w", filePath, tree, err)
+	}
+	return output, nil
+}
@@ -0,0 +1,127 @@
+package gitutils
+
+import (
+	"testing"
+)
+
+func TestGetPreviewConflictReport(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	head := runGit(t, repoPath, "rev-parse", "HEAD")
+
+	preview, report, err := GetPreviewConflictReport(repoPath, "main", "feature")
+	if err != nil {
+		t.Fatalf("GetPreviewConflictReport() error = %v", err)
+	}
+	if preview.Clean {
+		t.Fatal("expected the preview to conflict")
+	}
+
+	if len(report.ConflictedFiles) != 1 || report.ConflictedFiles[0].Path != "conflict.txt" {
+		t.Fatalf("unexpected conflicted files %+v", report.ConflictedFiles)
+	}
+	file := report.ConflictedFiles[0]
+	if file.FileConflict != nil {
+		t.Errorf("unexpected file-level conflict %+v", file.FileConflict)
+	}
+	if file.Encoding == nil || file.Encoding.Name != EncodingUTF8 || file.Error != "" {
+		t.Errorf("encoding = %+v, error = %q", file.Encoding, file.Error)
+	}
+	if len(file.Hunks) != 1 {
+		t.Fatalf("expected one hunk, got %+v", file.Hunks)
+	}
+	hunk := file.Hunks[0]
+	if !equalLines(hunk.OursLines, []string{"main"}) || !equalLines(hunk.TheirsLines, []string{"feature"}) ||
+		!equalLines(hunk.BaseLines, []string{"base"}) {
+		t.Errorf("unexpected hunk %+v", hunk)
+	}
+	if hunk.OursLabel != "main" || hunk.TheirsLabel != "feature" {
+		t.Errorf("hunk labels = %q/%q, expected main/feature", hunk.OursLabel, hunk.TheirsLabel)
+	}
+
+	// The working tree, index and HEAD must be left alone
+	if status := runGit(t, repoPath, "status", "--porcelain"); status != "" {
+		t.Errorf("preview modified the working tree: %q", status)
+	}
+	if after := runGit(t, repoPath, "rev-parse", "HEAD"); after != head {
+		t.Errorf("HEAD moved from %s to %s", head, after)
+	}
+}
+
+func TestGetPreviewConflictReport_FileLevelAndClean(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	runGit(t, repoPath, "checkout", "-q", "-b", "removal", "main~1")
+	runGit(t, repoPath, "rm", "-q", "conflict.txt")
+	runGit(t, repoPath, "commit", "-q", "-m", "remove conflict.txt")
+
+	_, report, err := GetPreviewConflictReport(repoPath, "removal", "main")
+	if err != nil {
+		t.Fatalf("GetPreviewConflictReport() error = %v", err)
+	}
+	if len(report.ConflictedFiles) != 1 || report.TotalFileConflicts != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	fileConflict := report.ConflictedFiles[0].FileConflict
+	if fileConflict == nil || fileConflict.Kind != ConflictKindDeletedByUs {
+		t.Fatalf("unexpected file conflict %+v", fileConflict)
+	}
+	if !equalLines(fileConflict.SurvivingContent, []string{"main"}) {
+		t.Errorf("surviving content = %q", fileConflict.SurvivingContent)
+	}
+
+	preview, report, err := GetPreviewConflictReport(repoPath, "feature", "feature~1")
+	if err != nil {
+		t.Fatalf("GetPreviewConflictReport() error = %v", err)
+	}
+	if !preview.Clean || len(report.ConflictedFiles) != 0 {
+		t.Errorf("expected a clean preview, got %+v", report)
+	}
+
+	if _, _, err := GetPreviewConflictReport(repoPath, "--output=x", "main"); err == nil {
+		t.Error("expected an option-like ref to be rejected")
+	}
+}
+
+func TestGetPreviewConflictReport_EncodingAndUnreadableFiles(t *testing.T) {
+	utf16 := func(text string) string {
+		encoded := []byte{0xFF, 0xFE}
+		for _, r := range text {
+			encoded = append(encoded, byte(r), 0)
+		}
+		return string(encoded)
+	}
+	repoPath := newTestRepo(t, map[string]string{
+		"notes.txt": utf16("base\n"),
+		"image.bin": "\x00\x01base",
+	})
+
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "notes.txt", utf16("feature\n"))
+	writeRepoFile(t, repoPath, "image.bin", "\x00\x01feature")
+	runGit(t, repoPath, "commit", "-q", "-am", "feature")
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "notes.txt", utf16("main\n"))
+	writeRepoFile(t, repoPath, "image.bin", "\x00\x01main")
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+
+	_, report, err := GetPreviewConflictReport(repoPath, "main", "feature")
+	if err != nil {
+		t.Fatalf("GetPreviewConflictReport() error = %v", err)
+	}
+	files := make(map[string]ConflictFile)
+	for _, file := range report.ConflictedFiles {
+		files[file.Path] = file
+	}
+
+	notes, ok := files["notes.txt"]
+	if !ok || notes.Encoding == nil || notes.Encoding.Name != EncodingUTF16LE || len(notes.Hunks) != 1 {
+		t.Errorf("notes.txt = %+v, expected its hunk and the UTF-16LE encoding of the stage blobs", notes)
+	}
+	// The hunks of a binary file cannot be computed, which the report says
+	image, ok := files["image.bin"]
+	if !ok {
+		t.Fatalf("image.bin was dropped from the preview: %+v", report.ConflictedFiles)
+	}
+	if image.Error == "" {
+		t.Error("expected the preview to say why the hunks of image.bin are missing")
+	}
+}
@@ -0,0 +1,159 @@
+package gitutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// rerereIDPattern matches the conflict ids git rerere uses as rr-cache directory names
+var rerereIDPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// RerereImportResult describes an import of git rerere records
+type RerereImportResult struct {
+	Records  int `json:"records"`
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// ImportRerere imports the recorded resolutions of git rerere from rr-cache into
+// the memory. Each record holds a preimage with conflict markers and the
+// postimage it was resolved to; the resolution of every hunk is recovered by
+// aligning the context around the hunks. Records without a postimage, records
+// that cannot be aligned and records imported before are skipped.
+func (m *ResolutionMemory) ImportRerere(repoPath string) (*RerereImportResult, error) {
+	result := &RerereImportResult{}
+
+	cacheDir, err := GetGitPath(repoPath, "rr-cache")
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rr-cache: %w", err)
+	}
+
+	imported := make(map[string]bool)
+	for _, id := range m.ImportedRerere {
+		imported[id] = true
+	}
+
+	for _, dirEntry := range dirEntries {
+		id := dirEntry.Name()
+		if !dirEntry.IsDir() || !rerereIDPattern.MatchString(id) {
+			continue
+		}
+		result.Records++
+
+		if imported[id] {
+			result.Skipped++
+			continue
+		}
+
+		preimage, preErr := os.ReadFile(filepath.Join(cacheDir, id, "preimage"))    // #nosec G304 - path is inside rr-cache
+		postimage, postErr := os.ReadFile(filepath.Join(cacheDir, id, "postimage")) // #nosec G304 - path is inside rr-cache
+		if preErr != nil || postErr != nil {
+			// Conflicts rerere saw but that were never resolved have no postimage
+			result.Skipped++
+			continue
+		}
+
+		hunks, resolved, ok := alignRerereImages(string(preimage), string(postimage))
+		if !ok {
+			result.Skipped++
+			continue
+		}
+
+		for i, hunk := range hunks {
+			hunk.BaseLines = nil
+			if len(resolved[i]) == 0 {
+				continue
+			}
+			m.Record("", hunk, resolved[i], 1.0, MemorySourceRerere)
+		}
+		m.ImportedRerere = append(m.ImportedRerere, id)
+		imported[id] = true
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// alignRerereImages returns the hunks of a rerere preimage and the lines of the
+// postimage each of them was resolved to. The lines between hunks must appear
+// unchanged in the postimage.
+func alignRerereImages(preimage, postimage string) ([]ConflictHunk, [][]string, bool) {
+	hunks, err := parseConflictMarkers(preimage)
+	if err != nil || len(hunks) == 0 {
+		return nil, nil, false
+	}
+
+	preLines := splitContentLines(preimage)
+	postLines := splitContentLines(postimage)
+
+	// Context segments around the hunks: before the first, between each pair
+	// and after the last
+	contexts := make([][]string, 0, len(hunks)+1)
+	previousEnd := 0
+	for _, hunk := range hunks {
+		contexts = append(contexts, preLines[previousEnd:hunk.StartLine-1])
+		previousEnd = hunk.EndLine
+	}
+	contexts = append(contexts, preLines[previousEnd:])
+
+	trailing := contexts[len(contexts)-1]
+	if !hasLinesAt(postLines, 0, contexts[0]) || len(postLines) < len(contexts[0])+len(trailing) ||
+		!hasLinesAt(postLines, len(postLines)-len(trailing), trailing) {
+		return nil, nil, false
+	}
+
+	resolved := make([][]string, len(hunks))
+	position := len(contexts[0])
+	limit := len(postLines) - len(trailing)
+	for i := range hunks {
+		next := contexts[i+1]
+		if i == len(hunks)-1 {
+			if position > limit {
+				return nil, nil, false
+			}
+			resolved[i] = postLines[position:limit]
+			break
+		}
+
+		end := -1
+		for candidate := position; candidate+len(next) <= limit; candidate++ {
+			if len(next) > 0 && hasLinesAt(postLines, candidate, next) {
+				end = candidate
+				break
+			}
+		}
+		if end < 0 {
+			return nil, nil, false
+		}
+		resolved[i] = postLines[position:end]
+		position = end + len(next)
+	}
+
+	for _, lines := range resolved {
+		for _, line := range lines {
+			if containsConflictMarker(line) {
+				return nil, nil, false
+			}
+		}
+	}
+
+	return hunks, resolved, true
+}
+
+// hasLinesAt reports whether lines occur in content at the given position
+func hasLinesAt(content []string, position int, lines []string) bool {
+	if position < 0 || position+len(lines) > len(content) {
+		return false
+	}
+	return equalLines(content[position:position+len(lines)], lines)
+}
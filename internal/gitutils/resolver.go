@@ -2,18 +2,74 @@ package gitutils
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
 // ConflictResolution represents a resolved merge conflict
 type ConflictResolution struct {
 	FilePath      string   `json:"file_path"`
+	HunkID        string   `json:"hunk_id,omitempty"` // ID of the resolved hunk, see HunkID
 	StartLine     int      `json:"start_line"`
 	EndLine       int      `json:"end_line"`
 	ResolvedLines []string `json:"resolved_lines"`
 	Confidence    float64  `json:"confidence"`
 	Reasoning     string   `json:"reasoning,omitempty"`
+	Reused        bool     `json:"reused,omitempty"`   // Taken from the resolution memory
+	Strategy      string   `json:"strategy,omitempty"` // Deterministic strategy that made it, see package strategy
+}
+
+// File resolution actions for file-level conflicts
+const (
+	// FileActionKeep keeps the file, using ResolvedLines when provided or the
+	// current working tree content otherwise
+	FileActionKeep = "keep"
+	// FileActionDelete removes the file from the working tree
+	FileActionDelete = "delete"
+	// FileActionMerge writes ResolvedLines as the new file content
+	FileActionMerge = "merge"
+)
+
+// FileResolution represents a resolved file-level conflict such as modify/delete
+type FileResolution struct {
+	FilePath      string   `json:"file_path"`
+	Action        string   `json:"action"`
+	ResolvedLines []string `json:"resolved_lines,omitempty"`
+	Confidence    float64  `json:"confidence"`
+	Reasoning     string   `json:"reasoning,omitempty"`
+	Strategy      string   `json:"strategy,omitempty"` // Structured format the file was merged in, or regenerate
+}
+
+// ValidateFileResolution validates that a file-level resolution is well-formed
+func ValidateFileResolution(resolution FileResolution) error {
+	if resolution.FilePath == "" {
+		return fmt.Errorf("file path cannot be empty")
+	}
+
+	switch resolution.Action {
+	case FileActionKeep, FileActionDelete:
+	case FileActionMerge:
+		if len(resolution.ResolvedLines) == 0 {
+			return fmt.Errorf("merge action requires resolved lines")
+		}
+	default:
+		return fmt.Errorf("unknown file action %q (expected keep, delete or merge)", resolution.Action)
+	}
+
+	if resolution.Confidence < 0.0 || resolution.Confidence > 1.0 {
+		return fmt.Errorf("confidence must be between 0.0 and 1.0, got %f", resolution.Confidence)
+	}
+
+	for i, line := range resolution.ResolvedLines {
+		if containsConflictMarker(line) {
+			return fmt.Errorf("resolved line %d contains conflict marker: %s", i+1, line)
+		}
+	}
+
+	return nil
 }
 
 // ValidateResolution validates that a conflict resolution is well-formed
@@ -30,8 +86,9 @@ func ValidateResolution(resolution ConflictResolution) error {
 		return fmt.Errorf("end line (%d) must be >= start line (%d)", resolution.EndLine, resolution.StartLine)
 	}
 
-	if len(resolution.ResolvedLines) == 0 {
-		return fmt.Errorf("resolved lines cannot be empty")
+	// An empty resolution removes the hunk, as when one side deleted the lines
+	if resolution.ResolvedLines == nil {
+		return fmt.Errorf("resolved lines are missing")
 	}
 
 	if resolution.Confidence < 0.0 || resolution.Confidence > 1.0 {
@@ -67,63 +124,142 @@ func containsConflictMarker(line string) bool {
 	return false
 }
 
-// ApplyResolution applies a conflict resolution to the original file content
-func ApplyResolution(originalContent string, resolution ConflictResolution) (string, error) {
-	if err := ValidateResolution(resolution); err != nil {
-		return "", fmt.Errorf("invalid resolution: %w", err)
+// HunkMismatchError reports a resolution whose conflict hunk is no longer found
+// in the file it is applied to, e.g. because the file changed after the
+// conflicts were read
+type HunkMismatchError struct {
+	FilePath  string
+	HunkID    string
+	StartLine int
+	EndLine   int
+}
+
+func (e *HunkMismatchError) Error() string {
+	if e.HunkID != "" {
+		return fmt.Sprintf("conflict hunk %s (lines %d-%d) no longer matches %s", e.HunkID, e.StartLine, e.EndLine, e.FilePath)
 	}
+	return fmt.Sprintf("lines %d-%d of %s are not a conflict hunk", e.StartLine, e.EndLine, e.FilePath)
+}
+
+// AnchorResolutions locates the conflict hunk of every resolution in content.
+// Resolutions with a HunkID are matched by the content of the marker block and
+// get the block's current line numbers; resolutions without one must cover a
+// whole marker block exactly. Resolutions whose hunk is not found are returned
+// as mismatches instead of being applied to whatever the lines now hold.
+func AnchorResolutions(content string, resolutions []ConflictResolution) ([]ConflictResolution, []*HunkMismatchError) {
+	hunks := findConflictBlocks(content)
 
-	lines := strings.Split(originalContent, "\n")
-	if resolution.EndLine > len(lines) {
-		return "", fmt.Errorf("end line %d exceeds file length %d", resolution.EndLine, len(lines))
+	anchored := make([]ConflictResolution, 0, len(resolutions))
+	var mismatches []*HunkMismatchError
+	for _, resolution := range resolutions {
+		hunk, found := matchResolutionHunk(hunks, resolution)
+		if !found {
+			mismatches = append(mismatches, &HunkMismatchError{
+				FilePath:  resolution.FilePath,
+				HunkID:    resolution.HunkID,
+				StartLine: resolution.StartLine,
+				EndLine:   resolution.EndLine,
+			})
+			continue
+		}
+
+		resolution.HunkID = hunk.ID
+		resolution.StartLine = hunk.StartLine
+		resolution.EndLine = hunk.EndLine
+		anchored = append(anchored, resolution)
 	}
 
-	// Replace the lines from StartLine to EndLine with resolved lines
-	// Note: Line numbers are 1-based, but slice indices are 0-based
-	startIdx := resolution.StartLine - 1
-	endIdx := resolution.EndLine
+	return anchored, mismatches
+}
+
+// matchResolutionHunk returns the hunk a resolution refers to
+func matchResolutionHunk(hunks []ConflictHunk, resolution ConflictResolution) (ConflictHunk, bool) {
+	for _, hunk := range hunks {
+		if resolution.HunkID != "" {
+			if hunk.ID == resolution.HunkID {
+				return hunk, true
+			}
+			continue
+		}
+		if hunk.StartLine == resolution.StartLine && hunk.EndLine == resolution.EndLine {
+			return hunk, true
+		}
+	}
+	return ConflictHunk{}, false
+}
+
+// findConflictBlocks parses the conflict blocks of content for every marker
+// size it uses, so blocks written with a conflict-marker-size attribute are
+// found without knowing the attributes of the file
+func findConflictBlocks(content string) []ConflictHunk {
+	sizes := map[int]bool{DefaultMarkerSize: true}
+	for _, line := range strings.Split(content, "\n") {
+		size := len(line) - len(strings.TrimLeft(line, "<"))
+		if size > DefaultMarkerSize {
+			sizes[size] = true
+		}
+	}
 
-	var newLines []string
-	newLines = append(newLines, lines[:startIdx]...)
-	newLines = append(newLines, resolution.ResolvedLines...)
-	if endIdx < len(lines) {
-		newLines = append(newLines, lines[endIdx:]...)
+	var hunks []ConflictHunk
+	for size := range sizes {
+		sized, _ := parseConflictMarkersWithSize(content, size)
+		hunks = append(hunks, sized...)
 	}
+	return hunks
+}
 
-	return strings.Join(newLines, "\n"), nil
+// ApplyResolution applies a conflict resolution to the original file content.
+// The resolution is anchored to its conflict hunk first; a *HunkMismatchError
+// is returned when the hunk is no longer in the content.
+func ApplyResolution(originalContent string, resolution ConflictResolution) (string, error) {
+	return ApplyMultipleResolutions(originalContent, []ConflictResolution{resolution})
 }
 
-// ApplyMultipleResolutions applies multiple conflict resolutions to file content
+// ApplyMultipleResolutions applies multiple conflict resolutions to file
+// content. Every resolution is anchored to its conflict hunk; resolutions whose
+// hunk no longer matches and resolutions with overlapping ranges are rejected
+// and nothing is applied. The line endings, byte order mark and final newline
+// of the content are kept, and resolved lines get the line ending of the file.
 func ApplyMultipleResolutions(originalContent string, resolutions []ConflictResolution) (string, error) {
 	if len(resolutions) == 0 {
 		return originalContent, nil
 	}
 
-	// Sort resolutions by line number (highest first) to avoid line number shifts
-	sortedResolutions := make([]ConflictResolution, len(resolutions))
-	copy(sortedResolutions, resolutions)
+	file := ParseTextFile([]byte(originalContent))
 
-	// Simple bubble sort by StartLine (descending)
-	for i := 0; i < len(sortedResolutions)-1; i++ {
-		for j := 0; j < len(sortedResolutions)-1-i; j++ {
-			if sortedResolutions[j].StartLine < sortedResolutions[j+1].StartLine {
-				sortedResolutions[j], sortedResolutions[j+1] = sortedResolutions[j+1], sortedResolutions[j]
-			}
-		}
+	anchored, mismatches := AnchorResolutions(file.Text(), resolutions)
+	if len(mismatches) > 0 {
+		return "", mismatches[0]
 	}
 
-	// Apply each resolution
-	result := originalContent
-	for _, resolution := range sortedResolutions {
-		var err error
-		result, err = ApplyResolution(result, resolution)
-		if err != nil {
-			return "", fmt.Errorf("failed to apply resolution for %s:%d-%d: %w",
+	for _, resolution := range anchored {
+		if err := ValidateResolution(resolution); err != nil {
+			return "", fmt.Errorf("invalid resolution for %s:%d-%d: %w",
 				resolution.FilePath, resolution.StartLine, resolution.EndLine, err)
 		}
 	}
 
-	return result, nil
+	// Apply from the bottom of the file up so earlier line numbers stay valid
+	sort.Slice(anchored, func(i, j int) bool {
+		return anchored[i].StartLine > anchored[j].StartLine
+	})
+	for i := 1; i < len(anchored); i++ {
+		if anchored[i].EndLine >= anchored[i-1].StartLine {
+			return "", fmt.Errorf("resolutions for %s overlap: lines %d-%d and %d-%d",
+				anchored[i].FilePath, anchored[i].StartLine, anchored[i].EndLine,
+				anchored[i-1].StartLine, anchored[i-1].EndLine)
+		}
+	}
+
+	// A suggestion block left after a conflict is replaced along with it
+	for _, resolution := range anchored {
+		end := suggestionBlockEnd(file.Lines, resolution.EndLine)
+		if err := file.ReplaceLines(resolution.StartLine, end, resolution.ResolvedLines); err != nil {
+			return "", fmt.Errorf("failed to apply resolution for %s: %w", resolution.FilePath, err)
+		}
+	}
+
+	return string(file.Bytes()), nil
 }
 
 // CalculateResolutionStats calculates statistics for a set of resolutions
@@ -189,19 +325,197 @@ type ResolutionResult struct {
 	AppliedCount  int                 `json:"applied_count"`
 	FailedCount   int                 `json:"failed_count"`
 	ModifiedFiles []string            `json:"modified_files"`
+	DeletedFiles  []string            `json:"deleted_files,omitempty"`
 	FailedFiles   []ResolutionFailure `json:"failed_files,omitempty"`
 	Errors        []string            `json:"errors,omitempty"`
 	Stats         ResolutionStats     `json:"stats"`
+	// Transaction is the outcome of an all-or-nothing apply
+	Transaction *TransactionResult `json:"transaction,omitempty"`
+	// Staging is the index outcome of every touched file, when resolved files
+	// were staged
+	Staging []FileStaging `json:"staging,omitempty"`
+}
+
+// FileStaging reports whether a file touched by an apply was added to the index
+type FileStaging struct {
+	FilePath string `json:"file_path"`
+	Staged   bool   `json:"staged"`
+	Reason   string `json:"reason,omitempty"` // Why the file was left unmerged
 }
 
 // ResolutionFailure represents a failed resolution application
 type ResolutionFailure struct {
 	FilePath     string `json:"file_path"`
+	HunkID       string `json:"hunk_id,omitempty"`
+	StartLine    int    `json:"start_line,omitempty"`
+	EndLine      int    `json:"end_line,omitempty"`
 	ErrorMessage string `json:"error_message"`
 }
 
-// ApplyResolutions applies multiple resolutions to their respective files
+// resolutionTarget is where resolutions are applied: the working tree, or the
+// staged contents of a TransactionalWriter
+type resolutionTarget interface {
+	readFile(filePath string) ([]byte, error)
+	writeFile(filePath string, content []byte) error
+	removeFile(filePath string) error
+	remember(filePath string, hunks []ConflictHunk, resolutions []ConflictResolution) error
+}
+
+// worktreeTarget writes resolutions straight to the working tree
+type worktreeTarget struct {
+	repoPath string
+}
+
+func (t worktreeTarget) readFile(filePath string) ([]byte, error) {
+	fullPath, err := ResolveRepoPath(t.repoPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+}
+
+func (t worktreeTarget) writeFile(filePath string, content []byte) error {
+	fullPath, err := ResolveRepoPath(t.repoPath, filePath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	// Existing files keep their permissions, e.g. the executable bit of scripts
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(fullPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(fullPath, content, mode); err != nil {
+		return err
+	}
+	return os.Chmod(fullPath, mode)
+}
+
+func (t worktreeTarget) removeFile(filePath string) error {
+	fullPath, err := ResolveRepoPath(t.repoPath, filePath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (t worktreeTarget) remember(filePath string, hunks []ConflictHunk, resolutions []ConflictResolution) error {
+	_, err := RecordAppliedResolutions(t.repoPath, filePath, hunks, resolutions)
+	return err
+}
+
+// ApplyResolutions applies multiple resolutions to their respective files and
+// records them in the resolution memory. Files are written one by one; use
+// ApplyResolutionsAtomically to write all of them or none.
 func ApplyResolutions(repoPath string, resolutions []ConflictResolution) (*ResolutionResult, error) {
+	return applyResolutions(repoPath, resolutions, worktreeTarget{repoPath: repoPath}), nil
+}
+
+// ApplyResolutionsAtomically applies resolutions to all of their files or to
+// none of them. Every file is staged first; if any resolution fails, nothing is
+// written. The staged files are then committed with a TransactionalWriter,
+// which restores every file when a write or the validate hook fails.
+func ApplyResolutionsAtomically(repoPath string, resolutions []ConflictResolution, validate TransactionValidator) (*ResolutionResult, error) {
+	writer := NewTransactionalWriter(repoPath, validate)
+	result := writer.StageResolutions(resolutions)
+	return CommitResolutions(writer, result)
+}
+
+// CommitResolutions commits the changes staged on a writer, unless result
+// reports a failure, and records the outcome of the transaction in result.
+// When the transaction is rolled back no resolution counts as applied.
+func CommitResolutions(writer *TransactionalWriter, result *ResolutionResult) (*ResolutionResult, error) {
+	if !result.Success {
+		result.Transaction = writer.Discard(fmt.Sprintf("%d files failed to resolve", result.FailedCount))
+		result.AppliedCount = 0
+		result.ModifiedFiles = []string{}
+		result.DeletedFiles = nil
+		return result, nil
+	}
+
+	transaction, err := writer.Commit()
+	if transaction == nil {
+		return result, err
+	}
+	result.Transaction = transaction
+	result.Errors = append(result.Errors, transaction.Warnings...)
+	result.Errors = append(result.Errors, transaction.RollbackErrors...)
+	if !transaction.Committed() {
+		result.Success = false
+		result.AppliedCount = 0
+		result.ModifiedFiles = []string{}
+		result.DeletedFiles = nil
+		result.Errors = append(result.Errors, err.Error())
+	}
+	return result, nil
+}
+
+// StageResolvedFiles adds the files of an apply to the index whose resolutions
+// all applied and that no longer contain conflict markers or suggestion
+// blocks. Partially resolved files are left unmerged. The outcome of every
+// file is recorded in result.Staging.
+func StageResolvedFiles(repoPath string, result *ResolutionResult) error {
+	failures := make(map[string]string)
+	for _, failure := range result.FailedFiles {
+		if _, exists := failures[failure.FilePath]; !exists {
+			failures[failure.FilePath] = failure.ErrorMessage
+		}
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, group := range [][]string{result.ModifiedFiles, result.DeletedFiles} {
+		for _, path := range group {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	for _, failure := range result.FailedFiles {
+		if !seen[failure.FilePath] {
+			seen[failure.FilePath] = true
+			paths = append(paths, failure.FilePath)
+		}
+	}
+
+	result.Staging = []FileStaging{}
+	var resolved []string
+	for _, path := range paths {
+		staging := FileStaging{FilePath: path}
+		if message, failed := failures[path]; failed {
+			staging.Reason = fmt.Sprintf("a resolution failed: %s", message)
+		} else if reason, err := UnresolvedReason(repoPath, path); err != nil {
+			staging.Reason = err.Error()
+		} else if reason != "" {
+			staging.Reason = reason
+		} else {
+			staging.Staged = true
+			resolved = append(resolved, path)
+		}
+		result.Staging = append(result.Staging, staging)
+	}
+
+	if err := StageFiles(repoPath, resolved); err != nil {
+		for i := range result.Staging {
+			if result.Staging[i].Staged {
+				result.Staging[i].Staged = false
+				result.Staging[i].Reason = "git add failed"
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// applyResolutions applies resolutions to the files of a target
+func applyResolutions(repoPath string, resolutions []ConflictResolution, target resolutionTarget) *ResolutionResult {
 	result := &ResolutionResult{
 		ModifiedFiles: make([]string, 0),
 		FailedFiles:   make([]ResolutionFailure, 0),
@@ -209,18 +523,34 @@ func ApplyResolutions(repoPath string, resolutions []ConflictResolution) (*Resol
 		Stats:         CalculateResolutionStats(resolutions),
 	}
 
+	// Applied resolutions are remembered in the resolution memory of a repository
+	remember := IsGitRepositoryPath(repoPath)
+
 	// Group resolutions by file path
 	fileResolutions := make(map[string][]ConflictResolution)
+	var filePaths []string
 	for _, resolution := range resolutions {
+		if _, exists := fileResolutions[resolution.FilePath]; !exists {
+			filePaths = append(filePaths, resolution.FilePath)
+		}
 		fileResolutions[resolution.FilePath] = append(fileResolutions[resolution.FilePath], resolution)
 	}
 
 	// Apply resolutions to each file
-	for filePath, fileResolutions := range fileResolutions {
-		fullPath := fmt.Sprintf("%s/%s", repoPath, filePath)
+	for _, filePath := range filePaths {
+		fileResolutions := fileResolutions[filePath]
+
+		if _, err := ResolveRepoPath(repoPath, filePath); err != nil {
+			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+				FilePath:     filePath,
+				ErrorMessage: fmt.Sprintf("invalid file path: %v", err),
+			})
+			result.FailedCount++
+			continue
+		}
 
 		// Read original file content
-		content, err := os.ReadFile(fullPath)
+		raw, err := target.readFile(filePath)
 		if err != nil {
 			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
 				FilePath:     filePath,
@@ -230,8 +560,43 @@ func ApplyResolutions(repoPath string, resolutions []ConflictResolution) (*Resol
 			continue
 		}
 
+		// Resolutions are UTF-8; files in other encodings are transcoded
+		content, encoding, err := decodeFileContent(repoPath, filePath, raw)
+		if err != nil {
+			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+				FilePath:     filePath,
+				ErrorMessage: fmt.Sprintf("failed to read file: %v", err),
+			})
+			result.FailedCount++
+			continue
+		}
+
+		// The conflicts are read before they are resolved, so the resolutions can
+		// be remembered for the hunks they resolved
+		var hunks []ConflictHunk
+		if remember {
+			hunks, _ = GetConflictHunks(filePath, repoPath)
+		}
+
+		// Resolutions are refused for the whole file when any of their hunks no
+		// longer matches, since the file changed after they were made
+		anchored, mismatches := AnchorResolutions(string(content), fileResolutions)
+		if len(mismatches) > 0 {
+			for _, mismatch := range mismatches {
+				result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+					FilePath:     filePath,
+					HunkID:       mismatch.HunkID,
+					StartLine:    mismatch.StartLine,
+					EndLine:      mismatch.EndLine,
+					ErrorMessage: mismatch.Error(),
+				})
+			}
+			result.FailedCount++
+			continue
+		}
+
 		// Apply resolutions to the file
-		modifiedContent, err := ApplyMultipleResolutions(string(content), fileResolutions)
+		modifiedContent, err := ApplyMultipleResolutions(string(content), anchored)
 		if err != nil {
 			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
 				FilePath:     filePath,
@@ -241,9 +606,18 @@ func ApplyResolutions(repoPath string, resolutions []ConflictResolution) (*Resol
 			continue
 		}
 
-		// Write modified content back to file
-		err = os.WriteFile(fullPath, []byte(modifiedContent), 0644)
+		encoded, err := EncodeFromUTF8([]byte(modifiedContent), encoding)
 		if err != nil {
+			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+				FilePath:     filePath,
+				ErrorMessage: fmt.Sprintf("failed to apply resolutions: %v", err),
+			})
+			result.FailedCount++
+			continue
+		}
+
+		// Write modified content back to file
+		if err := target.writeFile(filePath, encoded); err != nil {
 			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
 				FilePath:     filePath,
 				ErrorMessage: fmt.Sprintf("failed to write file: %v", err),
@@ -254,28 +628,107 @@ func ApplyResolutions(repoPath string, resolutions []ConflictResolution) (*Resol
 
 		result.ModifiedFiles = append(result.ModifiedFiles, filePath)
 		result.AppliedCount++
+
+		if remember {
+			if err := target.remember(filePath, hunks, anchored); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("failed to remember resolutions of %s: %v", filePath, err))
+			}
+		}
 	}
 
 	result.Success = result.FailedCount == 0
+	return result
+}
+
+// ApplyFileResolutions applies file-level resolutions (keep, delete or merge) to
+// the working tree and returns the outcome per file. The applied files are
+// staged: file-level conflicts have no markers to remove, so staging is the
+// only way to mark them resolved.
+func ApplyFileResolutions(repoPath string, resolutions []FileResolution) (*ResolutionResult, error) {
+	result := applyFileResolutions(repoPath, resolutions, worktreeTarget{repoPath: repoPath})
+	if err := StageFiles(repoPath, AppliedFiles(result)); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
 	return result, nil
 }
 
-// CreateBackup creates a backup of a file before modification
-func CreateBackup(repoPath, filePath string) error {
-	fullPath := fmt.Sprintf("%s/%s", repoPath, filePath)
-	backupPath := fullPath + ".backup"
+// AppliedFiles returns the files a resolution result modified or deleted
+func AppliedFiles(result *ResolutionResult) []string {
+	paths := make([]string, 0, len(result.ModifiedFiles)+len(result.DeletedFiles))
+	paths = append(paths, result.ModifiedFiles...)
+	return append(paths, result.DeletedFiles...)
+}
 
-	// Read original file
-	content, err := os.ReadFile(fullPath)
-	if err != nil {
-		return fmt.Errorf("failed to read original file: %w", err)
+// applyFileResolutions applies file-level resolutions to the files of a target
+func applyFileResolutions(repoPath string, resolutions []FileResolution, target resolutionTarget) *ResolutionResult {
+	result := &ResolutionResult{
+		ModifiedFiles: make([]string, 0),
+		FailedFiles:   make([]ResolutionFailure, 0),
+		Errors:        make([]string, 0),
 	}
 
-	// Write backup file
-	err = os.WriteFile(backupPath, content, 0644)
+	for _, resolution := range resolutions {
+		if err := applyFileResolution(repoPath, target, resolution, result); err != nil {
+			result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+				FilePath:     resolution.FilePath,
+				ErrorMessage: err.Error(),
+			})
+			result.FailedCount++
+			continue
+		}
+		result.AppliedCount++
+	}
+
+	result.Success = result.FailedCount == 0
+	return result
+}
+
+// applyFileResolution applies a single file-level resolution
+func applyFileResolution(repoPath string, target resolutionTarget, resolution FileResolution, result *ResolutionResult) error {
+	if err := ValidateFileResolution(resolution); err != nil {
+		return fmt.Errorf("invalid file resolution: %w", err)
+	}
+
+	if _, err := ResolveRepoPath(repoPath, resolution.FilePath); err != nil {
+		return fmt.Errorf("invalid file path: %w", err)
+	}
+
+	switch resolution.Action {
+	case FileActionDelete:
+		if err := target.removeFile(resolution.FilePath); err != nil {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		result.DeletedFiles = append(result.DeletedFiles, resolution.FilePath)
+		return nil
+	case FileActionKeep:
+		if len(resolution.ResolvedLines) == 0 {
+			if _, err := target.readFile(resolution.FilePath); err != nil {
+				return fmt.Errorf("cannot keep file without content: %w", err)
+			}
+			result.ModifiedFiles = append(result.ModifiedFiles, resolution.FilePath)
+			return nil
+		}
+	}
+
+	// A merged file keeps the conventions and encoding of the version in the
+	// working tree
+	file := &TextFile{LineEnding: LineEndingLF}
+	encoding := FileEncoding{Name: EncodingUTF8}
+	if existing, err := target.readFile(resolution.FilePath); err == nil {
+		if decoded, detected, err := decodeFileContent(repoPath, resolution.FilePath, existing); err == nil {
+			file, encoding = ParseTextFile(decoded), detected
+		}
+	}
+	file.SetLines(resolution.ResolvedLines)
+
+	content, err := EncodeFromUTF8(file.Bytes(), encoding)
 	if err != nil {
-		return fmt.Errorf("failed to write backup file: %w", err)
+		return err
+	}
+	if err := target.writeFile(resolution.FilePath, content); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	result.ModifiedFiles = append(result.ModifiedFiles, resolution.FilePath)
 	return nil
 }
@@ -0,0 +1,223 @@
+package gitutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const anchorTestContent = "a\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\nb\n<<<<<<< HEAD\nx\n=======\ny\n>>>>>>> topic\nc"
+
+func TestHunkIDs(t *testing.T) {
+	hunks, _ := parseConflictMarkers(anchorTestContent)
+	if len(hunks) != 2 || hunks[0].ID == "" || hunks[0].ID == hunks[1].ID {
+		t.Fatalf("unexpected hunks %+v", hunks)
+	}
+
+	// Lines added above a hunk and CRLF line endings do not change its ID
+	shifted, _ := parseConflictMarkers(strings.ReplaceAll("new\nlines\n"+anchorTestContent, "\n", "\r\n"))
+	if len(shifted) != 2 || shifted[0].ID != hunks[0].ID || shifted[0].StartLine != hunks[0].StartLine+2 {
+		t.Errorf("shifted hunks %+v, expected ID %s", shifted, hunks[0].ID)
+	}
+
+	// Identical blocks get an occurrence suffix
+	block := "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic"
+	duplicates, _ := parseConflictMarkers(block + "\n" + block)
+	if len(duplicates) != 2 || duplicates[1].ID != duplicates[0].ID+"-2" {
+		t.Errorf("duplicate hunks %+v", duplicates)
+	}
+}
+
+func TestApplyMultipleResolutions_AnchorsByHunkID(t *testing.T) {
+	hunks, _ := parseConflictMarkers(anchorTestContent)
+
+	// The file gained lines after the resolutions were made
+	content := "new line\n" + anchorTestContent
+	applied, err := ApplyMultipleResolutions(content, []ConflictResolution{
+		{FilePath: "f", HunkID: hunks[1].ID, StartLine: hunks[1].StartLine, EndLine: hunks[1].EndLine, ResolvedLines: []string{"xy"}, Confidence: 0.9},
+		{FilePath: "f", HunkID: hunks[0].ID, StartLine: hunks[0].StartLine, EndLine: hunks[0].EndLine, ResolvedLines: []string{"both"}, Confidence: 0.9},
+	})
+	if err != nil {
+		t.Fatalf("ApplyMultipleResolutions() error = %v", err)
+	}
+	if expected := "new line\na\nboth\nb\nxy\nc"; applied != expected {
+		t.Errorf("ApplyMultipleResolutions() = %q, expected %q", applied, expected)
+	}
+}
+
+func TestApplyMultipleResolutions_RefusesStaleHunks(t *testing.T) {
+	hunks, _ := parseConflictMarkers(anchorTestContent)
+	changed := strings.Replace(anchorTestContent, "theirs", "edited", 1)
+
+	_, err := ApplyMultipleResolutions(changed, []ConflictResolution{
+		{FilePath: "f", HunkID: hunks[0].ID, StartLine: 2, EndLine: 6, ResolvedLines: []string{"both"}, Confidence: 0.9},
+	})
+	var mismatch *HunkMismatchError
+	if !errors.As(err, &mismatch) || mismatch.HunkID != hunks[0].ID {
+		t.Errorf("expected a HunkMismatchError for %s, got %v", hunks[0].ID, err)
+	}
+
+	// Without a hunk ID the range has to cover a conflict block exactly
+	_, err = ApplyResolution(anchorTestContent, ConflictResolution{
+		FilePath: "f", StartLine: 3, EndLine: 6, ResolvedLines: []string{"both"}, Confidence: 0.9,
+	})
+	if !errors.As(err, &mismatch) {
+		t.Errorf("expected a HunkMismatchError for a partial range, got %v", err)
+	}
+}
+
+func TestApplyMultipleResolutions_RejectsOverlaps(t *testing.T) {
+	hunks, _ := parseConflictMarkers(anchorTestContent)
+
+	_, err := ApplyMultipleResolutions(anchorTestContent, []ConflictResolution{
+		{FilePath: "f", HunkID: hunks[0].ID, ResolvedLines: []string{"one"}, Confidence: 0.9},
+		{FilePath: "f", StartLine: hunks[0].StartLine, EndLine: hunks[0].EndLine, ResolvedLines: []string{"two"}, Confidence: 0.9},
+	})
+	if err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Errorf("expected overlapping resolutions to be rejected, got %v", err)
+	}
+}
+
+func TestApplyResolutions_ReportsStaleHunks(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	hunks, err := GetConflictHunks("conflict.txt", repoPath)
+	if err != nil || len(hunks) != 1 {
+		t.Fatalf("GetConflictHunks() = %+v, %v", hunks, err)
+	}
+	writeRepoFile(t, repoPath, "conflict.txt", "resolved by hand\n")
+
+	result, err := ApplyResolutions(repoPath, []ConflictResolution{{
+		FilePath:      "conflict.txt",
+		HunkID:        hunks[0].ID,
+		StartLine:     hunks[0].StartLine,
+		EndLine:       hunks[0].EndLine,
+		ResolvedLines: []string{"main and feature"},
+		Confidence:    0.9,
+	}})
+	if err != nil {
+		t.Fatalf("ApplyResolutions() error = %v", err)
+	}
+	if result.Success || result.FailedCount != 1 || len(result.FailedFiles) != 1 || result.FailedFiles[0].HunkID != hunks[0].ID {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	content, err := os.ReadFile(filepath.Join(repoPath, "conflict.txt"))
+	if err != nil || string(content) != "resolved by hand\n" {
+		t.Errorf("refused resolution changed the file: %q, %v", content, err)
+	}
+}
+
+func TestApplyMultipleResolutions_PreservesConventions(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "windows file with byte order mark",
+			content:  "\ufeff<<<<<<< HEAD\r\nours\r\n=======\r\ntheirs\r\n>>>>>>> topic\r\nend\r\n",
+			expected: "\ufeffboth\r\nsides\r\nend\r\n",
+		},
+		{
+			name:     "conflict at the end of a file without trailing newline",
+			content:  "start\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic",
+			expected: "start\nboth\nsides",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks, _ := parseConflictMarkers(tt.content)
+			if len(hunks) != 1 {
+				t.Fatalf("parseConflictMarkers() = %+v", hunks)
+			}
+
+			applied, err := ApplyResolution(tt.content, ConflictResolution{
+				FilePath:      "f",
+				HunkID:        hunks[0].ID,
+				ResolvedLines: []string{"both", "sides"},
+				Confidence:    0.9,
+			})
+			if err != nil {
+				t.Fatalf("ApplyResolution() error = %v", err)
+			}
+			if applied != tt.expected {
+				t.Errorf("ApplyResolution() = %q, expected %q", applied, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyResolutions_KeepsExecutableBit(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "build.sh", "#!/bin/sh\n<<<<<<< HEAD\nmake\n=======\nmake all\n>>>>>>> topic\n")
+	scriptPath := filepath.Join(repoPath, "build.sh")
+	if err := os.Chmod(scriptPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ApplyResolutions(repoPath, []ConflictResolution{{
+		FilePath: "build.sh", StartLine: 2, EndLine: 6, ResolvedLines: []string{"make all"}, Confidence: 0.9,
+	}})
+	if err != nil || !result.Success {
+		t.Fatalf("ApplyResolutions() = %+v, %v", result, err)
+	}
+
+	info, err := os.Stat(scriptPath)
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("script mode = %v, %v; expected 0755", info.Mode(), err)
+	}
+	if content := readTestFile(t, repoPath, "build.sh"); content != "#!/bin/sh\nmake all\n" {
+		t.Errorf("build.sh = %q", content)
+	}
+}
+
+func TestStageResolvedFiles(t *testing.T) {
+	repoPath := newTestRepo(t, map[string]string{
+		"full.txt":    "base\n",
+		"partial.txt": "1\n2\n3\n4\n5\n6\n7\n",
+	})
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	writeRepoFile(t, repoPath, "full.txt", "feature\n")
+	writeRepoFile(t, repoPath, "partial.txt", "f1\n2\n3\n4\n5\n6\nf7\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "feature")
+	runGit(t, repoPath, "checkout", "-q", "main")
+	writeRepoFile(t, repoPath, "full.txt", "main\n")
+	writeRepoFile(t, repoPath, "partial.txt", "m1\n2\n3\n4\n5\n6\nm7\n")
+	runGit(t, repoPath, "commit", "-q", "-am", "main")
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	fullHunks, _ := GetConflictHunks("full.txt", repoPath)
+	partialHunks, _ := GetConflictHunks("partial.txt", repoPath)
+	if len(fullHunks) != 1 || len(partialHunks) != 2 {
+		t.Fatalf("unexpected hunks %+v %+v", fullHunks, partialHunks)
+	}
+
+	// Only the first of the two conflicts of partial.txt is resolved
+	result, err := ApplyResolutions(repoPath, []ConflictResolution{
+		{FilePath: "full.txt", HunkID: fullHunks[0].ID, ResolvedLines: []string{"merged"}, Confidence: 0.9},
+		{FilePath: "partial.txt", HunkID: partialHunks[0].ID, ResolvedLines: []string{"1"}, Confidence: 0.9},
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("ApplyResolutions() = %+v, %v", result, err)
+	}
+
+	if err := StageResolvedFiles(repoPath, result); err != nil {
+		t.Fatalf("StageResolvedFiles() error = %v", err)
+	}
+	expected := []FileStaging{
+		{FilePath: "full.txt", Staged: true},
+		{FilePath: "partial.txt", Reason: "conflict markers remain"},
+	}
+	if len(result.Staging) != len(expected) || result.Staging[0] != expected[0] || result.Staging[1] != expected[1] {
+		t.Errorf("Staging = %+v, expected %+v", result.Staging, expected)
+	}
+
+	unmerged, err := ListUnmergedEntries(repoPath)
+	if err != nil || len(unmerged) != 1 || unmerged[0].Path != "partial.txt" {
+		t.Errorf("unmerged entries = %+v, %v; expected only partial.txt", unmerged, err)
+	}
+}
@@ -0,0 +1,181 @@
+package gitutils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Review decisions on a conflict hunk
+const (
+	// ReviewAccept applies the proposed resolution
+	ReviewAccept = "accept"
+	// ReviewReject leaves the conflict markers in place
+	ReviewReject = "reject"
+	// ReviewOurs resolves the hunk to our side
+	ReviewOurs = "ours"
+	// ReviewTheirs resolves the hunk to their side
+	ReviewTheirs = "theirs"
+	// ReviewEdit resolves the hunk to lines written by the reviewer
+	ReviewEdit = "edit"
+)
+
+// reviewFileName is the review session file inside <gitdir>/syncwright
+const reviewFileName = "review.json"
+
+// ReviewDecision is a decision taken on a conflict hunk during a review
+type ReviewDecision struct {
+	FilePath      string    `json:"file_path"`
+	HunkID        string    `json:"hunk_id"`
+	Action        string    `json:"action"`
+	ResolvedLines []string  `json:"resolved_lines,omitempty"` // Lines the hunk resolves to; empty for ReviewReject
+	Confidence    float64   `json:"confidence,omitempty"`
+	Reasoning     string    `json:"reasoning,omitempty"`
+	DecidedAt     time.Time `json:"decided_at"`
+}
+
+// Resolution returns the resolution a decision resolves its hunk to, or false
+// when the decision leaves the hunk conflicted
+func (d *ReviewDecision) Resolution() (ConflictResolution, bool) {
+	if d.Action == ReviewReject {
+		return ConflictResolution{}, false
+	}
+	lines := d.ResolvedLines
+	if lines == nil {
+		lines = []string{}
+	}
+	return ConflictResolution{
+		FilePath:      d.FilePath,
+		HunkID:        d.HunkID,
+		ResolvedLines: lines,
+		Confidence:    d.Confidence,
+		Reasoning:     d.Reasoning,
+	}, true
+}
+
+// ReviewSession keeps the decisions of an interactive review, so a review that
+// was interrupted resumes where it stopped. Decisions are keyed by hunk ID, the
+// content hash of the marker block, so a hunk that changed is reviewed again.
+// The session is kept in <common gitdir>/syncwright/review.json.
+type ReviewSession struct {
+	Decisions map[string]*ReviewDecision `json:"decisions"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+
+	path string
+}
+
+// OpenReviewSession loads the review session of a repository. A missing
+// session file yields an empty session. All worktrees share one session, so a
+// review quit in an isolated worktree resumes after the worktree is gone.
+func OpenReviewSession(repoPath string) (*ReviewSession, error) {
+	gitDir, err := GetCommonGitDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &ReviewSession{
+		Decisions: make(map[string]*ReviewDecision),
+		path:      filepath.Join(gitDir, "syncwright", reviewFileName),
+	}
+
+	data, err := os.ReadFile(session.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return session, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review session: %w", err)
+	}
+
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, fmt.Errorf("failed to parse review session %s: %w", session.path, err)
+	}
+	if session.Decisions == nil {
+		session.Decisions = make(map[string]*ReviewDecision)
+	}
+	return session, nil
+}
+
+// Path returns the file the session is stored in
+func (s *ReviewSession) Path() string {
+	return s.path
+}
+
+// Decision returns the decision taken on a hunk
+func (s *ReviewSession) Decision(filePath, hunkID string) (*ReviewDecision, bool) {
+	decision, exists := s.Decisions[reviewKey(filePath, hunkID)]
+	return decision, exists
+}
+
+// Record stores a decision and saves the session, so it survives an
+// interrupted review
+func (s *ReviewSession) Record(decision ReviewDecision) error {
+	if decision.DecidedAt.IsZero() {
+		decision.DecidedAt = time.Now().UTC()
+	}
+	s.Decisions[reviewKey(decision.FilePath, decision.HunkID)] = &decision
+	return s.Save()
+}
+
+// Forget drops the decisions on the given hunks, typically once they were
+// applied, and saves the session. The session file is removed when no decision
+// is left.
+func (s *ReviewSession) Forget(decisions []ReviewDecision) error {
+	for _, decision := range decisions {
+		delete(s.Decisions, reviewKey(decision.FilePath, decision.HunkID))
+	}
+	if len(s.Decisions) > 0 {
+		return s.Save()
+	}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove review session: %w", err)
+	}
+	return nil
+}
+
+// List returns the decisions ordered by file and time
+func (s *ReviewSession) List() []ReviewDecision {
+	decisions := make([]ReviewDecision, 0, len(s.Decisions))
+	for _, decision := range s.Decisions {
+		decisions = append(decisions, *decision)
+	}
+	sort.Slice(decisions, func(i, j int) bool {
+		if decisions[i].FilePath != decisions[j].FilePath {
+			return decisions[i].FilePath < decisions[j].FilePath
+		}
+		return decisions[i].DecidedAt.Before(decisions[j].DecidedAt)
+	})
+	return decisions
+}
+
+// Save writes the session to its file, replacing it atomically
+func (s *ReviewSession) Save() error {
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal review session: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("failed to create review session directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write review session: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write review session: %w", err)
+	}
+	return nil
+}
+
+// reviewKey identifies a hunk of a file in the session
+func reviewKey(filePath, hunkID string) string {
+	return filePath + "#" + hunkID
+}
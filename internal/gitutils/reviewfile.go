@@ -0,0 +1,225 @@
+package gitutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Statuses of the entries of a review file
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusAccepted = "accepted"
+	ReviewStatusRejected = "rejected"
+)
+
+// ReviewFileVersion is the format version written to review files
+const ReviewFileVersion = "1"
+
+// DefaultReviewFileName is the review file written when no path is given
+const DefaultReviewFileName = "syncwright-review.json"
+
+// ReviewEntry is a conflict hunk of a review file, with the resolution
+// proposed for it and the decision of the reviewer
+type ReviewEntry struct {
+	FilePath      string    `json:"file_path"`
+	HunkID        string    `json:"hunk_id"`
+	StartLine     int       `json:"start_line"`
+	EndLine       int       `json:"end_line"`
+	OursLines     []string  `json:"ours_lines"`
+	BaseLines     []string  `json:"base_lines,omitempty"`
+	TheirsLines   []string  `json:"theirs_lines"`
+	ProposedLines []string  `json:"proposed_lines,omitempty"` // Omitted when no resolution was proposed
+	Proposed      bool      `json:"proposed"`
+	Confidence    float64   `json:"confidence"`
+	Reasoning     string    `json:"reasoning,omitempty"`
+	Status        string    `json:"status"`
+	EditedLines   []string  `json:"edited_lines,omitempty"` // Lines the reviewer wrote instead of the proposal
+	Edited        bool      `json:"edited,omitempty"`
+	Comment       string    `json:"comment,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Resolution returns the resolution an accepted entry applies: the edited
+// lines when the reviewer edited it, the proposed lines otherwise
+func (e *ReviewEntry) Resolution() ConflictResolution {
+	lines, confidence := e.ProposedLines, e.Confidence
+	if e.Edited {
+		lines, confidence = e.EditedLines, 1
+	}
+	if lines == nil {
+		lines = []string{}
+	}
+	return ConflictResolution{
+		FilePath:      e.FilePath,
+		HunkID:        e.HunkID,
+		StartLine:     e.StartLine,
+		EndLine:       e.EndLine,
+		ResolvedLines: lines,
+		Confidence:    confidence,
+		Reasoning:     e.Reasoning,
+	}
+}
+
+// ReviewFile lists proposed resolutions for a human to accept, reject or edit
+// outside of a terminal, for example in a later CI job. Only accepted entries
+// are applied, anchored to their hunk by its ID.
+type ReviewFile struct {
+	Version   string         `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Entries   []*ReviewEntry `json:"entries"`
+}
+
+// NewReviewFile creates a review file with every resolution pending
+func NewReviewFile(entries []*ReviewEntry) *ReviewFile {
+	now := time.Now().UTC()
+	for _, entry := range entries {
+		entry.Status = ReviewStatusPending
+		entry.UpdatedAt = now
+	}
+	return &ReviewFile{Version: ReviewFileVersion, CreatedAt: now, Entries: entries}
+}
+
+// LoadReviewFile reads a review file
+func LoadReviewFile(path string) (*ReviewFile, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is chosen by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review file: %w", err)
+	}
+
+	var file ReviewFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse review file %s: %w", path, err)
+	}
+	if file.Version != ReviewFileVersion {
+		return nil, fmt.Errorf("review file %s has unsupported version %q", path, file.Version)
+	}
+	for _, entry := range file.Entries {
+		switch entry.Status {
+		case ReviewStatusPending, ReviewStatusAccepted, ReviewStatusRejected:
+		default:
+			return nil, fmt.Errorf("review file %s: hunk %s has unknown status %q", path, entry.HunkID, entry.Status)
+		}
+	}
+	return &file, nil
+}
+
+// Save writes the review file, replacing it atomically
+func (f *ReviewFile) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal review file: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create review file directory: %w", err)
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write review file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write review file: %w", err)
+	}
+	return nil
+}
+
+// Find returns the entry whose hunk ID is id or starts with it. Hunk IDs are
+// only unique within a file, so filePath narrows the search when it is set.
+func (f *ReviewFile) Find(id, filePath string) (*ReviewEntry, error) {
+	if id == "" {
+		return nil, fmt.Errorf("no hunk ID given")
+	}
+
+	var exact, prefixed []*ReviewEntry
+	for _, entry := range f.Entries {
+		if filePath != "" && entry.FilePath != filePath {
+			continue
+		}
+		if entry.HunkID == id {
+			exact = append(exact, entry)
+		} else if strings.HasPrefix(entry.HunkID, id) {
+			prefixed = append(prefixed, entry)
+		}
+	}
+
+	matches := exact
+	if len(matches) == 0 {
+		matches = prefixed
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no hunk matches %q", id)
+	case 1:
+		return matches[0], nil
+	}
+	paths := make([]string, len(matches))
+	for i, entry := range matches {
+		paths[i] = entry.FilePath
+	}
+	return nil, fmt.Errorf("hunk ID %q is ambiguous (it matches hunks in %s); give the file path too",
+		id, strings.Join(paths, ", "))
+}
+
+// Accept marks an entry as accepted. An entry without a proposal must be
+// edited instead.
+func (e *ReviewEntry) Accept(comment string) error {
+	if !e.Proposed && !e.Edited {
+		return fmt.Errorf("hunk %s in %s has no proposed resolution to accept; edit it instead", e.HunkID, e.FilePath)
+	}
+	e.setStatus(ReviewStatusAccepted, comment)
+	return nil
+}
+
+// Reject marks an entry as rejected, leaving its conflict to be resolved by hand
+func (e *ReviewEntry) Reject(comment string) {
+	e.setStatus(ReviewStatusRejected, comment)
+}
+
+// Edit replaces the proposal of an entry with lines written by the reviewer and
+// accepts it
+func (e *ReviewEntry) Edit(lines []string, comment string) {
+	e.EditedLines = lines
+	e.Edited = true
+	e.setStatus(ReviewStatusAccepted, comment)
+}
+
+// setStatus records a decision on an entry
+func (e *ReviewEntry) setStatus(status, comment string) {
+	e.Status = status
+	if comment != "" {
+		e.Comment = comment
+	}
+	e.UpdatedAt = time.Now().UTC()
+}
+
+// AcceptedResolutions returns the resolutions of the accepted entries
+func (f *ReviewFile) AcceptedResolutions() []ConflictResolution {
+	var resolutions []ConflictResolution
+	for _, entry := range f.Entries {
+		if entry.Status == ReviewStatusAccepted {
+			resolutions = append(resolutions, entry.Resolution())
+		}
+	}
+	return resolutions
+}
+
+// CountByStatus counts the entries of each status
+func (f *ReviewFile) CountByStatus() map[string]int {
+	counts := map[string]int{
+		ReviewStatusPending:  0,
+		ReviewStatusAccepted: 0,
+		ReviewStatusRejected: 0,
+	}
+	for _, entry := range f.Entries {
+		counts[entry.Status]++
+	}
+	return counts
+}
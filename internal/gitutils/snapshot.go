@@ -0,0 +1,515 @@
+package gitutils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runsDirName is the directory inside <gitdir>/syncwright that keeps the
+// snapshots taken before each apply run
+const runsDirName = "runs"
+
+// runManifestName is the manifest file of a run snapshot
+const runManifestName = "manifest.json"
+
+// gitlinkMode is the index mode of submodule entries, which point to commits
+// rather than blobs
+const gitlinkMode = "160000"
+
+// IndexEntry is an index entry of a path at one stage; stage 0 is a merged
+// entry, stages 1-3 the base, ours and theirs entries of an unmerged path
+type IndexEntry struct {
+	Mode   string `json:"mode"`
+	Object string `json:"object"`
+	Stage  int    `json:"stage"`
+}
+
+// SnapshotFile is the state of a file before a run: its working tree content,
+// stored as a blob of the run, and its index entries
+type SnapshotFile struct {
+	Path   string       `json:"path"`
+	Exists bool         `json:"exists"`
+	Mode   os.FileMode  `json:"mode,omitempty"`
+	Blob   string       `json:"blob,omitempty"`
+	Index  []IndexEntry `json:"index,omitempty"`
+}
+
+// RunManifest describes a run snapshot stored in <gitdir>/syncwright/runs/<id>
+type RunManifest struct {
+	ID        string         `json:"id"`
+	Command   string         `json:"command"`
+	CreatedAt time.Time      `json:"created_at"`
+	Worktree  string         `json:"worktree,omitempty"` // Root of the working tree the files were taken from
+	Files     []SnapshotFile `json:"files"`
+	UndoneAt  *time.Time     `json:"undone_at,omitempty"`
+
+	dir string
+}
+
+// Undone reports whether the run was undone
+func (m *RunManifest) Undone() bool {
+	return m.UndoneAt != nil
+}
+
+// Dir returns the directory the snapshot is stored in
+func (m *RunManifest) Dir() string {
+	return m.dir
+}
+
+// UndoResult describes the files an undo restored
+type UndoResult struct {
+	RunID         string   `json:"run_id"`
+	RestoredFiles []string `json:"restored_files"`
+	RemovedFiles  []string `json:"removed_files,omitempty"`
+}
+
+// GetRunsDir returns the directory run snapshots are stored in. It is shared by
+// all worktrees, so the snapshots of a worktree outlive it.
+func GetRunsDir(repoPath string) (string, error) {
+	gitDir, err := GetCommonGitDir(repoPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "syncwright", runsDirName), nil
+}
+
+// CreateRunSnapshot records the working tree content, file mode and index
+// entries of paths before command changes them. Unmerged index stages are kept
+// with their blobs, so undoing the run brings the conflict back exactly.
+func CreateRunSnapshot(repoPath, command string, paths []string) (*RunManifest, error) {
+	runsDir, err := GetRunsDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := GetWorktreeRoot(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &RunManifest{
+		ID:        newRunID(),
+		Command:   command,
+		CreatedAt: time.Now().UTC(),
+		Worktree:  worktree,
+		Files:     []SnapshotFile{},
+	}
+	manifest.dir = filepath.Join(runsDir, manifest.ID)
+	if err := os.MkdirAll(filepath.Join(manifest.dir, "blobs"), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create run snapshot directory: %w", err)
+	}
+
+	cleanPaths, err := uniqueCleanPaths(paths)
+	if err != nil {
+		os.RemoveAll(manifest.dir)
+		return nil, err
+	}
+
+	entries, err := listIndexEntries(repoPath, cleanPaths)
+	if err != nil {
+		os.RemoveAll(manifest.dir)
+		return nil, err
+	}
+
+	for _, path := range cleanPaths {
+		file, err := manifest.snapshotFile(repoPath, path, entries[path])
+		if err != nil {
+			os.RemoveAll(manifest.dir)
+			return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+		}
+		manifest.Files = append(manifest.Files, *file)
+	}
+
+	if err := manifest.save(); err != nil {
+		os.RemoveAll(manifest.dir)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ListRuns returns the run snapshots of the working tree containing repoPath,
+// newest first. Snapshots with an unreadable manifest are skipped, and so are
+// those taken in other worktrees of the repository.
+func ListRuns(repoPath string) ([]*RunManifest, error) {
+	runsDir, err := GetRunsDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := GetWorktreeRoot(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(runsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []*RunManifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run snapshots: %w", err)
+	}
+
+	runs := []*RunManifest{}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() {
+			continue
+		}
+		manifest, err := loadRunManifest(filepath.Join(runsDir, dirEntry.Name()))
+		if err != nil || manifest.Worktree != "" && manifest.Worktree != worktree {
+			continue
+		}
+		runs = append(runs, manifest)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		}
+		return runs[i].ID > runs[j].ID
+	})
+	return runs, nil
+}
+
+// FindRun returns the run whose ID is id or starts with it. An empty id selects
+// the most recent run that was not undone yet.
+func FindRun(repoPath, id string) (*RunManifest, error) {
+	runs, err := ListRuns(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		for _, run := range runs {
+			if !run.Undone() {
+				return run, nil
+			}
+		}
+		return nil, fmt.Errorf("no run to undo")
+	}
+
+	var matches []*RunManifest
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+		if strings.HasPrefix(run.ID, id) {
+			matches = append(matches, run)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no run matches %q", id)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("run ID %q is ambiguous (%d runs match)", id, len(matches))
+}
+
+// UndoRun restores the files of a run to the state recorded before it, working
+// tree and index alike, and marks the run as undone
+func UndoRun(repoPath string, manifest *RunManifest) (*UndoResult, error) {
+	if manifest.Undone() {
+		return nil, fmt.Errorf("run %s was already undone at %s", manifest.ID,
+			manifest.UndoneAt.Format(time.RFC3339))
+	}
+
+	result := &UndoResult{RunID: manifest.ID, RestoredFiles: []string{}}
+	for _, file := range manifest.Files {
+		removed, err := manifest.restoreWorktreeFile(repoPath, file)
+		if err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", file.Path, err)
+		}
+		if removed {
+			result.RemovedFiles = append(result.RemovedFiles, file.Path)
+		} else {
+			result.RestoredFiles = append(result.RestoredFiles, file.Path)
+		}
+	}
+
+	if err := manifest.restoreIndex(repoPath); err != nil {
+		return result, err
+	}
+
+	undoneAt := time.Now().UTC()
+	manifest.UndoneAt = &undoneAt
+	if err := manifest.save(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// snapshotFile stores the working tree content and index blobs of a file
+func (m *RunManifest) snapshotFile(repoPath, path string, index []IndexEntry) (*SnapshotFile, error) {
+	file := &SnapshotFile{Path: path, Index: index}
+
+	for _, entry := range index {
+		if entry.Mode == gitlinkMode {
+			continue
+		}
+		content, err := readBlob(repoPath, entry.Object)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.writeBlob(entry.Object, content); err != nil {
+			return nil, err
+		}
+	}
+
+	fullPath, err := ResolveRepoPath(repoPath, path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file")
+	}
+
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+	if err != nil {
+		return nil, err
+	}
+	object, err := hashObject(repoPath, content)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.writeBlob(object, content); err != nil {
+		return nil, err
+	}
+
+	file.Exists = true
+	file.Mode = info.Mode().Perm()
+	file.Blob = object
+	return file, nil
+}
+
+// restoreWorktreeFile writes the recorded content of a file back, or removes
+// the file when it did not exist before the run
+func (m *RunManifest) restoreWorktreeFile(repoPath string, file SnapshotFile) (bool, error) {
+	fullPath, err := ResolveRepoPath(repoPath, file.Path)
+	if err != nil {
+		return false, err
+	}
+
+	if !file.Exists {
+		if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+		return true, nil
+	}
+
+	content, err := m.readBlob(file.Blob)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0750); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(fullPath, content, file.Mode); err != nil {
+		return false, err
+	}
+	return false, os.Chmod(fullPath, file.Mode)
+}
+
+// restoreIndex replaces the index entries of the run's files with the
+// recorded ones, bringing back unmerged stages
+func (m *RunManifest) restoreIndex(repoPath string) error {
+	var paths, info bytes.Buffer
+	for _, file := range m.Files {
+		paths.WriteString(file.Path)
+		paths.WriteByte(0)
+
+		for _, entry := range file.Index {
+			fmt.Fprintf(&info, "%s %s %d\t%s", entry.Mode, entry.Object, entry.Stage, file.Path)
+			info.WriteByte(0)
+			if entry.Mode == gitlinkMode {
+				continue
+			}
+
+			// The blobs of resolved stages may have been pruned since
+			content, err := m.readBlob(entry.Object)
+			if err != nil {
+				return err
+			}
+			object, err := hashObject(repoPath, content)
+			if err != nil {
+				return err
+			}
+			if object != entry.Object {
+				return fmt.Errorf("snapshot blob %s of %s is corrupt", entry.Object, file.Path)
+			}
+		}
+	}
+
+	remove := exec.Command("git", "update-index", "-z", "--force-remove", "--stdin")
+	remove.Dir = repoPath
+	remove.Stdin = &paths
+	if output, err := remove.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to reset index entries: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	if info.Len() == 0 {
+		return nil
+	}
+	add := exec.Command("git", "update-index", "-z", "--index-info")
+	add.Dir = repoPath
+	add.Stdin = &info
+	if output, err := add.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restore index entries: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeBlob stores content under its object name in the run directory
+func (m *RunManifest) writeBlob(object string, content []byte) error {
+	if !objectIDPattern.MatchString(object) {
+		return fmt.Errorf("invalid object name: %s", object)
+	}
+	path := filepath.Join(m.dir, "blobs", object)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// readBlob reads a blob stored in the run directory
+func (m *RunManifest) readBlob(object string) ([]byte, error) {
+	if !objectIDPattern.MatchString(object) {
+		return nil, fmt.Errorf("invalid object name: %s", object)
+	}
+	content, err := os.ReadFile(filepath.Join(m.dir, "blobs", object)) // #nosec G304 - object name validated above
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot blob: %w", err)
+	}
+	return content, nil
+}
+
+// save writes the manifest, replacing it atomically
+func (m *RunManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run manifest: %w", err)
+	}
+
+	path := filepath.Join(m.dir, runManifestName)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	return nil
+}
+
+// loadRunManifest reads the manifest of the run stored in dir
+func loadRunManifest(dir string) (*RunManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, runManifestName)) // #nosec G304 - dir is inside the git directory
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &RunManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse run manifest in %s: %w", dir, err)
+	}
+	manifest.dir = dir
+	return manifest, nil
+}
+
+// listIndexEntries returns the index entries of paths at every stage
+func listIndexEntries(repoPath string, paths []string) (map[string][]IndexEntry, error) {
+	entries := make(map[string][]IndexEntry)
+	if len(paths) == 0 {
+		return entries, nil
+	}
+
+	args := append([]string{"--literal-pathspecs", "ls-files", "-s", "-z", "--"}, paths...)
+	cmd := exec.Command("git", args...) // #nosec G204 - paths are validated by the caller
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list index entries: %w", err)
+	}
+
+	for _, record := range bytes.Split(output, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+
+		tab := bytes.IndexByte(record, '\t')
+		if tab < 0 {
+			return nil, fmt.Errorf("malformed ls-files record: %q", record)
+		}
+		fields := strings.Fields(string(record[:tab]))
+		if len(fields) != 3 || !objectIDPattern.MatchString(fields[1]) {
+			return nil, fmt.Errorf("malformed ls-files record: %q", record)
+		}
+		stage, err := strconv.Atoi(fields[2])
+		if err != nil || stage < 0 || stage > StageTheirs {
+			return nil, fmt.Errorf("invalid stage in ls-files record: %q", record)
+		}
+
+		path := string(record[tab+1:])
+		entries[path] = append(entries[path], IndexEntry{Mode: fields[0], Object: fields[1], Stage: stage})
+	}
+
+	return entries, nil
+}
+
+// hashObject writes content to the object database and returns its name
+func hashObject(repoPath string, content []byte) (string, error) {
+	cmd := exec.Command("git", "hash-object", "-w", "--stdin")
+	cmd.Dir = repoPath
+	cmd.Stdin = bytes.NewReader(content)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git hash-object failed: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// uniqueCleanPaths validates paths and drops duplicates, keeping their order
+func uniqueCleanPaths(paths []string) ([]string, error) {
+	seen := make(map[string]bool, len(paths))
+	cleanPaths := make([]string, 0, len(paths))
+	for _, path := range paths {
+		cleanPath, err := validateConflictFilePath(path)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[cleanPath] {
+			seen[cleanPath] = true
+			cleanPaths = append(cleanPaths, cleanPath)
+		}
+	}
+	return cleanPaths, nil
+}
+
+// newRunID returns a sortable, unique run ID made of the UTC time and a
+// random suffix
+func newRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().UTC().Format("20060102-150405.000000")
+	}
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,69 @@
+package gitutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUndoRun_RestoresConflictState(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	conflicted := readTestFile(t, repoPath, "conflict.txt")
+	stagesBefore := runGit(t, repoPath, "ls-files", "-u")
+
+	manifest, err := CreateRunSnapshot(repoPath, "ai-apply", []string{"conflict.txt", "added.txt", "conflict.txt"})
+	if err != nil {
+		t.Fatalf("CreateRunSnapshot() error = %v", err)
+	}
+	if len(manifest.Files) != 2 || len(manifest.Files[0].Index) != 3 || manifest.Files[1].Exists {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	// Resolve and stage the conflict, and add a file
+	writeRepoFile(t, repoPath, "conflict.txt", "main and feature\n")
+	writeRepoFile(t, repoPath, "added.txt", "added\n")
+	runGit(t, repoPath, "add", "conflict.txt", "added.txt")
+
+	run, err := FindRun(repoPath, "")
+	if err != nil || run.ID != manifest.ID {
+		t.Fatalf("FindRun() = %+v, %v", run, err)
+	}
+	result, err := UndoRun(repoPath, run)
+	if err != nil {
+		t.Fatalf("UndoRun() error = %v", err)
+	}
+	if len(result.RestoredFiles) != 1 || len(result.RemovedFiles) != 1 {
+		t.Errorf("unexpected undo result %+v", result)
+	}
+
+	if content := readTestFile(t, repoPath, "conflict.txt"); content != conflicted {
+		t.Errorf("conflict.txt = %q, expected the conflict markers back", content)
+	}
+	if stages := runGit(t, repoPath, "ls-files", "-u"); stages != stagesBefore {
+		t.Errorf("unmerged stages = %q, expected %q", stages, stagesBefore)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "added.txt")); !os.IsNotExist(err) {
+		t.Errorf("added.txt was not removed: %v", err)
+	}
+	if staged := runGit(t, repoPath, "ls-files", "added.txt"); strings.TrimSpace(staged) != "" {
+		t.Errorf("added.txt is still in the index")
+	}
+
+	// The run is marked as undone and cannot be undone twice
+	runs, err := ListRuns(repoPath)
+	if err != nil || len(runs) != 1 || !runs[0].Undone() {
+		t.Fatalf("ListRuns() = %+v, %v", runs, err)
+	}
+	if _, err := UndoRun(repoPath, runs[0]); err == nil {
+		t.Error("expected a second undo to fail")
+	}
+	if _, err := FindRun(repoPath, ""); err == nil {
+		t.Error("expected no run left to undo")
+	}
+	if found, err := FindRun(repoPath, manifest.ID[:8]); err != nil || found.ID != manifest.ID {
+		t.Errorf("FindRun(prefix) = %+v, %v", found, err)
+	}
+}
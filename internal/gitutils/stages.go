@@ -0,0 +1,373 @@
+package gitutils
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Index stage numbers of an unmerged path
+const (
+	StageBase   = 1
+	StageOurs   = 2
+	StageTheirs = 3
+)
+
+// objectIDPattern matches the hexadecimal object names printed by git ls-files
+var objectIDPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// StageEntry is a single stage of an unmerged index entry
+type StageEntry struct {
+	Mode   string `json:"mode"`
+	Object string `json:"object"`
+}
+
+// UnmergedEntry holds the index stages recorded for an unmerged path
+type UnmergedEntry struct {
+	Path   string             `json:"path"`
+	Stages map[int]StageEntry `json:"stages"`
+}
+
+// HasStage reports whether the entry has the given stage
+func (e UnmergedEntry) HasStage(stage int) bool {
+	_, exists := e.Stages[stage]
+	return exists
+}
+
+// StageVersions contains the base, ours and theirs versions of a conflicted file
+// as read from the index. A missing stage leaves the corresponding Has flag false.
+type StageVersions struct {
+	Path        string
+	BaseLines   []string
+	OursLines   []string
+	TheirsLines []string
+	HasBase     bool
+	HasOurs     bool
+	HasTheirs   bool
+	Binary      bool
+}
+
+// ListUnmergedEntries lists the unmerged index entries using git ls-files -u.
+// When paths are given, only those paths are listed.
+func ListUnmergedEntries(repoPath string, paths ...string) ([]UnmergedEntry, error) {
+	if err := validateGitPath(repoPath); err != nil {
+		return nil, fmt.Errorf("invalid repository path: %w", err)
+	}
+
+	// Paths are matched literally so that names containing glob characters or
+	// pathspec magic cannot select other files
+	args := []string{"--literal-pathspecs", "ls-files", "-u", "-z"}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		for _, path := range paths {
+			cleanPath, err := validateConflictFilePath(path)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, cleanPath)
+		}
+	}
+
+	cmd := exec.Command("git", args...) // #nosec G204 - paths are validated above
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmerged entries: %w", err)
+	}
+
+	return parseUnmergedEntries(output)
+}
+
+// parseUnmergedEntries parses NUL separated "<mode> <object> <stage>\t<path>" records
+func parseUnmergedEntries(output []byte) ([]UnmergedEntry, error) {
+	var entries []UnmergedEntry
+	index := make(map[string]int)
+
+	for _, record := range bytes.Split(output, []byte{0}) {
+		if len(record) == 0 {
+			continue
+		}
+
+		tab := bytes.IndexByte(record, '\t')
+		if tab < 0 {
+			return nil, fmt.Errorf("malformed ls-files record: %q", record)
+		}
+
+		fields := strings.Fields(string(record[:tab]))
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("malformed ls-files record: %q", record)
+		}
+
+		stage, err := strconv.Atoi(fields[2])
+		if err != nil || stage < StageBase || stage > StageTheirs {
+			return nil, fmt.Errorf("invalid stage in ls-files record: %q", record)
+		}
+
+		if !objectIDPattern.MatchString(fields[1]) {
+			return nil, fmt.Errorf("invalid object name in ls-files record: %q", record)
+		}
+
+		path := string(record[tab+1:])
+		i, exists := index[path]
+		if !exists {
+			i = len(entries)
+			index[path] = i
+			entries = append(entries, UnmergedEntry{Path: path, Stages: make(map[int]StageEntry)})
+		}
+		entries[i].Stages[stage] = StageEntry{Mode: fields[0], Object: fields[1]}
+	}
+
+	return entries, nil
+}
+
+// ReadStageVersions reads the base, ours and theirs blobs of an unmerged entry
+func ReadStageVersions(repoPath string, entry UnmergedEntry) (*StageVersions, error) {
+	versions := &StageVersions{Path: entry.Path}
+
+	for stage, target := range map[int]*[]string{
+		StageBase:   &versions.BaseLines,
+		StageOurs:   &versions.OursLines,
+		StageTheirs: &versions.TheirsLines,
+	} {
+		stageEntry, exists := entry.Stages[stage]
+		if !exists {
+			continue
+		}
+
+		content, err := readBlob(repoPath, stageEntry.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stage %d of %s: %w", stage, entry.Path, err)
+		}
+
+		// Legacy encodings are merged as UTF-8, like the working tree is parsed
+		content = decodeBlob(content)
+		if bytes.IndexByte(content, 0) >= 0 {
+			versions.Binary = true
+		}
+		*target = splitContentLines(string(content))
+	}
+
+	versions.HasBase = entry.HasStage(StageBase)
+	versions.HasOurs = entry.HasStage(StageOurs)
+	versions.HasTheirs = entry.HasStage(StageTheirs)
+
+	return versions, nil
+}
+
+// GetStageVersions reads the index stages of a single conflicted file
+func GetStageVersions(repoPath, filePath string) (*StageVersions, error) {
+	entries, err := ListUnmergedEntries(repoPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Path == filePath {
+			return ReadStageVersions(repoPath, entry)
+		}
+	}
+
+	return nil, fmt.Errorf("no unmerged index entry for %s", filePath)
+}
+
+// CheckoutStage writes one stage of a conflicted file to the working tree the
+// way git checks files out, so its line endings, final newline and encoding
+// are those of that side. The index is left as it is.
+func CheckoutStage(repoPath, filePath string, stage int) error {
+	if _, err := ResolveRepoPath(repoPath, filePath); err != nil {
+		return err
+	}
+	if stage < StageBase || stage > StageTheirs {
+		return fmt.Errorf("invalid stage %d", stage)
+	}
+
+	// #nosec G204 - the stage is a number and the path follows --
+	cmd := exec.Command("git", "checkout-index", "--force", fmt.Sprintf("--stage=%d", stage), "--", filePath)
+	cmd.Dir = repoPath
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to check out stage %d of %s: %w: %s", stage, filePath, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// readBlob reads a blob from the object database with git cat-file
+func readBlob(repoPath, object string) ([]byte, error) {
+	if !objectIDPattern.MatchString(object) {
+		return nil, fmt.Errorf("invalid object name: %s", object)
+	}
+
+	cmd := exec.Command("git", "cat-file", "blob", object) // #nosec G204 - object name validated above
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file failed: %w", err)
+	}
+
+	return output, nil
+}
+
+// splitContentLines splits file content into lines without a trailing empty element
+func splitContentLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// ParseConflictHunksFromStages computes the conflict hunks of a file from its index
+// stages with a three-way merge, so base lines are available regardless of
+// merge.conflictStyle. Hunks are located in the working tree by matching their
+// content against the conflict markers there. A working tree without markers has
+// been resolved already, so it has no hunks.
+func ParseConflictHunksFromStages(filePath, repoPath string) ([]ConflictHunk, error) {
+	if err := validateGitPath(repoPath); err != nil {
+		return nil, fmt.Errorf("invalid repository path: %w", err)
+	}
+
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := GetStageVersions(repoPath, cleanPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if versions.Binary {
+		return nil, fmt.Errorf("cannot compute hunks for binary file %s", filePath)
+	}
+	if !versions.HasOurs || !versions.HasTheirs {
+		// Deleted on one side: there is no content conflict to compute
+		return nil, nil
+	}
+
+	regions := Merge3(versions.BaseLines, versions.OursLines, versions.TheirsLines)
+
+	var markerHunks []ConflictHunk
+	content, err := readConflictFileContent(cleanPath, repoPath)
+	if err == nil {
+		markerHunks, _ = parseConflictMarkersWithSize(string(content), GetConflictMarkerSize(repoPath, cleanPath))
+	}
+
+	if len(markerHunks) == 0 {
+		return nil, nil
+	}
+
+	return locateStageHunks(regions, markerHunks), nil
+}
+
+// renderedConflictHunks positions conflict regions as git would render them with
+// merge markers (ours, base and theirs sections)
+func renderedConflictHunks(regions []MergeRegion) []ConflictHunk {
+	var hunks []ConflictHunk
+
+	line := 1
+	for _, region := range regions {
+		if region.Kind != RegionConflict {
+			line += len(region.MergedLines())
+			continue
+		}
+
+		hunk := ConflictHunk{
+			StartLine:   line,
+			OursLines:   region.OursLines,
+			TheirsLines: region.TheirsLines,
+			BaseLines:   region.BaseLines,
+		}
+		// Start marker, ours, base marker, base, separator, theirs, end marker
+		line += 4 + len(region.OursLines) + len(region.BaseLines) + len(region.TheirsLines)
+		hunk.EndLine = line - 1
+		hunks = append(hunks, hunk)
+	}
+
+	return hunks
+}
+
+// locateStageHunks fills in base lines for the marker hunks found in the working
+// tree by matching them against the three-way merge regions. Git may trim lines
+// common to both sides or merge neighbouring conflicts, so runs of regions and
+// trimmed variants are considered. Marker hunks without a match are kept as-is.
+func locateStageHunks(regions []MergeRegion, markerHunks []ConflictHunk) []ConflictHunk {
+	hunks := make([]ConflictHunk, len(markerHunks))
+	copy(hunks, markerHunks)
+
+	next := 0
+	for i := range hunks {
+		start, baseLines, found := matchConflictRegions(regions, next, hunks[i])
+		if !found {
+			continue
+		}
+		// diff3 and zdiff3 markers carry their own (possibly empty) base section
+		if hunks[i].BaseLines == nil {
+			hunks[i].BaseLines = baseLines
+		}
+		next = start + 1
+	}
+
+	return hunks
+}
+
+// matchConflictRegions searches for a run of regions starting at a conflict whose
+// combined sides equal the marker hunk, returning the start index and base lines
+func matchConflictRegions(regions []MergeRegion, from int, hunk ConflictHunk) (int, []string, bool) {
+	for start := from; start < len(regions); start++ {
+		if regions[start].Kind != RegionConflict {
+			continue
+		}
+
+		var base, ours, theirs []string
+		for end := start; end < len(regions); end++ {
+			region := regions[end]
+			base = append(base, region.BaseLines...)
+			if region.Kind == RegionConflict {
+				ours = append(ours, region.OursLines...)
+				theirs = append(theirs, region.TheirsLines...)
+			} else {
+				ours = append(ours, region.MergedLines()...)
+				theirs = append(theirs, region.MergedLines()...)
+			}
+
+			if region.Kind != RegionConflict {
+				continue
+			}
+			if len(ours) > len(hunk.OursLines)+len(hunk.TheirsLines) &&
+				len(theirs) > len(hunk.OursLines)+len(hunk.TheirsLines) {
+				break
+			}
+
+			if sidesMatch(ours, theirs, hunk) {
+				return start, base, true
+			}
+		}
+	}
+
+	return 0, nil, false
+}
+
+// sidesMatch compares the combined sides with a marker hunk, either verbatim or
+// after trimming the lines both sides share at the start and end
+func sidesMatch(ours, theirs []string, hunk ConflictHunk) bool {
+	if equalLines(ours, hunk.OursLines) && equalLines(theirs, hunk.TheirsLines) {
+		return true
+	}
+
+	prefix := 0
+	for prefix < len(ours) && prefix < len(theirs) && ours[prefix] == theirs[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ours)-prefix && suffix < len(theirs)-prefix &&
+		ours[len(ours)-1-suffix] == theirs[len(theirs)-1-suffix] {
+		suffix++
+	}
+
+	return equalLines(ours[prefix:len(ours)-suffix], hunk.OursLines) &&
+		equalLines(theirs[prefix:len(theirs)-suffix], hunk.TheirsLines)
+}
@@ -0,0 +1,222 @@
+package gitutils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Where the proposed resolutions of conflicts left unresolved are written
+const (
+	// SuggestionsInline puts a suggestion block right after each conflict
+	SuggestionsInline = "inline"
+	// SuggestionsSidecar collects the suggestions in SuggestionsFileName
+	SuggestionsSidecar = "sidecar"
+)
+
+// SuggestionsFileName is the sidecar file at the repository root that collects
+// the suggestions of a run
+const SuggestionsFileName = ".syncwright-suggestions"
+
+// Delimiters of an inline suggestion block. They are not conflict markers, so
+// git and editors keep treating the conflict above as the conflict, but they
+// stand out as clearly as one.
+const (
+	suggestionStartMarker = "%%%%%%% syncwright suggestion"
+	suggestionEndMarker   = "%%%%%%% end of syncwright suggestion"
+)
+
+// SuggestionBlock renders a proposed resolution as an inline suggestion block
+func SuggestionBlock(suggestion ConflictResolution) []string {
+	block := make([]string, 0, len(suggestion.ResolvedLines)+2)
+	block = append(block, fmt.Sprintf("%s for the conflict above (confidence %.2f)",
+		suggestionStartMarker, suggestion.Confidence))
+	block = append(block, suggestion.ResolvedLines...)
+	return append(block, suggestionEndMarker)
+}
+
+// InsertSuggestions puts the proposed resolutions of conflicts that are left
+// unresolved into their files, as a suggestion block right after each conflict.
+// A block left by an earlier run is replaced. Suggestions whose conflict is not
+// found are reported as failures; the others are still placed.
+func InsertSuggestions(repoPath string, suggestions []ConflictResolution) (*ResolutionResult, error) {
+	return insertSuggestions(repoPath, suggestions, worktreeTarget{repoPath: repoPath}), nil
+}
+
+// StageSuggestions puts suggestion blocks into the staged contents of their
+// files, like InsertSuggestions
+func (w *TransactionalWriter) StageSuggestions(suggestions []ConflictResolution) *ResolutionResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return insertSuggestions(w.repoPath, suggestions, w)
+}
+
+// insertSuggestions puts suggestion blocks into the files of a target
+func insertSuggestions(repoPath string, suggestions []ConflictResolution, target resolutionTarget) *ResolutionResult {
+	result := &ResolutionResult{
+		ModifiedFiles: make([]string, 0),
+		FailedFiles:   make([]ResolutionFailure, 0),
+		Errors:        make([]string, 0),
+		Stats:         CalculateResolutionStats(suggestions),
+	}
+
+	fileSuggestions := make(map[string][]ConflictResolution)
+	var filePaths []string
+	for _, suggestion := range suggestions {
+		if _, exists := fileSuggestions[suggestion.FilePath]; !exists {
+			filePaths = append(filePaths, suggestion.FilePath)
+		}
+		fileSuggestions[suggestion.FilePath] = append(fileSuggestions[suggestion.FilePath], suggestion)
+	}
+
+	fail := func(suggestion ConflictResolution, message string) {
+		result.FailedFiles = append(result.FailedFiles, ResolutionFailure{
+			FilePath:     suggestion.FilePath,
+			HunkID:       suggestion.HunkID,
+			StartLine:    suggestion.StartLine,
+			EndLine:      suggestion.EndLine,
+			ErrorMessage: message,
+		})
+		result.FailedCount++
+	}
+
+	for _, filePath := range filePaths {
+		raw, err := target.readFile(filePath)
+		if err != nil {
+			for _, suggestion := range fileSuggestions[filePath] {
+				fail(suggestion, fmt.Sprintf("failed to read file: %v", err))
+			}
+			continue
+		}
+		content, encoding, err := decodeFileContent(repoPath, filePath, raw)
+		if err != nil {
+			for _, suggestion := range fileSuggestions[filePath] {
+				fail(suggestion, fmt.Sprintf("failed to read file: %v", err))
+			}
+			continue
+		}
+
+		file := ParseTextFile(content)
+		anchored, mismatches := AnchorResolutions(file.Text(), fileSuggestions[filePath])
+		for _, mismatch := range mismatches {
+			fail(ConflictResolution{FilePath: filePath, HunkID: mismatch.HunkID,
+				StartLine: mismatch.StartLine, EndLine: mismatch.EndLine}, mismatch.Error())
+		}
+
+		// Insert from the bottom of the file up so earlier line numbers stay valid
+		sort.Slice(anchored, func(i, j int) bool {
+			return anchored[i].StartLine > anchored[j].StartLine
+		})
+		placed := 0
+		for _, suggestion := range anchored {
+			if err := ValidateResolution(suggestion); err != nil {
+				fail(suggestion, fmt.Sprintf("invalid suggestion: %v", err))
+				continue
+			}
+
+			block := SuggestionBlock(suggestion)
+			if end := suggestionBlockEnd(file.Lines, suggestion.EndLine); end > suggestion.EndLine {
+				err = file.ReplaceLines(suggestion.EndLine+1, end, block)
+			} else {
+				err = file.InsertLines(suggestion.EndLine, block)
+			}
+			if err != nil {
+				fail(suggestion, fmt.Sprintf("failed to insert suggestion: %v", err))
+				continue
+			}
+			placed++
+		}
+		if placed == 0 {
+			continue
+		}
+
+		encoded, err := EncodeFromUTF8(file.Bytes(), encoding)
+		if err == nil {
+			err = target.writeFile(filePath, encoded)
+		}
+		if err != nil {
+			for _, suggestion := range anchored {
+				fail(suggestion, fmt.Sprintf("failed to write file: %v", err))
+			}
+			continue
+		}
+
+		result.ModifiedFiles = append(result.ModifiedFiles, filePath)
+		result.AppliedCount += placed
+	}
+
+	result.Success = result.FailedCount == 0
+	return result
+}
+
+// suggestionBlockEnd returns the last line of the suggestion block that starts
+// right after line after (1-based), or after when there is none
+func suggestionBlockEnd(lines []string, after int) int {
+	if after < 0 || after >= len(lines) || !strings.HasPrefix(lines[after], suggestionStartMarker) {
+		return after
+	}
+	for i := after + 1; i < len(lines); i++ {
+		if lines[i] == suggestionEndMarker {
+			return i + 1
+		}
+	}
+	return after
+}
+
+// HasSuggestionBlocks reports whether the working tree version of a file still
+// contains an inline suggestion block. Missing files have none.
+func HasSuggestionBlocks(repoPath, filePath string) (bool, error) {
+	cleanPath, err := validateConflictFilePath(filePath)
+	if err != nil {
+		return false, err
+	}
+	content, err := readConflictFileContent(cleanPath, repoPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, suggestionStartMarker) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WriteSuggestionsFile writes the proposed resolutions of conflicts that are
+// left unresolved to SuggestionsFileName at the repository root, replacing the
+// suggestions of an earlier run
+func WriteSuggestionsFile(repoPath string, suggestions []ConflictResolution) error {
+	fullPath, err := ResolveRepoPath(repoPath, SuggestionsFileName)
+	if err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	builder.WriteString("# Syncwright suggestions for the conflicts left to a human reviewer.\n")
+	builder.WriteString("# Resolve each conflict by hand, then delete this file.\n")
+
+	for _, suggestion := range suggestions {
+		fmt.Fprintf(&builder, "\n## %s, lines %d-%d", suggestion.FilePath, suggestion.StartLine, suggestion.EndLine)
+		if suggestion.HunkID != "" {
+			fmt.Fprintf(&builder, " (hunk %s)", suggestion.HunkID)
+		}
+		fmt.Fprintf(&builder, "\nConfidence: %.2f\n", suggestion.Confidence)
+		if suggestion.Reasoning != "" {
+			fmt.Fprintf(&builder, "Reasoning: %s\n", strings.Join(strings.Fields(suggestion.Reasoning), " "))
+		}
+		builder.WriteString("\n")
+		for _, line := range suggestion.ResolvedLines {
+			fmt.Fprintf(&builder, "    %s\n", line)
+		}
+	}
+
+	if err := os.WriteFile(fullPath, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SuggestionsFileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package gitutils
+
+import (
+	"strings"
+	"testing"
+)
+
+const twoConflicts = `start
+<<<<<<< HEAD
+ours one
+=======
+theirs one
+>>>>>>> topic
+middle
+<<<<<<< HEAD
+ours two
+=======
+theirs two
+>>>>>>> topic
+end
+`
+
+func TestInsertSuggestions_KeepsConflictAndIsReplacedOnResolve(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "notes.txt", twoConflicts)
+
+	suggestion := ConflictResolution{
+		FilePath: "notes.txt", StartLine: 8, EndLine: 12, ResolvedLines: []string{"merged two"}, Confidence: 0.4,
+	}
+	result, err := InsertSuggestions(repoPath, []ConflictResolution{suggestion})
+	if err != nil || !result.Success || result.AppliedCount != 1 {
+		t.Fatalf("InsertSuggestions() = %+v, %v", result, err)
+	}
+
+	expected := strings.Replace(twoConflicts, ">>>>>>> topic\nend\n", ">>>>>>> topic\n"+
+		"%%%%%%% syncwright suggestion for the conflict above (confidence 0.40)\n"+
+		"merged two\n"+
+		"%%%%%%% end of syncwright suggestion\n"+
+		"end\n", 1)
+	if content := readTestFile(t, repoPath, "notes.txt"); content != expected {
+		t.Fatalf("notes.txt = %q, expected %q", content, expected)
+	}
+
+	// A second run replaces the block instead of adding another one
+	suggestion.ResolvedLines = []string{"merged two again"}
+	if result, _ := InsertSuggestions(repoPath, []ConflictResolution{suggestion}); !result.Success {
+		t.Fatalf("second InsertSuggestions() = %+v", result)
+	}
+	if content := readTestFile(t, repoPath, "notes.txt"); strings.Count(content, suggestionEndMarker) != 1 ||
+		!strings.Contains(content, "merged two again\n") {
+		t.Fatalf("notes.txt = %q, expected a single updated suggestion", content)
+	}
+
+	// Resolving the conflict removes its suggestion; the first conflict is untouched
+	hunks, err := ParseConflictHunks("notes.txt", repoPath)
+	if err != nil || len(hunks) != 2 {
+		t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+	}
+	applied, err := ApplyResolutions(repoPath, []ConflictResolution{{
+		FilePath: "notes.txt", HunkID: hunks[1].ID, ResolvedLines: []string{"merged two"}, Confidence: 0.9,
+	}})
+	if err != nil || !applied.Success {
+		t.Fatalf("ApplyResolutions() = %+v, %v", applied, err)
+	}
+	expected = strings.Replace(twoConflicts, "<<<<<<< HEAD\nours two\n=======\ntheirs two\n>>>>>>> topic\n", "merged two\n", 1)
+	if content := readTestFile(t, repoPath, "notes.txt"); content != expected {
+		t.Errorf("notes.txt = %q, expected %q", content, expected)
+	}
+}
+
+func TestInsertSuggestions_ReportsMissingConflict(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "notes.txt", twoConflicts)
+
+	result, _ := InsertSuggestions(repoPath, []ConflictResolution{
+		{FilePath: "notes.txt", StartLine: 2, EndLine: 6, ResolvedLines: []string{"merged one"}, Confidence: 0.5},
+		{FilePath: "notes.txt", HunkID: "0123456789abcdef", ResolvedLines: []string{"gone"}, Confidence: 0.5},
+	})
+	if result.Success || result.AppliedCount != 1 || result.FailedCount != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if content := readTestFile(t, repoPath, "notes.txt"); !strings.Contains(content, ">>>>>>> topic\n%%%%%%% syncwright suggestion") {
+		t.Errorf("notes.txt = %q, expected the placeable suggestion", content)
+	}
+}
+
+func TestUnresolvedReason_SuggestionBlockLeftBehind(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "notes.txt", twoConflicts)
+
+	if reason, err := UnresolvedReason(repoPath, "notes.txt"); err != nil || reason != "conflict markers remain" {
+		t.Errorf("UnresolvedReason() = %q, %v with conflicts", reason, err)
+	}
+
+	// The markers were removed by hand, but the suggestion block was not
+	writeRepoFile(t, repoPath, "notes.txt", "start\nmerged\n"+strings.Join(SuggestionBlock(ConflictResolution{
+		ResolvedLines: []string{"merged"}, Confidence: 0.4,
+	}), "\n")+"\nend\n")
+	if reason, err := UnresolvedReason(repoPath, "notes.txt"); err != nil || reason != "a suggestion block remains" {
+		t.Errorf("UnresolvedReason() = %q, %v with a suggestion block", reason, err)
+	}
+
+	writeRepoFile(t, repoPath, "notes.txt", "start\nmerged\nend\n")
+	if reason, err := UnresolvedReason(repoPath, "notes.txt"); err != nil || reason != "" {
+		t.Errorf("UnresolvedReason() = %q, %v once resolved", reason, err)
+	}
+	if reason, err := UnresolvedReason(repoPath, "missing.txt"); err != nil || reason != "" {
+		t.Errorf("UnresolvedReason() = %q, %v for a missing file", reason, err)
+	}
+}
+
+func TestWriteSuggestionsFile(t *testing.T) {
+	repoPath := t.TempDir()
+
+	err := WriteSuggestionsFile(repoPath, []ConflictResolution{{
+		FilePath:      "src/main.go",
+		HunkID:        "3f9a1c2b5d6e7f80",
+		StartLine:     10,
+		EndLine:       16,
+		ResolvedLines: []string{"x := 1", "y := 2"},
+		Confidence:    0.55,
+		Reasoning:     "Both sides add\na variable",
+	}})
+	if err != nil {
+		t.Fatalf("WriteSuggestionsFile() error = %v", err)
+	}
+
+	content := readTestFile(t, repoPath, SuggestionsFileName)
+	for _, expected := range []string{
+		"## src/main.go, lines 10-16 (hunk 3f9a1c2b5d6e7f80)\n",
+		"Confidence: 0.55\n",
+		"Reasoning: Both sides add a variable\n",
+		"\n    x := 1\n    y := 2\n",
+	} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("%s does not contain %q:\n%s", SuggestionsFileName, expected, content)
+		}
+	}
+}
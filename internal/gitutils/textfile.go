@@ -0,0 +1,168 @@
+package gitutils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// utf8BOM is the byte order mark some Windows editors put at the start of UTF-8 files
+const utf8BOM = "\ufeff"
+
+// Line endings
+const (
+	LineEndingLF   = "\n"
+	LineEndingCRLF = "\r\n"
+)
+
+// TextFile is the content of a text file split into lines, along with the
+// conventions it was written with, so it can be edited line by line and
+// written back byte for byte where it was not changed. Every line keeps its own
+// ending; lines that are added use the predominant LineEnding of the file.
+type TextFile struct {
+	Lines        []string // Lines without their line endings
+	LineEnding   string   // Predominant line ending, LineEndingLF or LineEndingCRLF
+	BOM          bool     // Content starts with a UTF-8 byte order mark
+	FinalNewline bool     // Last line is terminated by a line ending
+
+	endings []string // Ending of each line; empty for lines added later
+}
+
+// ParseTextFile splits content into lines and detects its conventions
+func ParseTextFile(content []byte) *TextFile {
+	file := &TextFile{LineEnding: LineEndingLF}
+
+	text := string(content)
+	if strings.HasPrefix(text, utf8BOM) {
+		file.BOM = true
+		text = text[len(utf8BOM):]
+	}
+	if text == "" {
+		return file
+	}
+
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		file.FinalNewline = true
+		lines = lines[:len(lines)-1]
+	}
+
+	crlf := 0
+	file.Lines = make([]string, len(lines))
+	file.endings = make([]string, len(lines))
+	for i, line := range lines {
+		terminated := i < len(lines)-1 || file.FinalNewline
+		if !terminated {
+			file.Lines[i] = line
+			continue
+		}
+		if strings.HasSuffix(line, "\r") {
+			file.Lines[i] = line[:len(line)-1]
+			file.endings[i] = LineEndingCRLF
+			crlf++
+		} else {
+			file.Lines[i] = line
+			file.endings[i] = LineEndingLF
+		}
+	}
+
+	terminated := len(lines)
+	if !file.FinalNewline {
+		terminated--
+	}
+	if crlf > 0 && crlf*2 >= terminated {
+		file.LineEnding = LineEndingCRLF
+	}
+
+	return file
+}
+
+// Text returns the lines joined with "\n", without the byte order mark. Line
+// numbers in it are the line numbers of the file.
+func (f *TextFile) Text() string {
+	text := strings.Join(f.Lines, "\n")
+	if f.FinalNewline && len(f.Lines) > 0 {
+		text += "\n"
+	}
+	return text
+}
+
+// ReplaceLines replaces the lines from start to end (1-based, inclusive) with
+// lines. Line endings and trailing carriage returns in lines are dropped; the
+// new lines get the predominant line ending of the file.
+func (f *TextFile) ReplaceLines(start, end int, lines []string) error {
+	if start < 1 || end < start || end > len(f.Lines) {
+		return fmt.Errorf("lines %d-%d are outside the file (%d lines)", start, end, len(f.Lines))
+	}
+
+	replacement := normalizeLines(lines)
+
+	newLines := make([]string, 0, len(f.Lines)-(end-start+1)+len(replacement))
+	newLines = append(newLines, f.Lines[:start-1]...)
+	newLines = append(newLines, replacement...)
+	newLines = append(newLines, f.Lines[end:]...)
+
+	newEndings := make([]string, 0, len(newLines))
+	newEndings = append(newEndings, f.endings[:start-1]...)
+	newEndings = append(newEndings, make([]string, len(replacement))...)
+	newEndings = append(newEndings, f.endings[end:]...)
+
+	f.Lines = newLines
+	f.endings = newEndings
+	return nil
+}
+
+// InsertLines inserts lines after line after (0 inserts at the top). The new
+// lines get the predominant line ending of the file.
+func (f *TextFile) InsertLines(after int, lines []string) error {
+	if after < 0 || after > len(f.Lines) {
+		return fmt.Errorf("line %d is outside the file (%d lines)", after, len(f.Lines))
+	}
+
+	inserted := normalizeLines(lines)
+	f.Lines = append(f.Lines[:after], append(inserted, f.Lines[after:]...)...)
+	f.endings = append(f.endings[:after], append(make([]string, len(inserted)), f.endings[after:]...)...)
+	return nil
+}
+
+// SetLines replaces the whole content with lines, keeping the conventions of
+// the file. An empty file gets a final newline.
+func (f *TextFile) SetLines(lines []string) {
+	if len(f.Lines) == 0 {
+		f.FinalNewline = true
+	}
+	f.Lines = normalizeLines(lines)
+	f.endings = make([]string, len(f.Lines))
+}
+
+// Bytes renders the file with its conventions
+func (f *TextFile) Bytes() []byte {
+	var buf bytes.Buffer
+	if f.BOM {
+		buf.WriteString(utf8BOM)
+	}
+
+	for i, line := range f.Lines {
+		buf.WriteString(line)
+		if i == len(f.Lines)-1 && !f.FinalNewline {
+			break
+		}
+		ending := f.LineEnding
+		if i < len(f.endings) && f.endings[i] != "" {
+			ending = f.endings[i]
+		}
+		buf.WriteString(ending)
+	}
+
+	return buf.Bytes()
+}
+
+// normalizeLines drops the carriage returns left at the end of lines, so CRLF
+// content does not end up with doubled carriage returns
+func normalizeLines(lines []string) []string {
+	normalized := make([]string, len(lines))
+	for i, line := range lines {
+		normalized[i] = strings.TrimRight(line, "\r\n")
+	}
+	return normalized
+}
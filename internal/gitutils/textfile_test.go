@@ -0,0 +1,69 @@
+package gitutils
+
+import (
+	"testing"
+)
+
+func TestParseTextFile_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		lineEnding   string
+		bom          bool
+		finalNewline bool
+		lines        int
+	}{
+		{name: "unix", content: "a\nb\n", lineEnding: LineEndingLF, finalNewline: true, lines: 2},
+		{name: "windows", content: "a\r\nb\r\n", lineEnding: LineEndingCRLF, finalNewline: true, lines: 2},
+		{name: "byte order mark", content: "\ufeffa\r\nb\r\n", lineEnding: LineEndingCRLF, bom: true, finalNewline: true, lines: 2},
+		{name: "no trailing newline", content: "a\nb", lineEnding: LineEndingLF, lines: 2},
+		{name: "mixed endings", content: "a\r\nb\nc\r\n", lineEnding: LineEndingCRLF, finalNewline: true, lines: 3},
+		{name: "empty", content: "", lineEnding: LineEndingLF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := ParseTextFile([]byte(tt.content))
+			if file.LineEnding != tt.lineEnding || file.BOM != tt.bom || file.FinalNewline != tt.finalNewline || len(file.Lines) != tt.lines {
+				t.Errorf("ParseTextFile() = %+v", file)
+			}
+			if rendered := string(file.Bytes()); rendered != tt.content {
+				t.Errorf("Bytes() = %q, expected %q", rendered, tt.content)
+			}
+		})
+	}
+}
+
+func TestTextFile_ReplaceLines(t *testing.T) {
+	file := ParseTextFile([]byte("a\r\nb\nc\r\nd"))
+
+	// Lines that are not replaced keep their own ending, new ones use the predominant one
+	if err := file.ReplaceLines(3, 4, []string{"x\r", "y"}); err != nil {
+		t.Fatalf("ReplaceLines() error = %v", err)
+	}
+	if rendered := string(file.Bytes()); rendered != "a\r\nb\nx\r\ny" {
+		t.Errorf("Bytes() = %q", rendered)
+	}
+
+	if err := file.ReplaceLines(4, 5, []string{"z"}); err == nil {
+		t.Error("expected a range past the end of the file to be rejected")
+	}
+}
+
+func TestTextFile_InsertLines(t *testing.T) {
+	file := ParseTextFile([]byte("a\r\nb\r\n"))
+
+	if err := file.InsertLines(1, []string{"x"}); err != nil {
+		t.Fatalf("InsertLines() error = %v", err)
+	}
+	if err := file.InsertLines(0, []string{"top"}); err != nil {
+		t.Fatalf("InsertLines() error = %v", err)
+	}
+	if rendered := string(file.Bytes()); rendered != "top\r\na\r\nx\r\nb\r\n" {
+		t.Errorf("Bytes() = %q", rendered)
+	}
+
+	if err := file.InsertLines(5, []string{"z"}); err == nil {
+		t.Error("expected a line past the end of the file to be rejected")
+	}
+}
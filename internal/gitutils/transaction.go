@@ -0,0 +1,432 @@
+package gitutils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Transaction states
+const (
+	TransactionOpen       = "open"
+	TransactionCommitted  = "committed"
+	TransactionRolledBack = "rolled_back"
+)
+
+// TransactionValidator is the post-apply hook of a transaction. It runs after
+// every file was written and gets the repository-relative paths that changed;
+// an error rolls the whole transaction back.
+type TransactionValidator func(paths []string) error
+
+// TransactionResult describes the outcome of a transactional write
+type TransactionResult struct {
+	State          string   `json:"state"`
+	Files          []string `json:"files"`
+	DeletedFiles   []string `json:"deleted_files,omitempty"`
+	FailedStep     string   `json:"failed_step,omitempty"`
+	ErrorMessage   string   `json:"error_message,omitempty"`
+	RollbackErrors []string `json:"rollback_errors,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+}
+
+// Committed reports whether every change of the transaction was written
+func (r *TransactionResult) Committed() bool {
+	return r.State == TransactionCommitted
+}
+
+// stagedFile is a pending change of a transaction
+type stagedFile struct {
+	path     string
+	fullPath string
+	content  []byte
+	remove   bool
+}
+
+// fileSnapshot is the content a file had before a transaction wrote it
+type fileSnapshot struct {
+	exists  bool
+	content []byte
+	mode    fs.FileMode
+}
+
+// pendingMemory holds resolutions that are remembered once the transaction
+// commits
+type pendingMemory struct {
+	filePath    string
+	hunks       []ConflictHunk
+	resolutions []ConflictResolution
+}
+
+// TransactionalWriter collects the new contents of several files and writes
+// them all or none. Changes are staged in memory; Commit writes each file to
+// a temporary file next to it, syncs it, renames it into place and then runs
+// the validation hook. When any step fails, every file is restored from the
+// snapshot taken before the first write. It is safe for concurrent use, so
+// concurrent batches can stage their changes on a single writer.
+type TransactionalWriter struct {
+	repoPath string
+	validate TransactionValidator
+
+	mu     sync.Mutex
+	staged map[string]*stagedFile
+	order  []string
+	memory []pendingMemory
+	state  string
+}
+
+// NewTransactionalWriter creates a writer for files of a repository.
+// validate may be nil.
+func NewTransactionalWriter(repoPath string, validate TransactionValidator) *TransactionalWriter {
+	return &TransactionalWriter{
+		repoPath: repoPath,
+		validate: validate,
+		staged:   make(map[string]*stagedFile),
+		state:    TransactionOpen,
+	}
+}
+
+// ReadFile returns the staged content of a file, or its content on disk when
+// nothing is staged for it
+func (w *TransactionalWriter) ReadFile(filePath string) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.readFile(filePath)
+}
+
+// WriteFile stages new content for a file
+func (w *TransactionalWriter) WriteFile(filePath string, content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writeFile(filePath, content)
+}
+
+// RemoveFile stages the removal of a file
+func (w *TransactionalWriter) RemoveFile(filePath string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.removeFile(filePath)
+}
+
+// Paths returns the files with staged changes in the order they were staged
+func (w *TransactionalWriter) Paths() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.order...)
+}
+
+// StageResolutions applies resolutions to the staged contents of their files.
+// Nothing is written to disk until Commit.
+func (w *TransactionalWriter) StageResolutions(resolutions []ConflictResolution) *ResolutionResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return applyResolutions(w.repoPath, resolutions, w)
+}
+
+// StageFileResolutions stages file-level resolutions (keep, delete or merge)
+func (w *TransactionalWriter) StageFileResolutions(resolutions []FileResolution) *ResolutionResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return applyFileResolutions(w.repoPath, resolutions, w)
+}
+
+// Discard drops the staged changes without writing anything
+func (w *TransactionalWriter) Discard(reason string) *TransactionResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := &TransactionResult{State: TransactionRolledBack, Files: w.stagedPaths(false), ErrorMessage: reason}
+	w.staged = make(map[string]*stagedFile)
+	w.order = nil
+	w.memory = nil
+	w.state = TransactionRolledBack
+	return result
+}
+
+// Commit writes every staged change or none of them. The returned result
+// describes the outcome; the error is set when the transaction was rolled back.
+func (w *TransactionalWriter) Commit() (*TransactionResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.state != TransactionOpen {
+		return nil, fmt.Errorf("transaction is already %s", w.state)
+	}
+
+	result := &TransactionResult{
+		Files:        w.stagedPaths(false),
+		DeletedFiles: w.stagedPaths(true),
+	}
+	snapshots := make(map[string]fileSnapshot, len(w.order))
+	temps := make(map[string]string, len(w.order))
+	var written []*stagedFile
+	var createdDirs []string
+
+	fail := func(step string, err error) (*TransactionResult, error) {
+		for _, tmpPath := range temps {
+			os.Remove(tmpPath)
+		}
+		for i := len(written) - 1; i >= 0; i-- {
+			if restoreErr := restoreSnapshot(written[i].fullPath, snapshots[written[i].path]); restoreErr != nil {
+				result.RollbackErrors = append(result.RollbackErrors,
+					fmt.Sprintf("%s: %v", written[i].path, restoreErr))
+			}
+		}
+		// Directories are removed innermost first, once the files in them are gone
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			if removeErr := os.Remove(createdDirs[i]); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+				result.RollbackErrors = append(result.RollbackErrors, removeErr.Error())
+			}
+		}
+		w.state = TransactionRolledBack
+		result.State = TransactionRolledBack
+		result.FailedStep = step
+		result.ErrorMessage = err.Error()
+		return result, fmt.Errorf("transaction rolled back at %s: %w", step, err)
+	}
+
+	// Snapshot every file before anything is written
+	for _, path := range w.order {
+		file := w.staged[path]
+		snapshot, err := takeSnapshot(file.fullPath)
+		if err != nil {
+			return fail("snapshot", fmt.Errorf("%s: %w", path, err))
+		}
+		snapshots[path] = snapshot
+	}
+
+	// Stage new contents in synced temporary files next to their targets
+	for _, path := range w.order {
+		file := w.staged[path]
+		if file.remove {
+			continue
+		}
+		mode := fs.FileMode(0644)
+		if snapshots[path].exists {
+			mode = snapshots[path].mode
+		}
+		dirs, err := createParentDirs(file.fullPath)
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			return fail("stage", fmt.Errorf("%s: %w", path, err))
+		}
+		tmpPath, err := writeSyncedTemp(file.fullPath, file.content, mode)
+		if err != nil {
+			return fail("stage", fmt.Errorf("%s: %w", path, err))
+		}
+		temps[path] = tmpPath
+	}
+
+	// Move the new contents into place
+	for _, path := range w.order {
+		file := w.staged[path]
+		if file.remove {
+			if err := os.Remove(file.fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fail("rename", fmt.Errorf("%s: %w", path, err))
+			}
+		} else {
+			if err := os.Rename(temps[path], file.fullPath); err != nil {
+				return fail("rename", fmt.Errorf("%s: %w", path, err))
+			}
+			delete(temps, path)
+		}
+		written = append(written, file)
+		syncDir(filepath.Dir(file.fullPath))
+	}
+
+	if w.validate != nil {
+		if err := w.validate(append([]string(nil), w.order...)); err != nil {
+			return fail("validate", err)
+		}
+	}
+
+	w.state = TransactionCommitted
+	result.State = TransactionCommitted
+
+	// Resolutions are only remembered once they are on disk
+	for _, pending := range w.memory {
+		if _, err := RecordAppliedResolutions(w.repoPath, pending.filePath, pending.hunks, pending.resolutions); err != nil {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("failed to remember resolutions of %s: %v", pending.filePath, err))
+		}
+	}
+
+	return result, nil
+}
+
+// stagedPaths returns the staged paths that are written or, with removed set,
+// the staged paths that are removed
+func (w *TransactionalWriter) stagedPaths(removed bool) []string {
+	paths := make([]string, 0, len(w.order))
+	for _, path := range w.order {
+		if w.staged[path].remove == removed {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (w *TransactionalWriter) readFile(filePath string) ([]byte, error) {
+	fullPath, err := ResolveRepoPath(w.repoPath, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if file, exists := w.staged[w.key(filePath)]; exists {
+		if file.remove {
+			return nil, &fs.PathError{Op: "read", Path: fullPath, Err: fs.ErrNotExist}
+		}
+		return file.content, nil
+	}
+	return os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+}
+
+func (w *TransactionalWriter) writeFile(filePath string, content []byte) error {
+	return w.stage(filePath, content, false)
+}
+
+func (w *TransactionalWriter) removeFile(filePath string) error {
+	return w.stage(filePath, nil, true)
+}
+
+func (w *TransactionalWriter) remember(filePath string, hunks []ConflictHunk, resolutions []ConflictResolution) error {
+	w.memory = append(w.memory, pendingMemory{filePath: filePath, hunks: hunks, resolutions: resolutions})
+	return nil
+}
+
+func (w *TransactionalWriter) stage(filePath string, content []byte, remove bool) error {
+	if w.state != TransactionOpen {
+		return fmt.Errorf("transaction is already %s", w.state)
+	}
+
+	fullPath, err := ResolveRepoPath(w.repoPath, filePath)
+	if err != nil {
+		return err
+	}
+
+	key := w.key(filePath)
+	if _, exists := w.staged[key]; !exists {
+		w.order = append(w.order, key)
+	}
+	w.staged[key] = &stagedFile{path: key, fullPath: fullPath, content: content, remove: remove}
+	return nil
+}
+
+// key returns the cleaned path a file is staged under, so different spellings
+// of a path share their staged content
+func (w *TransactionalWriter) key(filePath string) string {
+	if clean, err := CleanRepoPath(filePath); err == nil {
+		return clean
+	}
+	return filePath
+}
+
+// takeSnapshot records the content and mode of a file, or that it is missing
+func takeSnapshot(fullPath string) (fileSnapshot, error) {
+	info, err := os.Stat(fullPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileSnapshot{}, nil
+	}
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+	return fileSnapshot{exists: true, content: content, mode: info.Mode().Perm()}, nil
+}
+
+// restoreSnapshot puts a file back the way takeSnapshot found it
+func restoreSnapshot(fullPath string, snapshot fileSnapshot) error {
+	if !snapshot.exists {
+		if err := os.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	tmpPath, err := writeSyncedTemp(fullPath, snapshot.content, snapshot.mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	syncDir(filepath.Dir(fullPath))
+	return nil
+}
+
+// createParentDirs creates the missing parent directories of fullPath and
+// returns the ones it created, outermost first, so a rollback can remove them
+func createParentDirs(fullPath string) ([]string, error) {
+	var missing []string
+	for dir := filepath.Dir(fullPath); ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(dir); err == nil || !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, dir)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return created, fmt.Errorf("failed to create parent directory: %w", err)
+		} else if err == nil {
+			created = append(created, missing[i])
+		}
+	}
+	return created, nil
+}
+
+// writeSyncedTemp writes content to a temporary file in the directory of
+// fullPath, so it can be renamed over it atomically, and syncs it to disk
+func writeSyncedTemp(fullPath string, content []byte, mode fs.FileMode) (string, error) {
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fullPath)+".syncwright-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	cleanup := func(err error) (string, error) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		return cleanup(fmt.Errorf("failed to write temporary file: %w", err))
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		return cleanup(fmt.Errorf("failed to set file mode: %w", err))
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(fmt.Errorf("failed to sync temporary file: %w", err))
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	return tmp.Name(), nil
+}
+
+// syncDir syncs a directory so renames in it are durable. Failures are
+// ignored, as some platforms cannot sync directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil { // #nosec G304 - dir is inside the repository
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package gitutils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestFile(t *testing.T, repoPath, filePath string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(repoPath, filePath))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", filePath, err)
+	}
+	return string(content)
+}
+
+func TestTransactionalWriter_Commit(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "script.sh", "old\n")
+	writeRepoFile(t, repoPath, "gone.txt", "delete me\n")
+	if err := os.Chmod(filepath.Join(repoPath, "script.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var validated []string
+	writer := NewTransactionalWriter(repoPath, func(paths []string) error {
+		validated = paths
+		return nil
+	})
+	if err := writer.WriteFile("script.sh", []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteFile("dir/added.txt", []byte("added\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.RemoveFile("gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is written before the commit
+	if content := readTestFile(t, repoPath, "script.sh"); content != "old\n" {
+		t.Fatalf("staged content was written early: %q", content)
+	}
+	if staged, _ := writer.ReadFile("script.sh"); string(staged) != "new\n" {
+		t.Errorf("ReadFile() = %q, expected the staged content", staged)
+	}
+
+	result, err := writer.Commit()
+	if err != nil || !result.Committed() {
+		t.Fatalf("Commit() = %+v, %v", result, err)
+	}
+	if len(validated) != 3 {
+		t.Errorf("validation hook got %v", validated)
+	}
+
+	if content := readTestFile(t, repoPath, "script.sh"); content != "new\n" {
+		t.Errorf("script.sh = %q", content)
+	}
+	if info, err := os.Stat(filepath.Join(repoPath, "script.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("file mode was not preserved: %v, %v", info.Mode(), err)
+	}
+	if content := readTestFile(t, repoPath, "dir/added.txt"); content != "added\n" {
+		t.Errorf("dir/added.txt = %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "gone.txt")); !os.IsNotExist(err) {
+		t.Errorf("gone.txt was not removed: %v", err)
+	}
+
+	if _, err := writer.Commit(); err == nil {
+		t.Error("expected a second commit to fail")
+	}
+}
+
+func TestTransactionalWriter_RollsBackWhenValidationFails(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "a.txt", "a\n")
+	writeRepoFile(t, repoPath, "b.txt", "b\n")
+
+	writer := NewTransactionalWriter(repoPath, func(paths []string) error {
+		return errors.New("build failed")
+	})
+	writer.WriteFile("a.txt", []byte("changed a\n"))
+	writer.WriteFile("new.txt", []byte("new\n"))
+	writer.RemoveFile("b.txt")
+
+	result, err := writer.Commit()
+	if err == nil || result.Committed() || result.FailedStep != "validate" || len(result.RollbackErrors) != 0 {
+		t.Fatalf("Commit() = %+v, %v", result, err)
+	}
+
+	if content := readTestFile(t, repoPath, "a.txt"); content != "a\n" {
+		t.Errorf("a.txt was not restored: %q", content)
+	}
+	if content := readTestFile(t, repoPath, "b.txt"); content != "b\n" {
+		t.Errorf("b.txt was not restored: %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("new.txt was not removed: %v", err)
+	}
+
+	entries, _ := os.ReadDir(repoPath)
+	if len(entries) != 2 {
+		t.Errorf("temporary files were left behind: %v", entries)
+	}
+}
+
+func TestTransactionalWriter_RollbackRemovesCreatedDirectories(t *testing.T) {
+	repoPath := t.TempDir()
+	writeRepoFile(t, repoPath, "existing/a.txt", "a\n")
+
+	writer := NewTransactionalWriter(repoPath, func(paths []string) error {
+		return errors.New("build failed")
+	})
+	writer.WriteFile("existing/b.txt", []byte("b\n"))
+	writer.WriteFile("new/nested/c.txt", []byte("c\n"))
+	writer.WriteFile("new/d.txt", []byte("d\n"))
+
+	result, err := writer.Commit()
+	if err == nil || result.FailedStep != "validate" || len(result.RollbackErrors) != 0 {
+		t.Fatalf("Commit() = %+v, %v", result, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(repoPath, "new")); !os.IsNotExist(err) {
+		t.Errorf("directories created by the transaction were left behind: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(repoPath, "existing")); len(entries) != 1 {
+		t.Errorf("existing directory = %v, expected only a.txt", entries)
+	}
+}
+
+func TestApplyResolutionsAtomically(t *testing.T) {
+	repoPath := t.TempDir()
+	conflict := "<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\n"
+	writeRepoFile(t, repoPath, "one.txt", conflict)
+	writeRepoFile(t, repoPath, "two.txt", conflict)
+
+	resolutions := []ConflictResolution{
+		{FilePath: "one.txt", StartLine: 1, EndLine: 5, ResolvedLines: []string{"both"}, Confidence: 0.9},
+		{FilePath: "two.txt", StartLine: 2, EndLine: 5, ResolvedLines: []string{"both"}, Confidence: 0.9},
+	}
+
+	// The second resolution does not cover a conflict, so neither file is written
+	result, err := ApplyResolutionsAtomically(repoPath, resolutions, nil)
+	if err != nil {
+		t.Fatalf("ApplyResolutionsAtomically() error = %v", err)
+	}
+	if result.Success || result.AppliedCount != 0 || result.Transaction == nil || result.Transaction.Committed() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if content := readTestFile(t, repoPath, "one.txt"); content != conflict {
+		t.Errorf("one.txt was written: %q", content)
+	}
+
+	resolutions[1].StartLine = 1
+	result, err = ApplyResolutionsAtomically(repoPath, resolutions, nil)
+	if err != nil || !result.Success || result.AppliedCount != 2 || !result.Transaction.Committed() {
+		t.Fatalf("ApplyResolutionsAtomically() = %+v, %v", result, err)
+	}
+	if content := readTestFile(t, repoPath, "two.txt"); content != "both\n" {
+		t.Errorf("two.txt = %q", content)
+	}
+}
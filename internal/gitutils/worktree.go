@@ -0,0 +1,222 @@
+package gitutils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MergeWorktree is a temporary linked worktree that reproduces the merge state
+// of a repository: the same index, including the unmerged stages, and the same
+// conflicted files. Resolutions can be applied, formatted and validated there
+// and only brought back into the real checkout once they pass.
+type MergeWorktree struct {
+	RepoPath        string   `json:"repo_path"`
+	Path            string   `json:"path"`
+	ConflictedPaths []string `json:"conflicted_paths"`
+}
+
+// CreateMergeWorktree creates a detached linked worktree in a temporary
+// directory and copies the merge state of repoPath into it. The real checkout
+// is only read.
+func CreateMergeWorktree(repoPath string) (*MergeWorktree, error) {
+	entries, err := ListUnmergedEntries(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := runGitOutput(repoPath, nil, "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "syncwright-worktree-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	worktree := &MergeWorktree{RepoPath: repoPath, Path: dir}
+	for _, entry := range entries {
+		worktree.ConflictedPaths = append(worktree.ConflictedPaths, entry.Path)
+	}
+
+	if err := worktree.populate(index); err != nil {
+		if removeErr := worktree.Remove(); removeErr != nil {
+			return nil, fmt.Errorf("%w (cleanup failed: %v)", err, removeErr)
+		}
+		return nil, err
+	}
+
+	return worktree, nil
+}
+
+// populate adds the worktree without checking out HEAD, loads the index of the
+// real checkout into it, writes the merged files and copies the conflicted files
+func (w *MergeWorktree) populate(index []byte) error {
+	if _, err := runGitOutput(w.RepoPath, nil, "worktree", "add", "--detach", "--no-checkout", w.Path, "HEAD"); err != nil {
+		return fmt.Errorf("failed to add worktree: %w", err)
+	}
+
+	// ls-files --stage output is the format update-index --index-info reads,
+	// so unmerged stages are carried over verbatim
+	if _, err := runGitOutput(w.Path, index, "update-index", "-z", "--index-info"); err != nil {
+		return fmt.Errorf("failed to copy the index: %w", err)
+	}
+	// Unmerged entries are skipped; they are copied from the real checkout below
+	if _, err := runGitOutput(w.Path, nil, "checkout-index", "--all", "--force"); err != nil {
+		return fmt.Errorf("failed to check out merged files: %w", err)
+	}
+
+	for _, path := range w.ConflictedPaths {
+		if err := copyRepoFile(w.RepoPath, w.Path, path); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// SyncBack copies the conflicted files whose content changed in the worktree
+// back into the real checkout, deleting those the worktree deleted. Other paths
+// rewritten in the worktree, such as by go mod tidy, are brought back the same
+// way. It returns the paths that were updated.
+func (w *MergeWorktree) SyncBack(otherPaths ...string) ([]string, error) {
+	synced := []string{}
+
+	for _, path := range append(append([]string{}, w.ConflictedPaths...), otherPaths...) {
+		same, err := sameRepoFile(w.Path, w.RepoPath, path)
+		if err != nil {
+			return synced, err
+		}
+		if same {
+			continue
+		}
+
+		if err := copyRepoFile(w.Path, w.RepoPath, path); err != nil {
+			return synced, fmt.Errorf("failed to bring back %s: %w", path, err)
+		}
+		synced = append(synced, path)
+	}
+
+	return synced, nil
+}
+
+// Remove deletes the worktree and its administrative files
+func (w *MergeWorktree) Remove() error {
+	_, err := runGitOutput(w.RepoPath, nil, "worktree", "remove", "--force", w.Path)
+	if removeErr := os.RemoveAll(w.Path); removeErr != nil && err == nil {
+		err = removeErr
+	}
+	if err != nil {
+		// A half-created worktree may be unknown to git; prune its leftovers
+		if _, pruneErr := runGitOutput(w.RepoPath, nil, "worktree", "prune"); pruneErr == nil && !fileExists(w.Path) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove worktree %s: %w", w.Path, err)
+	}
+	return nil
+}
+
+// copyRepoFile copies a repository path from one checkout to another, keeping
+// symlinks and the file mode. A path missing from the source is removed from the
+// destination.
+func copyRepoFile(fromRoot, toRoot, path string) error {
+	from, err := ResolveRepoPath(fromRoot, path)
+	if err != nil {
+		return err
+	}
+	to, err := ResolveRepoPath(toRoot, path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(from)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0750); err != nil {
+		return err
+	}
+	if err := os.Remove(to); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(from)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, to)
+	}
+
+	content, err := os.ReadFile(from) // #nosec G304 - from is contained in the checkout
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(to, content, info.Mode().Perm())
+}
+
+// sameRepoFile reports whether a path has the same existence, type and content
+// in two checkouts
+func sameRepoFile(rootA, rootB, path string) (bool, error) {
+	read := func(root string) ([]byte, bool, error) {
+		fullPath, err := ResolveRepoPath(root, path)
+		if err != nil {
+			return nil, false, err
+		}
+		info, err := os.Lstat(fullPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(fullPath)
+			return []byte("symlink:" + target), true, err
+		}
+		content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the checkout
+		return content, true, err
+	}
+
+	contentA, existsA, err := read(rootA)
+	if err != nil {
+		return false, err
+	}
+	contentB, existsB, err := read(rootB)
+	if err != nil {
+		return false, err
+	}
+
+	return existsA == existsB && bytes.Equal(contentA, contentB), nil
+}
+
+// runGitOutput runs git in dir, feeding stdin when given, and returns its output
+func runGitOutput(dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...) // #nosec G204 - callers pass fixed subcommands
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
@@ -0,0 +1,65 @@
+package gitutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeWorktree(t *testing.T) {
+	repoPath := newDivergedRepo(t)
+	writeRepoFile(t, repoPath, "untouched.txt", "local change\n")
+	mergeExpectingConflict(t, repoPath, "feature")
+
+	worktree, err := CreateMergeWorktree(repoPath)
+	if err != nil {
+		t.Fatalf("CreateMergeWorktree() error = %v", err)
+	}
+	defer worktree.Remove()
+
+	if len(worktree.ConflictedPaths) != 1 || worktree.ConflictedPaths[0] != "conflict.txt" {
+		t.Fatalf("unexpected conflicted paths %v", worktree.ConflictedPaths)
+	}
+
+	// The worktree reproduces the unmerged index and the merged files
+	conflicts, err := DetectConflicts(worktree.Path)
+	if err != nil || len(conflicts) != 1 || conflicts[0].Status != "UU" {
+		t.Fatalf("DetectConflicts() in worktree = %+v, %v", conflicts, err)
+	}
+	hunks, err := GetConflictHunks("conflict.txt", worktree.Path)
+	if err != nil || len(hunks) != 1 {
+		t.Fatalf("GetConflictHunks() in worktree = %+v, %v", hunks, err)
+	}
+	if content, err := os.ReadFile(filepath.Join(worktree.Path, "other.txt")); err != nil || string(content) != "feature only\n" {
+		t.Errorf("merged file other.txt = %q, %v", content, err)
+	}
+
+	// Nothing changed yet, so nothing is brought back
+	synced, err := worktree.SyncBack()
+	if err != nil || len(synced) != 0 {
+		t.Fatalf("SyncBack() = %v, %v, expected no changes", synced, err)
+	}
+
+	writeRepoFile(t, worktree.Path, "conflict.txt", "resolved\n")
+	synced, err = worktree.SyncBack()
+	if err != nil || len(synced) != 1 {
+		t.Fatalf("SyncBack() = %v, %v", synced, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "conflict.txt")); string(content) != "resolved\n" {
+		t.Errorf("conflict.txt in checkout = %q", content)
+	}
+	if content, _ := os.ReadFile(filepath.Join(repoPath, "untouched.txt")); string(content) != "local change\n" {
+		t.Errorf("local change was overwritten: %q", content)
+	}
+
+	if err := worktree.Remove(); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := os.Stat(worktree.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree directory still exists: %v", err)
+	}
+	if list := runGit(t, repoPath, "worktree", "list"); strings.Count(list, "\n") != 1 {
+		t.Errorf("worktree still registered:\n%s", list)
+	}
+}
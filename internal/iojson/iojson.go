@@ -10,30 +10,31 @@ import (
 	"strings"
 )
 
-// validateFilePath performs basic security validation on file paths
+// validateFilePath performs basic security validation on file paths. Relative
+// paths must stay inside the working directory; absolute paths are an explicit
+// choice of the caller and are allowed. Names are opened directly, never through
+// a shell, so no characters other than NUL are rejected.
 func validateFilePath(filePath string) error {
 	if filePath == "" || filePath == "-" {
 		return nil // stdin/stdout are allowed
 	}
 
+	if strings.ContainsRune(filePath, 0) {
+		return fmt.Errorf("NUL byte in path: %q", filePath)
+	}
+
 	// Clean the path to resolve . and .. components
 	cleanPath := filepath.Clean(filePath)
 
-	// Check for path traversal attempts
-	if strings.Contains(cleanPath, "..") {
-		return fmt.Errorf("path traversal detected in: %s", filePath)
-	}
-
-	// Check for absolute paths that go outside expected boundaries
 	if filepath.IsAbs(cleanPath) {
 		// Allow absolute paths but log them for security review
-		// In production, you might want to restrict this further
 		fmt.Fprintf(os.Stderr, "Info: using absolute path: %s\n", cleanPath)
+		return nil
 	}
 
-	// Check for potentially dangerous characters
-	if strings.ContainsAny(cleanPath, ";|&`$") {
-		return fmt.Errorf("potentially dangerous characters in path: %s", filePath)
+	// Check for path traversal out of the working directory
+	if cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path traversal detected in: %s", filePath)
 	}
 
 	return nil
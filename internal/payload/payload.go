@@ -28,20 +28,58 @@ type PayloadMetadata struct {
 
 // ConflictFilePayload represents a single file's conflict data for AI processing
 type ConflictFilePayload struct {
-	Path      string                `json:"path"`
-	Language  string                `json:"language"`
-	Conflicts []ConflictHunkPayload `json:"conflicts"`
-	Context   FileContext           `json:"context,omitempty"`
+	Path         string                `json:"path"`
+	Language     string                `json:"language"`
+	Encoding     string                `json:"encoding,omitempty"` // Original encoding; content is always UTF-8
+	Conflicts    []ConflictHunkPayload `json:"conflicts"`
+	Context      FileContext           `json:"context,omitempty"`
+	FileConflict *FileConflictPayload  `json:"file_conflict,omitempty"`
+}
+
+// FileConflictPayload describes a file-level conflict (modify/delete, add/add,
+// delete/delete) that has to be resolved as keep, delete or merge
+type FileConflictPayload struct {
+	Kind             string   `json:"kind"`
+	Status           string   `json:"status"`
+	DeletedBy        string   `json:"deleted_by,omitempty"`
+	AddedBy          string   `json:"added_by,omitempty"`
+	SurvivingSide    string   `json:"surviving_side,omitempty"`
+	SurvivingContent []string `json:"surviving_content,omitempty"`
+}
+
+// NewFileConflictPayload converts a gitutils file conflict into its payload form
+func NewFileConflictPayload(fileConflict *gitutils.FileConflict) *FileConflictPayload {
+	if fileConflict == nil {
+		return nil
+	}
+
+	return &FileConflictPayload{
+		Kind:             string(fileConflict.Kind),
+		Status:           fileConflict.Status,
+		DeletedBy:        fileConflict.DeletedBy,
+		AddedBy:          fileConflict.AddedBy,
+		SurvivingSide:    fileConflict.SurvivingSide,
+		SurvivingContent: fileConflict.SurvivingContent,
+	}
 }
 
 // ConflictHunkPayload represents a conflict hunk with essential data
 type ConflictHunkPayload struct {
-	ID          string   `json:"id,omitempty"` // For compatibility with existing code
+	ID          string   `json:"id,omitempty"`      // For compatibility with existing code
+	HunkID      string   `json:"hunk_id,omitempty"` // Content hash of the marker block, see gitutils.HunkID
 	StartLine   int      `json:"start_line"`
 	EndLine     int      `json:"end_line"`
 	OursLines   []string `json:"ours_lines"`
 	TheirsLines []string `json:"theirs_lines"`
 	BaseLines   []string `json:"base_lines,omitempty"` // For compatibility with diff3 style conflicts
+	OursLabel   string   `json:"ours_label,omitempty"` // Marker label of our side, e.g. HEAD
+	BaseLabel   string   `json:"base_label,omitempty"`
+	TheirsLabel string   `json:"theirs_label,omitempty"` // Marker label of their side, e.g. a branch name
+	// KeyPath is set on conflicts of a JSON or YAML file merged key by key. The
+	// sides then hold only the value of that key instead of file lines.
+	KeyPath string `json:"key_path,omitempty"`
+	// ReviewerHint is guidance typed by a reviewer who rejected an earlier proposal
+	ReviewerHint string `json:"reviewer_hint,omitempty"`
 }
 
 // FileContext provides minimal context for better AI understanding (compatibility)
@@ -64,6 +102,12 @@ var excludePatterns = []string{
 	"package-lock.json",
 	"yarn.lock",
 	"Cargo.lock",
+}
+
+// Files whose conflicts stay in the payload for the deterministic strategies
+// but are never sent to AI. A go.sum hunk the go-sum strategy declines has
+// conflicting checksums, which only go mod tidy or a human can settle.
+var humanOnlyFiles = []string{
 	"go.sum",
 }
 
@@ -98,21 +142,35 @@ func BuildSimplePayload(report *gitutils.ConflictReport) (*ConflictPayload, erro
 			continue
 		}
 
+		// Never send paths outside the repository to the AI
+		if _, err := gitutils.CleanRepoPath(conflictFile.Path); err != nil {
+			continue
+		}
+
 		filePayload := ConflictFilePayload{
 			Path:     conflictFile.Path,
 			Language: detectSimpleLanguage(conflictFile.Path),
 			Context:  FileContext{}, // Empty context for simplicity
+
+			FileConflict: NewFileConflictPayload(conflictFile.FileConflict),
+		}
+		if conflictFile.Encoding != nil {
+			filePayload.Encoding = conflictFile.Encoding.String()
 		}
 
 		// Convert conflict hunks
 		for i, hunk := range conflictFile.Hunks {
 			hunkPayload := ConflictHunkPayload{
 				ID:          fmt.Sprintf("%s:%d", conflictFile.Path, i), // For compatibility
+				HunkID:      hunk.ID,
 				StartLine:   hunk.StartLine,
 				EndLine:     hunk.EndLine,
 				OursLines:   hunk.OursLines,
 				TheirsLines: hunk.TheirsLines,
 				BaseLines:   hunk.BaseLines, // Preserve BaseLines for compatibility
+				OursLabel:   hunk.OursLabel,
+				BaseLabel:   hunk.BaseLabel,
+				TheirsLabel: hunk.TheirsLabel,
 			}
 			filePayload.Conflicts = append(filePayload.Conflicts, hunkPayload)
 		}
@@ -123,6 +181,33 @@ func BuildSimplePayload(report *gitutils.ConflictReport) (*ConflictPayload, erro
 	return payload, nil
 }
 
+// IsHumanOnly reports whether the conflicts of a file are never sent to AI
+func IsHumanOnly(filePath string) bool {
+	base := filepath.Base(filePath)
+	for _, name := range humanOnlyFiles {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForAI returns the payload without the files whose conflicts are never sent
+// to AI
+func (p *ConflictPayload) ForAI() *ConflictPayload {
+	forAI := &ConflictPayload{Metadata: p.Metadata}
+	forAI.Metadata.TotalConflicts = 0
+	for _, file := range p.Files {
+		if IsHumanOnly(file.Path) {
+			continue
+		}
+		forAI.Files = append(forAI.Files, file)
+		forAI.Metadata.TotalConflicts += len(file.Conflicts)
+	}
+	forAI.Metadata.TotalFiles = len(forAI.Files)
+	return forAI
+}
+
 // shouldExcludeFile determines if a file should be excluded from processing
 func shouldExcludeFile(filePath string) bool {
 	// Check exclude patterns
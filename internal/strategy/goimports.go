@@ -0,0 +1,475 @@
+package strategy
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+// goImport is an import spec of a Go file
+type goImport struct {
+	name string // Explicit package name, "_" or "."; "" when not given
+	path string
+}
+
+// goImportDecl is the import declaration of one side of a hunk, parsed from the
+// file with the hunk replaced by that side
+type goImportDecl struct {
+	present bool // The side has an import declaration
+	parens  bool // The declaration is a parenthesized block
+	start   int  // Index of its first line
+	end     int  // Index of its last line
+	specs   []goImport
+	groups  [][]goImport // Runs of specs separated by blank lines
+}
+
+// resolveGoImports merges a hunk within the import block of a Go file. Both
+// sides are parsed with the rest of the file, their imports are unioned, and
+// those the rest of the file does not use are dropped. The block is laid out
+// the way gofmt and goimports leave it: standard library first, then other
+// modules, then the module itself when the file keeps its own packages apart.
+// Only the hunk is replaced, so the strategy does not apply when the lines
+// around it would have to change.
+func resolveGoImports(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	if filepath.Ext(file.Path) != ".go" {
+		return nil, "", false
+	}
+	lines := file.Lines()
+	start, end := hunk.StartLine-1, hunk.EndLine-1
+	if start < 0 || end >= len(lines) || end <= start ||
+		!strings.HasPrefix(lines[start], "<<<<<<<") || !strings.HasPrefix(lines[end], ">>>>>>>") {
+		return nil, "", false
+	}
+	prefix, suffix := lines[:start], lines[end+1:]
+
+	ours, ok := parseGoImportDecl(file.Path, prefix, hunk.OursLines, suffix)
+	if !ok {
+		return nil, "", false
+	}
+	theirs, ok := parseGoImportDecl(file.Path, prefix, hunk.TheirsLines, suffix)
+	if !ok || (!ours.present && !theirs.present) {
+		return nil, "", false
+	}
+
+	// The lines of the declaration outside the hunk stay as they are
+	declStart, suffixLines := len(prefix), 0
+	for _, side := range []struct {
+		decl  goImportDecl
+		lines []string
+	}{{ours, hunk.OursLines}, {theirs, hunk.TheirsLines}} {
+		if !side.decl.present {
+			continue
+		}
+		declStart = min(declStart, side.decl.start)
+		suffixLines = max(suffixLines, side.decl.end-(len(prefix)+len(side.lines)-1))
+	}
+	fixedBefore, fixedAfter := prefix[declStart:], suffix[:suffixLines]
+	fixed := make(map[string]bool)
+	for _, spec := range parseGoImportSpecs(append(append([]string{}, fixedBefore...), fixedAfter...)) {
+		fixed[spec.path] = true
+	}
+
+	specs, ok := unionGoImports(ours.specs, theirs.specs)
+	if !ok {
+		return nil, "", false
+	}
+
+	used := usedQualifiers(suffix[suffixLines:])
+	var kept []goImport
+	var dropped []string
+	for _, spec := range specs {
+		names := goImportNames(spec)
+		switch {
+		case fixed[spec.path] || spec.name == "_" || spec.name == "." || spec.path == "C" ||
+			len(names) == 0 || usesAny(used, names):
+			kept = append(kept, spec)
+		case spec.name == "" && !isStandardImport(spec.path):
+			// Only loading the package tells the name it is used by
+			return nil, "", false
+		default:
+			dropped = append(dropped, spec.path)
+		}
+	}
+	if !distinctGoImportNames(kept) {
+		return nil, "", false
+	}
+
+	rendered := renderGoImports(kept, goImportLayout(file, ours, theirs), ours.parens || theirs.parens)
+	if len(rendered) < len(fixedBefore)+len(fixedAfter) ||
+		!equalLines(rendered[:len(fixedBefore)], fixedBefore) ||
+		!equalLines(rendered[len(rendered)-len(fixedAfter):], fixedAfter) {
+		return nil, "", false
+	}
+	if len(rendered) > 0 {
+		formatted, err := format.Source([]byte("package p\n\n" + strings.Join(rendered, "\n") + "\n"))
+		if err != nil || string(formatted) != "package p\n\n"+strings.Join(rendered, "\n")+"\n" {
+			return nil, "", false
+		}
+	}
+
+	reasoning := "Merged the imports of both sides"
+	if len(dropped) > 0 {
+		reasoning += fmt.Sprintf(" and dropped the unused %s", strings.Join(dropped, ", "))
+	}
+	return rendered[len(fixedBefore) : len(rendered)-len(fixedAfter)], reasoning, true
+}
+
+// parseGoImportDecl parses the import declaration of the file made of prefix,
+// side and suffix. It fails when the file does not parse, when it has more
+// than one import declaration or comments within it, or when side is not
+// entirely within the declaration. A side without imports must be blank.
+func parseGoImportDecl(filePath string, prefix, side, suffix []string) (goImportDecl, bool) {
+	lines := append(append(append([]string{}, prefix...), side...), suffix...)
+	src := strings.Join(lines, "\n")
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseFile(fset, filePath, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return goImportDecl{}, false
+	}
+
+	var decls []*ast.GenDecl
+	for _, decl := range parsed.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decls = append(decls, gen)
+		}
+	}
+	switch len(decls) {
+	case 0:
+		return goImportDecl{}, strings.TrimSpace(strings.Join(side, "")) == ""
+	case 1:
+	default:
+		return goImportDecl{}, false
+	}
+
+	gen := decls[0]
+	decl := goImportDecl{
+		present: true,
+		parens:  gen.Lparen.IsValid(),
+		start:   fset.Position(gen.Pos()).Line - 1,
+		end:     fset.Position(gen.End()).Line - 1,
+	}
+	sideStart, sideEnd := len(prefix), len(prefix)+len(side)-1
+	if len(side) == 0 {
+		if sideStart <= decl.start || sideStart > decl.end {
+			return goImportDecl{}, false
+		}
+	} else if sideStart < decl.start || sideEnd > decl.end {
+		return goImportDecl{}, false
+	}
+	for _, group := range parsed.Comments {
+		line := fset.Position(group.Pos()).Line - 1
+		if line >= decl.start && line <= decl.end {
+			return goImportDecl{}, false
+		}
+	}
+
+	lastLine := -1
+	for _, spec := range gen.Specs {
+		importSpec := spec.(*ast.ImportSpec)
+		importPath, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			return goImportDecl{}, false
+		}
+		parsedImport := goImport{path: importPath}
+		if importSpec.Name != nil {
+			parsedImport.name = importSpec.Name.Name
+		}
+
+		line := fset.Position(importSpec.Pos()).Line - 1
+		if len(decl.groups) == 0 || line > lastLine+1 {
+			decl.groups = append(decl.groups, nil)
+		}
+		decl.groups[len(decl.groups)-1] = append(decl.groups[len(decl.groups)-1], parsedImport)
+		decl.specs = append(decl.specs, parsedImport)
+		lastLine = fset.Position(importSpec.End()).Line - 1
+	}
+	return decl, true
+}
+
+// parseGoImportSpecs parses the import specs among lines of an import block
+func parseGoImportSpecs(lines []string) []goImport {
+	var specs []goImport
+	for _, line := range lines {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "import"))
+		if len(fields) == 0 || len(fields) > 2 {
+			continue
+		}
+		importPath, err := strconv.Unquote(fields[len(fields)-1])
+		if err != nil {
+			continue
+		}
+		spec := goImport{path: importPath}
+		if len(fields) == 2 {
+			spec.name = fields[0]
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// unionGoImports unions the imports of both sides. It fails when the sides
+// import the same path under different names.
+func unionGoImports(ours, theirs []goImport) ([]goImport, bool) {
+	byPath := make(map[string]goImport)
+	var specs []goImport
+	for _, spec := range append(append([]goImport{}, ours...), theirs...) {
+		if existing, found := byPath[spec.path]; found {
+			if existing.name != spec.name {
+				return nil, false
+			}
+			continue
+		}
+		byPath[spec.path] = spec
+		specs = append(specs, spec)
+	}
+	return specs, true
+}
+
+// distinctGoImportNames reports whether no two imports bind the same name
+func distinctGoImportNames(specs []goImport) bool {
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		name := goImportName(spec)
+		if name == "" || name == "_" || name == "." {
+			continue
+		}
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+	}
+	return true
+}
+
+// goImportName returns the name a file refers to an import by, or "" when it
+// cannot be told from the import path
+func goImportName(spec goImport) string {
+	if spec.name != "" {
+		return spec.name
+	}
+
+	elems := strings.Split(spec.path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	if strings.HasPrefix(spec.path, "gopkg.in/") {
+		if i := strings.Index(name, ".v"); i > 0 {
+			name = name[:i]
+		}
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if !token.IsIdentifier(name) {
+		return ""
+	}
+	return name
+}
+
+// goImportNames returns the names a file may refer to an import by, or none
+// when they cannot be told from the import path. An unnamed import is known by
+// its package name, which usually is the last path element but for a major
+// version suffix such as k8s.io/api/core/v1 may be either that or the element
+// before it, so both are returned.
+func goImportNames(spec goImport) []string {
+	if spec.name != "" {
+		return []string{spec.name}
+	}
+
+	var names []string
+	elems := strings.Split(spec.path, "/")
+	if last := elems[len(elems)-1]; isMajorVersion(last) && token.IsIdentifier(last) {
+		names = append(names, last)
+	}
+	if name := goImportName(spec); name != "" {
+		names = append(names, name)
+	}
+	return names
+}
+
+// usesAny reports whether any of names is among the used qualifiers
+func usesAny(used map[string]bool, names []string) bool {
+	for _, name := range names {
+		if used[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// isStandardImport reports whether an import path is in the standard library,
+// whose first path element has no dot
+func isStandardImport(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// isMajorVersion reports whether a path element is a major version suffix such as v2
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// usedQualifiers returns the identifiers lines use as a qualifier, as in
+// fmt.Println. Conflict markers and both sides of other conflicts are scanned
+// too, which only keeps more imports.
+func usedQualifiers(lines []string) map[string]bool {
+	src := []byte(strings.Join(lines, "\n"))
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, func(token.Position, string) {}, 0)
+
+	used := make(map[string]bool)
+	previous := ""
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.PERIOD && previous != "" {
+			used[previous] = true
+		}
+		previous = ""
+		if tok == token.IDENT {
+			previous = lit
+		}
+	}
+	return used
+}
+
+// Import groups, in the order they are laid out
+const (
+	goGroupStd = iota
+	goGroupOther
+	goGroupLocal
+)
+
+// goImportLayout decides how imports are grouped: not at all when a side
+// mixes the standard library with other imports in one group, and with the
+// packages of the module apart when a side keeps them in a group of their own
+func goImportLayout(file *File, decls ...goImportDecl) func(goImport) int {
+	module := goModulePath(file)
+	group := func(spec goImport) int {
+		switch {
+		case !strings.Contains(strings.Split(spec.path, "/")[0], "."):
+			return goGroupStd
+		case module != "" && (spec.path == module || strings.HasPrefix(spec.path, module+"/")):
+			return goGroupLocal
+		}
+		return goGroupOther
+	}
+
+	mixed, separateLocal := false, false
+	for _, decl := range decls {
+		for _, specs := range decl.groups {
+			std, local := 0, 0
+			for _, spec := range specs {
+				switch group(spec) {
+				case goGroupStd:
+					std++
+				case goGroupLocal:
+					local++
+				}
+			}
+			mixed = mixed || (std > 0 && std < len(specs))
+			separateLocal = separateLocal || local == len(specs)
+		}
+	}
+
+	return func(spec goImport) int {
+		kind := group(spec)
+		switch {
+		case mixed:
+			return goGroupStd
+		case kind == goGroupLocal && !separateLocal:
+			return goGroupOther
+		}
+		return kind
+	}
+}
+
+// goModulePath returns the module path of the go.mod closest to the file, or
+// "" when there is none
+func goModulePath(file *File) string {
+	if file.repoPath == "" {
+		return ""
+	}
+	for dir := path.Dir(filepath.ToSlash(file.Path)); ; dir = path.Dir(dir) {
+		if modulePath := readGoModulePath(filepath.Join(file.repoPath, filepath.FromSlash(dir), "go.mod")); modulePath != "" {
+			return modulePath
+		}
+		if dir == "." || dir == "/" {
+			return ""
+		}
+	}
+}
+
+// readGoModulePath reads the module directive of a go.mod file
+func readGoModulePath(goModPath string) string {
+	f, err := os.Open(goModPath) // #nosec G304 - goModPath is within the repository
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// renderGoImports lays out an import declaration, sorted by path within each group
+func renderGoImports(specs []goImport, groupOf func(goImport) int, parens bool) []string {
+	if len(specs) == 0 {
+		return []string{}
+	}
+
+	line := func(spec goImport) string {
+		if spec.name != "" {
+			return spec.name + " " + strconv.Quote(spec.path)
+		}
+		return strconv.Quote(spec.path)
+	}
+	if len(specs) == 1 && !parens {
+		return []string{"import " + line(specs[0])}
+	}
+
+	sorted := append([]goImport{}, specs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		gi, gj := groupOf(sorted[i]), groupOf(sorted[j])
+		if gi != gj {
+			return gi < gj
+		}
+		if sorted[i].path != sorted[j].path {
+			return sorted[i].path < sorted[j].path
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	rendered := []string{"import ("}
+	for i, spec := range sorted {
+		if i > 0 && groupOf(spec) != groupOf(sorted[i-1]) {
+			rendered = append(rendered, "")
+		}
+		rendered = append(rendered, "\t"+line(spec))
+	}
+	return append(rendered, ")")
+}
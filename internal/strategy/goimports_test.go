@@ -0,0 +1,239 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+func TestResolveGoImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string // Resolved lines joined by "|"; "" when the strategy does not apply
+		dropped  bool
+	}{
+		{
+			name: "both added to a block",
+			content: `package main
+
+import (
+	"fmt"
+<<<<<<< HEAD
+	"os"
+=======
+	"strings"
+>>>>>>> feature
+)
+
+func main() { fmt.Println(os.Args, strings.ToUpper("x")) }
+`,
+			expected: "\t\"os\"|\t\"strings\"",
+		},
+		{
+			name: "unused import dropped",
+			content: `package main
+
+import (
+	"fmt"
+<<<<<<< HEAD
+	"os"
+=======
+	"sort"
+>>>>>>> feature
+)
+
+func main() { fmt.Println(os.Args) }
+`,
+			expected: "\t\"os\"",
+			dropped:  true,
+		},
+		{
+			name: "version suffix used as the package name",
+			content: `package main
+
+import (
+<<<<<<< HEAD
+	"k8s.io/api/core/v1"
+=======
+	"sort"
+>>>>>>> feature
+)
+
+func main() { _ = v1.Pod{} }
+`,
+			expected: "\t\"k8s.io/api/core/v1\"",
+			dropped:  true,
+		},
+		{
+			name: "unused import outside the standard library",
+			content: `package main
+
+import (
+	"fmt"
+<<<<<<< HEAD
+	"os"
+=======
+	"github.com/pkg/errors"
+>>>>>>> feature
+)
+
+func main() { fmt.Println(os.Args) }
+`,
+		},
+		{
+			name: "regrouped with named and dot imports",
+			content: `package main
+
+<<<<<<< HEAD
+import (
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+=======
+import (
+	. "math"
+
+	"github.com/pkg/errors"
+
+	"example.com/app/internal/config"
+)
+>>>>>>> feature
+
+func main() {
+	_ = os.Args
+	_, _ = yaml.Marshal(config.Load())
+	_ = errors.New(Pi)
+}
+`,
+			expected: "import (|\t. \"math\"|\t\"os\"||\t\"github.com/pkg/errors\"|\tyaml \"gopkg.in/yaml.v3\"||\t\"example.com/app/internal/config\"|)",
+		},
+		{
+			name: "outside the imports",
+			content: `package main
+
+import "fmt"
+
+func main() {
+<<<<<<< HEAD
+	fmt.Println("a")
+=======
+	fmt.Println("b")
+>>>>>>> feature
+}
+`,
+		},
+		{
+			name: "comments in the block",
+			content: `package main
+
+import (
+<<<<<<< HEAD
+	"os" // for Args
+=======
+	"strings"
+>>>>>>> feature
+)
+
+func main() { _, _ = os.Args, strings.ToUpper }
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(repoPath, "go.mod"), []byte("module example.com/app\n\ngo 1.23\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Join(repoPath, "cmd"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, "cmd", "main.go"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			hunks, err := gitutils.ParseConflictHunks("cmd/main.go", repoPath)
+			if err != nil || len(hunks) != 1 {
+				t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+			}
+
+			lines, reasoning, ok := resolveGoImports(NewFile(repoPath, "cmd/main.go"), hunks[0])
+			if ok != (tt.expected != "") {
+				t.Fatalf("resolveGoImports() = %q, %v", lines, ok)
+			}
+			if !ok {
+				return
+			}
+			if got := strings.Join(lines, "|"); got != tt.expected {
+				t.Errorf("resolved lines = %q, expected %q", got, tt.expected)
+			}
+			if dropped := strings.Contains(reasoning, "unused"); dropped != tt.dropped {
+				t.Errorf("reasoning = %q", reasoning)
+			}
+
+			resolution, ok := NewEngine(repoPath).Resolve("cmd/main.go", hunks[0])
+			if !ok || resolution.Strategy != StrategyGoImports {
+				t.Errorf("Resolve() = %+v, %v", resolution, ok)
+			}
+		})
+	}
+}
+
+func TestResolveGoImports_ApplyUnusedImports(t *testing.T) {
+	// Neither side's import is used, so the import hunk resolves to no lines
+	// and is applied along with the other hunk of the file
+	content := `package main
+
+<<<<<<< HEAD
+import "os"
+=======
+import "strings"
+>>>>>>> feature
+
+func main() {
+<<<<<<< HEAD
+	println("done")
+=======
+	println("done")  
+>>>>>>> feature
+}
+`
+	repoPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoPath, "main.go"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hunks, err := gitutils.ParseConflictHunks("main.go", repoPath)
+	if err != nil || len(hunks) != 2 {
+		t.Fatalf("ParseConflictHunks() = %+v, %v", hunks, err)
+	}
+
+	engine := NewEngine(repoPath)
+	var resolutions []gitutils.ConflictResolution
+	for _, hunk := range hunks {
+		resolution, ok := engine.Resolve("main.go", hunk)
+		if !ok {
+			t.Fatalf("Resolve(%+v) did not apply", hunk)
+		}
+		resolutions = append(resolutions, resolution)
+	}
+	if resolutions[0].Strategy != StrategyGoImports || len(resolutions[0].ResolvedLines) != 0 {
+		t.Fatalf("import resolution = %+v", resolutions[0])
+	}
+
+	result, err := gitutils.ApplyResolutions(repoPath, resolutions)
+	if err != nil || result.AppliedCount != 1 || len(result.FailedFiles) != 0 {
+		t.Fatalf("ApplyResolutions() = %+v, %v", result, err)
+	}
+	applied, err := os.ReadFile(filepath.Join(repoPath, "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "package main\n\n\nfunc main() {\n\tprintln(\"done\")\n}\n"
+	if string(applied) != expected {
+		t.Errorf("applied file =\n%s\nexpected\n%s", applied, expected)
+	}
+}
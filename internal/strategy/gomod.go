@@ -0,0 +1,404 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// goModSides holds one directive of base, ours and theirs; "" marks a side
+// without it
+type goModSides struct {
+	base, ours, theirs string
+}
+
+// mergeGoMod merges three versions of a go.mod file directive by directive.
+// Requirements, replacements, exclusions, retractions, tools and godebug
+// settings of both sides are kept; a module both sides required at different
+// versions takes the higher one, and the go and toolchain directives take the
+// highest version. It fails where the sides cannot both be right: different
+// module paths, replacements or godebug values.
+func mergeGoMod(baseText, oursText, theirsText string) (*StructuredMerge, error) {
+	base, err := modfile.Parse("go.mod", []byte(baseText+"\n"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the base: %w", err)
+	}
+	ours, err := modfile.Parse("go.mod", []byte(oursText+"\n"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse our side: %w", err)
+	}
+	theirs, err := modfile.Parse("go.mod", []byte(theirsText+"\n"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse their side: %w", err)
+	}
+
+	// Our file is edited into the merge so its comments and layout stay
+	merger := &goModMerger{merged: ours}
+	for _, step := range []func(base, ours, theirs *modfile.File) error{
+		merger.mergeModule,
+		merger.mergeGo,
+		merger.mergeRequire,
+		merger.mergeReplace,
+		merger.mergeExclude,
+		merger.mergeRetract,
+		merger.mergeTool,
+		merger.mergeGodebug,
+	} {
+		if err := step(base, ours, theirs); err != nil {
+			return nil, err
+		}
+	}
+	merger.merged.Cleanup()
+	formatted, err := merger.merged.Format()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := modfile.Parse("go.mod", formatted, nil); err != nil {
+		return nil, fmt.Errorf("the merged go.mod does not parse: %w", err)
+	}
+
+	reasoning := "Merged the directives of both sides"
+	if merger.bumped > 0 {
+		reasoning += fmt.Sprintf(", taking the higher version of %d modules both sides changed", merger.bumped)
+	}
+	return &StructuredMerge{
+		Format:    FormatGoMod,
+		reasoning: reasoning,
+		render: func(map[string][]string) (string, error) {
+			return string(formatted), nil
+		},
+	}, nil
+}
+
+// goModMerger edits our go.mod into the merge of both sides
+type goModMerger struct {
+	merged *modfile.File
+	bumped int // Modules both sides required at different versions
+}
+
+// pick merges one directive three-way. It reports false when both sides
+// changed it differently.
+func (s goModSides) pick() (string, bool) {
+	switch {
+	case s.ours == s.theirs, s.base == s.theirs:
+		return s.ours, true
+	case s.base == s.ours:
+		return s.theirs, true
+	}
+	return "", false
+}
+
+// keep reports whether an entry of a set directive, such as an exclusion,
+// stays in the merge: it does unless one side removed it
+func (s goModSides) keep() bool {
+	if s.base != "" {
+		return s.ours != "" && s.theirs != ""
+	}
+	return s.ours != "" || s.theirs != ""
+}
+
+func (m *goModMerger) mergeModule(base, ours, theirs *modfile.File) error {
+	sides := goModSides{modulePath(base), modulePath(ours), modulePath(theirs)}
+	path, ok := sides.pick()
+	if !ok {
+		return fmt.Errorf("both sides renamed the module")
+	}
+	if path != sides.ours {
+		return m.merged.AddModuleStmt(path)
+	}
+	return nil
+}
+
+// mergeGo keeps the highest go and toolchain versions of both sides
+func (m *goModMerger) mergeGo(_, ours, theirs *modfile.File) error {
+	if theirs.Go != nil && (ours.Go == nil || compareGoVersions(theirs.Go.Version, ours.Go.Version) > 0) {
+		if err := m.merged.AddGoStmt(theirs.Go.Version); err != nil {
+			return err
+		}
+	}
+	if name := toolchainName(theirs); name != "" && compareGoVersions(name, toolchainName(ours)) > 0 {
+		return m.merged.AddToolchainStmt("go" + name)
+	}
+	return nil
+}
+
+func (m *goModMerger) mergeRequire(base, ours, theirs *modfile.File) error {
+	requirement := func(f *modfile.File) map[string]string {
+		versions := make(map[string]string, len(f.Require))
+		for _, r := range f.Require {
+			versions[r.Mod.Path] = r.Mod.Version
+			if r.Indirect {
+				versions[r.Mod.Path] += " // indirect"
+			}
+		}
+		return versions
+	}
+	baseReqs, ourReqs, theirReqs := requirement(base), requirement(ours), requirement(theirs)
+
+	var merged []*modfile.Require
+	for _, path := range unionKeys(ourReqs, theirReqs) {
+		sides := goModSides{baseReqs[path], ourReqs[path], theirReqs[path]}
+		requirement, ok := sides.pick()
+		if !ok {
+			requirement = higherRequirement(sides.ours, sides.theirs)
+			m.bumped++
+		}
+		if requirement == "" {
+			continue
+		}
+		merged = append(merged, &modfile.Require{
+			Mod:      module.Version{Path: path, Version: versionOf(requirement)},
+			Indirect: strings.HasSuffix(requirement, "indirect"),
+		})
+	}
+	m.merged.SetRequireSeparateIndirect(merged)
+	return nil
+}
+
+func (m *goModMerger) mergeReplace(base, ours, theirs *modfile.File) error {
+	replacement := func(f *modfile.File) map[string]string {
+		targets := make(map[string]string, len(f.Replace))
+		for _, r := range f.Replace {
+			targets[r.Old.Path+"@"+r.Old.Version] = r.New.Path + "@" + r.New.Version
+		}
+		return targets
+	}
+	baseReplace, ourReplace, theirReplace := replacement(base), replacement(ours), replacement(theirs)
+
+	for _, old := range unionKeys(ourReplace, theirReplace) {
+		sides := goModSides{baseReplace[old], ourReplace[old], theirReplace[old]}
+		target, ok := sides.pick()
+		if !ok {
+			return fmt.Errorf("both sides changed the replacement of %s", strings.TrimSuffix(old, "@"))
+		}
+		if target == sides.ours {
+			continue
+		}
+		oldPath, oldVersion, _ := strings.Cut(old, "@")
+		if target == "" {
+			if err := m.merged.DropReplace(oldPath, oldVersion); err != nil {
+				return err
+			}
+			continue
+		}
+		newPath, newVersion, _ := strings.Cut(target, "@")
+		if err := m.merged.AddReplace(oldPath, oldVersion, newPath, newVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *goModMerger) mergeExclude(base, ours, theirs *modfile.File) error {
+	exclusion := func(f *modfile.File) map[string]string {
+		excluded := make(map[string]string, len(f.Exclude))
+		for _, e := range f.Exclude {
+			excluded[e.Mod.Path+"@"+e.Mod.Version] = e.Mod.Version
+		}
+		return excluded
+	}
+	baseExclude, ourExclude, theirExclude := exclusion(base), exclusion(ours), exclusion(theirs)
+
+	for _, key := range unionKeys(ourExclude, theirExclude) {
+		sides := goModSides{baseExclude[key], ourExclude[key], theirExclude[key]}
+		path, version, _ := strings.Cut(key, "@")
+		switch keep := sides.keep(); {
+		case keep && sides.ours == "":
+			if err := m.merged.AddExclude(path, version); err != nil {
+				return err
+			}
+		case !keep && sides.ours != "":
+			if err := m.merged.DropExclude(path, version); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *goModMerger) mergeRetract(base, ours, theirs *modfile.File) error {
+	retraction := func(f *modfile.File) map[string]string {
+		retracted := make(map[string]string, len(f.Retract))
+		for _, r := range f.Retract {
+			retracted[r.Low+"@"+r.High] = "// " + r.Rationale
+		}
+		return retracted
+	}
+	baseRetract, ourRetract, theirRetract := retraction(base), retraction(ours), retraction(theirs)
+
+	for _, key := range unionKeys(ourRetract, theirRetract) {
+		sides := goModSides{baseRetract[key], ourRetract[key], theirRetract[key]}
+		low, high, _ := strings.Cut(key, "@")
+		interval := modfile.VersionInterval{Low: low, High: high}
+		switch keep := sides.keep(); {
+		case keep && sides.ours == "":
+			if err := m.merged.AddRetract(interval, strings.TrimPrefix(sides.theirs, "// ")); err != nil {
+				return err
+			}
+		case !keep && sides.ours != "":
+			if err := m.merged.DropRetract(interval); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *goModMerger) mergeTool(base, ours, theirs *modfile.File) error {
+	tools := func(f *modfile.File) map[string]string {
+		paths := make(map[string]string, len(f.Tool))
+		for _, t := range f.Tool {
+			paths[t.Path] = t.Path
+		}
+		return paths
+	}
+	baseTools, ourTools, theirTools := tools(base), tools(ours), tools(theirs)
+
+	for _, path := range unionKeys(ourTools, theirTools) {
+		sides := goModSides{baseTools[path], ourTools[path], theirTools[path]}
+		switch keep := sides.keep(); {
+		case keep && sides.ours == "":
+			if err := m.merged.AddTool(path); err != nil {
+				return err
+			}
+		case !keep && sides.ours != "":
+			if err := m.merged.DropTool(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *goModMerger) mergeGodebug(base, ours, theirs *modfile.File) error {
+	settings := func(f *modfile.File) map[string]string {
+		values := make(map[string]string, len(f.Godebug))
+		for _, g := range f.Godebug {
+			values[g.Key] = "=" + g.Value
+		}
+		return values
+	}
+	baseSettings, ourSettings, theirSettings := settings(base), settings(ours), settings(theirs)
+
+	for _, key := range unionKeys(ourSettings, theirSettings) {
+		sides := goModSides{baseSettings[key], ourSettings[key], theirSettings[key]}
+		value, ok := sides.pick()
+		switch {
+		case !ok:
+			return fmt.Errorf("both sides changed godebug %s", key)
+		case value == sides.ours:
+		case value == "":
+			if err := m.merged.DropGodebug(key); err != nil {
+				return err
+			}
+		default:
+			if err := m.merged.AddGodebug(key, strings.TrimPrefix(value, "=")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// modulePath returns the module path of a go.mod file, or "" without one
+func modulePath(f *modfile.File) string {
+	if f.Module == nil {
+		return ""
+	}
+	return f.Module.Mod.Path
+}
+
+// toolchainName returns the Go version of the toolchain directive without its
+// go prefix, or "" when there is none
+func toolchainName(f *modfile.File) string {
+	if f.Toolchain == nil || f.Toolchain.Name == "default" {
+		return ""
+	}
+	return strings.TrimPrefix(f.Toolchain.Name, "go")
+}
+
+// higherRequirement picks between two requirements of a module that both sides
+// changed: the higher version, or the one still required when the other side
+// dropped it. At the same version, the requirement is direct unless both are
+// indirect.
+func higherRequirement(ours, theirs string) string {
+	switch {
+	case ours == "":
+		return theirs
+	case theirs == "":
+		return ours
+	}
+	switch semver.Compare(versionOf(ours), versionOf(theirs)) {
+	case 1:
+		return ours
+	case -1:
+		return theirs
+	}
+	return versionOf(ours)
+}
+
+// versionOf returns the version of a requirement as kept by mergeRequire
+func versionOf(requirement string) string {
+	version, _, _ := strings.Cut(requirement, " ")
+	return version
+}
+
+// unionKeys returns the keys of both maps in sorted order
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareGoVersions compares Go versions such as 1.21, 1.21rc1 and 1.21.3,
+// which sort in that order. An empty version sorts first.
+func compareGoVersions(a, b string) int {
+	parse := func(version string) []int {
+		if version == "" {
+			return []int{-1}
+		}
+		parts := modfile.GoVersionRE.FindStringSubmatch(version)
+		if parts == nil {
+			return []int{-1}
+		}
+		major, _ := strconv.Atoi(parts[1])
+		minor, _ := strconv.Atoi(parts[2])
+		patch, kind, number := 0, 0, 0 // kind: 0 language version, 1 prerelease, 2 release
+		switch {
+		case parts[4] != "":
+			patch, _ = strconv.Atoi(parts[4])
+			kind = 2
+		case parts[5] != "":
+			kind = 1
+			name := strings.TrimRight(parts[5], "0123456789")
+			number, _ = strconv.Atoi(parts[5][len(name):])
+			// alpha, beta and rc are in alphabetical order
+			number += map[string]int{"alpha": 0, "beta": 1000, "rc": 2000}[name]
+		}
+		return []int{major, minor, kind, patch, number}
+	}
+
+	left, right := parse(a), parse(b)
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if left[i] != right[i] {
+			if left[i] < right[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(left) - len(right)
+}
@@ -0,0 +1,182 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+)
+
+func TestMergeGoMod(t *testing.T) {
+	base := `module example.com/app
+
+go 1.22
+
+require (
+	github.com/spf13/cobra v1.8.0
+	go.uber.org/zap v1.26.0
+)
+
+require github.com/spf13/pflag v1.0.5 // indirect
+
+exclude golang.org/x/net v0.1.0
+
+replace example.com/lib => ../lib
+`
+
+	tests := []struct {
+		name     string
+		ours     string
+		theirs   string
+		expected string // "" when the merge fails
+		bumped   bool
+	}{
+		{
+			name: "requirements of both sides",
+			ours: strings.NewReplacer(
+				"go 1.22", "go 1.23.0\n\ntoolchain go1.23.4",
+				"cobra v1.8.0", "cobra v1.8.1",
+				"go.uber.org/zap v1.26.0", "go.uber.org/zap v1.26.0\n\tgopkg.in/yaml.v3 v3.0.1",
+			).Replace(base),
+			theirs: strings.NewReplacer(
+				"go 1.22", "go 1.22.5\n\ntoolchain go1.24.4",
+				"cobra v1.8.0", "cobra v1.9.1",
+				"require github.com/spf13/pflag v1.0.5 // indirect", "require (\n\tgithub.com/spf13/pflag v1.0.6 // indirect\n\tgolang.org/x/sys v0.15.0 // indirect\n)",
+				"exclude golang.org/x/net v0.1.0", "",
+			).Replace(base),
+			expected: `module example.com/app
+
+go 1.23.0
+
+toolchain go1.24.4
+
+require (
+	github.com/spf13/cobra v1.9.1
+	go.uber.org/zap v1.26.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+)
+
+replace example.com/lib => ../lib`,
+			bumped: true,
+		},
+		{
+			name:   "replacement changed on both sides",
+			ours:   strings.Replace(base, "../lib", "../lib-ours", 1),
+			theirs: strings.Replace(base, "../lib", "example.com/lib v1.2.0", 1),
+		},
+		{
+			name:   "module renamed on both sides",
+			ours:   strings.Replace(base, "example.com/app", "example.com/app/v2", 1),
+			theirs: strings.Replace(base, "example.com/app", "example.org/app", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merge, err := MergeStructured("go.mod", strings.Split(base, "\n"), strings.Split(tt.ours, "\n"), strings.Split(tt.theirs, "\n"))
+			if (err == nil) != (tt.expected != "") {
+				t.Fatalf("MergeStructured() error = %v", err)
+			}
+			if err != nil {
+				return
+			}
+
+			lines, err := merge.Lines(nil)
+			if err != nil {
+				t.Fatalf("Lines() error = %v", err)
+			}
+			if got := strings.Join(lines, "\n"); got != tt.expected {
+				t.Errorf("merged =\n%s\nexpected\n%s", got, tt.expected)
+			}
+			if bumped := strings.Contains(merge.reasoning, "higher version"); bumped != tt.bumped {
+				t.Errorf("reasoning = %q", merge.reasoning)
+			}
+		})
+	}
+}
+
+func TestCompareGoVersions(t *testing.T) {
+	ordered := []string{"", "1.9", "1.21", "1.21rc1", "1.21rc2", "1.21.0", "1.21.10", "1.22"}
+	for i := range ordered {
+		for j := range ordered {
+			got := compareGoVersions(ordered[i], ordered[j])
+			if (got < 0) != (i < j) || (got == 0) != (i == j) {
+				t.Errorf("compareGoVersions(%q, %q) = %d", ordered[i], ordered[j], got)
+			}
+		}
+	}
+}
+
+func TestResolveGoSum(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		hunk     gitutils.ConflictHunk
+		expected string // Resolved lines joined by "|"; "" when the strategy does not apply
+	}{
+		{
+			name:     "checksums of both sides",
+			filePath: "go.sum",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{
+					"github.com/spf13/cobra v1.8.1 h1:a=",
+					"github.com/spf13/cobra v1.8.1/go.mod h1:b=",
+				},
+				TheirsLines: []string{
+					"github.com/spf13/cobra v1.10.1 h1:c=",
+					"github.com/spf13/cobra v1.10.1/go.mod h1:d=",
+					"github.com/spf13/cobra v1.8.1/go.mod h1:b=",
+				},
+			},
+			expected: "github.com/spf13/cobra v1.8.1 h1:a=|github.com/spf13/cobra v1.8.1/go.mod h1:b=|" +
+				"github.com/spf13/cobra v1.10.1 h1:c=|github.com/spf13/cobra v1.10.1/go.mod h1:d=",
+		},
+		{
+			name:     "checksums disagree",
+			filePath: "go.sum",
+			hunk: gitutils.ConflictHunk{
+				OursLines:   []string{"github.com/spf13/cobra v1.8.1 h1:a="},
+				TheirsLines: []string{"github.com/spf13/cobra v1.8.1 h1:x="},
+			},
+		},
+		{
+			name:     "not a go.sum file",
+			filePath: "sums.txt",
+			hunk: gitutils.ConflictHunk{
+				OursLines:   []string{"github.com/spf13/cobra v1.8.1 h1:a="},
+				TheirsLines: []string{"github.com/spf13/cobra v1.10.1 h1:c="},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, _, ok := resolveGoSum(NewFile("", tt.filePath), tt.hunk)
+			if ok != (tt.expected != "") {
+				t.Fatalf("resolveGoSum() = %q, %v", lines, ok)
+			}
+			if got := strings.Join(lines, "|"); ok && got != tt.expected {
+				t.Errorf("resolved lines = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEngine_LeavesConflictingGoSumChecksums(t *testing.T) {
+	hunk := gitutils.ConflictHunk{
+		OursLines:   []string{"github.com/spf13/cobra v1.8.1 h1:a="},
+		BaseLines:   []string{},
+		TheirsLines: []string{"github.com/spf13/cobra v1.8.1 h1:x="},
+	}
+	if name := NewEngine("").Classify("go.sum", hunk); name != "" {
+		t.Errorf("conflicting checksums were resolved by %q", name)
+	}
+	if name := NewEngine("").Classify("sums.txt", hunk); name != StrategyUnion {
+		t.Errorf("sums.txt classified as %q, expected %q", name, StrategyUnion)
+	}
+}
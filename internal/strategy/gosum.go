@@ -0,0 +1,63 @@
+package strategy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"golang.org/x/mod/semver"
+)
+
+// resolveGoSum resolves a hunk of a go.sum file with the checksums of both
+// sides, in the order go writes them. Extra checksums are harmless and go mod
+// tidy drops the ones no longer needed. It does not apply when the sides
+// disagree on the checksum of a module version.
+func resolveGoSum(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	if filepath.Base(file.Path) != "go.sum" {
+		return nil, "", false
+	}
+
+	sums := make(map[string]string)
+	var lines []string
+	for _, line := range append(append([]string{}, hunk.OursLines...), hunk.TheirsLines...) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.Contains(fields[2], ":") {
+			return nil, "", false
+		}
+		entry := fields[0] + " " + fields[1]
+		if sum, found := sums[entry]; found {
+			if sum != fields[2] {
+				return nil, "", false
+			}
+			continue
+		}
+		sums[entry] = fields[2]
+		lines = append(lines, strings.Join(fields, " "))
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return goSumLess(lines[i], lines[j])
+	})
+	added := len(lines) - len(hunk.OursLines)
+	return lines, fmt.Sprintf("Kept the checksums of both sides (%d from theirs)", added), true
+}
+
+// goSumLess orders go.sum lines by module path, then version, with the go.mod
+// checksum of a version after the checksum of its content
+func goSumLess(a, b string) bool {
+	pathA, versionA, _ := strings.Cut(a, " ")
+	pathB, versionB, _ := strings.Cut(b, " ")
+	if pathA != pathB {
+		return pathA < pathB
+	}
+	versionA, _, _ = strings.Cut(versionA, " ")
+	versionB, _, _ = strings.Cut(versionB, " ")
+	moduleA, goModA := strings.CutSuffix(versionA, "/go.mod")
+	moduleB, goModB := strings.CutSuffix(versionB, "/go.mod")
+	if moduleA != moduleB {
+		return semver.Compare(moduleA, moduleB) < 0
+	}
+	return !goModA && goModB
+}
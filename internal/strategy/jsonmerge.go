@@ -0,0 +1,234 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// jsonNode is a parsed JSON value that keeps its source text. Objects are
+// parsed into their fields in order; every other value is kept whole.
+type jsonNode struct {
+	raw    string
+	keys   []string // Keys of an object in order; nil for other values
+	fields map[string]*jsonNode
+}
+
+// isObject reports whether the node is a JSON object
+func (n *jsonNode) isObject() bool {
+	return n != nil && n.fields != nil
+}
+
+// parseJSON parses a JSON document
+func parseJSON(text string) (*jsonNode, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the JSON value")
+	}
+	return parseJSONValue(raw)
+}
+
+// parseJSONValue parses a raw JSON value, descending into objects
+func parseJSONValue(raw json.RawMessage) (*jsonNode, error) {
+	node := &jsonNode{raw: string(raw)}
+	if !bytes.HasPrefix(raw, []byte("{")) {
+		return node, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	node.keys = []string{}
+	node.fields = make(map[string]*jsonNode)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		if _, duplicate := node.fields[key]; duplicate {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		child, err := parseJSONValue(value)
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.fields[key] = child
+	}
+	return node, nil
+}
+
+// sameJSON reports whether two values are equal, ignoring layout. Absent
+// values are nil.
+func sameJSON(a, b *jsonNode) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, []byte(a.raw)) != nil || json.Compact(&compactB, []byte(b.raw)) != nil {
+		return a.raw == b.raw
+	}
+	return compactA.String() == compactB.String()
+}
+
+// jsonMerged is a value of a merged JSON document: a value taken from one
+// side, an object whose fields were merged, or a key conflict
+type jsonMerged struct {
+	raw      string
+	keys     []string
+	fields   map[string]*jsonMerged
+	conflict string // ID of the key conflict
+}
+
+// jsonStyle is the layout of the JSON document being merged
+type jsonStyle struct {
+	indent    string // One level of indentation; "" for single line documents
+	separator string // Between a key and its value
+}
+
+// detectJSONStyle reads the layout of a document: the indentation of its
+// first indented line and the spacing after the first key
+func detectJSONStyle(text string) jsonStyle {
+	style := jsonStyle{separator: ":"}
+	for _, line := range strings.Split(text, "\n")[1:] {
+		if indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; indent != "" {
+			style.indent = indent
+			break
+		}
+	}
+	if strings.Contains(text, `": `) {
+		style.separator = ": "
+	}
+	return style
+}
+
+// jsonMerger merges the values of three JSON documents
+type jsonMerger struct {
+	conflicts []KeyConflict
+}
+
+// mergeJSON merges three JSON documents key by key
+func mergeJSON(baseText, oursText, theirsText string) (*StructuredMerge, error) {
+	base, err := parseJSON(baseText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the base: %w", err)
+	}
+	ours, err := parseJSON(oursText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse our side: %w", err)
+	}
+	theirs, err := parseJSON(theirsText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse their side: %w", err)
+	}
+
+	merger := &jsonMerger{}
+	root := merger.merge("", base, ours, theirs)
+	if root == nil || root.conflict != "" {
+		return nil, fmt.Errorf("the documents conflict as a whole")
+	}
+
+	style := detectJSONStyle(oursText)
+	return &StructuredMerge{
+		Format:    FormatJSON,
+		Conflicts: merger.conflicts,
+		reasoning: "Merged the JSON keys of both sides",
+		render: func(resolved map[string][]string) (string, error) {
+			text := style.render(root, 0, resolved)
+			if !json.Valid([]byte(text)) {
+				return "", fmt.Errorf("the merged JSON does not parse")
+			}
+			return text, nil
+		},
+	}, nil
+}
+
+// merge merges the value at path, returning nil when the merged document has
+// no value there
+func (m *jsonMerger) merge(path string, base, ours, theirs *jsonNode) *jsonMerged {
+	switch {
+	case sameJSON(ours, theirs):
+		return takeJSON(ours)
+	case sameJSON(base, ours):
+		return takeJSON(theirs)
+	case sameJSON(base, theirs):
+		return takeJSON(ours)
+	case ours.isObject() && theirs.isObject() && (base == nil || base.isObject()):
+		merged := &jsonMerged{fields: make(map[string]*jsonMerged)}
+		for _, key := range mergeKeyOrder(ours.keys, theirs.keys) {
+			var baseField *jsonNode
+			if base != nil {
+				baseField = base.fields[key]
+			}
+			if field := m.merge(joinKeyPath(path, key), baseField, ours.fields[key], theirs.fields[key]); field != nil {
+				merged.keys = append(merged.keys, key)
+				merged.fields[key] = field
+			}
+		}
+		return merged
+	}
+
+	conflict := newKeyConflict(path, jsonLines(base), jsonLines(ours), jsonLines(theirs))
+	m.conflicts = append(m.conflicts, conflict)
+	return &jsonMerged{conflict: conflict.ID}
+}
+
+// takeJSON takes a value from one side as it is
+func takeJSON(node *jsonNode) *jsonMerged {
+	if node == nil {
+		return nil
+	}
+	return &jsonMerged{raw: node.raw}
+}
+
+// jsonLines returns the lines of a value, shifted to no indentation, or none
+// for an absent value
+func jsonLines(node *jsonNode) []string {
+	if node == nil {
+		return []string{}
+	}
+	return shiftIndent(strings.Split(node.raw, "\n"), "")
+}
+
+// render writes a merged value at the given depth
+func (s jsonStyle) render(value *jsonMerged, depth int, resolved map[string][]string) string {
+	indent := strings.Repeat(s.indent, depth)
+	switch {
+	case value.conflict != "":
+		return strings.Join(shiftIndent(resolved[value.conflict], indent), "\n")
+	case value.fields == nil:
+		return strings.Join(shiftIndent(strings.Split(value.raw, "\n"), indent), "\n")
+	}
+
+	var fields []string
+	for _, key := range value.keys {
+		field := value.fields[key]
+		if field.conflict != "" && len(resolved[field.conflict]) == 0 {
+			continue // Resolved by removing the key
+		}
+		name, _ := json.Marshal(key)
+		fields = append(fields, string(name)+s.separator+s.render(field, depth+1, resolved))
+	}
+
+	switch {
+	case len(fields) == 0:
+		return "{}"
+	case s.indent == "":
+		return "{" + strings.Join(fields, ",") + "}"
+	}
+	inner := strings.Repeat(s.indent, depth+1)
+	return "{\n" + inner + strings.Join(fields, ",\n"+inner) + "\n" + indent + "}"
+}
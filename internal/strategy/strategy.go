@@ -0,0 +1,409 @@
+// Package strategy resolves the conflict hunks that need no AI with
+// deterministic rules
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+// Names of the built-in strategies, recorded on the resolutions they produce
+const (
+	// StrategyIdentical resolves hunks where both sides made the same change
+	StrategyIdentical = "identical"
+	// StrategyOneSided resolves hunks where one side is unchanged from the base
+	// by taking the other side
+	StrategyOneSided = "one-sided"
+	// StrategyWhitespace resolves hunks whose sides differ only in whitespace
+	// by taking our side
+	StrategyWhitespace = "whitespace"
+	// StrategyGoSum resolves hunks of go.sum files by keeping the checksums
+	// of both sides, see resolveGoSum
+	StrategyGoSum = "go-sum"
+	// StrategyGoImports resolves hunks in the import block of a Go file by
+	// merging the imports of both sides, see resolveGoImports
+	StrategyGoImports = "go-imports"
+	// StrategyUnion resolves hunks where both sides only added lines by keeping
+	// both additions, ours first
+	StrategyUnion = "union"
+)
+
+// Strategy resolves one kind of conflict hunk without AI
+type Strategy interface {
+	// Name identifies the strategy on the resolutions it produces
+	Name() string
+	// Resolve returns the lines the hunk resolves to and the reasoning behind
+	// them, or false when the strategy does not apply to the hunk
+	Resolve(file *File, hunk gitutils.ConflictHunk) (lines []string, reasoning string, ok bool)
+}
+
+// ruleStrategy is a Strategy backed by a function
+type ruleStrategy struct {
+	name    string
+	resolve func(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool)
+}
+
+func (s ruleStrategy) Name() string { return s.name }
+
+func (s ruleStrategy) Resolve(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	return s.resolve(file, hunk)
+}
+
+// DefaultStrategies returns the built-in strategies in the order they are tried
+func DefaultStrategies() []Strategy {
+	return []Strategy{
+		ruleStrategy{name: StrategyIdentical, resolve: resolveIdentical},
+		ruleStrategy{name: StrategyOneSided, resolve: resolveOneSided},
+		ruleStrategy{name: StrategyGoSum, resolve: resolveGoSum},
+		ruleStrategy{name: StrategyGoImports, resolve: resolveGoImports},
+		ruleStrategy{name: StrategyWhitespace, resolve: resolveWhitespace},
+		ruleStrategy{name: StrategyUnion, resolve: resolveUnion},
+	}
+}
+
+// File is the conflicted file a hunk belongs to. Its content is only read
+// when a strategy needs more than the hunk.
+type File struct {
+	Path string // Relative to the repository
+
+	repoPath string
+	lines    []string
+	loaded   bool
+}
+
+// NewFile creates the file at path in the repository at repoPath
+func NewFile(repoPath, path string) *File {
+	return &File{Path: path, repoPath: repoPath}
+}
+
+// NewFileFromContent creates a file whose content is given rather than read
+// from the working tree, such as a merge result not written yet
+func NewFileFromContent(path string, content []byte) *File {
+	return &File{Path: path, lines: gitutils.ParseTextFile(content).Lines, loaded: true}
+}
+
+// Lines returns the working tree content of the file, conflict markers
+// included, or nil when it cannot be read
+func (f *File) Lines() []string {
+	if f.loaded {
+		return f.lines
+	}
+	f.loaded = true
+
+	if f.repoPath == "" {
+		return nil
+	}
+	fullPath, err := gitutils.ResolveRepoPath(f.repoPath, f.Path)
+	if err != nil {
+		return nil
+	}
+	content, err := os.ReadFile(fullPath) // #nosec G304 - fullPath is contained in the repository
+	if err != nil {
+		return nil
+	}
+	f.lines = gitutils.ParseTextFile(content).Lines
+	return f.lines
+}
+
+// Engine classifies conflict hunks and resolves the ones a strategy applies
+// to. Its resolutions have confidence 1 and name the strategy that made them.
+type Engine struct {
+	// LineOnly merges JSON, YAML and go.mod files hunk by hunk like any other file
+	LineOnly bool
+
+	repoPath   string
+	strategies []Strategy
+}
+
+// NewEngine creates an engine for the repository at repoPath trying the given
+// strategies in order, or the default strategies when none are given. Without
+// a repository path, strategies that read the rest of the file do not apply.
+func NewEngine(repoPath string, strategies ...Strategy) *Engine {
+	if len(strategies) == 0 {
+		strategies = DefaultStrategies()
+	}
+	return &Engine{repoPath: repoPath, strategies: strategies}
+}
+
+// Classify returns the name of the first strategy that resolves the hunk, or
+// "" when the hunk needs AI or a human
+func (e *Engine) Classify(filePath string, hunk gitutils.ConflictHunk) string {
+	if resolution, ok := e.Resolve(filePath, hunk); ok {
+		return resolution.Strategy
+	}
+	return ""
+}
+
+// Resolve resolves the hunk with the first strategy that applies to it
+func (e *Engine) Resolve(filePath string, hunk gitutils.ConflictHunk) (gitutils.ConflictResolution, bool) {
+	return e.resolve(NewFile(e.repoPath, filePath), hunk)
+}
+
+// ResolveFile resolves a hunk of file with the first strategy that applies to
+// it, for hunks whose file is not in the working tree
+func (e *Engine) ResolveFile(file *File, hunk gitutils.ConflictHunk) (gitutils.ConflictResolution, bool) {
+	return e.resolve(file, hunk)
+}
+
+// resolve resolves a hunk of file with the first strategy that applies to it
+func (e *Engine) resolve(file *File, hunk gitutils.ConflictHunk) (gitutils.ConflictResolution, bool) {
+	for _, strategy := range e.strategies {
+		lines, reasoning, ok := strategy.Resolve(file, hunk)
+		if !ok {
+			continue
+		}
+		if lines == nil {
+			lines = []string{}
+		}
+		return gitutils.ConflictResolution{
+			FilePath:      file.Path,
+			HunkID:        hunk.ID,
+			StartLine:     hunk.StartLine,
+			EndLine:       hunk.EndLine,
+			ResolvedLines: lines,
+			Confidence:    1.0,
+			Reasoning:     reasoning,
+			Strategy:      strategy.Name(),
+		}, true
+	}
+	return gitutils.ConflictResolution{}, false
+}
+
+// PayloadResult is the outcome of resolving a payload without AI
+type PayloadResult struct {
+	Resolutions     []gitutils.ConflictResolution // Hunks a strategy resolved
+	FileResolutions []gitutils.FileResolution     // Files merged by their structure, see MergeStructured
+	Remaining       *payload.ConflictPayload      // Hunks and keys left for AI
+
+	structured map[string]*StructuredMerge // Merges waiting on AI for their key conflicts, by path
+}
+
+// ResolvePayload resolves the hunks of a payload that a strategy applies to.
+// JSON, YAML and go.mod files are merged by their structure from their index
+// stages first; the keys both sides changed replace their hunks in the
+// remaining payload,
+// see PayloadResult.Complete. Files whose hunks were all resolved are left
+// out, unless they have a file-level conflict. Hunks carrying a reviewer hint
+// are always left for AI.
+func (e *Engine) ResolvePayload(conflictPayload *payload.ConflictPayload) *PayloadResult {
+	result := &PayloadResult{
+		Remaining: &payload.ConflictPayload{
+			Metadata: conflictPayload.Metadata,
+			Files:    make([]payload.ConflictFilePayload, 0, len(conflictPayload.Files)),
+		},
+		structured: make(map[string]*StructuredMerge),
+	}
+
+	totalConflicts := 0
+	for _, file := range conflictPayload.Files {
+		if merge := e.mergeStructured(file); merge != nil {
+			if len(merge.Conflicts) == 0 {
+				if fileResolution, err := merge.resolve(file.Path, nil, 1.0); err == nil {
+					result.FileResolutions = append(result.FileResolutions, fileResolution)
+					continue
+				}
+			} else {
+				result.structured[file.Path] = merge
+				file.Conflicts = merge.payloadConflicts(file.Path)
+				file.Context = payload.FileContext{}
+				result.Remaining.Files = append(result.Remaining.Files, file)
+				totalConflicts += len(file.Conflicts)
+				continue
+			}
+		}
+
+		target := NewFile(e.repoPath, file.Path)
+		var conflicts []payload.ConflictHunkPayload
+		for _, conflict := range file.Conflicts {
+			if conflict.ReviewerHint != "" {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+			resolution, ok := e.resolve(target, hunkFromPayload(conflict))
+			if !ok {
+				conflicts = append(conflicts, conflict)
+				continue
+			}
+			result.Resolutions = append(result.Resolutions, resolution)
+		}
+
+		if len(conflicts) == 0 && file.FileConflict == nil {
+			continue
+		}
+		file.Conflicts = conflicts
+		result.Remaining.Files = append(result.Remaining.Files, file)
+		totalConflicts += len(conflicts)
+	}
+
+	result.Remaining.Metadata.TotalFiles = len(result.Remaining.Files)
+	result.Remaining.Metadata.TotalConflicts = totalConflicts
+	return result
+}
+
+// hunkFromPayload converts a payload hunk back into a conflict hunk
+func hunkFromPayload(conflict payload.ConflictHunkPayload) gitutils.ConflictHunk {
+	id := conflict.HunkID
+	if id == "" {
+		id = conflict.ID
+	}
+	return gitutils.ConflictHunk{
+		ID:          id,
+		StartLine:   conflict.StartLine,
+		EndLine:     conflict.EndLine,
+		OursLines:   conflict.OursLines,
+		TheirsLines: conflict.TheirsLines,
+		BaseLines:   conflict.BaseLines,
+		OursLabel:   conflict.OursLabel,
+		BaseLabel:   conflict.BaseLabel,
+		TheirsLabel: conflict.TheirsLabel,
+	}
+}
+
+// hasBase reports whether the hunk carries its base section. An empty base
+// loses its non-nil slice on a JSON round trip, so the label marks it too.
+func hasBase(hunk gitutils.ConflictHunk) bool {
+	return hunk.BaseLines != nil || hunk.BaseLabel != ""
+}
+
+// resolveIdentical takes either side when both made the same change
+func resolveIdentical(_ *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	if !equalLines(hunk.OursLines, hunk.TheirsLines) {
+		return nil, "", false
+	}
+	return hunk.OursLines, "Both sides made the same change", true
+}
+
+// resolveOneSided takes the side that changed when the other one still
+// matches the base
+func resolveOneSided(_ *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	if !hasBase(hunk) {
+		return nil, "", false
+	}
+	switch {
+	case equalLines(hunk.OursLines, hunk.BaseLines):
+		return hunk.TheirsLines, "Our side is unchanged from the base, so their change is taken", true
+	case equalLines(hunk.TheirsLines, hunk.BaseLines):
+		return hunk.OursLines, "Their side is unchanged from the base, so our change is taken", true
+	}
+	return nil, "", false
+}
+
+// resolveWhitespace takes our side when the sides differ only in whitespace,
+// and the other side when one only changed the whitespace of the base.
+// Trailing whitespace is ignored; indentation and blank lines are too, except
+// in files where they carry meaning.
+func resolveWhitespace(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	keepIndent := indentationSensitive(file.Path)
+	keepBlank := blankLineSensitive(file.Path)
+	normalize := func(lines []string) []string {
+		return normalizeWhitespace(lines, keepIndent, keepBlank)
+	}
+	ours, theirs := normalize(hunk.OursLines), normalize(hunk.TheirsLines)
+	switch {
+	case equalLines(ours, theirs):
+		return hunk.OursLines, "The sides differ only in whitespace, so our formatting is kept", true
+	case !hasBase(hunk):
+		return nil, "", false
+	case equalLines(ours, normalize(hunk.BaseLines)):
+		return hunk.TheirsLines, "Our side only changed whitespace, so their change is taken", true
+	case equalLines(theirs, normalize(hunk.BaseLines)):
+		return hunk.OursLines, "Their side only changed whitespace, so our change is taken", true
+	}
+	return nil, "", false
+}
+
+// resolveUnion keeps both sides when each only added lines to the base. The
+// sides are merged line by line against the base; every region both changed
+// must be an insertion, which is resolved as our lines followed by theirs.
+// Changes to different lines of the base merge on their own. go.sum hunks are
+// left to the go-sum strategy, since a hunk it declines adds conflicting
+// checksums that must not both be kept.
+func resolveUnion(file *File, hunk gitutils.ConflictHunk) ([]string, string, bool) {
+	if !hasBase(hunk) || filepath.Base(file.Path) == "go.sum" {
+		return nil, "", false
+	}
+
+	lines := []string{}
+	unioned := false
+	for _, region := range gitutils.Merge3(hunk.BaseLines, hunk.OursLines, hunk.TheirsLines) {
+		if region.Kind != gitutils.RegionConflict {
+			lines = append(lines, region.MergedLines()...)
+			continue
+		}
+		if len(region.BaseLines) > 0 {
+			return nil, "", false
+		}
+		lines = append(lines, region.OursLines...)
+		lines = append(lines, region.TheirsLines...)
+		unioned = true
+	}
+
+	if !unioned {
+		return lines, "The sides changed different lines of the base, which merge cleanly", true
+	}
+	return lines, "Both sides only added lines, so both additions are kept, ours first", true
+}
+
+// indentationSensitive reports whether indentation changes the meaning of the file
+func indentationSensitive(filePath string) bool {
+	base := filepath.Base(filePath)
+	if base == "Makefile" || base == "GNUmakefile" {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".py", ".pyi", ".yaml", ".yml", ".mk", ".haml", ".pug", ".coffee", ".nim":
+		return true
+	}
+	return false
+}
+
+// blankLineSensitive reports whether blank lines change the meaning of the
+// file: they end paragraphs in Markdown, reStructuredText and plain text, and
+// belong to the content of YAML block scalars
+func blankLineSensitive(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md", ".markdown", ".rst", ".txt", ".text", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// normalizeWhitespace drops the leading and trailing whitespace of lines,
+// keeping their indentation when keepIndent is set, and drops blank lines
+// unless keepBlank is set. Spacing within a line is kept, since it can be part
+// of a string or alignment.
+func normalizeWhitespace(lines []string, keepIndent, keepBlank bool) []string {
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if keepBlank {
+				normalized = append(normalized, "")
+			}
+			continue
+		}
+		if keepIndent {
+			normalized = append(normalized, strings.TrimRightFunc(line, unicode.IsSpace))
+			continue
+		}
+		normalized = append(normalized, strings.TrimSpace(line))
+	}
+	return normalized
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+func TestEngine_Resolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		hunk     gitutils.ConflictHunk
+		strategy string
+		expected string
+	}{
+		{
+			name:     "identical",
+			hunk:     gitutils.ConflictHunk{OursLines: []string{"a", "b"}, TheirsLines: []string{"a", "b"}},
+			strategy: StrategyIdentical,
+			expected: "a|b",
+		},
+		{
+			name: "ours unchanged",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"x"}, BaseLines: []string{"x"}, TheirsLines: []string{"y"}, BaseLabel: "base",
+			},
+			strategy: StrategyOneSided,
+			expected: "y",
+		},
+		{
+			name: "theirs unchanged",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"y"}, BaseLines: []string{"x"}, TheirsLines: []string{"x"}, BaseLabel: "base",
+			},
+			strategy: StrategyOneSided,
+			expected: "y",
+		},
+		{
+			name:     "whitespace",
+			filePath: "main.go",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"\tx := 1", ""}, TheirsLines: []string{"    x := 1   "},
+			},
+			strategy: StrategyWhitespace,
+			expected: "\tx := 1|",
+		},
+		{
+			name:     "spacing within a line",
+			filePath: "main.go",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{`s := "a b"`}, TheirsLines: []string{`s := "a  b"`},
+			},
+		},
+		{
+			name:     "blank lines end paragraphs in markdown",
+			filePath: "README.md",
+			hunk: gitutils.ConflictHunk{
+				OursLines:   []string{"First paragraph.", "", "Second paragraph."},
+				TheirsLines: []string{"First paragraph.", "Second paragraph."},
+			},
+		},
+		{
+			name:     "trailing whitespace of blank lines in markdown",
+			filePath: "README.md",
+			hunk: gitutils.ConflictHunk{
+				OursLines:   []string{"First paragraph.", "", "Second paragraph."},
+				TheirsLines: []string{"First paragraph.  ", "   ", "Second paragraph."},
+			},
+			strategy: StrategyWhitespace,
+			expected: "First paragraph.||Second paragraph.",
+		},
+		{
+			name:     "indentation matters in python",
+			filePath: "app.py",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"    return x"}, TheirsLines: []string{"return x"},
+			},
+		},
+		{
+			name: "both added",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"ours"}, TheirsLines: []string{"theirs"}, BaseLabel: "base",
+			},
+			strategy: StrategyUnion,
+			expected: "ours|theirs",
+		},
+		{
+			name: "both appended to the base",
+			hunk: gitutils.ConflictHunk{
+				OursLines:   []string{"keep", "ours"},
+				BaseLines:   []string{"keep"},
+				TheirsLines: []string{"keep", "theirs"},
+			},
+			strategy: StrategyUnion,
+			expected: "keep|ours|theirs",
+		},
+		{
+			name: "both changed the base",
+			hunk: gitutils.ConflictHunk{
+				OursLines: []string{"ours"}, BaseLines: []string{"base"}, TheirsLines: []string{"theirs"},
+			},
+		},
+		{
+			name: "no base",
+			hunk: gitutils.ConflictHunk{OursLines: []string{"ours"}, TheirsLines: []string{"theirs"}},
+		},
+	}
+
+	engine := NewEngine("")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := tt.filePath
+			if filePath == "" {
+				filePath = "file.txt"
+			}
+			resolution, ok := engine.Resolve(filePath, tt.hunk)
+			if ok != (tt.strategy != "") || resolution.Strategy != tt.strategy {
+				t.Fatalf("Resolve() = %+v, %v, expected strategy %q", resolution, ok, tt.strategy)
+			}
+			if !ok {
+				return
+			}
+			if got := strings.Join(resolution.ResolvedLines, "|"); got != tt.expected {
+				t.Errorf("resolved lines = %q, expected %q", got, tt.expected)
+			}
+			if resolution.Confidence != 1.0 || resolution.FilePath != filePath {
+				t.Errorf("resolution = %+v", resolution)
+			}
+		})
+	}
+}
+
+func TestEngine_ResolvePayload(t *testing.T) {
+	conflictPayload := &payload.ConflictPayload{
+		Files: []payload.ConflictFilePayload{
+			{
+				Path: "trivial.txt",
+				Conflicts: []payload.ConflictHunkPayload{
+					{HunkID: "1111", OursLines: []string{"a"}, TheirsLines: []string{"a"}},
+				},
+			},
+			{
+				Path: "mixed.txt",
+				Conflicts: []payload.ConflictHunkPayload{
+					{HunkID: "2222", OursLines: []string{"a"}, TheirsLines: []string{"a "}},
+					{HunkID: "3333", OursLines: []string{"a"}, TheirsLines: []string{"b"}},
+					{HunkID: "4444", OursLines: []string{"a"}, TheirsLines: []string{"a"}, ReviewerHint: "try again"},
+				},
+			},
+		},
+	}
+
+	ruled := NewEngine("").ResolvePayload(conflictPayload)
+	resolutions, remaining := ruled.Resolutions, ruled.Remaining
+	if len(resolutions) != 2 || resolutions[0].HunkID != "1111" || resolutions[1].Strategy != StrategyWhitespace {
+		t.Fatalf("resolutions = %+v", resolutions)
+	}
+	if len(remaining.Files) != 1 || remaining.Files[0].Path != "mixed.txt" || len(remaining.Files[0].Conflicts) != 2 {
+		t.Fatalf("remaining = %+v", remaining.Files)
+	}
+	if remaining.Metadata.TotalFiles != 1 || remaining.Metadata.TotalConflicts != 2 {
+		t.Errorf("remaining metadata = %+v", remaining.Metadata)
+	}
+	if len(conflictPayload.Files[1].Conflicts) != 3 {
+		t.Error("ResolvePayload() modified its input")
+	}
+}
@@ -0,0 +1,273 @@
+package strategy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+// Formats merged by their structure instead of line by line
+const (
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatGoMod = "go.mod"
+)
+
+// KeyConflict is a key of a JSON or YAML file that both sides changed
+// differently. The sides show only the key: its value for JSON, its entry for
+// YAML. A side that removed the key is empty.
+type KeyConflict struct {
+	ID          string // Hunk ID the conflict is sent to AI under
+	Path        string // Key path, e.g. scripts.build
+	BaseLines   []string
+	OursLines   []string
+	TheirsLines []string
+}
+
+// StructuredMerge is a three-way merge of a JSON or YAML file done on its keys.
+// Keys only one side changed are merged; Conflicts are the keys both changed.
+type StructuredMerge struct {
+	Format    string
+	Conflicts []KeyConflict
+
+	reasoning string
+	render    func(resolved map[string][]string) (string, error)
+}
+
+// StructuredFormat returns the format a file is merged in by its structure, or
+// "" when it is merged line by line
+func StructuredFormat(filePath string) string {
+	if filepath.Base(filePath) == "go.mod" {
+		return FormatGoMod
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	return ""
+}
+
+// MergeStructured merges the base, ours and theirs versions of a JSON or YAML
+// file key by key. The merged file keeps the key order, indentation and, for
+// YAML, comments of our side, with the keys their side added placed after the
+// key that precedes them there. go.mod files are merged directive by
+// directive, see mergeGoMod.
+func MergeStructured(filePath string, base, ours, theirs []string) (*StructuredMerge, error) {
+	switch StructuredFormat(filePath) {
+	case FormatJSON:
+		return mergeJSON(joinLines(base), joinLines(ours), joinLines(theirs))
+	case FormatYAML:
+		return mergeYAML(joinLines(base), joinLines(ours), joinLines(theirs))
+	case FormatGoMod:
+		return mergeGoMod(joinLines(base), joinLines(ours), joinLines(theirs))
+	}
+	return nil, fmt.Errorf("%s is not a JSON, YAML or go.mod file", filePath)
+}
+
+// Lines renders the merged file, resolving each key conflict with the lines
+// given for its ID. It fails when a conflict is left unresolved or when the
+// result does not parse.
+func (m *StructuredMerge) Lines(resolved map[string][]string) ([]string, error) {
+	for _, conflict := range m.Conflicts {
+		if _, found := resolved[conflict.ID]; !found {
+			return nil, fmt.Errorf("key %s is not resolved", conflict.Path)
+		}
+	}
+
+	text, err := m.render(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), nil
+}
+
+// newKeyConflict records a conflict on the key at path
+func newKeyConflict(path string, base, ours, theirs []string) KeyConflict {
+	return KeyConflict{
+		ID:          gitutils.HunkID([]string{"key", path}),
+		Path:        path,
+		BaseLines:   base,
+		OursLines:   ours,
+		TheirsLines: theirs,
+	}
+}
+
+// joinKeyPath appends a key to a key path, quoting keys that contain dots
+func joinKeyPath(path, key string) string {
+	if key == "" || strings.ContainsAny(key, `."[]`) {
+		key = fmt.Sprintf("[%q]", key)
+	} else if path != "" {
+		key = "." + key
+	}
+	return path + key
+}
+
+// mergeKeyOrder orders the keys of a merged mapping: our keys in our order,
+// then each key only their side has right after the key preceding it there
+func mergeKeyOrder(ours, theirs []string) []string {
+	order := append([]string{}, ours...)
+	position := make(map[string]int, len(order))
+	for i, key := range order {
+		position[key] = i
+	}
+
+	previous := ""
+	for _, key := range theirs {
+		if _, found := position[key]; !found {
+			at := 0
+			if previous != "" {
+				at = position[previous] + 1
+			}
+			order = append(order[:at], append([]string{key}, order[at:]...)...)
+			for i, ordered := range order {
+				position[ordered] = i
+			}
+		}
+		previous = key
+	}
+	return order
+}
+
+// shiftIndent moves every line but the first by the difference between want
+// and the indentation of the last line. Values rendered at another depth than
+// they were written at keep their inner layout this way.
+func shiftIndent(lines []string, want string) []string {
+	if len(lines) < 2 {
+		return lines
+	}
+	last := lines[len(lines)-1]
+	have := last[:len(last)-len(strings.TrimLeft(last, " \t"))]
+
+	shifted := make([]string, len(lines))
+	shifted[0] = lines[0]
+	for i, line := range lines[1:] {
+		if strings.HasPrefix(line, have) {
+			line = want + line[len(have):]
+		}
+		shifted[i+1] = line
+	}
+	return shifted
+}
+
+// joinLines joins lines read from the index, dropping carriage returns
+func joinLines(lines []string) string {
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed[i] = strings.TrimSuffix(line, "\r")
+	}
+	return strings.Join(trimmed, "\n")
+}
+
+// mergeStructured merges a JSON, YAML or go.mod file of the payload by its
+// structure from its index stages. It returns nil when the file is not one, when the merge
+// needs a human, e.g. for a file-level conflict or a reviewer hint, or when a
+// version does not parse.
+func (e *Engine) mergeStructured(file payload.ConflictFilePayload) *StructuredMerge {
+	if e.LineOnly || e.repoPath == "" || file.FileConflict != nil || StructuredFormat(file.Path) == "" {
+		return nil
+	}
+	for _, conflict := range file.Conflicts {
+		if conflict.ReviewerHint != "" {
+			return nil
+		}
+	}
+
+	versions, err := gitutils.GetStageVersions(e.repoPath, file.Path)
+	if err != nil || versions.Binary || !versions.HasBase || !versions.HasOurs || !versions.HasTheirs {
+		return nil
+	}
+	merge, err := MergeStructured(file.Path, versions.BaseLines, versions.OursLines, versions.TheirsLines)
+	if err != nil {
+		return nil
+	}
+	return merge
+}
+
+// payloadConflicts returns the key conflicts of the merge as payload hunks
+// for AI
+func (m *StructuredMerge) payloadConflicts(filePath string) []payload.ConflictHunkPayload {
+	conflicts := make([]payload.ConflictHunkPayload, 0, len(m.Conflicts))
+	for i, conflict := range m.Conflicts {
+		conflicts = append(conflicts, payload.ConflictHunkPayload{
+			ID:          fmt.Sprintf("%s:%d", filePath, i),
+			HunkID:      conflict.ID,
+			KeyPath:     conflict.Path,
+			OursLines:   conflict.OursLines,
+			BaseLines:   conflict.BaseLines,
+			TheirsLines: conflict.TheirsLines,
+			OursLabel:   "ours",
+			BaseLabel:   "base",
+			TheirsLabel: "theirs",
+		})
+	}
+	return conflicts
+}
+
+// resolve renders the merged file as a file resolution
+func (m *StructuredMerge) resolve(filePath string, resolved map[string][]string, confidence float64) (gitutils.FileResolution, error) {
+	lines, err := m.Lines(resolved)
+	if err != nil {
+		return gitutils.FileResolution{}, err
+	}
+
+	reasoning := m.reasoning
+	if len(m.Conflicts) > 0 {
+		reasoning += fmt.Sprintf("; %d keys both sides changed were resolved by AI", len(m.Conflicts))
+	}
+	return gitutils.FileResolution{
+		FilePath:      filePath,
+		Action:        gitutils.FileActionMerge,
+		ResolvedLines: lines,
+		Confidence:    confidence,
+		Reasoning:     reasoning,
+		Strategy:      m.Format,
+	}, nil
+}
+
+// Complete turns the AI resolutions of key conflicts into file resolutions of
+// the files merged key by key, with the lowest confidence among their keys.
+// The other resolutions are returned as they are. A file whose keys were not
+// all resolved, or whose merged content does not parse, gets no resolution
+// and is reported in the warnings instead.
+func (r *PayloadResult) Complete(resolutions []gitutils.ConflictResolution) ([]gitutils.FileResolution, []gitutils.ConflictResolution, []string) {
+	if r == nil || len(r.structured) == 0 {
+		return nil, resolutions, nil
+	}
+
+	resolved := make(map[string]map[string][]string)
+	confidence := make(map[string]float64)
+	var rest []gitutils.ConflictResolution
+	for _, resolution := range resolutions {
+		if _, found := r.structured[resolution.FilePath]; !found || resolution.HunkID == "" {
+			rest = append(rest, resolution)
+			continue
+		}
+		if resolved[resolution.FilePath] == nil {
+			resolved[resolution.FilePath] = make(map[string][]string)
+			confidence[resolution.FilePath] = resolution.Confidence
+		}
+		resolved[resolution.FilePath][resolution.HunkID] = resolution.ResolvedLines
+		confidence[resolution.FilePath] = min(confidence[resolution.FilePath], resolution.Confidence)
+	}
+
+	var fileResolutions []gitutils.FileResolution
+	var warnings []string
+	for _, file := range r.Remaining.Files {
+		merge, found := r.structured[file.Path]
+		if !found {
+			continue
+		}
+		fileResolution, err := merge.resolve(file.Path, resolved[file.Path], confidence[file.Path])
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s was not merged: %v", file.Path, err))
+			continue
+		}
+		fileResolutions = append(fileResolutions, fileResolution)
+	}
+	return fileResolutions, rest, warnings
+}
@@ -0,0 +1,315 @@
+package strategy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NeuBlink/syncwright/internal/gitutils"
+	"github.com/NeuBlink/syncwright/internal/payload"
+)
+
+func TestMergeStructured(t *testing.T) {
+	tests := []struct {
+		name      string
+		filePath  string
+		base      string
+		ours      string
+		theirs    string
+		conflicts []string // Key paths both sides changed
+		resolved  []string // Resolutions of the conflicts, lines joined by "|"
+		expected  string
+	}{
+		{
+			name:     "json keys changed on both sides",
+			filePath: "package.json",
+			base: `{
+  "name": "app",
+  "version": "1.0.0",
+  "scripts": {
+    "build": "tsc",
+    "test": "jest"
+  }
+}`,
+			ours: `{
+  "name": "app",
+  "version": "1.1.0",
+  "scripts": {
+    "build": "tsc",
+    "lint": "eslint .",
+    "test": "jest"
+  }
+}`,
+			theirs: `{
+  "name": "app",
+  "version": "1.0.0",
+  "license": "MIT",
+  "scripts": {
+    "build": "tsc -p .",
+    "test": "jest"
+  }
+}`,
+			expected: `{
+  "name": "app",
+  "version": "1.1.0",
+  "license": "MIT",
+  "scripts": {
+    "build": "tsc -p .",
+    "lint": "eslint .",
+    "test": "jest"
+  }
+}`,
+		},
+		{
+			name:      "json key changed differently",
+			filePath:  "config.json",
+			base:      "{\n\t\"port\": 80,\n\t\"tls\": {\"enabled\": false}\n}",
+			ours:      "{\n\t\"port\": 8080,\n\t\"tls\": {\"enabled\": false}\n}",
+			theirs:    "{\n\t\"port\": 9090,\n\t\"tls\": {\"enabled\": true}\n}",
+			conflicts: []string{"port"},
+			resolved:  []string{"8081"},
+			expected:  "{\n\t\"port\": 8081,\n\t\"tls\": {\"enabled\": true}\n}",
+		},
+		{
+			name:      "json key removed and changed",
+			filePath:  "config.json",
+			base:      `{"a": 1, "b": 2}`,
+			ours:      `{"a": 1}`,
+			theirs:    `{"a": 1, "b": 3}`,
+			conflicts: []string{"b"},
+			resolved:  []string{""},
+			expected:  `{"a": 1}`,
+		},
+		{
+			name:     "yaml comments kept",
+			filePath: "config.yml",
+			base: `# Service settings
+service:
+  name: api
+  # Port the API listens on
+  port: 80
+
+logging:
+  level: info`,
+			ours: `# Service settings
+service:
+  name: api
+  # Port the API listens on
+  port: 8080
+
+logging:
+  level: info`,
+			theirs: `# Service settings
+service:
+    name: api
+    # Port the API listens on
+    port: 80
+    # Seconds before a request times out
+    timeout: 30
+
+logging:
+  level: debug # noisy`,
+			expected: `# Service settings
+service:
+  name: api
+  # Port the API listens on
+  port: 8080
+  # Seconds before a request times out
+  timeout: 30
+
+logging:
+  level: debug # noisy`,
+		},
+		{
+			name:     "yaml key changed differently",
+			filePath: "deploy.yaml",
+			base: `image: app:1
+env:
+  DEBUG: "false"
+---
+kind: Service`,
+			ours: `image: app:2
+env:
+  DEBUG: "false"
+---
+kind: Service`,
+			theirs: `image: app:3
+env:
+  DEBUG: "true"
+---
+kind: Service
+port: 80`,
+			conflicts: []string{"[0].image"},
+			resolved:  []string{"image: app:3"},
+			expected: `image: app:3
+env:
+  DEBUG: "true"
+---
+kind: Service
+port: 80`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merge, err := MergeStructured(tt.filePath, strings.Split(tt.base, "\n"), strings.Split(tt.ours, "\n"), strings.Split(tt.theirs, "\n"))
+			if err != nil {
+				t.Fatalf("MergeStructured() error = %v", err)
+			}
+			if len(merge.Conflicts) != len(tt.conflicts) {
+				t.Fatalf("conflicts = %+v, expected %v", merge.Conflicts, tt.conflicts)
+			}
+
+			resolved := make(map[string][]string)
+			for i, conflict := range merge.Conflicts {
+				if conflict.Path != tt.conflicts[i] {
+					t.Errorf("conflict %d path = %q, expected %q", i, conflict.Path, tt.conflicts[i])
+				}
+				if _, err := merge.Lines(resolved); err == nil {
+					t.Error("Lines() succeeded with unresolved keys")
+				}
+				resolved[conflict.ID] = nil
+				if tt.resolved[i] != "" {
+					resolved[conflict.ID] = strings.Split(tt.resolved[i], "|")
+				}
+			}
+
+			lines, err := merge.Lines(resolved)
+			if err != nil {
+				t.Fatalf("Lines() error = %v", err)
+			}
+			if got := strings.Join(lines, "\n"); got != tt.expected {
+				t.Errorf("merged =\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMergeStructured_Declines(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		base     string
+		ours     string
+		theirs   string
+	}{
+		{name: "invalid json", filePath: "a.json", base: `{}`, ours: `{"a": 1,}`, theirs: `{}`},
+		{name: "json arrays changed on both sides", filePath: "a.json", base: `[1]`, ours: `[1, 2]`, theirs: `[1, 3]`},
+		{name: "yaml documents added", filePath: "a.yaml", base: "a: 1", ours: "a: 1\n---\nb: 2", theirs: "a: 2"},
+		{name: "yaml lists changed on both sides", filePath: "a.yaml", base: "- a", ours: "- a\n- b", theirs: "- a\n- c"},
+		{name: "not structured", filePath: "a.txt", base: "a", ours: "b", theirs: "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if merge, err := MergeStructured(tt.filePath, strings.Split(tt.base, "\n"), strings.Split(tt.ours, "\n"), strings.Split(tt.theirs, "\n")); err == nil {
+				t.Errorf("MergeStructured() = %+v, expected an error", merge)
+			}
+		})
+	}
+}
+
+// testGitCommand builds a git command with a fixed identity for test repositories
+func testGitCommand(dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	return cmd
+}
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	if output, err := testGitCommand(dir, args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v (output: %s)", args, err, output)
+	}
+}
+
+// commitFiles writes files to the repository and commits them
+func commitFiles(t *testing.T, repoPath string, files map[string]string) {
+	t.Helper()
+
+	for filePath, content := range files {
+		if err := os.WriteFile(filepath.Join(repoPath, filePath), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, repoPath, "add", "-A")
+	runGit(t, repoPath, "commit", "-q", "-m", "update")
+}
+
+func TestEngine_ResolvePayload_Structured(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoPath := t.TempDir()
+	runGit(t, repoPath, "init", "-q", "-b", "main")
+	commitFiles(t, repoPath, map[string]string{
+		"config.json":   "{\n  \"a\": 1,\n  \"b\": 1\n}\n",
+		"settings.yaml": "name: app\nreplicas: 1\n",
+	})
+	runGit(t, repoPath, "checkout", "-q", "-b", "feature")
+	commitFiles(t, repoPath, map[string]string{
+		"config.json":   "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+		"settings.yaml": "name: app\nreplicas: 3\n",
+	})
+	runGit(t, repoPath, "checkout", "-q", "main")
+	commitFiles(t, repoPath, map[string]string{
+		"config.json":   "{\n  \"a\": 2,\n  \"b\": 1\n}\n",
+		"settings.yaml": "name: app\nreplicas: 2\n",
+	})
+	if err := testGitCommand(repoPath, "merge", "-q", "--no-edit", "feature").Run(); err == nil {
+		t.Fatal("expected the merge to conflict")
+	}
+
+	conflictPayload := &payload.ConflictPayload{}
+	for _, filePath := range []string{"config.json", "settings.yaml"} {
+		hunks, err := gitutils.ParseConflictHunks(filePath, repoPath)
+		if err != nil || len(hunks) == 0 {
+			t.Fatalf("ParseConflictHunks(%s) = %+v, %v", filePath, hunks, err)
+		}
+		file := payload.ConflictFilePayload{Path: filePath}
+		for _, hunk := range hunks {
+			file.Conflicts = append(file.Conflicts, payload.ConflictHunkPayload{
+				HunkID: hunk.ID, StartLine: hunk.StartLine, EndLine: hunk.EndLine,
+				OursLines: hunk.OursLines, TheirsLines: hunk.TheirsLines,
+			})
+		}
+		conflictPayload.Files = append(conflictPayload.Files, file)
+	}
+
+	ruled := NewEngine(repoPath).ResolvePayload(conflictPayload)
+	if len(ruled.FileResolutions) != 1 || ruled.FileResolutions[0].Strategy != FormatJSON {
+		t.Fatalf("file resolutions = %+v", ruled.FileResolutions)
+	}
+	if got := strings.Join(ruled.FileResolutions[0].ResolvedLines, "|"); got != `{|  "a": 2,|  "b": 2|}` {
+		t.Errorf("merged config.json = %q", got)
+	}
+
+	if len(ruled.Remaining.Files) != 1 || len(ruled.Remaining.Files[0].Conflicts) != 1 {
+		t.Fatalf("remaining = %+v", ruled.Remaining.Files)
+	}
+	key := ruled.Remaining.Files[0].Conflicts[0]
+	if key.KeyPath != "replicas" || strings.Join(key.OursLines, "|") != "replicas: 2" || strings.Join(key.TheirsLines, "|") != "replicas: 3" {
+		t.Errorf("key conflict = %+v", key)
+	}
+
+	other := gitutils.ConflictResolution{FilePath: "other.txt", HunkID: "1234", ResolvedLines: []string{"x"}}
+	merged, rest, warnings := ruled.Complete([]gitutils.ConflictResolution{
+		{FilePath: "settings.yaml", HunkID: key.HunkID, ResolvedLines: []string{"replicas: 3"}, Confidence: 0.8},
+		other,
+	})
+	if len(warnings) != 0 || len(rest) != 1 || rest[0].FilePath != "other.txt" {
+		t.Fatalf("Complete() = %+v, %+v, %v", merged, rest, warnings)
+	}
+	if len(merged) != 1 || merged[0].Confidence != 0.8 || strings.Join(merged[0].ResolvedLines, "|") != "name: app|replicas: 3" {
+		t.Errorf("merged settings.yaml = %+v", merged)
+	}
+}
@@ -0,0 +1,370 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlEntry is a key of a block mapping with the lines it spans: the comments
+// above it, the key and its value, and the blank lines after it
+type yamlEntry struct {
+	column  int
+	lines   []string
+	header  []string     // Lines before the first key of mapping
+	trailer []string     // Blank lines after the last key of mapping
+	mapping *yamlMapping // Block mapping value, nil for other values
+}
+
+// yamlMapping is a block mapping with its entries in order
+type yamlMapping struct {
+	column  int
+	keys    []string
+	entries map[string]*yamlEntry
+}
+
+// yamlDocument is a document of a YAML stream. Documents whose root is not a
+// block mapping are merged as a whole.
+type yamlDocument struct {
+	lines   []string
+	header  []string // Lines before the first key of mapping
+	trailer []string // Blank lines after the last key of mapping
+	mapping *yamlMapping
+}
+
+// text returns the lines of an entry shifted to column zero
+func (e *yamlEntry) text() []string {
+	if e == nil {
+		return nil
+	}
+	return dedentYAML(e.lines, e.column)
+}
+
+// headerText returns the header lines of an entry shifted to column zero
+func (e *yamlEntry) headerText() []string {
+	return dedentYAML(e.header, e.column)
+}
+
+// parseYAMLStream splits a YAML stream on its document markers and parses each
+// document
+func parseYAMLStream(text string) ([]*yamlDocument, error) {
+	var documents []*yamlDocument
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimRight(line, " ") == "---" {
+			document, err := parseYAMLDocument(lines)
+			if err != nil {
+				return nil, err
+			}
+			documents = append(documents, document)
+			lines = nil
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	document, err := parseYAMLDocument(lines)
+	if err != nil {
+		return nil, err
+	}
+	return append(documents, document), nil
+}
+
+// parseYAMLDocument parses a single document
+func parseYAMLDocument(lines []string) (*yamlDocument, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(strings.Join(lines, "\n")))
+	var root yaml.Node
+	if err := decoder.Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &yamlDocument{lines: lines}, nil // Only comments or nothing
+		}
+		return nil, err
+	}
+	var next yaml.Node
+	if err := decoder.Decode(&next); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected document marker")
+	}
+
+	document := &yamlDocument{lines: lines}
+	if len(root.Content) == 1 && isBlockMapping(root.Content[0]) {
+		mapping, start, end, err := parseYAMLMapping(lines, root.Content[0], len(lines))
+		if err != nil {
+			return nil, err
+		}
+		document.header = lines[:start]
+		document.trailer = lines[end:]
+		document.mapping = mapping
+	}
+	return document, nil
+}
+
+// isBlockMapping reports whether a node is a mapping written in block style
+func isBlockMapping(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && node.Style&yaml.FlowStyle == 0 && len(node.Content) > 0
+}
+
+// parseYAMLMapping splits the lines of a block mapping that ends before line
+// end into its entries. It also returns the line its first entry starts at and
+// the line its last entry ends before, leaving out the blank lines after it.
+func parseYAMLMapping(lines []string, node *yaml.Node, end int) (*yamlMapping, int, int, error) {
+	mapping := &yamlMapping{column: node.Content[0].Column - 1, entries: make(map[string]*yamlEntry)}
+	starts := make([]int, 0, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Kind != yaml.ScalarNode {
+			return nil, 0, 0, fmt.Errorf("line %d: only scalar keys are supported", key.Line)
+		}
+		if _, duplicate := mapping.entries[key.Value]; duplicate {
+			return nil, 0, 0, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+		}
+
+		// Comments right above a key at its indentation belong to it
+		start := key.Line - 1
+		indent := strings.Repeat(" ", mapping.column)
+		for start > 0 && strings.HasPrefix(lines[start-1], indent+"#") {
+			start--
+		}
+		starts = append(starts, start)
+		mapping.keys = append(mapping.keys, key.Value)
+		mapping.entries[key.Value] = &yamlEntry{column: mapping.column}
+	}
+
+	last := len(starts) - 1
+	for end > starts[last]+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	for i, key := range mapping.keys {
+		entryEnd := end
+		if i+1 < len(starts) {
+			entryEnd = starts[i+1]
+		}
+		entry := mapping.entries[key]
+		entry.lines = lines[starts[i]:entryEnd]
+
+		if value := node.Content[2*i+1]; isBlockMapping(value) && value.Line > node.Content[2*i].Line {
+			child, start, childEnd, err := parseYAMLMapping(lines, value, entryEnd)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			entry.header = lines[starts[i]:start]
+			entry.trailer = lines[childEnd:entryEnd]
+			entry.mapping = child
+		}
+	}
+	return mapping, starts[0], end, nil
+}
+
+// yamlPiece is a part of a merged YAML stream: lines taken as they are, or a
+// key conflict to be resolved at a column
+type yamlPiece struct {
+	lines    []string
+	conflict string
+	column   int
+}
+
+// yamlMerger merges the entries of three YAML streams
+type yamlMerger struct {
+	pieces    []yamlPiece
+	conflicts []KeyConflict
+}
+
+// mergeYAML merges three YAML streams key by key
+func mergeYAML(baseText, oursText, theirsText string) (*StructuredMerge, error) {
+	base, err := parseYAMLStream(baseText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the base: %w", err)
+	}
+	ours, err := parseYAMLStream(oursText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse our side: %w", err)
+	}
+	theirs, err := parseYAMLStream(theirsText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse their side: %w", err)
+	}
+	if len(base) != len(ours) || len(ours) != len(theirs) {
+		return nil, fmt.Errorf("the sides have different numbers of documents")
+	}
+
+	merger := &yamlMerger{}
+	for i := range ours {
+		if i > 0 {
+			merger.emit([]string{"---"})
+		}
+		path := ""
+		if len(ours) > 1 {
+			path = fmt.Sprintf("[%d]", i)
+		}
+		if err := merger.mergeDocument(path, base[i], ours[i], theirs[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &StructuredMerge{
+		Format:    FormatYAML,
+		Conflicts: merger.conflicts,
+		reasoning: "Merged the YAML keys of both sides",
+		render:    merger.render,
+	}, nil
+}
+
+// mergeDocument merges a document of the stream
+func (m *yamlMerger) mergeDocument(path string, base, ours, theirs *yamlDocument) error {
+	if ours.mapping == nil || theirs.mapping == nil || base.mapping == nil {
+		lines, ok := pickYAML(base.lines, ours.lines, theirs.lines)
+		if !ok {
+			return fmt.Errorf("both sides changed a document that is not a mapping")
+		}
+		m.emit(lines)
+		return nil
+	}
+
+	header, ok := pickYAML(base.header, ours.header, theirs.header)
+	if !ok {
+		return fmt.Errorf("both sides changed the comments at the top of a document")
+	}
+	m.emit(header)
+	m.mergeMapping(path, base.mapping, ours.mapping, theirs.mapping)
+	m.emit(ours.trailer)
+	return nil
+}
+
+// mergeMapping merges a block mapping, writing it at the column of our side
+func (m *yamlMerger) mergeMapping(path string, base, ours, theirs *yamlMapping) {
+	for _, key := range mergeKeyOrder(ours.keys, theirs.keys) {
+		var baseEntry *yamlEntry
+		if base != nil {
+			baseEntry = base.entries[key]
+		}
+		m.mergeEntry(joinKeyPath(path, key), ours.column, baseEntry, ours.entries[key], theirs.entries[key])
+	}
+}
+
+// mergeEntry merges the entry of a key
+func (m *yamlMerger) mergeEntry(path string, column int, base, ours, theirs *yamlEntry) {
+	if lines, ok := pickYAML(base.text(), ours.text(), theirs.text()); ok {
+		if ours != nil && sameYAML(lines, ours.text()) {
+			m.emit(ours.lines)
+		} else {
+			m.emit(indentYAML(lines, column))
+		}
+		return
+	}
+
+	if ours != nil && theirs != nil && ours.mapping != nil && theirs.mapping != nil && (base == nil || base.mapping != nil) {
+		var baseHeader []string
+		var baseMapping *yamlMapping
+		if base != nil {
+			baseHeader, baseMapping = base.headerText(), base.mapping
+		}
+		if header, ok := pickYAML(baseHeader, ours.headerText(), theirs.headerText()); ok {
+			m.emit(indentYAML(header, column))
+			m.mergeMapping(path, baseMapping, ours.mapping, theirs.mapping)
+			m.emit(ours.trailer)
+			return
+		}
+	}
+
+	conflict := newKeyConflict(path, trimBlankLines(base.text()), trimBlankLines(ours.text()), trimBlankLines(theirs.text()))
+	m.conflicts = append(m.conflicts, conflict)
+	m.pieces = append(m.pieces, yamlPiece{conflict: conflict.ID, column: column})
+	if ours != nil {
+		m.emit(ours.lines[len(trimBlankLines(ours.lines)):]) // Keep the spacing after the key
+	}
+}
+
+// emit appends lines to the merged stream
+func (m *yamlMerger) emit(lines []string) {
+	if len(lines) > 0 {
+		m.pieces = append(m.pieces, yamlPiece{lines: lines})
+	}
+}
+
+// render writes the merged stream, checking that it parses
+func (m *yamlMerger) render(resolved map[string][]string) (string, error) {
+	var lines []string
+	for _, piece := range m.pieces {
+		if piece.conflict != "" {
+			lines = append(lines, indentYAML(resolved[piece.conflict], piece.column)...)
+			continue
+		}
+		lines = append(lines, piece.lines...)
+	}
+	text := strings.Join(lines, "\n")
+
+	decoder := yaml.NewDecoder(strings.NewReader(text))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", fmt.Errorf("the merged YAML does not parse: %w", err)
+		}
+	}
+	return text, nil
+}
+
+// pickYAML merges three versions of some lines when at most one side changed
+// them. An absent version is nil.
+func pickYAML(base, ours, theirs []string) ([]string, bool) {
+	switch {
+	case sameYAML(ours, theirs), sameYAML(base, theirs):
+		return ours, true
+	case sameYAML(base, ours):
+		return theirs, true
+	}
+	return nil, false
+}
+
+// sameYAML reports whether two versions of some lines are equal, ignoring
+// trailing blank lines. An absent version only equals another absent one.
+func sameYAML(a, b []string) bool {
+	if a == nil || b == nil {
+		return (a == nil) == (b == nil)
+	}
+	return equalLines(trimBlankLines(a), trimBlankLines(b))
+}
+
+// trimBlankLines drops the blank lines at the end of some lines
+func trimBlankLines(lines []string) []string {
+	if lines == nil {
+		return []string{}
+	}
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
+
+// dedentYAML removes up to column leading spaces from each line
+func dedentYAML(lines []string, column int) []string {
+	if lines == nil {
+		return nil
+	}
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(line)-len(trimmed) > column {
+			trimmed = line[column:]
+		}
+		dedented[i] = trimmed
+	}
+	return dedented
+}
+
+// indentYAML prefixes each non-blank line with column spaces
+func indentYAML(lines []string, column int) []string {
+	indent := strings.Repeat(" ", column)
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			line = indent + line
+		}
+		indented[i] = line
+	}
+	return indented
+}
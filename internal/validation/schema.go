@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/NeuBlink/syncwright/internal/gitutils"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -17,6 +20,7 @@ const (
 	MaxLineLength       = 10000            // Maximum characters per line
 	MaxContextLines     = 50               // Maximum context lines
 	MaxTotalConflicts   = 5000             // Maximum total conflicts across all files
+	MaxConflictIDLength = 512              // Maximum conflict ID length ("<path>:<index>")
 )
 
 // PayloadValidator handles JSON payload validation
@@ -32,6 +36,7 @@ func NewPayloadValidator() *PayloadValidator {
 	v.RegisterValidation("filepath", validateFilePath)
 	v.RegisterValidation("language", validateLanguage)
 	v.RegisterValidation("conflict_id", validateConflictID)
+	v.RegisterValidation("hunk_id", validateHunkID)
 	v.RegisterValidation("safe_content", validateSafeContent)
 	v.RegisterValidation("repo_path", validateRepoPath)
 
@@ -48,18 +53,35 @@ type ValidatedConflictPayload struct {
 type ValidatedFilePayload struct {
 	Path      string                  `json:"path" validate:"required,filepath,max=500"`
 	Language  string                  `json:"language" validate:"required,language"`
-	Conflicts []ValidatedConflictHunk `json:"conflicts" validate:"required,max=100,dive"`
+	Encoding  string                  `json:"encoding,omitempty" validate:"omitempty,max=64,printascii"`
+	Conflicts []ValidatedConflictHunk `json:"conflicts" validate:"omitempty,max=100,dive"`
 	Context   ValidatedFileContext    `json:"context" validate:"required"`
+	// FileConflict is set for modify/delete, add/add and delete/delete conflicts
+	FileConflict *ValidatedFileConflict `json:"file_conflict,omitempty" validate:"omitempty"`
+}
+
+// ValidatedFileConflict represents a validated file-level conflict description
+type ValidatedFileConflict struct {
+	Kind             string   `json:"kind" validate:"required,oneof=both_modified both_added both_deleted added_by_us added_by_them deleted_by_us deleted_by_them"`
+	Status           string   `json:"status" validate:"required,len=2,alpha"`
+	DeletedBy        string   `json:"deleted_by,omitempty" validate:"omitempty,oneof=ours theirs both"`
+	AddedBy          string   `json:"added_by,omitempty" validate:"omitempty,oneof=ours theirs both"`
+	SurvivingSide    string   `json:"surviving_side,omitempty" validate:"omitempty,oneof=ours theirs"`
+	SurvivingContent []string `json:"surviving_content,omitempty" validate:"dive,safe_content,max=10000"`
 }
 
 // ValidatedConflictHunk represents a validated conflict hunk
 type ValidatedConflictHunk struct {
-	ID          string   `json:"id,omitempty" validate:"omitempty,conflict_id,max=100"`
+	ID          string   `json:"id,omitempty" validate:"omitempty,conflict_id,max=512"`
+	HunkID      string   `json:"hunk_id,omitempty" validate:"omitempty,hunk_id,max=64"`
 	StartLine   int      `json:"start_line" validate:"required,min=1,max=1000000"`
 	EndLine     int      `json:"end_line" validate:"required,min=1,max=1000000,gtfield=StartLine"`
 	OursLines   []string `json:"ours_lines" validate:"required,dive,safe_content,max=10000"`
 	TheirsLines []string `json:"theirs_lines" validate:"required,dive,safe_content,max=10000"`
 	BaseLines   []string `json:"base_lines,omitempty" validate:"dive,safe_content,max=10000"`
+	OursLabel   string   `json:"ours_label,omitempty" validate:"omitempty,safe_content,max=200"`
+	BaseLabel   string   `json:"base_label,omitempty" validate:"omitempty,safe_content,max=200"`
+	TheirsLabel string   `json:"theirs_label,omitempty" validate:"omitempty,safe_content,max=200"`
 }
 
 // ValidatedFileContext represents validated file context
@@ -195,6 +217,16 @@ func (pv *PayloadValidator) validateBusinessLogic(payload *ValidatedConflictPayl
 	for fileIdx, file := range payload.Files {
 		totalConflicts += len(file.Conflicts)
 
+		// Every file needs either conflict hunks or a file-level conflict
+		if len(file.Conflicts) == 0 && file.FileConflict == nil {
+			result.Errors = append(result.Errors, ValidationError{
+				Field:   fmt.Sprintf("files[%d].conflicts", fileIdx),
+				Tag:     "required",
+				Message: fmt.Sprintf("File %s has neither conflicts nor a file-level conflict", file.Path),
+			})
+			return fmt.Errorf("file %s has neither conflicts nor a file-level conflict", file.Path)
+		}
+
 		// Validate no duplicate file paths
 		for otherIdx, otherFile := range payload.Files {
 			if fileIdx != otherIdx && file.Path == otherFile.Path {
@@ -328,29 +360,23 @@ func (pv *PayloadValidator) calculateSummary(payload *ValidatedConflictPayload,
 
 // Custom validation functions
 
-// validateFilePath validates file paths for security
+// validateFilePath validates file paths for security. Paths may contain any
+// printable characters; they must be relative and stay inside the repository.
 func validateFilePath(fl validator.FieldLevel) bool {
 	path := fl.Field().String()
 
-	// Check for dangerous characters and path traversal attempts
-	dangerous := []string{"..", "\x00", "\n", "\r", "|", "&", ";", "$", "`", "\\", "//"}
-	for _, char := range dangerous {
-		if strings.Contains(path, char) {
-			return false
-		}
+	if len(path) == 0 || len(path) >= 500 || containsControl(path) {
+		return false
 	}
 
-	// Check for absolute paths starting with dangerous locations
-	dangerousPaths := []string{"/etc/", "/usr/", "/bin/", "/sbin/", "/root/", "/home/", "/var/", "/tmp/", "/proc/", "/sys/"}
-	lowerPath := strings.ToLower(path)
-	for _, dangerousPath := range dangerousPaths {
-		if strings.HasPrefix(lowerPath, dangerousPath) {
-			return false
-		}
-	}
+	_, err := gitutils.CleanRepoPath(path)
+	return err == nil
+}
 
-	// Must be a reasonable file path
-	return len(path) > 0 && len(path) < 500 && path != "/"
+// containsControl reports whether s contains control characters such as NUL,
+// newlines or terminal escape sequences
+func containsControl(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
 }
 
 // validateLanguage validates programming language identifiers
@@ -379,20 +405,40 @@ func validateConflictID(fl validator.FieldLevel) bool {
 		return true
 	}
 
-	// Conflict ID should be in format "file:index" or similar safe format
-	if len(id) > 100 {
+	if len(id) > MaxConflictIDLength || containsControl(id) {
+		return false
+	}
+
+	// Conflict IDs have the form "<file path>:<index>" or "<file path>:file"
+	separator := strings.LastIndex(id, ":")
+	if separator <= 0 {
 		return false
 	}
 
-	// Only allow alphanumeric, colon, hyphen, underscore, dot, slash
-	for _, r := range id {
-		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == ':' || r == '-' || r == '_' || r == '.' || r == '/') {
+	suffix := id[separator+1:]
+	if suffix != "file" {
+		if suffix == "" || strings.TrimLeft(suffix, "0123456789") != "" {
 			return false
 		}
 	}
 
-	return true
+	_, err := gitutils.CleanRepoPath(id[:separator])
+	return err == nil
+}
+
+// validateHunkID validates hunk ID format: hex digits with an optional
+// "-<occurrence>" suffix
+func validateHunkID(fl validator.FieldLevel) bool {
+	id := fl.Field().String()
+	if id == "" {
+		return true
+	}
+
+	hash, occurrence, hasOccurrence := strings.Cut(id, "-")
+	if hash == "" || strings.TrimLeft(hash, "0123456789abcdef") != "" {
+		return false
+	}
+	return !hasOccurrence || (occurrence != "" && strings.TrimLeft(occurrence, "0123456789") == "")
 }
 
 // validateSafeContent validates content for dangerous characters
@@ -430,12 +476,10 @@ func validateSafeContent(fl validator.FieldLevel) bool {
 func validateRepoPath(fl validator.FieldLevel) bool {
 	path := fl.Field().String()
 
-	// Check for dangerous characters
-	dangerous := []string{"\x00", "\n", "\r", "|", "&", ";", "$", "`"}
-	for _, char := range dangerous {
-		if strings.Contains(path, char) {
-			return false
-		}
+	// The repository path is only used as a working directory, never passed
+	// through a shell, so only control characters are rejected
+	if containsControl(path) {
+		return false
 	}
 
 	// Must be a reasonable repository path
@@ -464,6 +508,10 @@ func (pv *PayloadValidator) SanitizePayload(payload *ValidatedConflictPayload) {
 			}
 		}
 
+		if file.FileConflict != nil {
+			file.FileConflict.SurvivingContent = pv.sanitizeLines(file.FileConflict.SurvivingContent)
+		}
+
 		// Sanitize context
 		file.Context.BeforeLines = pv.sanitizeLines(file.Context.BeforeLines)
 		file.Context.AfterLines = pv.sanitizeLines(file.Context.AfterLines)
@@ -475,17 +523,32 @@ func (pv *PayloadValidator) SanitizePayload(payload *ValidatedConflictPayload) {
 
 // sanitizePath removes dangerous content from file paths
 func (pv *PayloadValidator) sanitizePath(path string) string {
-	// Remove null bytes and path traversal attempts
+	// Remove null bytes, traversal components and empty components
 	cleaned := strings.ReplaceAll(path, "\x00", "")
-	cleaned = strings.ReplaceAll(cleaned, "..", "")
-	cleaned = strings.ReplaceAll(cleaned, "//", "/")
 
-	// Limit length
-	if len(cleaned) > 500 {
-		cleaned = cleaned[:500]
+	components := strings.Split(cleaned, "/")
+	kept := make([]string, 0, len(components))
+	for i, component := range components {
+		if component == ".." || (component == "" && i > 0) {
+			continue
+		}
+		kept = append(kept, component)
 	}
+	cleaned = strings.Join(kept, "/")
+
+	// Limit length
+	return truncateString(cleaned, 500)
+}
 
-	return cleaned
+// truncateString shortens s to at most limit bytes without splitting a rune
+func truncateString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
 }
 
 // sanitizeLines removes dangerous content from lines
@@ -507,21 +570,15 @@ func (pv *PayloadValidator) sanitizeLines(lines []string) []string {
 
 // sanitizeConflictID removes dangerous content from conflict IDs
 func (pv *PayloadValidator) sanitizeConflictID(id string) string {
-	// Keep only safe characters
-	var result strings.Builder
-	for _, r := range id {
-		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == ':' || r == '-' || r == '_' || r == '.' || r == '/' {
-			result.WriteRune(r)
+	// Drop control characters; IDs embed file paths, so other characters are kept
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
 		}
-	}
-
-	cleaned := result.String()
-	if len(cleaned) > 100 {
-		cleaned = cleaned[:100]
-	}
+		return r
+	}, id)
 
-	return cleaned
+	return truncateString(cleaned, MaxConflictIDLength)
 }
 
 // ValidateAndSanitize performs both validation and sanitization in one step
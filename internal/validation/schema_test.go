@@ -188,6 +188,49 @@ func TestPayloadValidator_ValidatePayload(t *testing.T) {
 		assert.False(t, result.Valid)
 		assert.Contains(t, err.Error(), "too large")
 	})
+
+	t.Run("File-level conflict without hunks", func(t *testing.T) {
+		payload := createValidPayload()
+		payload.Files[0].Conflicts = nil
+		payload.Files[0].FileConflict = &ValidatedFileConflict{
+			Kind:             "deleted_by_them",
+			Status:           "UD",
+			DeletedBy:        "theirs",
+			SurvivingSide:    "ours",
+			SurvivingContent: []string{"package main"},
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		validatedPayload, result, err := validator.ValidatePayload(data)
+		assert.NoError(t, err)
+		assert.True(t, result.Valid)
+		require.NotNil(t, validatedPayload.Files[0].FileConflict)
+		assert.Equal(t, "deleted_by_them", validatedPayload.Files[0].FileConflict.Kind)
+	})
+
+	t.Run("Invalid file-level conflict kind", func(t *testing.T) {
+		payload := createValidPayload()
+		payload.Files[0].FileConflict = &ValidatedFileConflict{Kind: "renamed", Status: "UD"}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		_, result, err := validator.ValidatePayload(data)
+		assert.Error(t, err)
+		assert.False(t, result.Valid)
+	})
+
+	t.Run("File without hunks or file-level conflict", func(t *testing.T) {
+		payload := createValidPayload()
+		payload.Files[0].Conflicts = nil
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		_, result, err := validator.ValidatePayload(data)
+		assert.Error(t, err)
+		assert.False(t, result.Valid)
+		assert.Contains(t, err.Error(), "neither conflicts nor a file-level conflict")
+	})
 }
 
 func TestPayloadValidator_ValidateAndSanitize(t *testing.T) {
@@ -232,9 +275,15 @@ func TestCustomValidationFunctions(t *testing.T) {
 			{"../../../etc/passwd", false},
 			{"/etc/passwd", false},
 			{"file\x00with\x00nulls", false},
-			{"file|with|pipes", false},
-			{"file;with;semicolons", false},
-			{"file`with`backticks", false},
+			{"file|with|pipes", true},
+			{"file;with;semicolons", true},
+			{"file`with`backticks", true},
+			{"docs/Guía de uso.md", true},
+			{"src/My Component.tsx", true},
+			{"src/../../escape.go", false},
+			{`..\..\windows\system32`, false},
+			{".git/config", false},
+			{"file\nwith\nnewlines", false},
 			{"", false},
 			{"/", false},
 			{strings.Repeat("a", 501), false}, // Too long
@@ -296,15 +345,19 @@ func TestCustomValidationFunctions(t *testing.T) {
 			id    string
 			valid bool
 		}{
-			{"", true},                        // Empty is valid (optional)
-			{"test.go:0", true},               // Standard format
-			{"file_name:1", true},             // Underscore
-			{"path/to/file.go:2", true},       // Path with slashes
-			{"test-file.go:3", true},          // Hyphen
-			{"valid.123:4", true},             // Numbers and dots
-			{"invalid@char", false},           // Invalid character
-			{"too#many$special%chars", false}, // Multiple invalid chars
-			{strings.Repeat("a", 101), false}, // Too long
+			{"", true},                               // Empty is valid (optional)
+			{"test.go:0", true},                      // Standard format
+			{"file_name:1", true},                    // Underscore
+			{"path/to/file.go:2", true},              // Path with slashes
+			{"test-file.go:3", true},                 // Hyphen
+			{"valid.123:4", true},                    // Numbers and dots
+			{"docs/Guía de uso.md:0", true},          // Unicode and spaces
+			{"deleted file.txt:file", true},          // File-level conflict
+			{"invalid@char", false},                  // Missing index
+			{"too#many$special%chars", false},        // Missing index
+			{"../escape.go:0", false},                // Path outside the repository
+			{"test.go:1\x1b[2J", false},              // Control characters
+			{strings.Repeat("a", 600) + ":0", false}, // Too long
 		}
 
 		for _, tc := range testCases {
@@ -398,9 +451,11 @@ func TestPayloadValidator_Sanitization(t *testing.T) {
 			expected string
 		}{
 			{"valid:id", "valid:id"},
-			{"invalid@chars#here", "invalidcharshere"},
+			{"invalid@chars#here", "invalid@chars#here"},
 			{"test.go:1-conflict", "test.go:1-conflict"},
-			{strings.Repeat("a", 150), strings.Repeat("a", 100)},
+			{"docs/Guía de uso.md:0", "docs/Guía de uso.md:0"},
+			{"test.go:1\x1b[2J", "test.go:1[2J"},
+			{strings.Repeat("a", 600), strings.Repeat("a", MaxConflictIDLength)},
 		}
 
 		for _, tc := range testCases {